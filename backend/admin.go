@@ -0,0 +1,135 @@
+package main
+
+import (
+	"e-device-recycle-backend/models"
+	"e-device-recycle-backend/utils"
+	"flag"
+	"fmt"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// create-admin 子命令：创建管理员或评估师账号
+func runCreateAdmin(args []string) {
+	fs := flag.NewFlagSet("create-admin", flag.ExitOnError)
+	username := fs.String("username", "admin", "用户名")
+	password := fs.String("password", "", "密码，为空时随机生成并输出")
+	email := fs.String("email", "", "邮箱")
+	phone := fs.String("phone", "", "手机号")
+	realName := fs.String("name", "系统管理员", "姓名")
+	role := fs.String("role", "admin", "角色: admin, evaluator")
+	fs.Parse(args)
+
+	if *role != "admin" && *role != "evaluator" {
+		log.Fatal("角色必须为 admin 或 evaluator")
+	}
+
+	models.InitDB()
+
+	var count int64
+	models.DB.Model(&models.User{}).Where("username = ?", *username).Count(&count)
+	if count > 0 {
+		log.Fatalf("用户名已存在: %s", *username)
+	}
+
+	plain, generated := resolvePassword(*password)
+	hashedPassword, err := utils.HashPassword(plain)
+	if err != nil {
+		log.Fatal("密码加密失败:", err)
+	}
+
+	user := models.User{
+		Username: *username,
+		Password: hashedPassword,
+		Phone:    *phone,
+		Email:    *email,
+		RealName: *realName,
+		Role:     *role,
+		Status:   "active",
+	}
+
+	// 手机号、邮箱为空时不写入，避免唯一索引冲突
+	query := models.DB
+	if user.Phone == "" {
+		query = query.Omit("Phone")
+	}
+	if user.Email == "" {
+		query = query.Omit("Email")
+	}
+	if err := query.Create(&user).Error; err != nil {
+		log.Fatal("创建用户失败:", err)
+	}
+
+	fmt.Printf("已创建%s账号: %s (ID %d)\n", user.Role, user.Username, user.ID)
+	if generated {
+		fmt.Printf("初始密码: %s\n", plain)
+	}
+	fmt.Println("首次登录需要绑定两步验证")
+}
+
+// reset-password 子命令：重置用户密码，所有已登录会话失效
+func runResetPassword(args []string) {
+	fs := flag.NewFlagSet("reset-password", flag.ExitOnError)
+	username := fs.String("username", "", "用户名（必填）")
+	password := fs.String("password", "", "新密码，为空时随机生成并输出")
+	reset2FA := fs.Bool("reset-2fa", false, "同时关闭两步验证（丢失认证器时使用）")
+	fs.Parse(args)
+
+	if *username == "" {
+		fs.Usage()
+		log.Fatal("请指定 -username")
+	}
+
+	models.InitDB()
+
+	var user models.User
+	if err := models.DB.Where("username = ?", *username).First(&user).Error; err != nil {
+		log.Fatalf("用户不存在: %s", *username)
+	}
+
+	plain, generated := resolvePassword(*password)
+	hashedPassword, err := utils.HashPassword(plain)
+	if err != nil {
+		log.Fatal("密码加密失败:", err)
+	}
+
+	err = models.DB.Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{
+			"password":      hashedPassword,
+			"token_version": gorm.Expr("token_version + 1"),
+		}
+		if *reset2FA {
+			updates["totp_enabled"] = false
+			updates["totp_secret"] = ""
+			if err := tx.Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&user).Updates(updates).Error
+	})
+	if err != nil {
+		log.Fatal("重置密码失败:", err)
+	}
+
+	fmt.Printf("已重置用户 %s 的密码\n", user.Username)
+	if generated {
+		fmt.Printf("新密码: %s\n", plain)
+	}
+}
+
+// 未指定密码时生成随机密码
+func resolvePassword(password string) (string, bool) {
+	if password != "" {
+		if len(password) < 6 {
+			log.Fatal("密码长度不能少于6位")
+		}
+		return password, false
+	}
+
+	generated, err := utils.GenerateRandomToken(12)
+	if err != nil {
+		log.Fatal("生成密码失败:", err)
+	}
+	return generated, true
+}
@@ -0,0 +1,94 @@
+// Package apierror 定义统一的错误响应格式和错误码，错误信息按Accept-Language返回中文或英文。
+//
+//	{"error": {"code": "DEVICE_NOT_FOUND", "message": "设备不存在", "request_id": "..."}}
+package apierror
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 支持的语言
+const (
+	ZhCN = "zh-CN"
+	EnUS = "en-US"
+)
+
+// 默认语言
+const DefaultLocale = ZhCN
+
+type Error struct {
+	Code      Code         `json:"code"`
+	Message   string       `json:"message"`
+	Fields    []FieldError `json:"fields,omitempty"` // 参数校验失败的字段
+	RequestID string       `json:"request_id,omitempty"`
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"` // 未通过的校验规则，如 required、min、oneof
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return string(e.Code) + ": " + e.Message
+}
+
+// 根据请求的语言生成错误，args用于填充错误信息中的占位符
+func New(c *gin.Context, code Code, args ...interface{}) *Error {
+	locale := Locale(c)
+	c.Header("Content-Language", locale)
+	return &Error{
+		Code:      code,
+		Message:   Message(locale, code, args...),
+		RequestID: requestID(c),
+	}
+}
+
+// 返回错误响应并中止后续处理
+func Respond(c *gin.Context, status int, code Code, args ...interface{}) {
+	RespondError(c, status, New(c, code, args...))
+}
+
+func RespondError(c *gin.Context, status int, err *Error) {
+	c.AbortWithStatusJSON(status, gin.H{"error": err})
+}
+
+// 指定语言的错误信息，未翻译时使用默认语言
+func Message(locale string, code Code, args ...interface{}) string {
+	messages, ok := catalog[code]
+	if !ok {
+		return string(code)
+	}
+	message, ok := messages[locale]
+	if !ok {
+		message = messages[DefaultLocale]
+	}
+	if len(args) > 0 {
+		message = fmt.Sprintf(message, args...)
+	}
+	return message
+}
+
+// 根据Accept-Language选择语言，按客户端的偏好顺序取第一个支持的语言
+func Locale(c *gin.Context) string {
+	for _, tag := range strings.Split(c.GetHeader("Accept-Language"), ",") {
+		tag = strings.ToLower(strings.TrimSpace(strings.Split(tag, ";")[0]))
+		switch {
+		case strings.HasPrefix(tag, "zh"):
+			return ZhCN
+		case strings.HasPrefix(tag, "en"):
+			return EnUS
+		}
+	}
+	return DefaultLocale
+}
+
+func requestID(c *gin.Context) string {
+	if id := c.GetString("request_id"); id != "" {
+		return id
+	}
+	return c.GetHeader("X-Request-ID")
+}
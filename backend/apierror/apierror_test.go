@@ -0,0 +1,126 @@
+package apierror
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLocale(t *testing.T) {
+	cases := map[string]string{
+		"":                        ZhCN,
+		"en-US,en;q=0.9":          EnUS,
+		"en":                      EnUS,
+		"zh-CN,zh;q=0.9,en;q=0.8": ZhCN,
+		"zh-TW":                   ZhCN,
+		"fr-FR, en-GB;q=0.8":      EnUS,
+		"ja-JP":                   ZhCN,
+		"EN-us":                   EnUS,
+	}
+	for header, want := range cases {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+		c.Request.Header.Set("Accept-Language", header)
+		if got := Locale(c); got != want {
+			t.Errorf("Locale(%q) = %s, want %s", header, got, want)
+		}
+	}
+}
+
+func TestCatalogTranslated(t *testing.T) {
+	for _, code := range Codes() {
+		for _, locale := range []string{ZhCN, EnUS} {
+			if catalog[code][locale] == "" {
+				t.Errorf("%s 缺少 %s 翻译", code, locale)
+			}
+		}
+	}
+	if got := Message(EnUS, LoginLocked, 30); got != "Too many login attempts, please try again in 30 seconds" {
+		t.Errorf("Message = %q", got)
+	}
+}
+
+type signupRequest struct {
+	Username string `json:"username" binding:"required,min=3"`
+	Age      int    `json:"age" binding:"min=18"`
+	Role     string `json:"role" binding:"omitempty,oneof=user admin"`
+}
+
+func bind(t *testing.T, body, language string) (int, Error) {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/", func(c *gin.Context) {
+		var req signupRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			RespondValidation(c, err)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Language", language)
+	req.Header.Set("X-Request-ID", "req-1")
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, req)
+
+	var res struct {
+		Error Error `json:"error"`
+	}
+	if recorder.Code != http.StatusOK {
+		if err := json.Unmarshal(recorder.Body.Bytes(), &res); err != nil {
+			t.Fatalf("解析响应失败: %v", err)
+		}
+	}
+	return recorder.Code, res.Error
+}
+
+func TestRespondValidation(t *testing.T) {
+	status, err := bind(t, `{"username": "al", "age": 16, "role": "root"}`, "zh-CN")
+	if status != http.StatusBadRequest || err.Code != InvalidRequest || err.RequestID != "req-1" {
+		t.Fatalf("status %d, error %+v", status, err)
+	}
+
+	want := []FieldError{
+		{Field: "username", Rule: "min", Message: "username长度不能少于3个字符"},
+		{Field: "age", Rule: "min", Message: "age不能小于18"},
+		{Field: "role", Rule: "oneof", Message: "role必须是以下值之一：user, admin"},
+	}
+	if len(err.Fields) != len(want) {
+		t.Fatalf("字段错误 %+v", err.Fields)
+	}
+	for i := range want {
+		if err.Fields[i] != want[i] {
+			t.Errorf("字段错误 %+v, want %+v", err.Fields[i], want[i])
+		}
+	}
+	if err.Message != want[0].Message {
+		t.Errorf("错误信息 %q, want %q", err.Message, want[0].Message)
+	}
+
+	_, err = bind(t, `{"age": 20}`, "en-US")
+	if len(err.Fields) != 1 || err.Fields[0].Message != "username is required" {
+		t.Fatalf("字段错误 %+v", err.Fields)
+	}
+
+	_, err = bind(t, `{"username": "alice", "age": "twenty"}`, "en-US")
+	if len(err.Fields) != 1 || err.Fields[0].Field != "age" || err.Fields[0].Rule != "type" {
+		t.Fatalf("类型错误 %+v", err.Fields)
+	}
+
+	_, err = bind(t, `{"username": `, "")
+	if err.Code != InvalidJSON {
+		t.Fatalf("错误码 %s, want %s", err.Code, InvalidJSON)
+	}
+
+	if status, _ := bind(t, `{"username": "alice", "age": 20}`, ""); status != http.StatusOK {
+		t.Fatalf("合法请求返回 %d", status)
+	}
+}
@@ -0,0 +1,151 @@
+package apierror
+
+// 错误码，客户端应根据错误码而不是错误信息判断错误类型
+type Code string
+
+const (
+	// 通用
+	InvalidRequest Code = "INVALID_REQUEST"
+	InvalidJSON    Code = "INVALID_JSON"
+	InvalidTime    Code = "INVALID_TIME"
+	Unauthorized   Code = "UNAUTHORIZED"
+	TokenInvalid   Code = "TOKEN_INVALID"
+	TokenExpired   Code = "TOKEN_EXPIRED"
+	Forbidden      Code = "FORBIDDEN"
+	NotFound       Code = "NOT_FOUND"
+	RateLimited    Code = "RATE_LIMITED"
+	Internal       Code = "INTERNAL_ERROR"
+
+	// 用户和登录
+	UserNotFound         Code = "USER_NOT_FOUND"
+	UsernameTaken        Code = "USERNAME_TAKEN"
+	PhoneTaken           Code = "PHONE_TAKEN"
+	InvalidCredentials   Code = "INVALID_CREDENTIALS"
+	AccountDisabled      Code = "ACCOUNT_DISABLED"
+	LoginLocked          Code = "LOGIN_LOCKED"
+	WrongPassword        Code = "WRONG_PASSWORD"
+	WrongOldPassword     Code = "WRONG_OLD_PASSWORD"
+	ResetContactRequired Code = "RESET_CONTACT_REQUIRED"
+	ResetTokenRequired   Code = "RESET_TOKEN_REQUIRED"
+	ResetTokenInvalid    Code = "RESET_TOKEN_INVALID"
+	ResetCodeInvalid     Code = "RESET_CODE_INVALID"
+
+	// 第三方登录
+	ProviderNotSupported Code = "PROVIDER_NOT_SUPPORTED"
+	ProviderUnavailable  Code = "PROVIDER_UNAVAILABLE"
+	OAuthCodeInvalid     Code = "OAUTH_CODE_INVALID"
+	IdentityTaken        Code = "IDENTITY_TAKEN"
+	IdentityProviderUsed Code = "IDENTITY_PROVIDER_BOUND"
+	IdentityNotBound     Code = "IDENTITY_NOT_BOUND"
+	IdentityLastLogin    Code = "IDENTITY_LAST_LOGIN"
+
+	// 两步验证
+	ChallengeExpired       Code = "CHALLENGE_EXPIRED"
+	TwoFactorCodeInvalid   Code = "TWO_FACTOR_CODE_INVALID"
+	TwoFactorCodeRequired  Code = "TWO_FACTOR_CODE_REQUIRED"
+	RecoveryCodeInvalid    Code = "RECOVERY_CODE_INVALID"
+	TwoFactorEnabled       Code = "TWO_FACTOR_ALREADY_ENABLED"
+	TwoFactorNotEnabled    Code = "TWO_FACTOR_NOT_ENABLED"
+	TwoFactorSetupRequired Code = "TWO_FACTOR_SETUP_REQUIRED"
+	TwoFactorMandatory     Code = "TWO_FACTOR_MANDATORY"
+
+	// 设备、订单和评估
+	DeviceNotFound      Code = "DEVICE_NOT_FOUND"
+	OrderNotFound       Code = "ORDER_NOT_FOUND"
+	OrderNotCancellable Code = "ORDER_NOT_CANCELLABLE"
+	EvaluationNotFound  Code = "EVALUATION_NOT_FOUND"
+	EvaluationExists    Code = "EVALUATION_EXISTS"
+
+	// 品牌、系列和型号目录
+	BrandNotFound       Code = "BRAND_NOT_FOUND"
+	BrandExists         Code = "BRAND_EXISTS"
+	BrandInUse          Code = "BRAND_IN_USE"
+	SeriesNotFound      Code = "SERIES_NOT_FOUND"
+	SeriesExists        Code = "SERIES_EXISTS"
+	SeriesInUse         Code = "SERIES_IN_USE"
+	SeriesBrandMismatch Code = "SERIES_BRAND_MISMATCH"
+	ModelNotFound       Code = "MODEL_NOT_FOUND"
+	ModelExists         Code = "MODEL_EXISTS"
+	ModelInUse          Code = "MODEL_IN_USE"
+
+	// 设备分类
+	CategoryNotFound      Code = "CATEGORY_NOT_FOUND"
+	CategoryExists        Code = "CATEGORY_EXISTS"
+	CategoryInUse         Code = "CATEGORY_IN_USE"
+	CategoryParentInvalid Code = "CATEGORY_PARENT_INVALID"
+)
+
+// 各语言的错误信息，可包含fmt格式化占位符
+var catalog = map[Code]map[string]string{
+	InvalidRequest: {ZhCN: "请求参数错误", EnUS: "Invalid request parameters"},
+	InvalidJSON:    {ZhCN: "请求内容不是有效的JSON", EnUS: "Request body is not valid JSON"},
+	InvalidTime:    {ZhCN: "时间格式错误: %s", EnUS: "Invalid time format: %s"},
+	Unauthorized:   {ZhCN: "缺少认证令牌", EnUS: "Authentication token is missing"},
+	TokenInvalid:   {ZhCN: "无效的认证令牌", EnUS: "Invalid authentication token"},
+	TokenExpired:   {ZhCN: "认证令牌已失效，请重新登录", EnUS: "Session has expired, please log in again"},
+	Forbidden:      {ZhCN: "没有权限执行该操作", EnUS: "You do not have permission to perform this action"},
+	NotFound:       {ZhCN: "请求的资源不存在", EnUS: "The requested resource was not found"},
+	RateLimited:    {ZhCN: "请求过于频繁，请稍后再试", EnUS: "Too many requests, please try again later"},
+	Internal:       {ZhCN: "服务器内部错误，请稍后再试", EnUS: "Internal server error, please try again later"},
+
+	UserNotFound:         {ZhCN: "用户不存在", EnUS: "User not found"},
+	UsernameTaken:        {ZhCN: "用户名已存在", EnUS: "Username is already taken"},
+	PhoneTaken:           {ZhCN: "手机号已存在", EnUS: "Phone number is already registered"},
+	InvalidCredentials:   {ZhCN: "用户名或密码错误", EnUS: "Incorrect username or password"},
+	AccountDisabled:      {ZhCN: "账户已被禁用", EnUS: "Account has been disabled"},
+	LoginLocked:          {ZhCN: "登录尝试过于频繁，请%d秒后再试", EnUS: "Too many login attempts, please try again in %d seconds"},
+	WrongPassword:        {ZhCN: "密码错误", EnUS: "Incorrect password"},
+	WrongOldPassword:     {ZhCN: "原密码错误", EnUS: "Current password is incorrect"},
+	ResetContactRequired: {ZhCN: "请提供邮箱或手机号", EnUS: "Email or phone number is required"},
+	ResetTokenRequired:   {ZhCN: "请提供重置令牌或手机验证码", EnUS: "Reset token or SMS code is required"},
+	ResetTokenInvalid:    {ZhCN: "重置令牌无效或已过期", EnUS: "Reset token is invalid or has expired"},
+	ResetCodeInvalid:     {ZhCN: "验证码无效或已过期", EnUS: "Verification code is invalid or has expired"},
+
+	ProviderNotSupported: {ZhCN: "不支持的登录方式", EnUS: "Unsupported login provider"},
+	ProviderUnavailable:  {ZhCN: "第三方登录服务暂不可用", EnUS: "Login provider is temporarily unavailable"},
+	OAuthCodeInvalid:     {ZhCN: "授权码无效或已过期", EnUS: "Authorization code is invalid or has expired"},
+	IdentityTaken:        {ZhCN: "该账号已绑定其他用户", EnUS: "This account is already linked to another user"},
+	IdentityProviderUsed: {ZhCN: "已绑定该平台的其他账号，请先解绑", EnUS: "Another account from this provider is already linked, unlink it first"},
+	IdentityNotBound:     {ZhCN: "未绑定该平台账号", EnUS: "No account from this provider is linked"},
+	IdentityLastLogin:    {ZhCN: "这是账号唯一的登录方式，请先绑定手机号或邮箱", EnUS: "This is the only way to sign in, add a phone number or email first"},
+
+	ChallengeExpired:       {ZhCN: "验证已过期，请重新登录", EnUS: "Verification has expired, please log in again"},
+	TwoFactorCodeInvalid:   {ZhCN: "验证码错误", EnUS: "Incorrect verification code"},
+	TwoFactorCodeRequired:  {ZhCN: "请输入验证码或恢复码", EnUS: "Verification code or recovery code is required"},
+	RecoveryCodeInvalid:    {ZhCN: "恢复码无效", EnUS: "Invalid recovery code"},
+	TwoFactorEnabled:       {ZhCN: "已开启两步验证", EnUS: "Two-factor authentication is already enabled"},
+	TwoFactorNotEnabled:    {ZhCN: "未开启两步验证", EnUS: "Two-factor authentication is not enabled"},
+	TwoFactorSetupRequired: {ZhCN: "请先生成两步验证密钥", EnUS: "Generate a two-factor secret first"},
+	TwoFactorMandatory:     {ZhCN: "该账号必须开启两步验证", EnUS: "Two-factor authentication is mandatory for this account"},
+
+	DeviceNotFound:      {ZhCN: "设备不存在", EnUS: "Device not found"},
+	OrderNotFound:       {ZhCN: "订单不存在", EnUS: "Order not found"},
+	OrderNotCancellable: {ZhCN: "订单状态不允许取消", EnUS: "Order can no longer be cancelled"},
+	EvaluationNotFound:  {ZhCN: "评估不存在", EnUS: "Evaluation not found"},
+	EvaluationExists:    {ZhCN: "该订单已有评估记录", EnUS: "This order has already been evaluated"},
+
+	BrandNotFound:       {ZhCN: "品牌不存在", EnUS: "Brand not found"},
+	BrandExists:         {ZhCN: "品牌名称或别名已被其他品牌使用", EnUS: "Brand name or alias is already used by another brand"},
+	BrandInUse:          {ZhCN: "品牌下还有系列、型号或设备，不能删除", EnUS: "Brand still has series, models or devices and cannot be deleted"},
+	SeriesNotFound:      {ZhCN: "系列不存在", EnUS: "Series not found"},
+	SeriesExists:        {ZhCN: "该品牌下已有同名系列", EnUS: "A series with this name already exists for the brand"},
+	SeriesInUse:         {ZhCN: "系列下还有型号，不能删除或移到其他品牌", EnUS: "Series still has models and cannot be deleted or moved to another brand"},
+	SeriesBrandMismatch: {ZhCN: "系列不属于该品牌", EnUS: "Series does not belong to the brand"},
+	ModelNotFound:       {ZhCN: "型号不存在", EnUS: "Model not found"},
+	ModelExists:         {ZhCN: "型号名称或别名已被该品牌的其他型号使用", EnUS: "Model name or alias is already used by another model of the brand"},
+	ModelInUse:          {ZhCN: "还有设备使用该型号，不能删除", EnUS: "Model is still used by devices and cannot be deleted"},
+
+	CategoryNotFound:      {ZhCN: "分类不存在", EnUS: "Category not found"},
+	CategoryExists:        {ZhCN: "分类代码已存在", EnUS: "Category code already exists"},
+	CategoryInUse:         {ZhCN: "分类下还有子分类或设备，不能删除或修改代码", EnUS: "Category still has subcategories or devices and cannot be deleted or renamed"},
+	CategoryParentInvalid: {ZhCN: "上级分类不能是该分类本身或其子分类", EnUS: "Parent category cannot be the category itself or one of its subcategories"},
+}
+
+// 所有错误码
+func Codes() []Code {
+	codes := make([]Code, 0, len(catalog))
+	for code := range catalog {
+		codes = append(codes, code)
+	}
+	return codes
+}
@@ -0,0 +1,136 @@
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// 校验失败时使用JSON字段名，与请求中的字段一致
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			if name == "-" {
+				return ""
+			}
+			return name
+		})
+	}
+}
+
+// 各校验规则的错误信息，第一个占位符为字段名，第二个为规则参数
+var ruleMessages = map[string]map[string]string{
+	"required": {ZhCN: "%s不能为空", EnUS: "%s is required"},
+	// 与其他字段二选一
+	"required_without": {ZhCN: "%s不能为空", EnUS: "%s is required"},
+	"min":              {ZhCN: "%s不能小于%s", EnUS: "%s must be at least %s"},
+	"max":              {ZhCN: "%s不能大于%s", EnUS: "%s must be at most %s"},
+	"min_length":       {ZhCN: "%s长度不能少于%s个字符", EnUS: "%s must be at least %s characters long"},
+	"max_length":       {ZhCN: "%s长度不能超过%s个字符", EnUS: "%s must be at most %s characters long"},
+	"len":              {ZhCN: "%s长度必须为%s个字符", EnUS: "%s must be exactly %s characters long"},
+	"oneof":            {ZhCN: "%s必须是以下值之一：%s", EnUS: "%s must be one of: %s"},
+	"email":            {ZhCN: "%s不是有效的邮箱地址", EnUS: "%s must be a valid email address"},
+	"numeric":          {ZhCN: "%s只能包含数字", EnUS: "%s must contain only digits"},
+	"unique":           {ZhCN: "%s不能包含重复的值", EnUS: "%s must not contain duplicates"},
+	"type":             {ZhCN: "%s类型不正确", EnUS: "%s has an invalid type"},
+	"invalid":          {ZhCN: "%s格式不正确", EnUS: "%s is invalid"},
+}
+
+// 返回请求参数绑定失败的错误响应，校验错误按字段翻译
+func RespondValidation(c *gin.Context, err error) {
+	locale := Locale(c)
+	apiErr := New(c, InvalidRequest)
+
+	var validationErrors validator.ValidationErrors
+	var typeError *json.UnmarshalTypeError
+	var syntaxError *json.SyntaxError
+	switch {
+	case errors.As(err, &validationErrors):
+		for _, fe := range validationErrors {
+			apiErr.Fields = append(apiErr.Fields, translateField(locale, fe))
+		}
+	case errors.As(err, &typeError):
+		apiErr.Fields = []FieldError{{
+			Field:   typeError.Field,
+			Rule:    "type",
+			Message: ruleMessage(locale, "type", typeError.Field, ""),
+		}}
+	case errors.As(err, &syntaxError), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		apiErr = New(c, InvalidJSON)
+	}
+
+	// 便于客户端直接展示，使用第一个字段的错误作为错误信息
+	if len(apiErr.Fields) > 0 {
+		apiErr.Message = apiErr.Fields[0].Message
+	}
+
+	RespondError(c, http.StatusBadRequest, apiErr)
+}
+
+// 返回单个查询参数不合法的错误响应，rule和param的含义与binding标签相同（数值的min、max等）
+func RespondInvalidParam(c *gin.Context, field, rule, param string) {
+	locale := Locale(c)
+	apiErr := New(c, InvalidRequest)
+
+	key := rule
+	if rule == "oneof" {
+		param = strings.Join(strings.Fields(param), ", ")
+	}
+	if _, ok := ruleMessages[key]; !ok {
+		key = "invalid"
+	}
+	apiErr.Fields = []FieldError{{
+		Field:   field,
+		Rule:    rule,
+		Message: ruleMessage(locale, key, field, param),
+	}}
+	apiErr.Message = apiErr.Fields[0].Message
+
+	RespondError(c, http.StatusBadRequest, apiErr)
+}
+
+func translateField(locale string, fe validator.FieldError) FieldError {
+	rule := fe.Tag()
+	param := fe.Param()
+
+	key := rule
+	switch rule {
+	case "min", "max":
+		// 字符串校验的是长度
+		if fe.Kind() == reflect.String {
+			key = rule + "_length"
+		}
+	case "oneof":
+		param = strings.Join(strings.Fields(param), ", ")
+	}
+	if _, ok := ruleMessages[key]; !ok {
+		key = "invalid"
+	}
+
+	return FieldError{
+		Field:   fe.Field(),
+		Rule:    rule,
+		Message: ruleMessage(locale, key, fe.Field(), param),
+	}
+}
+
+func ruleMessage(locale, key, field, param string) string {
+	messages := ruleMessages[key]
+	message, ok := messages[locale]
+	if !ok {
+		message = messages[DefaultLocale]
+	}
+	if strings.Count(message, "%s") == 2 {
+		return fmt.Sprintf(message, field, param)
+	}
+	return fmt.Sprintf(message, field)
+}
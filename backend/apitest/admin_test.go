@@ -0,0 +1,167 @@
+package apitest
+
+import (
+	"e-device-recycle-backend/apierror"
+	"e-device-recycle-backend/models"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func TestAuditLogs(t *testing.T) {
+	s := NewServer(t)
+	device := s.CreateDevice("MacBook Pro", "Apple", 10000)
+	admin := s.CreateUser("admin", "admin")
+	token := s.Token(admin)
+
+	s.Do(http.MethodPut, fmt.Sprintf("/api/v1/admin/devices/%d", device.ID), token, gin.H{"base_price": 9000}).
+		Status(http.StatusOK)
+
+	var logs struct {
+		Logs []struct {
+			Action    string `json:"action"`
+			ActorID   uint   `json:"actor_id"`
+			Diff      string `json:"diff"`
+			RequestID string `json:"request_id"`
+		} `json:"logs"`
+	}
+	s.Do(http.MethodGet, fmt.Sprintf("/api/v1/admin/audit-logs/?entity_type=device&entity_id=%d", device.ID), token, nil).
+		Status(http.StatusOK).Decode(&logs)
+	if len(logs.Logs) != 1 {
+		t.Fatalf("审计日志数量 %d, want 1", len(logs.Logs))
+	}
+	entry := logs.Logs[0]
+	if entry.Action != "device.update" || entry.ActorID != admin.ID || !strings.Contains(entry.Diff, "base_price") {
+		t.Fatalf("审计日志不正确: %+v", entry)
+	}
+
+	s.Do(http.MethodGet, "/api/v1/admin/audit-logs/?start=yesterday", token, nil).Status(http.StatusBadRequest)
+
+	res := s.Do(http.MethodGet, "/api/v1/admin/audit-logs/export?action=device.update", token, nil).
+		Status(http.StatusOK)
+	if !strings.HasPrefix(res.Header().Get("Content-Type"), "text/csv") {
+		t.Fatalf("Content-Type %q", res.Header().Get("Content-Type"))
+	}
+	records, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(res.Body.String(), "\xEF\xBB\xBF"))).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[1][5] != "device.update" {
+		t.Fatalf("导出内容不正确: %v", records)
+	}
+}
+
+func TestAuditLogRecordsSpecChanges(t *testing.T) {
+	s := NewServer(t)
+	device := s.CreateDevice("MacBook Pro", "Apple", 10000)
+	token := s.Token(s.CreateUser("admin", "admin"))
+
+	// 只修改结构化规格时同样记录变更
+	s.Do(http.MethodPut, fmt.Sprintf("/api/v1/admin/devices/%d", device.ID), token, gin.H{
+		"specs": gin.H{"storage_type": "hdd"},
+	}).Status(http.StatusOK)
+
+	var entry models.AuditLog
+	if err := s.DB.Where("action = ? AND entity_id = ?", "device.update", device.ID).First(&entry).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(entry.Diff, `"specs.storage_type"`) || !strings.Contains(entry.After, `"specs.storage_type":"hdd"`) {
+		t.Errorf("diff = %s, after = %s", entry.Diff, entry.After)
+	}
+
+	// 规格按创建设备时的规则校验
+	s.Do(http.MethodPut, fmt.Sprintf("/api/v1/admin/devices/%d", device.ID), token, gin.H{
+		"specs": gin.H{"storage_type": "tape"},
+	}).Status(http.StatusBadRequest)
+}
+
+func TestAuditLogExportBatches(t *testing.T) {
+	s := NewServer(t)
+	token := s.Token(s.CreateUser("admin", "admin"))
+
+	// 超过一批（500行）的日志全部导出，按ID从新到旧
+	logs := make([]models.AuditLog, 1200)
+	for i := range logs {
+		logs[i] = models.AuditLog{Action: "device.update", EntityType: "device", EntityID: uint(i + 1)}
+	}
+	if err := s.DB.CreateInBatches(logs, 200).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	res := s.Do(http.MethodGet, "/api/v1/admin/audit-logs/export?action=device.update", token, nil).Status(http.StatusOK)
+	records, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(res.Body.String(), "\xEF\xBB\xBF"))).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1201 {
+		t.Fatalf("导出 %d 行, want 1200", len(records)-1)
+	}
+	seen := make(map[string]bool)
+	prev := uint64(math.MaxUint64)
+	for _, record := range records[1:] {
+		id, _ := strconv.ParseUint(record[0], 10, 64)
+		if seen[record[0]] || id >= prev {
+			t.Fatalf("ID %d 重复或顺序错误（上一行 %d）", id, prev)
+		}
+		seen[record[0]], prev = true, id
+	}
+}
+
+func TestAuditLogExportErrors(t *testing.T) {
+	s := NewServer(t)
+	token := s.Token(s.CreateUser("admin", "admin"))
+
+	logs := make([]models.AuditLog, 600)
+	for i := range logs {
+		logs[i] = models.AuditLog{Action: "device.update", EntityType: "device", EntityID: uint(i + 1)}
+	}
+	if err := s.DB.CreateInBatches(logs, 200).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// 第failAt次查询审计日志时返回错误
+	var queries, failAt int32
+	err := s.DB.Callback().Query().Before("gorm:query").Register("apitest:fail_audit_logs", func(db *gorm.DB) {
+		if db.Statement.Table == "audit_logs" && atomic.AddInt32(&queries, 1) == atomic.LoadInt32(&failAt) {
+			db.AddError(errors.New("数据库连接中断"))
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 第一批读取失败时还没有写出响应，返回错误
+	atomic.StoreInt32(&failAt, 1)
+	res := s.Do(http.MethodGet, "/api/v1/admin/audit-logs/export", token, nil).
+		Status(http.StatusInternalServerError).ErrorCode(apierror.Internal)
+	if got := res.Header().Get("Content-Disposition"); got != "" {
+		t.Errorf("失败的导出不应带附件头: %q", got)
+	}
+
+	// 之后的批次失败时中断连接，客户端读不到完整的响应
+	atomic.StoreInt32(&queries, 0)
+	atomic.StoreInt32(&failAt, 2)
+	server := httptest.NewServer(s.Router)
+	defer server.Close()
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/api/v1/admin/audit-logs/export", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := server.Client().Do(req)
+	if err == nil {
+		_, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+	if err == nil {
+		t.Error("导出中途失败时响应正常结束，want 连接中断")
+	}
+}
@@ -0,0 +1,341 @@
+// Package apitest 提供接口集成测试工具：使用内存SQLite启动完整的路由，
+// 并提供用户、设备、订单等测试数据和获取JWT的辅助方法。
+//
+// 服务依赖models.DB等全局变量，使用该包的测试不能并行执行。
+package apitest
+
+import (
+	"bytes"
+	"context"
+	"e-device-recycle-backend/apierror"
+	"e-device-recycle-backend/config"
+	"e-device-recycle-backend/identity"
+	"e-device-recycle-backend/logging"
+	"e-device-recycle-backend/middleware"
+	"e-device-recycle-backend/migrations"
+	"e-device-recycle-backend/models"
+	"e-device-recycle-backend/repositories"
+	"e-device-recycle-backend/routes"
+	"e-device-recycle-backend/search"
+	"e-device-recycle-backend/security"
+	"e-device-recycle-backend/services"
+	"e-device-recycle-backend/store"
+	"e-device-recycle-backend/utils"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// 测试用户的默认密码
+const Password = "password123"
+
+var dbSeq int64
+
+type Server struct {
+	t      *testing.T
+	Router *gin.Engine
+	DB     *gorm.DB
+	Outbox *Outbox // 发出的邮件和短信
+}
+
+// 启动测试服务，每次调用使用独立的内存数据库，测试结束后自动关闭
+func NewServer(t *testing.T) *Server {
+	t.Helper()
+
+	name := fmt.Sprintf("file:apitest%d?mode=memory&cache=shared", atomic.AddInt64(&dbSeq, 1))
+	err := config.Init("", func(cfg *config.Config) {
+		cfg.Env = config.EnvDevelopment
+		cfg.Database.Driver = "sqlite"
+		cfg.Database.Path = name
+	})
+	if err != nil {
+		t.Fatalf("加载配置失败: %v", err)
+	}
+
+	db, err := models.Open(config.GetConfig(), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("打开测试数据库失败: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	migrator, err := migrations.New(db)
+	if err != nil {
+		t.Fatalf("加载数据库迁移失败: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("执行数据库迁移失败: %v", err)
+	}
+
+	models.DB = db
+	store.Redis = nil
+	security.Guard = security.NewLoginGuard(store.NewCounterStore())
+	middleware.SetRateLimiter(nil)
+
+	// 测试中不输出日志
+	previousLogger := slog.Default()
+	slog.SetDefault(slog.New(logging.NewHandler(io.Discard, slog.LevelError, "json")))
+	t.Cleanup(func() { slog.SetDefault(previousLogger) })
+
+	outbox := &Outbox{}
+	previous := utils.GetNotifier()
+	utils.SetNotifier(outbox)
+	t.Cleanup(func() { utils.SetNotifier(previous) })
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	if err := r.SetTrustedProxies(config.GetConfig().Server.TrustedProxies); err != nil {
+		t.Fatalf("设置可信代理失败: %v", err)
+	}
+	r.Use(middleware.RequestID(), middleware.AccessLog(), middleware.Metrics(), middleware.Recovery())
+	routes.SetupRoutes(r, services.New(repositories.New(db), search.NewDBIndex(db)))
+
+	return &Server{t: t, Router: r, DB: db, Outbox: outbox}
+}
+
+// 创建用户，密码为Password
+func (s *Server) CreateUser(username, role string) *models.User {
+	s.t.Helper()
+
+	hashed, err := utils.HashPassword(Password)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	user := &models.User{
+		Username: username,
+		Password: hashed,
+		Phone:    fmt.Sprintf("138%08d", atomic.AddInt64(&dbSeq, 1)),
+		Email:    username + "@example.com",
+		RealName: username,
+		Role:     role,
+		Status:   "active",
+	}
+	if err := s.DB.Create(user).Error; err != nil {
+		s.t.Fatalf("创建用户失败: %v", err)
+	}
+	return user
+}
+
+// 注册连接模拟微信接口的微信登录
+func (s *Server) EnableWeChat() {
+	mock := httptest.NewServer(identity.NewMockWeChatServer())
+	s.t.Cleanup(mock.Close)
+	identity.Register(identity.NewWeChatProvider("apitest-appid", "apitest-secret", mock.URL))
+}
+
+// 生成两步验证码，offset为相对当前时间的时间步数（同一时间步的验证码只能使用一次）
+func (s *Server) TOTPCode(secret string, offset int) string {
+	s.t.Helper()
+
+	code, err := utils.GenerateTOTP(secret, time.Now().Add(time.Duration(offset)*30*time.Second))
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	return code
+}
+
+// 创建在售设备，品牌不在目录中时新建品牌
+func (s *Server) CreateDevice(name, brand string, basePrice float64) *models.Device {
+	s.t.Helper()
+
+	catalogBrand := models.Brand{Name: brand, Aliases: []string{}}
+	if err := s.DB.Where("name = ?", brand).FirstOrCreate(&catalogBrand).Error; err != nil {
+		s.t.Fatalf("创建品牌失败: %v", err)
+	}
+	device := &models.Device{
+		Name:       name,
+		Brand:      brand,
+		BrandID:    &catalogBrand.ID,
+		Model:      name,
+		Category:   "laptop",
+		CPU:        "Intel i7",
+		Memory:     "16GB",
+		Storage:    "512GB SSD",
+		Condition:  "good",
+		YearBought: time.Now().Year() - 1,
+		BasePrice:  basePrice,
+		Images:     "[]",
+		Status:     "active",
+	}
+	if err := s.DB.Create(device).Error; err != nil {
+		s.t.Fatalf("创建设备失败: %v", err)
+	}
+	return device
+}
+
+// 通过接口为用户创建订单
+func (s *Server) CreateOrder(user *models.User, device *models.Device) models.RecycleOrderResponse {
+	s.t.Helper()
+
+	var body struct {
+		Order models.RecycleOrderResponse `json:"order"`
+	}
+	s.Do(http.MethodPost, "/api/v1/orders/", s.Token(user), gin.H{
+		"device_id":      device.ID,
+		"contact_name":   user.RealName,
+		"contact_phone":  user.Phone,
+		"pickup_address": "北京市海淀区中关村大街1号",
+	}).Status(http.StatusCreated).Decode(&body)
+	return body.Order
+}
+
+// 签发用户的正式访问令牌（跳过登录和两步验证）
+func (s *Server) Token(user *models.User) string {
+	s.t.Helper()
+
+	// 读取最新的令牌版本，修改密码后旧令牌失效
+	var current models.User
+	if err := s.DB.First(&current, user.ID).Error; err != nil {
+		s.t.Fatalf("查询用户失败: %v", err)
+	}
+	token, err := utils.GenerateJWT(current.ID, current.Username, current.Role, current.TokenVersion)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	return token
+}
+
+// 发送原始请求，用于自定义请求头等场景
+func (s *Server) Serve(req *http.Request) *Response {
+	recorder := httptest.NewRecorder()
+	s.Router.ServeHTTP(recorder, req)
+	return &Response{t: s.t, method: req.Method, path: req.URL.Path, ResponseRecorder: recorder}
+}
+
+// 发送请求，token为空时不携带认证头，body会编码为JSON
+func (s *Server) Do(method, path, token string, body interface{}) *Response {
+	s.t.Helper()
+
+	req := NewRequest(s.t, method, path, body)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	return s.Serve(req)
+}
+
+// 创建请求，body会编码为JSON，用于需要自定义请求头的场景
+func NewRequest(t *testing.T, method, path string, body interface{}) *http.Request {
+	t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req
+}
+
+type Response struct {
+	*httptest.ResponseRecorder
+	t      *testing.T
+	method string
+	path   string
+}
+
+// 断言状态码，不一致时终止测试并输出响应内容
+func (r *Response) Status(want int) *Response {
+	r.t.Helper()
+	if r.Code != want {
+		r.t.Fatalf("%s %s: 状态码 %d, want %d, 响应: %s", r.method, r.path, r.Code, want, r.Body.String())
+	}
+	return r
+}
+
+// 将响应内容解析到v
+func (r *Response) Decode(v interface{}) {
+	r.t.Helper()
+	if err := json.Unmarshal(r.Body.Bytes(), v); err != nil {
+		r.t.Fatalf("%s %s: 解析响应失败: %v, 响应: %s", r.method, r.path, err, r.Body.String())
+	}
+}
+
+// 解析错误响应
+func (r *Response) Error() apierror.Error {
+	r.t.Helper()
+	var body struct {
+		Error apierror.Error `json:"error"`
+	}
+	r.Decode(&body)
+	return body.Error
+}
+
+// 断言错误码
+func (r *Response) ErrorCode(want apierror.Code) *Response {
+	r.t.Helper()
+	if got := r.Error().Code; got != want {
+		r.t.Fatalf("%s %s: 错误码 %s, want %s, 响应: %s", r.method, r.path, got, want, r.Body.String())
+	}
+	return r
+}
+
+// 将响应解析为map
+func (r *Response) JSON() map[string]interface{} {
+	r.t.Helper()
+	var body map[string]interface{}
+	r.Decode(&body)
+	return body
+}
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// 记录发出的通知，替代真实的邮件/短信服务
+type Outbox struct {
+	mu     sync.Mutex
+	Emails []Message
+	SMS    []Message
+}
+
+func (o *Outbox) SendEmail(to, subject, body string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.Emails = append(o.Emails, Message{To: to, Subject: subject, Body: body})
+	return nil
+}
+
+func (o *Outbox) SendSMS(phone, content string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.SMS = append(o.SMS, Message{To: phone, Body: content})
+	return nil
+}
+
+// 最近一条发往指定手机号的短信
+func (o *Outbox) LastSMS(phone string) (Message, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for i := len(o.SMS) - 1; i >= 0; i-- {
+		if o.SMS[i].To == phone {
+			return o.SMS[i], true
+		}
+	}
+	return Message{}, false
+}
@@ -0,0 +1,394 @@
+package apitest
+
+import (
+	"e-device-recycle-backend/models"
+	"e-device-recycle-backend/security"
+	"e-device-recycle-backend/utils"
+	"fmt"
+	"net/http"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRegisterAndLogin(t *testing.T) {
+	s := NewServer(t)
+
+	res := s.Do(http.MethodPost, "/api/v1/auth/register", "", gin.H{
+		"username":  "alice",
+		"password":  Password,
+		"phone":     "13800000001",
+		"email":     "alice@example.com",
+		"real_name": "Alice",
+	}).Status(http.StatusCreated).JSON()
+	if res["token"] == "" {
+		t.Fatal("注册未返回token")
+	}
+
+	// 用户名重复
+	s.Do(http.MethodPost, "/api/v1/auth/register", "", gin.H{
+		"username": "alice",
+		"password": Password,
+		"phone":    "13800000002",
+		"email":    "alice2@example.com",
+	}).Status(http.StatusConflict)
+
+	// 参数校验失败
+	s.Do(http.MethodPost, "/api/v1/auth/register", "", gin.H{"username": "al"}).Status(http.StatusBadRequest)
+
+	var login struct {
+		Token string `json:"token"`
+		User  struct {
+			Username string `json:"username"`
+		} `json:"user"`
+	}
+	s.Do(http.MethodPost, "/api/v1/auth/login", "", gin.H{
+		"username": "alice",
+		"password": Password,
+	}).Status(http.StatusOK).Decode(&login)
+	if login.Token == "" || login.User.Username != "alice" {
+		t.Fatalf("登录响应不正确: %+v", login)
+	}
+
+	s.Do(http.MethodGet, "/api/v1/user/profile", login.Token, nil).Status(http.StatusOK)
+
+	s.Do(http.MethodPost, "/api/v1/auth/login", "", gin.H{
+		"username": "alice",
+		"password": "wrong-password",
+	}).Status(http.StatusUnauthorized)
+	s.Do(http.MethodPost, "/api/v1/auth/login", "", gin.H{
+		"username": "nobody",
+		"password": Password,
+	}).Status(http.StatusUnauthorized)
+}
+
+func TestLoginDisabledUser(t *testing.T) {
+	s := NewServer(t)
+	user := s.CreateUser("disabled", "user")
+	token := s.Token(user)
+	s.DB.Model(user).Update("status", "disabled")
+
+	s.Do(http.MethodPost, "/api/v1/auth/login", "", gin.H{
+		"username": "disabled",
+		"password": Password,
+	}).Status(http.StatusForbidden)
+
+	// 已签发的令牌同样失效
+	s.Do(http.MethodGet, "/api/v1/user/profile", token, nil).Status(http.StatusUnauthorized)
+}
+
+func TestLoginLockoutAndUnlock(t *testing.T) {
+	s := NewServer(t)
+	s.CreateUser("bob", "user")
+	admin := s.CreateUser("admin", "admin")
+	// 关闭逐步延迟，只验证达到次数后的锁定
+	security.Guard.DelayAfter = security.Guard.MaxFailures
+
+	for i := 0; i < 5; i++ {
+		s.Do(http.MethodPost, "/api/v1/auth/login", "", gin.H{
+			"username": "bob",
+			"password": "wrong-password",
+		}).Status(http.StatusUnauthorized)
+	}
+
+	res := s.Do(http.MethodPost, "/api/v1/auth/login", "", gin.H{
+		"username": "bob",
+		"password": Password,
+	}).Status(http.StatusTooManyRequests)
+	if res.Header().Get("Retry-After") == "" {
+		t.Fatal("锁定响应缺少Retry-After")
+	}
+
+	var attempts struct {
+		Attempts []struct {
+			Reason string `json:"reason"`
+		} `json:"attempts"`
+	}
+	s.Do(http.MethodGet, "/api/v1/admin/login-attempts?username=bob", s.Token(admin), nil).
+		Status(http.StatusOK).Decode(&attempts)
+	if len(attempts.Attempts) != 6 {
+		t.Fatalf("登录记录数 %d, want 6", len(attempts.Attempts))
+	}
+
+	var bob struct{ ID uint }
+	s.DB.Table("users").Where("username = ?", "bob").Select("id").Scan(&bob)
+	s.Do(http.MethodPost, fmt.Sprintf("/api/v1/admin/users/%d/unlock?ip=192.0.2.1", bob.ID), s.Token(admin), nil).
+		Status(http.StatusOK)
+	s.Do(http.MethodPost, "/api/v1/admin/users/999/unlock", s.Token(admin), nil).
+		Status(http.StatusNotFound)
+
+	s.Do(http.MethodPost, "/api/v1/auth/login", "", gin.H{
+		"username": "bob",
+		"password": Password,
+	}).Status(http.StatusOK)
+}
+
+func TestPasswordResetBySMS(t *testing.T) {
+	s := NewServer(t)
+	user := s.CreateUser("carol", "user")
+
+	s.Do(http.MethodPost, "/api/v1/auth/password/forgot", "", gin.H{
+		"channel": "sms",
+		"phone":   user.Phone,
+	}).Status(http.StatusOK)
+
+	// 不存在的账户返回相同结果
+	s.Do(http.MethodPost, "/api/v1/auth/password/forgot", "", gin.H{
+		"channel": "sms",
+		"phone":   "13999999999",
+	}).Status(http.StatusOK)
+
+	message, ok := s.Outbox.LastSMS(user.Phone)
+	if !ok {
+		t.Fatal("未发送验证码短信")
+	}
+	code := regexp.MustCompile(`\d{6}`).FindString(message.Body)
+
+	s.Do(http.MethodPost, "/api/v1/auth/password/reset", "", gin.H{
+		"phone":        user.Phone,
+		"code":         "abcdef",
+		"new_password": "new-password",
+	}).Status(http.StatusBadRequest)
+
+	oldToken := s.Token(user)
+	s.Do(http.MethodPost, "/api/v1/auth/password/reset", "", gin.H{
+		"phone":        user.Phone,
+		"code":         code,
+		"new_password": "new-password",
+	}).Status(http.StatusOK)
+
+	// 验证码只能使用一次
+	s.Do(http.MethodPost, "/api/v1/auth/password/reset", "", gin.H{
+		"phone":        user.Phone,
+		"code":         code,
+		"new_password": "other-password",
+	}).Status(http.StatusBadRequest)
+
+	s.Do(http.MethodGet, "/api/v1/user/profile", oldToken, nil).Status(http.StatusUnauthorized)
+	s.Do(http.MethodPost, "/api/v1/auth/login", "", gin.H{
+		"username": "carol",
+		"password": "new-password",
+	}).Status(http.StatusOK)
+}
+
+func TestPasswordResetRepeatedSMSCode(t *testing.T) {
+	s := NewServer(t)
+	user := s.CreateUser("dave", "user")
+
+	s.Do(http.MethodPost, "/api/v1/auth/password/forgot", "", gin.H{
+		"channel": "sms",
+		"phone":   user.Phone,
+	}).Status(http.StatusOK)
+	message, _ := s.Outbox.LastSMS(user.Phone)
+	code := regexp.MustCompile(`\d{6}`).FindString(message.Body)
+
+	// 之前发送过相同的验证码（已使用），保存时不能冲突，校验时只看最新的验证码
+	usedAt := time.Now().Add(-time.Hour)
+	if err := s.DB.Create(&models.PasswordResetToken{
+		UserID:    user.ID,
+		Channel:   "sms",
+		TokenHash: utils.HashToken(code),
+		ExpiresAt: usedAt,
+		UsedAt:    &usedAt,
+	}).Error; err != nil {
+		t.Fatalf("保存相同的验证码失败: %v", err)
+	}
+
+	s.Do(http.MethodPost, "/api/v1/auth/password/reset", "", gin.H{
+		"phone":        user.Phone,
+		"code":         code,
+		"new_password": "new-password",
+	}).Status(http.StatusOK)
+}
+
+func TestPasswordResetAttemptsPerAccount(t *testing.T) {
+	s := NewServer(t)
+	user := s.CreateUser("erin", "user")
+
+	// 前一个验证码已经用完错误次数，重新获取验证码后仍不能重置
+	usedAt := time.Now()
+	if err := s.DB.Create(&models.PasswordResetToken{
+		UserID:    user.ID,
+		Channel:   "sms",
+		TokenHash: utils.HashToken("000000"),
+		Attempts:  5,
+		ExpiresAt: usedAt.Add(10 * time.Minute),
+		UsedAt:    &usedAt,
+	}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	s.Do(http.MethodPost, "/api/v1/auth/password/forgot", "", gin.H{
+		"channel": "sms",
+		"phone":   user.Phone,
+	}).Status(http.StatusOK)
+	message, _ := s.Outbox.LastSMS(user.Phone)
+	code := regexp.MustCompile(`\d{6}`).FindString(message.Body)
+
+	s.Do(http.MethodPost, "/api/v1/auth/password/reset", "", gin.H{
+		"phone":        user.Phone,
+		"code":         code,
+		"new_password": "new-password",
+	}).Status(http.StatusBadRequest)
+}
+
+func TestRequiredTwoFactorLogin(t *testing.T) {
+	s := NewServer(t)
+	s.CreateUser("admin", "admin")
+
+	// 管理员首次登录需要先开启两步验证
+	var challenge struct {
+		ChallengeToken         string `json:"challenge_token"`
+		TwoFactorSetupRequired bool   `json:"two_factor_setup_required"`
+	}
+	s.Do(http.MethodPost, "/api/v1/auth/login", "", gin.H{
+		"username": "admin",
+		"password": Password,
+	}).Status(http.StatusOK).Decode(&challenge)
+	if !challenge.TwoFactorSetupRequired || challenge.ChallengeToken == "" {
+		t.Fatalf("管理员登录未要求开启两步验证: %+v", challenge)
+	}
+
+	// 临时令牌不能访问接口
+	s.Do(http.MethodGet, "/api/v1/user/profile", challenge.ChallengeToken, nil).Status(http.StatusUnauthorized)
+
+	var setup struct {
+		Secret string `json:"secret"`
+	}
+	s.Do(http.MethodPost, "/api/v1/auth/2fa/setup", "", gin.H{
+		"challenge_token": challenge.ChallengeToken,
+	}).Status(http.StatusOK).Decode(&setup)
+
+	var enabled struct {
+		Token         string   `json:"token"`
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	s.Do(http.MethodPost, "/api/v1/auth/2fa/enable", "", gin.H{
+		"challenge_token": challenge.ChallengeToken,
+		"code":            s.TOTPCode(setup.Secret, 0),
+	}).Status(http.StatusOK).Decode(&enabled)
+	if enabled.Token == "" || len(enabled.RecoveryCodes) != 10 {
+		t.Fatalf("开启两步验证响应不正确: %+v", enabled)
+	}
+	s.Do(http.MethodGet, "/api/v1/admin/orders/", enabled.Token, nil).Status(http.StatusOK)
+
+	// 再次登录需要输入验证码
+	login := func() string {
+		var res struct {
+			ChallengeToken    string `json:"challenge_token"`
+			TwoFactorRequired bool   `json:"two_factor_required"`
+		}
+		s.Do(http.MethodPost, "/api/v1/auth/login", "", gin.H{
+			"username": "admin",
+			"password": Password,
+		}).Status(http.StatusOK).Decode(&res)
+		if !res.TwoFactorRequired {
+			t.Fatal("登录未要求两步验证")
+		}
+		return res.ChallengeToken
+	}
+
+	token := login()
+	s.Do(http.MethodPost, "/api/v1/auth/2fa/verify", "", gin.H{
+		"challenge_token": token,
+		"code":            "000000",
+	}).Status(http.StatusUnauthorized)
+	code := s.TOTPCode(setup.Secret, 1)
+	s.Do(http.MethodPost, "/api/v1/auth/2fa/verify", "", gin.H{
+		"challenge_token": token,
+		"code":            code,
+	}).Status(http.StatusOK)
+
+	// 已使用的验证码不能再次使用
+	s.Do(http.MethodPost, "/api/v1/auth/2fa/verify", "", gin.H{
+		"challenge_token": login(),
+		"code":            code,
+	}).Status(http.StatusUnauthorized)
+
+	// 恢复码只能使用一次
+	token = login()
+	s.Do(http.MethodPost, "/api/v1/auth/2fa/verify", "", gin.H{
+		"challenge_token": token,
+		"recovery_code":   enabled.RecoveryCodes[0],
+	}).Status(http.StatusOK)
+	s.Do(http.MethodPost, "/api/v1/auth/2fa/verify", "", gin.H{
+		"challenge_token": token,
+		"recovery_code":   enabled.RecoveryCodes[0],
+	}).Status(http.StatusUnauthorized)
+
+	s.Do(http.MethodPost, "/api/v1/auth/2fa/verify", "", gin.H{
+		"challenge_token": "invalid",
+		"code":            "123456",
+	}).Status(http.StatusUnauthorized)
+}
+
+func TestTwoFactorFailuresNotResetByPassword(t *testing.T) {
+	s := NewServer(t)
+	s.CreateUser("admin", "admin")
+	security.Guard.DelayAfter = security.Guard.MaxFailures
+
+	var challenge struct {
+		ChallengeToken string `json:"challenge_token"`
+	}
+	s.Do(http.MethodPost, "/api/v1/auth/login", "", gin.H{
+		"username": "admin",
+		"password": Password,
+	}).Status(http.StatusOK).Decode(&challenge)
+	var setup struct {
+		Secret string `json:"secret"`
+	}
+	s.Do(http.MethodPost, "/api/v1/auth/2fa/setup", "", gin.H{
+		"challenge_token": challenge.ChallengeToken,
+	}).Status(http.StatusOK).Decode(&setup)
+	s.Do(http.MethodPost, "/api/v1/auth/2fa/enable", "", gin.H{
+		"challenge_token": challenge.ChallengeToken,
+		"code":            s.TOTPCode(setup.Secret, 0),
+	}).Status(http.StatusOK)
+
+	// 知道密码时每次重新登录都不能清除验证码错误的次数
+	for i := 0; i < 5; i++ {
+		s.Do(http.MethodPost, "/api/v1/auth/login", "", gin.H{
+			"username": "admin",
+			"password": Password,
+		}).Status(http.StatusOK).Decode(&challenge)
+		s.Do(http.MethodPost, "/api/v1/auth/2fa/verify", "", gin.H{
+			"challenge_token": challenge.ChallengeToken,
+			"code":            "000000",
+		}).Status(http.StatusUnauthorized)
+	}
+	s.Do(http.MethodPost, "/api/v1/auth/login", "", gin.H{
+		"username": "admin",
+		"password": Password,
+	}).Status(http.StatusTooManyRequests)
+}
+
+func TestOAuthLogin(t *testing.T) {
+	s := NewServer(t)
+	s.EnableWeChat()
+
+	var first struct {
+		Token     string `json:"token"`
+		IsNewUser bool   `json:"is_new_user"`
+	}
+	s.Do(http.MethodPost, "/api/v1/auth/oauth/wechat/login", "", gin.H{"code": "alice#1"}).
+		Status(http.StatusOK).Decode(&first)
+	if first.Token == "" || !first.IsNewUser {
+		t.Fatalf("首次微信登录响应不正确: %+v", first)
+	}
+
+	var second struct {
+		IsNewUser bool `json:"is_new_user"`
+	}
+	s.Do(http.MethodPost, "/api/v1/auth/oauth/wechat/login", "", gin.H{"code": "alice#2"}).
+		Status(http.StatusOK).Decode(&second)
+	if second.IsNewUser {
+		t.Fatal("再次登录不应创建新用户")
+	}
+
+	s.Do(http.MethodPost, "/api/v1/auth/oauth/wechat/login", "", gin.H{"code": "invalid"}).
+		Status(http.StatusUnauthorized)
+	s.Do(http.MethodPost, "/api/v1/auth/oauth/unknown/login", "", gin.H{"code": "alice#3"}).
+		Status(http.StatusNotFound)
+}
@@ -0,0 +1,229 @@
+package apitest
+
+import (
+	"e-device-recycle-backend/apierror"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type catalogDevice struct {
+	Device struct {
+		ID      uint   `json:"id"`
+		Brand   string `json:"brand"`
+		Model   string `json:"model"`
+		ModelID *uint  `json:"model_id"`
+	} `json:"device"`
+}
+
+func TestCatalog(t *testing.T) {
+	s := NewServer(t)
+	admin := s.Token(s.CreateUser("admin", "admin"))
+	user := s.Token(s.CreateUser("alice", "user"))
+
+	// 迁移内置常见品牌
+	var brands struct {
+		Brands []struct {
+			ID   uint   `json:"id"`
+			Name string `json:"name"`
+		} `json:"brands"`
+	}
+	s.Do(http.MethodGet, "/api/v1/catalog/brands", "", nil).Status(http.StatusOK).Decode(&brands)
+	var huaweiID uint
+	for _, brand := range brands.Brands {
+		if brand.Name == "Huawei" {
+			huaweiID = brand.ID
+		}
+	}
+	if huaweiID == 0 {
+		t.Fatalf("品牌列表缺少 Huawei: %+v", brands.Brands)
+	}
+
+	s.Do(http.MethodPost, "/api/v1/admin/catalog/brands", user, gin.H{"name": "Nokia"}).Status(http.StatusForbidden)
+	s.Do(http.MethodPost, "/api/v1/admin/catalog/brands", admin, gin.H{"name": "华为"}).
+		Status(http.StatusConflict).ErrorCode(apierror.BrandExists)
+
+	var series struct {
+		Series struct {
+			ID uint `json:"id"`
+		} `json:"series"`
+	}
+	s.Do(http.MethodPost, "/api/v1/admin/catalog/series", admin, gin.H{"brand_id": huaweiID, "name": "Mate"}).
+		Status(http.StatusCreated).Decode(&series)
+
+	var model struct {
+		Model struct {
+			ID uint `json:"id"`
+		} `json:"model"`
+	}
+	s.Do(http.MethodPost, "/api/v1/admin/catalog/models", admin, gin.H{
+		"brand_id": huaweiID, "series_id": series.Series.ID, "name": "Mate 60 Pro",
+		"aliases": []string{"mate60pro"}, "launch_year": 2023, "msrp": 6999,
+	}).Status(http.StatusCreated).Decode(&model)
+	s.Do(http.MethodPost, "/api/v1/admin/catalog/models", admin, gin.H{"brand_id": 1, "series_id": series.Series.ID, "name": "X"}).
+		Status(http.StatusBadRequest).ErrorCode(apierror.SeriesBrandMismatch)
+
+	var detail struct {
+		Series []struct {
+			Name string `json:"name"`
+		} `json:"series"`
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	s.Do(http.MethodGet, fmt.Sprintf("/api/v1/catalog/brands/%d", huaweiID), "", nil).Status(http.StatusOK).Decode(&detail)
+	if len(detail.Series) != 1 || len(detail.Models) != 1 || detail.Models[0].Name != "Mate 60 Pro" {
+		t.Fatalf("品牌详情 = %+v", detail)
+	}
+	s.Do(http.MethodGet, "/api/v1/catalog/brands/999", "", nil).Status(http.StatusNotFound).ErrorCode(apierror.BrandNotFound)
+
+	// 创建设备时按别名识别品牌和型号
+	var created catalogDevice
+	s.Do(http.MethodPost, "/api/v1/admin/devices/", admin, gin.H{
+		"name": "华为旗舰", "brand": "华为", "model": "MATE60PRO", "category": "phone", "condition": "good", "year_bought": 2023,
+	}).Status(http.StatusCreated).Decode(&created)
+	if created.Device.Brand != "Huawei" || created.Device.Model != "Mate 60 Pro" ||
+		created.Device.ModelID == nil || *created.Device.ModelID != model.Model.ID {
+		t.Fatalf("设备 = %+v", created.Device)
+	}
+	s.Do(http.MethodPost, "/api/v1/admin/devices/", admin, gin.H{
+		"name": "x", "brand": "Nokia", "category": "phone", "condition": "good", "year_bought": 2023,
+	}).Status(http.StatusBadRequest).ErrorCode(apierror.BrandNotFound)
+	s.Do(http.MethodPost, "/api/v1/admin/devices/", admin, gin.H{
+		"name": "x", "category": "phone", "condition": "good", "year_bought": 2023,
+	}).Status(http.StatusBadRequest).ErrorCode(apierror.InvalidRequest)
+
+	// 按品牌别名、系列和型号过滤
+	for _, query := range []string{
+		"brand=%E5%8D%8E%E4%B8%BA",
+		fmt.Sprintf("brand_id=%d", huaweiID),
+		fmt.Sprintf("series_id=%d", series.Series.ID),
+		fmt.Sprintf("model_id=%d", model.Model.ID),
+	} {
+		var list struct {
+			Devices []struct {
+				ID uint `json:"id"`
+			} `json:"devices"`
+		}
+		s.Do(http.MethodGet, "/api/v1/devices/?"+query, "", nil).Status(http.StatusOK).Decode(&list)
+		if len(list.Devices) != 1 || list.Devices[0].ID != created.Device.ID {
+			t.Errorf("%s: 设备 %+v", query, list.Devices)
+		}
+	}
+
+	// 型号改名后设备同步更新
+	s.Do(http.MethodPut, fmt.Sprintf("/api/v1/admin/catalog/models/%d", model.Model.ID), admin, gin.H{
+		"brand_id": huaweiID, "series_id": series.Series.ID, "name": "Mate60 Pro", "launch_year": 2023, "msrp": 6999,
+	}).Status(http.StatusOK)
+	var synced catalogDevice
+	s.Do(http.MethodGet, fmt.Sprintf("/api/v1/devices/%d", created.Device.ID), "", nil).Status(http.StatusOK).Decode(&synced)
+	if synced.Device.Model != "Mate60 Pro" {
+		t.Errorf("同步后型号 = %q, want Mate60 Pro", synced.Device.Model)
+	}
+
+	// 仍在使用的型号和品牌不能删除
+	s.Do(http.MethodDelete, fmt.Sprintf("/api/v1/admin/catalog/models/%d", model.Model.ID), admin, nil).
+		Status(http.StatusConflict).ErrorCode(apierror.ModelInUse)
+	s.Do(http.MethodDelete, fmt.Sprintf("/api/v1/admin/catalog/brands/%d", huaweiID), admin, nil).
+		Status(http.StatusConflict).ErrorCode(apierror.BrandInUse)
+	s.Do(http.MethodDelete, "/api/v1/admin/catalog/series/999", admin, nil).
+		Status(http.StatusNotFound).ErrorCode(apierror.SeriesNotFound)
+}
+
+func TestCategories(t *testing.T) {
+	s := NewServer(t)
+	admin := s.Token(s.CreateUser("admin", "admin"))
+	user := s.Token(s.CreateUser("alice", "user"))
+
+	// 迁移内置电脑和手机平板两个顶级分类
+	var list struct {
+		Categories []struct {
+			ID       uint   `json:"id"`
+			Code     string `json:"code"`
+			ParentID *uint  `json:"parent_id"`
+		} `json:"categories"`
+	}
+	s.Do(http.MethodGet, "/api/v1/catalog/categories", "", nil).Status(http.StatusOK).Decode(&list)
+	ids := map[string]uint{}
+	for _, category := range list.Categories {
+		ids[category.Code] = category.ID
+	}
+	if ids["computer"] == 0 || ids["laptop"] == 0 {
+		t.Fatalf("分类列表 = %+v", list.Categories)
+	}
+
+	s.Do(http.MethodPost, "/api/v1/admin/catalog/categories", user, gin.H{"code": "wearable", "name": "穿戴设备"}).
+		Status(http.StatusForbidden)
+	s.Do(http.MethodPost, "/api/v1/admin/catalog/categories", admin, gin.H{"code": "laptop", "name": "笔记本"}).
+		Status(http.StatusConflict).ErrorCode(apierror.CategoryExists)
+	s.Do(http.MethodPost, "/api/v1/admin/catalog/categories", admin, gin.H{"code": "x", "name": "x", "parent_id": 999}).
+		Status(http.StatusBadRequest).ErrorCode(apierror.CategoryNotFound)
+
+	var created struct {
+		Category struct {
+			ID uint `json:"id"`
+		} `json:"category"`
+	}
+	s.Do(http.MethodPost, "/api/v1/admin/catalog/categories", admin, gin.H{
+		"code": "watch", "name": "智能手表", "parent_id": ids["mobile"],
+		"spec_fields": []gin.H{{"key": "screen", "label": "表盘", "required": true}},
+		"pricing":     gin.H{"annual_depreciation": 0.3, "default_base_price": 1500},
+	}).Status(http.StatusCreated).Decode(&created)
+
+	var detail struct {
+		SpecFields []struct {
+			Key string `json:"key"`
+		} `json:"spec_fields"`
+		Pricing struct {
+			AnnualDepreciation float64 `json:"annual_depreciation"`
+			MaxDepreciation    float64 `json:"max_depreciation"`
+		} `json:"pricing"`
+	}
+	s.Do(http.MethodGet, fmt.Sprintf("/api/v1/catalog/categories/%d", created.Category.ID), "", nil).Status(http.StatusOK).Decode(&detail)
+	if detail.Pricing.AnnualDepreciation != 0.3 || detail.Pricing.MaxDepreciation != 0.8 {
+		t.Errorf("定价参数 = %+v", detail.Pricing)
+	}
+
+	// 创建设备时校验分类和必填规格
+	device := gin.H{"name": "Apple Watch", "brand": "Apple", "category": "watch", "condition": "good", "year_bought": 2023}
+	s.Do(http.MethodPost, "/api/v1/admin/devices/", admin, device).
+		Status(http.StatusBadRequest).ErrorCode(apierror.InvalidRequest)
+	device["screen"] = "1.9英寸"
+	var watch struct {
+		Device struct {
+			ID        uint    `json:"id"`
+			BasePrice float64 `json:"base_price"`
+		} `json:"device"`
+	}
+	s.Do(http.MethodPost, "/api/v1/admin/devices/", admin, device).Status(http.StatusCreated).Decode(&watch)
+	if watch.Device.BasePrice != 1500 {
+		t.Errorf("BasePrice = %v, want 1500", watch.Device.BasePrice)
+	}
+	device["category"] = "toaster"
+	s.Do(http.MethodPost, "/api/v1/admin/devices/", admin, device).
+		Status(http.StatusBadRequest).ErrorCode(apierror.CategoryNotFound)
+
+	// 按上级分类过滤包含子分类的设备
+	var devices struct {
+		Devices []struct {
+			ID uint `json:"id"`
+		} `json:"devices"`
+	}
+	s.Do(http.MethodGet, "/api/v1/devices/?category=mobile", "", nil).Status(http.StatusOK).Decode(&devices)
+	if len(devices.Devices) != 1 || devices.Devices[0].ID != watch.Device.ID {
+		t.Errorf("按上级分类过滤 = %+v", devices.Devices)
+	}
+
+	// 有设备或子分类的分类不能删除
+	s.Do(http.MethodDelete, fmt.Sprintf("/api/v1/admin/catalog/categories/%d", created.Category.ID), admin, nil).
+		Status(http.StatusConflict).ErrorCode(apierror.CategoryInUse)
+	s.Do(http.MethodDelete, fmt.Sprintf("/api/v1/admin/catalog/categories/%d", ids["mobile"]), admin, nil).
+		Status(http.StatusConflict).ErrorCode(apierror.CategoryInUse)
+	s.Do(http.MethodPut, fmt.Sprintf("/api/v1/admin/catalog/categories/%d", ids["computer"]), admin, gin.H{
+		"code": "computer", "name": "电脑", "parent_id": ids["laptop"],
+	}).Status(http.StatusBadRequest).ErrorCode(apierror.CategoryParentInvalid)
+	s.Do(http.MethodDelete, fmt.Sprintf("/api/v1/admin/catalog/categories/%d", ids["desktop"]), admin, nil).Status(http.StatusOK)
+	s.Do(http.MethodGet, "/api/v1/catalog/categories/999", "", nil).Status(http.StatusNotFound).ErrorCode(apierror.CategoryNotFound)
+}
@@ -0,0 +1,81 @@
+package apitest
+
+import (
+	"e-device-recycle-backend/middleware"
+	"e-device-recycle-backend/models"
+	"e-device-recycle-backend/security"
+	"e-device-recycle-backend/store"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 从remoteAddr发送登录请求，forwardedFor不为空时携带X-Forwarded-For
+func (s *Server) loginFrom(remoteAddr, forwardedFor string, body gin.H) *Response {
+	s.t.Helper()
+	req := NewRequest(s.t, http.MethodPost, "/api/v1/auth/login", body)
+	req.RemoteAddr = remoteAddr
+	if forwardedFor != "" {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	return s.Serve(req)
+}
+
+func TestRateLimitIgnoresSpoofedForwardedFor(t *testing.T) {
+	s := NewServer(t)
+	middleware.SetRateLimiter(store.NewMemoryRateLimiter())
+	t.Cleanup(func() { middleware.SetRateLimiter(nil) })
+
+	// 直接访问的客户端每次更换X-Forwarded-For，仍按连接地址计数（认证接口每分钟20次）
+	for i := 0; i < 20; i++ {
+		s.loginFrom("192.0.2.1:1234", fmt.Sprintf("203.0.113.%d", i), gin.H{}).Status(http.StatusBadRequest)
+	}
+	s.loginFrom("192.0.2.1:1234", "203.0.113.100", gin.H{}).Status(http.StatusTooManyRequests)
+
+	// 来自可信代理的请求按X-Forwarded-For中的客户端IP计数
+	s.loginFrom("127.0.0.1:5678", "198.51.100.7", gin.H{}).Status(http.StatusBadRequest)
+}
+
+func TestLoginIPLockoutIgnoresSpoofedForwardedFor(t *testing.T) {
+	s := NewServer(t)
+	bob := s.CreateUser("bob", "user")
+	admin := s.CreateUser("admin", "admin")
+	security.Guard.IPMaxFailures = 3
+
+	// 每次更换用户名和X-Forwarded-For，失败次数仍计入连接地址
+	for i := 0; i < 3; i++ {
+		s.loginFrom("192.0.2.1:1234", fmt.Sprintf("203.0.113.%d", i), gin.H{
+			"username": fmt.Sprintf("guess%d", i),
+			"password": "wrong-password",
+		}).Status(http.StatusUnauthorized)
+	}
+	s.loginFrom("192.0.2.1:1234", "203.0.113.100", gin.H{
+		"username": "bob",
+		"password": Password,
+	}).Status(http.StatusTooManyRequests)
+
+	// 登录失败记录使用连接地址
+	var ips []string
+	s.DB.Model(&models.LoginAttempt{}).Distinct().Pluck("ip", &ips)
+	if len(ips) != 1 || ips[0] != "192.0.2.1" {
+		t.Errorf("登录失败记录的IP = %v, want [192.0.2.1]", ips)
+	}
+
+	// 审计日志同样记录连接地址
+	req := NewRequest(t, http.MethodPost, fmt.Sprintf("/api/v1/admin/users/%d/unlock?ip=192.0.2.1", bob.ID), nil)
+	req.Header.Set("Authorization", "Bearer "+s.Token(admin))
+	req.Header.Set("X-Forwarded-For", "203.0.113.200")
+	s.Serve(req).Status(http.StatusOK)
+	var entry models.AuditLog
+	s.DB.Where("action = ?", "user.unlock").First(&entry)
+	if entry.IP != "192.0.2.1" {
+		t.Errorf("审计日志IP = %q, want 192.0.2.1", entry.IP)
+	}
+
+	s.loginFrom("192.0.2.1:1234", "", gin.H{
+		"username": "bob",
+		"password": Password,
+	}).Status(http.StatusOK)
+}
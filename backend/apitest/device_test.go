@@ -0,0 +1,276 @@
+package apitest
+
+import (
+	"e-device-recycle-backend/apierror"
+	"e-device-recycle-backend/models"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPublicDevices(t *testing.T) {
+	s := NewServer(t)
+	macbook := s.CreateDevice("MacBook Pro", "Apple", 12000)
+	s.CreateDevice("ThinkPad X1", "Lenovo", 8000)
+	inactive := s.CreateDevice("Old Laptop", "Apple", 1000)
+	s.DB.Model(inactive).Update("status", "inactive")
+
+	var list struct {
+		Devices []struct {
+			Name string `json:"name"`
+		} `json:"devices"`
+		Pagination struct {
+			Total int64 `json:"total"`
+		} `json:"pagination"`
+	}
+	s.Do(http.MethodGet, "/api/v1/devices/", "", nil).Status(http.StatusOK).Decode(&list)
+	if list.Pagination.Total != 2 {
+		t.Fatalf("设备总数 %d, want 2（下架设备不可见）", list.Pagination.Total)
+	}
+
+	s.Do(http.MethodGet, "/api/v1/devices/?brand=apple&category=laptop", "", nil).Status(http.StatusOK).Decode(&list)
+	if len(list.Devices) != 1 || list.Devices[0].Name != "MacBook Pro" {
+		t.Fatalf("品牌筛选结果不正确: %+v", list.Devices)
+	}
+
+	var detail struct {
+		Device struct {
+			ID        uint    `json:"id"`
+			BasePrice float64 `json:"base_price"`
+		} `json:"device"`
+	}
+	s.Do(http.MethodGet, fmt.Sprintf("/api/v1/devices/%d", macbook.ID), "", nil).Status(http.StatusOK).Decode(&detail)
+	if detail.Device.ID != macbook.ID || detail.Device.BasePrice != 12000 {
+		t.Fatalf("设备详情不正确: %+v", detail.Device)
+	}
+
+	s.Do(http.MethodGet, fmt.Sprintf("/api/v1/devices/%d", inactive.ID), "", nil).Status(http.StatusNotFound)
+	s.Do(http.MethodGet, "/api/v1/devices/999", "", nil).Status(http.StatusNotFound)
+}
+
+func TestAdminDevices(t *testing.T) {
+	s := NewServer(t)
+	token := s.Token(s.CreateUser("admin", "admin"))
+
+	s.Do(http.MethodPost, "/api/v1/admin/devices/", token, gin.H{
+		"name":     "iPad Air",
+		"brand":    "Apple",
+		"category": "watch",
+	}).Status(http.StatusBadRequest)
+
+	var created struct {
+		Device struct {
+			ID uint `json:"id"`
+		} `json:"device"`
+	}
+	s.Do(http.MethodPost, "/api/v1/admin/devices/", token, gin.H{
+		"name":        "iPad Air",
+		"brand":       "Apple",
+		"category":    "tablet",
+		"condition":   "excellent",
+		"year_bought": 2022,
+		"base_price":  3000,
+	}).Status(http.StatusCreated).Decode(&created)
+	path := fmt.Sprintf("/api/v1/admin/devices/%d", created.Device.ID)
+
+	var updated struct {
+		Device struct {
+			BasePrice float64 `json:"base_price"`
+		} `json:"device"`
+	}
+	s.Do(http.MethodPut, path, token, gin.H{"base_price": 2800}).Status(http.StatusOK).Decode(&updated)
+	if updated.Device.BasePrice != 2800 {
+		t.Fatalf("基础价格 %v, want 2800", updated.Device.BasePrice)
+	}
+
+	s.Do(http.MethodDelete, path, token, nil).Status(http.StatusOK)
+	s.Do(http.MethodGet, fmt.Sprintf("/api/v1/devices/%d", created.Device.ID), "", nil).Status(http.StatusNotFound)
+
+	s.Do(http.MethodPut, "/api/v1/admin/devices/999", token, gin.H{"base_price": 1}).Status(http.StatusNotFound)
+	s.Do(http.MethodDelete, "/api/v1/admin/devices/999", token, nil).Status(http.StatusNotFound)
+}
+
+func TestDeviceConditionalRequests(t *testing.T) {
+	s := NewServer(t)
+	device := s.CreateDevice("MacBook Pro", "Apple", 12000)
+	admin := s.Token(s.CreateUser("admin", "admin"))
+
+	conditional := func(path, header, value string) *Response {
+		req := NewRequest(t, http.MethodGet, path, nil)
+		req.Header.Set(header, value)
+		return s.Serve(req)
+	}
+
+	for _, path := range []string{"/api/v1/devices/?brand=apple", fmt.Sprintf("/api/v1/devices/%d", device.ID)} {
+		res := s.Do(http.MethodGet, path, "", nil).Status(http.StatusOK)
+		etag, lastModified := res.Header().Get("ETag"), res.Header().Get("Last-Modified")
+		if etag == "" || lastModified == "" {
+			t.Fatalf("%s: 缺少ETag或Last-Modified: %v", path, res.Header())
+		}
+		if got := res.Header().Get("Cache-Control"); !strings.HasPrefix(got, "public") {
+			t.Errorf("%s: Cache-Control = %q", path, got)
+		}
+
+		res = conditional(path, "If-None-Match", etag).Status(http.StatusNotModified)
+		if res.Body.Len() != 0 {
+			t.Errorf("%s: 304响应不应有内容: %s", path, res.Body.String())
+		}
+		if res.Header().Get("ETag") != etag {
+			t.Errorf("%s: 304响应的ETag = %q, want %q", path, res.Header().Get("ETag"), etag)
+		}
+		conditional(path, "If-None-Match", `W/"other", `+etag).Status(http.StatusNotModified)
+		conditional(path, "If-None-Match", `W/"other"`).Status(http.StatusOK)
+		conditional(path, "If-Modified-Since", lastModified).Status(http.StatusNotModified)
+		conditional(path, "If-Modified-Since", "Mon, 01 Jan 2001 00:00:00 GMT").Status(http.StatusOK)
+	}
+
+	// 查询参数不同的列表ETag不同
+	appleETag := s.Do(http.MethodGet, "/api/v1/devices/?brand=apple", "", nil).Header().Get("ETag")
+	allETag := s.Do(http.MethodGet, "/api/v1/devices/", "", nil).Header().Get("ETag")
+	if appleETag == allETag {
+		t.Error("不同查询参数的列表ETag相同")
+	}
+
+	// 设备变更后ETag失效
+	detailPath := fmt.Sprintf("/api/v1/devices/%d", device.ID)
+	detailETag := s.Do(http.MethodGet, detailPath, "", nil).Header().Get("ETag")
+	time.Sleep(10 * time.Millisecond)
+	s.Do(http.MethodPut, fmt.Sprintf("/api/v1/admin/devices/%d", device.ID), admin, gin.H{"base_price": 11000}).
+		Status(http.StatusOK)
+
+	conditional(detailPath, "If-None-Match", detailETag).Status(http.StatusOK)
+	conditional("/api/v1/devices/?brand=apple", "If-None-Match", appleETag).Status(http.StatusOK)
+
+	// 下架设备会使列表变化
+	allETag = s.Do(http.MethodGet, "/api/v1/devices/", "", nil).Header().Get("ETag")
+	time.Sleep(10 * time.Millisecond)
+	s.Do(http.MethodDelete, fmt.Sprintf("/api/v1/admin/devices/%d", device.ID), admin, nil).Status(http.StatusOK)
+	conditional("/api/v1/devices/", "If-None-Match", allETag).Status(http.StatusOK)
+
+	// 错误响应不带缓存头
+	res := s.Do(http.MethodGet, detailPath, "", nil).Status(http.StatusNotFound)
+	if res.Header().Get("ETag") != "" || res.Header().Get("Cache-Control") != "" {
+		t.Errorf("404响应不应带缓存头: %v", res.Header())
+	}
+}
+
+func TestDeviceListETagFollowsCategories(t *testing.T) {
+	s := NewServer(t)
+	s.CreateDevice("MacBook Pro", "Apple", 12000)
+	admin := s.Token(s.CreateUser("admin", "admin"))
+
+	var laptop, mobile models.Category
+	if err := s.DB.Where("code = ?", "laptop").First(&laptop).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DB.Where("code = ?", "mobile").First(&mobile).Error; err != nil {
+		t.Fatal(err)
+	}
+	etag := func() string {
+		return s.Do(http.MethodGet, "/api/v1/devices/?category=mobile", "", nil).Status(http.StatusOK).Header().Get("ETag")
+	}
+	notModified := func(etag string) int {
+		req := NewRequest(t, http.MethodGet, "/api/v1/devices/?category=mobile", nil)
+		req.Header.Set("If-None-Match", etag)
+		return s.Serve(req).Code
+	}
+
+	// 设备没有变化，但移动分类后按上级分类过滤的结果变化
+	before := etag()
+	time.Sleep(10 * time.Millisecond)
+	s.Do(http.MethodPut, fmt.Sprintf("/api/v1/admin/catalog/categories/%d", laptop.ID), admin, gin.H{
+		"code": "laptop", "name": laptop.Name, "parent_id": mobile.ID,
+	}).Status(http.StatusOK)
+	if code := notModified(before); code != http.StatusOK {
+		t.Errorf("移动分类后旧ETag的响应 = %d, want 200", code)
+	}
+
+	// 删除分类同样改变ETag
+	var created struct {
+		Category struct {
+			ID uint `json:"id"`
+		} `json:"category"`
+	}
+	s.Do(http.MethodPost, "/api/v1/admin/catalog/categories", admin, gin.H{"code": "wearable", "name": "穿戴设备"}).
+		Status(http.StatusCreated).Decode(&created)
+	before = etag()
+	s.Do(http.MethodDelete, fmt.Sprintf("/api/v1/admin/catalog/categories/%d", created.Category.ID), admin, nil).
+		Status(http.StatusOK)
+	if code := notModified(before); code != http.StatusOK {
+		t.Errorf("删除分类后旧ETag的响应 = %d, want 200", code)
+	}
+}
+
+func TestDeviceSpecFilters(t *testing.T) {
+	s := NewServer(t)
+	admin := s.Token(s.CreateUser("admin", "admin"))
+
+	for _, device := range []gin.H{
+		{"name": "D1", "cpu": "Intel i5-8265U", "memory": "8GB", "storage": "256GB SSD", "screen": "14英寸"},
+		{"name": "D2", "cpu": "Intel i7-12700H", "memory": "16GB DDR5", "storage": "512GB SSD + 1TB HDD", "screen": `15.6"`},
+		{"name": "D3", "cpu": "AMD Ryzen 7 5800H", "memory": "32G", "storage": "1TB HDD", "specs": gin.H{"screen_inches": 17.3}},
+		{"name": "D4", "cpu": "定制处理器", "memory": "未知"},
+	} {
+		device["brand"], device["category"], device["condition"], device["year_bought"] = "Lenovo", "laptop", "good", 2022
+		s.Do(http.MethodPost, "/api/v1/admin/devices/", admin, device).Status(http.StatusCreated)
+	}
+
+	var detail struct {
+		Device struct {
+			Specs struct {
+				MemoryGB      *int     `json:"memory_gb"`
+				StorageGB     *int     `json:"storage_gb"`
+				StorageType   string   `json:"storage_type"`
+				CPUFamily     string   `json:"cpu_family"`
+				CPUGeneration *int     `json:"cpu_generation"`
+				ScreenInches  *float64 `json:"screen_inches"`
+			} `json:"specs"`
+		} `json:"device"`
+	}
+	var d2 uint
+	s.DB.Raw("SELECT id FROM devices WHERE name = ?", "D2").Scan(&d2)
+	s.Do(http.MethodGet, fmt.Sprintf("/api/v1/devices/%d", d2), "", nil).Status(http.StatusOK).Decode(&detail)
+	if specs := detail.Device.Specs; *specs.MemoryGB != 16 || *specs.StorageGB != 1536 || specs.StorageType != "ssd" ||
+		specs.CPUFamily != "intel-core-i7" || *specs.CPUGeneration != 12 || *specs.ScreenInches != 15.6 {
+		t.Errorf("D2 specs = %+v", specs)
+	}
+
+	for query, want := range map[string]string{
+		"min_memory=16":               "D2,D3",
+		"min_memory=16&max_memory=16": "D2",
+		"min_storage=1000":            "D2,D3",
+		"storage_type=hdd":            "D3",
+		"cpu_family=intel-core-i7":    "D2",
+		"min_cpu_generation=10":       "D2",
+		"min_screen=15":               "D2,D3",
+		"max_screen=14":               "D1",
+		"min_memory=8":                "D1,D2,D3",
+	} {
+		var l deviceList
+		s.Do(http.MethodGet, "/api/v1/devices/?"+query, "", nil).Status(http.StatusOK).Decode(&l)
+		if got := l.names(); got != want {
+			t.Errorf("%s: %s, want %s", query, got, want)
+		}
+	}
+
+	err := s.Do(http.MethodGet, "/api/v1/devices/?min_memory=32&max_memory=16", "", nil).Status(http.StatusBadRequest).Error()
+	if len(err.Fields) != 1 || err.Fields[0].Field != "max_memory" {
+		t.Errorf("错误 = %+v", err)
+	}
+	s.Do(http.MethodPost, "/api/v1/admin/devices/", admin, gin.H{
+		"name": "x", "brand": "Lenovo", "category": "laptop", "condition": "good", "year_bought": 2022,
+		"specs": gin.H{"storage_type": "tape"},
+	}).Status(http.StatusBadRequest).ErrorCode(apierror.InvalidRequest)
+
+	// 修改规格文本后重新识别
+	s.Do(http.MethodPut, fmt.Sprintf("/api/v1/admin/devices/%d", d2), admin, gin.H{"memory": "64GB"}).Status(http.StatusOK)
+	var l deviceList
+	s.Do(http.MethodGet, "/api/v1/devices/?min_memory=64", "", nil).Status(http.StatusOK).Decode(&l)
+	if l.names() != "D2" {
+		t.Errorf("更新后 min_memory=64: %s, want D2", l.names())
+	}
+}
@@ -0,0 +1,62 @@
+package apitest
+
+import (
+	"e-device-recycle-backend/apierror"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestErrorEnvelope(t *testing.T) {
+	s := NewServer(t)
+	s.CreateUser("alice", "user")
+
+	req := NewRequest(t, http.MethodGet, "/api/v1/devices/999", nil)
+	req.Header.Set("Accept-Language", "en-US,en;q=0.9")
+	req.Header.Set("X-Request-ID", "trace-1")
+	res := s.Serve(req).Status(http.StatusNotFound)
+	err := res.Error()
+	if err.Code != apierror.DeviceNotFound || err.Message != "Device not found" || err.RequestID != "trace-1" {
+		t.Fatalf("错误响应不正确: %+v", err)
+	}
+	if res.Header().Get("Content-Language") != apierror.EnUS {
+		t.Fatalf("Content-Language %q", res.Header().Get("Content-Language"))
+	}
+
+	// 默认返回中文
+	err = s.Do(http.MethodGet, "/api/v1/devices/999", "", nil).Error()
+	if err.Message != "设备不存在" {
+		t.Fatalf("错误信息 %q", err.Message)
+	}
+
+	// 参数校验错误按字段返回
+	err = s.Do(http.MethodPost, "/api/v1/auth/register", "", gin.H{
+		"username": "al",
+		"password": Password,
+		"email":    "not-an-email",
+	}).Status(http.StatusBadRequest).ErrorCode(apierror.InvalidRequest).Error()
+	fields := make(map[string]string)
+	for _, field := range err.Fields {
+		fields[field.Field] = field.Rule
+	}
+	if fields["username"] != "min" || fields["phone"] != "required" || fields["email"] != "email" {
+		t.Fatalf("字段错误不正确: %+v", err.Fields)
+	}
+
+	s.Do(http.MethodPost, "/api/v1/auth/register", "", gin.H{
+		"username": "alice",
+		"password": Password,
+		"phone":    "13900000000",
+		"email":    "alice2@example.com",
+	}).Status(http.StatusConflict).ErrorCode(apierror.UsernameTaken)
+
+	s.Do(http.MethodPost, "/api/v1/auth/login", "", gin.H{"username": "alice", "password": "wrong-password"}).
+		Status(http.StatusUnauthorized).ErrorCode(apierror.InvalidCredentials)
+	s.Do(http.MethodGet, "/api/v1/user/profile", "", nil).
+		Status(http.StatusUnauthorized).ErrorCode(apierror.Unauthorized)
+	s.Do(http.MethodGet, "/api/v1/admin/orders/", s.Token(s.CreateUser("bob", "user")), nil).
+		Status(http.StatusForbidden).ErrorCode(apierror.Forbidden)
+	s.Do(http.MethodGet, "/api/v1/unknown", "", nil).
+		Status(http.StatusNotFound).ErrorCode(apierror.NotFound)
+}
@@ -0,0 +1,103 @@
+package apitest
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestEvaluations(t *testing.T) {
+	s := NewServer(t)
+	device := s.CreateDevice("MacBook Pro", "Apple", 10000)
+	alice := s.CreateUser("alice", "user")
+	bob := s.CreateUser("bob", "user")
+	token := s.Token(s.CreateUser("admin", "admin"))
+
+	order := s.CreateOrder(alice, device)
+	byOrder := fmt.Sprintf("/api/v1/evaluations/order/%d", order.ID)
+
+	s.Do(http.MethodGet, byOrder, s.Token(alice), nil).Status(http.StatusNotFound)
+
+	request := gin.H{
+		"order_id":          order.ID,
+		"appearance_score":  8,
+		"function_score":    9,
+		"performance_score": 7,
+		"market_price":      6000,
+		"depreciation_rate": 0.2,
+		"evaluation_report": "外观轻微划痕",
+	}
+	var created struct {
+		Evaluation struct {
+			ID         uint    `json:"id"`
+			FinalPrice float64 `json:"final_price"`
+		} `json:"evaluation"`
+	}
+	s.Do(http.MethodPost, "/api/v1/admin/evaluations/", token, request).
+		Status(http.StatusCreated).Decode(&created)
+	if created.Evaluation.FinalPrice <= 0 {
+		t.Fatalf("最终价格 %v", created.Evaluation.FinalPrice)
+	}
+
+	// 同一订单不能重复评估
+	s.Do(http.MethodPost, "/api/v1/admin/evaluations/", token, request).Status(http.StatusConflict)
+	request["order_id"] = 999
+	s.Do(http.MethodPost, "/api/v1/admin/evaluations/", token, request).Status(http.StatusBadRequest)
+
+	// 评估后订单状态和价格同步更新
+	var detail struct {
+		Order struct {
+			Status     string   `json:"status"`
+			FinalPrice *float64 `json:"final_price"`
+		} `json:"order"`
+	}
+	s.Do(http.MethodGet, fmt.Sprintf("/api/v1/orders/%d", order.ID), s.Token(alice), nil).
+		Status(http.StatusOK).Decode(&detail)
+	if detail.Order.Status != "evaluated" || detail.Order.FinalPrice == nil ||
+		*detail.Order.FinalPrice != created.Evaluation.FinalPrice {
+		t.Fatalf("订单未同步评估结果: %+v", detail.Order)
+	}
+
+	s.Do(http.MethodGet, byOrder, s.Token(alice), nil).Status(http.StatusOK)
+	s.Do(http.MethodGet, byOrder, s.Token(bob), nil).Status(http.StatusNotFound)
+
+	var list struct {
+		Evaluations []struct {
+			ID uint `json:"id"`
+		} `json:"evaluations"`
+	}
+	s.Do(http.MethodGet, "/api/v1/admin/evaluations/", token, nil).Status(http.StatusOK).Decode(&list)
+	if len(list.Evaluations) != 1 {
+		t.Fatalf("评估数量 %d, want 1", len(list.Evaluations))
+	}
+
+	path := fmt.Sprintf("/api/v1/admin/evaluations/%d", created.Evaluation.ID)
+	s.Do(http.MethodGet, path, token, nil).Status(http.StatusOK)
+	s.Do(http.MethodGet, "/api/v1/admin/evaluations/999", token, nil).Status(http.StatusNotFound)
+
+	var updated struct {
+		Evaluation struct {
+			FinalPrice float64 `json:"final_price"`
+			Status     string  `json:"status"`
+		} `json:"evaluation"`
+	}
+	s.Do(http.MethodPut, path, token, gin.H{
+		"appearance_score":  8,
+		"function_score":    9,
+		"performance_score": 7,
+		"market_price":      5000,
+		"depreciation_rate": 0.2,
+		"status":            "completed",
+	}).Status(http.StatusOK).Decode(&updated)
+	if updated.Evaluation.FinalPrice >= created.Evaluation.FinalPrice || updated.Evaluation.Status != "completed" {
+		t.Fatalf("评估更新不正确: %+v", updated.Evaluation)
+	}
+
+	s.Do(http.MethodGet, fmt.Sprintf("/api/v1/orders/%d", order.ID), s.Token(alice), nil).
+		Status(http.StatusOK).Decode(&detail)
+	if *detail.Order.FinalPrice != updated.Evaluation.FinalPrice {
+		t.Fatalf("订单价格 %v, want %v", *detail.Order.FinalPrice, updated.Evaluation.FinalPrice)
+	}
+}
@@ -0,0 +1,44 @@
+package apitest
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestHealth(t *testing.T) {
+	s := NewServer(t)
+
+	s.Do(http.MethodGet, "/healthz", "", nil).Status(http.StatusOK)
+
+	var ready struct {
+		Status string            `json:"status"`
+		Checks map[string]string `json:"checks"`
+	}
+	s.Do(http.MethodGet, "/readyz", "", nil).Status(http.StatusOK).Decode(&ready)
+	for _, name := range []string{"database", "migrations", "redis"} {
+		if ready.Checks[name] != "ok" {
+			t.Fatalf("检查项 %s: %q", name, ready.Checks[name])
+		}
+	}
+
+	// 有未执行的迁移时不就绪，存活检查不受影响
+	if err := s.DB.Exec("DELETE FROM schema_migrations WHERE version = (SELECT MAX(version) FROM schema_migrations)").Error; err != nil {
+		t.Fatal(err)
+	}
+	s.Do(http.MethodGet, "/readyz", "", nil).Status(http.StatusServiceUnavailable).Decode(&ready)
+	if ready.Status != "unavailable" || ready.Checks["migrations"] == "ok" || ready.Checks["database"] != "ok" {
+		t.Fatalf("就绪检查结果不正确: %+v", ready)
+	}
+	s.Do(http.MethodGet, "/healthz", "", nil).Status(http.StatusOK)
+
+	// 数据库不可用
+	sqlDB, err := s.DB.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.Close()
+	s.Do(http.MethodGet, "/readyz", "", nil).Status(http.StatusServiceUnavailable).Decode(&ready)
+	if ready.Checks["database"] == "ok" {
+		t.Fatalf("数据库关闭后检查仍通过: %+v", ready)
+	}
+}
@@ -0,0 +1,153 @@
+package apitest
+
+import (
+	"e-device-recycle-backend/apierror"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+type deviceList struct {
+	Devices []struct {
+		Name       string  `json:"name"`
+		BasePrice  float64 `json:"base_price"`
+		YearBought int     `json:"year_bought"`
+	} `json:"devices"`
+	Pagination struct {
+		Page       int    `json:"page"`
+		Total      int64  `json:"total"`
+		NextCursor string `json:"next_cursor"`
+	} `json:"pagination"`
+}
+
+func (l deviceList) names() string {
+	var names []string
+	for _, device := range l.Devices {
+		names = append(names, device.Name)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestListSortAndRanges(t *testing.T) {
+	s := NewServer(t)
+	for i, price := range []float64{3000, 12000, 8000, 500} {
+		device := s.CreateDevice(fmt.Sprintf("D%d", i+1), "Apple", price)
+		s.DB.Model(device).Update("year_bought", 2018+i)
+	}
+
+	list := func(query string) deviceList {
+		t.Helper()
+		var l deviceList
+		s.Do(http.MethodGet, "/api/v1/devices/?"+query, "", nil).Status(http.StatusOK).Decode(&l)
+		return l
+	}
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", "D1,D2,D3,D4"},
+		{"sort=-base_price", "D2,D3,D1,D4"},
+		{"sort=year_bought", "D1,D2,D3,D4"},
+		{"sort=-name&page_size=2&page=2", "D2,D1"},
+		{"min_price=1000&max_price=8000", "D1,D3"},
+		{"min_year=2020&sort=-base_price", "D3,D4"},
+	}
+	for _, tt := range tests {
+		if got := list(tt.query).names(); got != tt.want {
+			t.Errorf("%s: %s, want %s", tt.query, got, tt.want)
+		}
+	}
+
+	for query, field := range map[string]string{
+		"sort=password":                  "sort",
+		"page_size=101":                  "page_size",
+		"page=0":                         "page",
+		"min_price=cheap":                "min_price",
+		"min_price=100&max_price=10":     "max_price",
+		"cursor=" + url.QueryEscape("x"): "cursor",
+	} {
+		err := s.Do(http.MethodGet, "/api/v1/devices/?"+query, "", nil).Status(http.StatusBadRequest).Error()
+		if err.Code != apierror.InvalidRequest || len(err.Fields) != 1 || err.Fields[0].Field != field {
+			t.Errorf("%s: 错误 = %+v, want 字段 %s", query, err, field)
+		}
+	}
+}
+
+func TestCursorPagination(t *testing.T) {
+	s := NewServer(t)
+	token := s.Token(s.CreateUser("admin", "admin"))
+	user := s.CreateUser("alice", "user")
+	device := s.CreateDevice("MacBook Pro", "Apple", 10000)
+	for i := 0; i < 5; i++ {
+		s.CreateOrder(user, device)
+	}
+
+	type orderList struct {
+		Orders []struct {
+			ID uint `json:"id"`
+		} `json:"orders"`
+		Pagination struct {
+			Page       int    `json:"page"`
+			Total      int64  `json:"total"`
+			NextCursor string `json:"next_cursor"`
+		} `json:"pagination"`
+	}
+
+	// 按游标翻页，期间新增的订单不影响后续页
+	seen := make(map[uint]bool)
+	path := "/api/v1/admin/orders/?page_size=2"
+	for pages := 0; ; pages++ {
+		if pages > 5 {
+			t.Fatal("游标分页没有结束")
+		}
+		var list orderList
+		s.Do(http.MethodGet, path, token, nil).Status(http.StatusOK).Decode(&list)
+		for _, order := range list.Orders {
+			if seen[order.ID] {
+				t.Fatalf("订单 %d 重复出现", order.ID)
+			}
+			seen[order.ID] = true
+		}
+		if pages == 0 {
+			s.CreateOrder(user, device)
+		} else if list.Pagination.Page != 0 {
+			t.Errorf("游标分页不应返回页码: %+v", list.Pagination)
+		}
+		if list.Pagination.NextCursor == "" {
+			break
+		}
+		path = "/api/v1/admin/orders/?page_size=2&cursor=" + url.QueryEscape(list.Pagination.NextCursor)
+	}
+	if len(seen) != 5 {
+		t.Errorf("游标分页返回 %d 个订单, want 5", len(seen))
+	}
+
+	// 最后一页没有下一页
+	var list orderList
+	s.Do(http.MethodGet, "/api/v1/admin/orders/?page_size=3&page=2", token, nil).Status(http.StatusOK).Decode(&list)
+	if len(list.Orders) != 3 || list.Pagination.NextCursor != "" {
+		t.Errorf("最后一页: %d 个订单, next_cursor = %q", len(list.Orders), list.Pagination.NextCursor)
+	}
+
+	// 下单时间范围
+	tomorrow := time.Now().AddDate(0, 0, 1).Format("2006-01-02")
+	s.Do(http.MethodGet, "/api/v1/admin/orders/?start="+tomorrow, token, nil).Status(http.StatusOK).Decode(&list)
+	if list.Pagination.Total != 0 {
+		t.Errorf("start=%s: total = %d, want 0", tomorrow, list.Pagination.Total)
+	}
+	s.Do(http.MethodGet, "/api/v1/orders/?end="+tomorrow, s.Token(user), nil).Status(http.StatusOK).Decode(&list)
+	if list.Pagination.Total != 6 {
+		t.Errorf("end=%s: total = %d, want 6", tomorrow, list.Pagination.Total)
+	}
+	s.Do(http.MethodGet, "/api/v1/admin/orders/?start=yesterday", token, nil).
+		Status(http.StatusBadRequest).ErrorCode(apierror.InvalidTime)
+
+	// 游标与排序不一致
+	s.Do(http.MethodGet, "/api/v1/admin/orders/?page_size=2", token, nil).Status(http.StatusOK).Decode(&list)
+	s.Do(http.MethodGet, "/api/v1/admin/orders/?sort=estimated_price&cursor="+url.QueryEscape(list.Pagination.NextCursor), token, nil).
+		Status(http.StatusBadRequest)
+}
@@ -0,0 +1,111 @@
+package apitest
+
+import (
+	"bytes"
+	"e-device-recycle-backend/logging"
+	"e-device-recycle-backend/models"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestID(t *testing.T) {
+	s := NewServer(t)
+
+	// 未传入时生成
+	res := s.Do(http.MethodGet, "/api/v1/devices/", "", nil).Status(http.StatusOK)
+	generated := res.Header().Get("X-Request-ID")
+	if generated == "" {
+		t.Fatal("响应缺少X-Request-ID")
+	}
+	if next := s.Do(http.MethodGet, "/api/v1/devices/", "", nil).Header().Get("X-Request-ID"); next == generated {
+		t.Fatal("每个请求应生成不同的请求ID")
+	}
+
+	// 合法的请求ID原样返回
+	req := NewRequest(t, http.MethodGet, "/api/v1/devices/", nil)
+	req.Header.Set("X-Request-ID", "gateway-42")
+	if id := s.Serve(req).Header().Get("X-Request-ID"); id != "gateway-42" {
+		t.Fatalf("请求ID %q", id)
+	}
+
+	// 不合法的请求ID被替换
+	req = NewRequest(t, http.MethodGet, "/api/v1/devices/", nil)
+	req.Header.Set("X-Request-ID", "bad id\nforged=1")
+	if id := s.Serve(req).Header().Get("X-Request-ID"); id == "" || strings.ContainsAny(id, " \n") {
+		t.Fatalf("请求ID %q", id)
+	}
+
+	// 审计日志记录请求ID
+	admin := s.CreateUser("admin", "admin")
+	req = NewRequest(t, http.MethodPost, "/api/v1/admin/devices/", gin.H{
+		"name":        "ThinkPad X1",
+		"brand":       "Lenovo",
+		"category":    "laptop",
+		"condition":   "good",
+		"year_bought": 2021,
+		"base_price":  3000,
+	})
+	req.Header.Set("Authorization", "Bearer "+s.Token(admin))
+	req.Header.Set("X-Request-ID", "audit-1")
+	s.Serve(req).Status(http.StatusCreated)
+
+	var entry models.AuditLog
+	if err := s.DB.Where("action = ?", "device.create").First(&entry).Error; err != nil {
+		t.Fatal(err)
+	}
+	if entry.RequestID != "audit-1" {
+		t.Fatalf("审计日志请求ID %q", entry.RequestID)
+	}
+}
+
+func TestAccessLog(t *testing.T) {
+	s := NewServer(t)
+	user := s.CreateUser("alice", "user")
+
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(logging.NewHandler(&buf, slog.LevelInfo, "json")))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	req := NewRequest(t, http.MethodGet, "/api/v1/user/profile?x=1", nil)
+	req.Header.Set("Authorization", "Bearer "+s.Token(user))
+	req.Header.Set("X-Request-ID", "access-1")
+	s.Serve(req).Status(http.StatusOK)
+	s.Do(http.MethodGet, "/api/v1/unknown", "", nil).Status(http.StatusNotFound)
+
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("日志不是JSON: %q", line)
+		}
+		if entry["msg"] == "access" {
+			entries = append(entries, entry)
+		}
+	}
+	if len(entries) != 2 {
+		t.Fatalf("访问日志条数 %d: %s", len(entries), buf.String())
+	}
+
+	ok := entries[0]
+	if ok["level"] != "INFO" || ok["request_id"] != "access-1" || ok["route"] != "/api/v1/user/profile" ||
+		ok["status"] != float64(200) || ok["user_id"] != float64(user.ID) || ok["query"] != "x=1" {
+		t.Fatalf("访问日志不正确: %v", ok)
+	}
+	if _, has := ok["latency_ms"]; !has {
+		t.Fatalf("访问日志缺少耗时: %v", ok)
+	}
+
+	notFound := entries[1]
+	if notFound["level"] != "WARN" || notFound["status"] != float64(404) || notFound["request_id"] == "" {
+		t.Fatalf("访问日志不正确: %v", notFound)
+	}
+	if _, has := notFound["user_id"]; has {
+		t.Fatalf("未登录请求不应记录用户: %v", notFound)
+	}
+}
@@ -0,0 +1,100 @@
+package apitest
+
+import (
+	"e-device-recycle-backend/metrics"
+	"e-device-recycle-backend/models"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetrics(t *testing.T) {
+	s := NewServer(t)
+	device := s.CreateDevice("MacBook Pro", "Apple", 10000)
+	alice := s.CreateUser("alice", "user")
+	admin := s.Token(s.CreateUser("admin", "admin"))
+
+	created := testutil.ToFloat64(metrics.OrdersCreated)
+	evaluated := testutil.ToFloat64(metrics.EvaluationsCompleted)
+	cancelled := testutil.ToFloat64(metrics.OrdersCancelled.WithLabelValues(models.CancelReasonChangedMind))
+	notFound := testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues("GET", "/api/v1/devices/:id", "404"))
+
+	// 下单、评估、完成
+	order := s.CreateOrder(alice, device)
+	s.Do(http.MethodPost, "/api/v1/admin/evaluations/", admin, gin.H{
+		"order_id":          order.ID,
+		"appearance_score":  8,
+		"function_score":    9,
+		"performance_score": 8,
+		"market_price":      6000,
+		"depreciation_rate": 0.2,
+	}).Status(http.StatusCreated)
+	s.Do(http.MethodPut, fmt.Sprintf("/api/v1/admin/orders/%d", order.ID), admin, gin.H{"status": "completed"}).
+		Status(http.StatusOK)
+
+	// 用户取消
+	order = s.CreateOrder(alice, device)
+	s.Do(http.MethodPut, fmt.Sprintf("/api/v1/orders/%d/cancel", order.ID), s.Token(alice),
+		gin.H{"reason": models.CancelReasonChangedMind}).Status(http.StatusOK)
+
+	s.Do(http.MethodGet, "/api/v1/devices/999", "", nil).Status(http.StatusNotFound)
+	s.Do(http.MethodGet, "/api/v1/no-such-route/1", "", nil).Status(http.StatusNotFound)
+
+	if got := testutil.ToFloat64(metrics.OrdersCreated); got != created+2 {
+		t.Errorf("创建订单数 = %v, want %v", got, created+2)
+	}
+	if got := testutil.ToFloat64(metrics.EvaluationsCompleted); got != evaluated+1 {
+		t.Errorf("完成评估数 = %v, want %v", got, evaluated+1)
+	}
+	if got := testutil.ToFloat64(metrics.OrdersCancelled.WithLabelValues(models.CancelReasonChangedMind)); got != cancelled+1 {
+		t.Errorf("取消订单数 = %v, want %v", got, cancelled+1)
+	}
+	if got := testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues("GET", "/api/v1/devices/:id", "404")); got != notFound+1 {
+		t.Errorf("按路由统计的请求数 = %v, want %v", got, notFound+1)
+	}
+
+	// 指标接口需要令牌
+	handler := metrics.Handler("secret")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("未携带令牌: 状态码 %d", rec.Code)
+	}
+
+	sqlDB, err := s.DB.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	metrics.RegisterDB(sqlDB, "apitest")
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("状态码 %d", rec.Code)
+	}
+
+	body := rec.Body.String()
+	for _, want := range []string{
+		`recycle_http_request_duration_seconds_bucket{method="GET",route="/api/v1/devices/:id"`,
+		`recycle_http_requests_total{method="GET",route="unmatched",status="404"}`,
+		`recycle_orders_cancelled_total{reason="changed_mind"}`,
+		`recycle_payout_amount_yuan_count`,
+		`go_sql_open_connections{db_name="apitest"}`,
+		`go_goroutines`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("指标缺少 %s", want)
+		}
+	}
+	// 路径参数不应出现在指标标签中
+	if strings.Contains(body, `route="/api/v1/devices/999"`) {
+		t.Error("指标按原始路径统计")
+	}
+}
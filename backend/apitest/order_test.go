@@ -0,0 +1,127 @@
+package apitest
+
+import (
+	"e-device-recycle-backend/apierror"
+	"e-device-recycle-backend/models"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestUserOrders(t *testing.T) {
+	s := NewServer(t)
+	device := s.CreateDevice("MacBook Pro", "Apple", 10000)
+	alice := s.CreateUser("alice", "user")
+	bob := s.CreateUser("bob", "user")
+
+	order := s.CreateOrder(alice, device)
+	if order.Status != "pending" || order.OrderNo == "" || order.EstimatedPrice <= 0 {
+		t.Fatalf("订单不正确: %+v", order)
+	}
+	path := fmt.Sprintf("/api/v1/orders/%d", order.ID)
+
+	// 下架设备不能下单
+	inactive := s.CreateDevice("Old Laptop", "Apple", 1000)
+	s.DB.Model(inactive).Update("status", "inactive")
+	s.Do(http.MethodPost, "/api/v1/orders/", s.Token(alice), gin.H{
+		"device_id":      inactive.ID,
+		"contact_name":   "Alice",
+		"contact_phone":  alice.Phone,
+		"pickup_address": "北京市",
+	}).Status(http.StatusBadRequest)
+	s.Do(http.MethodPost, "/api/v1/orders/", s.Token(alice), gin.H{"device_id": device.ID}).
+		Status(http.StatusBadRequest)
+
+	var list struct {
+		Orders []struct {
+			ID uint `json:"id"`
+		} `json:"orders"`
+	}
+	s.Do(http.MethodGet, "/api/v1/orders/", s.Token(alice), nil).Status(http.StatusOK).Decode(&list)
+	if len(list.Orders) != 1 || list.Orders[0].ID != order.ID {
+		t.Fatalf("订单列表不正确: %+v", list.Orders)
+	}
+	s.Do(http.MethodGet, "/api/v1/orders/", s.Token(bob), nil).Status(http.StatusOK).Decode(&list)
+	if len(list.Orders) != 0 {
+		t.Fatalf("其他用户可以看到 %d 个订单", len(list.Orders))
+	}
+
+	s.Do(http.MethodGet, path, s.Token(alice), nil).Status(http.StatusOK)
+
+	// 其他用户的订单视为不存在
+	s.Do(http.MethodGet, path, s.Token(bob), nil).Status(http.StatusNotFound)
+	s.Do(http.MethodPut, path+"/cancel", s.Token(bob), nil).Status(http.StatusNotFound)
+
+	s.Do(http.MethodPut, path+"/cancel", s.Token(alice), gin.H{"reason": "too_expensive"}).
+		Status(http.StatusBadRequest).ErrorCode(apierror.InvalidRequest)
+	s.Do(http.MethodPut, path+"/cancel", s.Token(alice), gin.H{"reason": models.CancelReasonSoldElsewhere}).
+		Status(http.StatusOK)
+	s.Do(http.MethodPut, path+"/cancel", s.Token(alice), nil).Status(http.StatusBadRequest)
+
+	var detail struct {
+		Order models.RecycleOrderResponse `json:"order"`
+	}
+	s.Do(http.MethodGet, path, s.Token(alice), nil).Status(http.StatusOK).Decode(&detail)
+	if detail.Order.Status != "cancelled" || detail.Order.CancelReason != models.CancelReasonSoldElsewhere {
+		t.Fatalf("取消后的订单不正确: %s %s", detail.Order.Status, detail.Order.CancelReason)
+	}
+
+	// 不传请求体时原因记为other
+	order = s.CreateOrder(alice, device)
+	s.Do(http.MethodPut, fmt.Sprintf("/api/v1/orders/%d/cancel", order.ID), s.Token(alice), nil).Status(http.StatusOK)
+	s.Do(http.MethodGet, fmt.Sprintf("/api/v1/orders/%d", order.ID), s.Token(alice), nil).Decode(&detail)
+	if detail.Order.CancelReason != models.CancelReasonOther {
+		t.Fatalf("取消原因 %q, want other", detail.Order.CancelReason)
+	}
+}
+
+func TestAdminOrders(t *testing.T) {
+	s := NewServer(t)
+	device := s.CreateDevice("MacBook Pro", "Apple", 10000)
+	alice := s.CreateUser("alice", "user")
+	bob := s.CreateUser("bob", "user")
+	token := s.Token(s.CreateUser("admin", "admin"))
+
+	order := s.CreateOrder(alice, device)
+	s.CreateOrder(bob, device)
+
+	var list struct {
+		Pagination struct {
+			Total int64 `json:"total"`
+		} `json:"pagination"`
+	}
+	s.Do(http.MethodGet, "/api/v1/admin/orders/", token, nil).Status(http.StatusOK).Decode(&list)
+	if list.Pagination.Total != 2 {
+		t.Fatalf("订单总数 %d, want 2", list.Pagination.Total)
+	}
+	s.Do(http.MethodGet, fmt.Sprintf("/api/v1/admin/orders/?user_id=%d", alice.ID), token, nil).
+		Status(http.StatusOK).Decode(&list)
+	if list.Pagination.Total != 1 {
+		t.Fatalf("按用户筛选订单总数 %d, want 1", list.Pagination.Total)
+	}
+
+	// 管理员可以查看任意订单
+	s.Do(http.MethodGet, fmt.Sprintf("/api/v1/orders/%d", order.ID), token, nil).Status(http.StatusOK)
+
+	var updated struct {
+		Order struct {
+			Status string `json:"status"`
+		} `json:"order"`
+	}
+	s.Do(http.MethodPut, fmt.Sprintf("/api/v1/admin/orders/%d", order.ID), token, gin.H{"status": "confirmed"}).
+		Status(http.StatusOK).Decode(&updated)
+	if updated.Order.Status != "confirmed" {
+		t.Fatalf("订单状态 %q, want confirmed", updated.Order.Status)
+	}
+
+	s.Do(http.MethodPut, fmt.Sprintf("/api/v1/admin/orders/%d", order.ID), token, gin.H{"status": "unknown"}).
+		Status(http.StatusBadRequest)
+	s.Do(http.MethodPut, "/api/v1/admin/orders/999", token, gin.H{"status": "confirmed"}).
+		Status(http.StatusNotFound)
+
+	// 已确认的订单不能取消
+	s.Do(http.MethodPut, fmt.Sprintf("/api/v1/orders/%d/cancel", order.ID), s.Token(alice), nil).
+		Status(http.StatusBadRequest)
+}
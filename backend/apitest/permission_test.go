@@ -0,0 +1,52 @@
+package apitest
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// 公开接口前缀，其余接口都需要登录
+var publicPrefixes = []string{"/healthz", "/readyz", "/api/docs", "/api/v1/auth/", "/api/v1/devices/", "/api/v1/catalog/"}
+
+func isPublic(path string) bool {
+	for _, prefix := range publicPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// 将路由参数替换为示例值
+func examplePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segments[i] = "1"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// 遍历所有已注册的路由，新增接口无需修改本测试即可覆盖
+func TestRoutePermissions(t *testing.T) {
+	s := NewServer(t)
+	user := s.Token(s.CreateUser("alice", "user"))
+	evaluator := s.Token(s.CreateUser("evaluator", "evaluator"))
+
+	for _, route := range s.Router.Routes() {
+		if isPublic(route.Path) {
+			continue
+		}
+		path := examplePath(route.Path)
+
+		s.Do(route.Method, path, "", nil).Status(http.StatusUnauthorized)
+		s.Do(route.Method, path, "invalid-token", nil).Status(http.StatusUnauthorized)
+
+		if strings.HasPrefix(route.Path, "/api/v1/admin/") {
+			s.Do(route.Method, path, user, nil).Status(http.StatusForbidden)
+			s.Do(route.Method, path, evaluator, nil).Status(http.StatusForbidden)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package apitest
+
+import (
+	"e-device-recycle-backend/apierror"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type searchResult struct {
+	Devices []struct {
+		Device struct {
+			ID   uint   `json:"id"`
+			Name string `json:"name"`
+		} `json:"device"`
+		Score      float64           `json:"score"`
+		Highlights map[string]string `json:"highlights"`
+	} `json:"devices"`
+	Pagination struct {
+		Total int64 `json:"total"`
+	} `json:"pagination"`
+}
+
+func (r searchResult) names() []string {
+	names := make([]string, len(r.Devices))
+	for i, hit := range r.Devices {
+		names[i] = hit.Device.Name
+	}
+	return names
+}
+
+func TestSearchDevices(t *testing.T) {
+	s := NewServer(t)
+	token := s.Token(s.CreateUser("admin", "admin"))
+
+	// 通过接口创建的设备会写入搜索索引
+	create := func(body gin.H) uint {
+		var resp struct {
+			Device struct {
+				ID uint `json:"id"`
+			} `json:"device"`
+		}
+		body["condition"] = "good"
+		body["year_bought"] = 2022
+		s.Do(http.MethodPost, "/api/v1/admin/devices/", token, body).Status(http.StatusCreated).Decode(&resp)
+		return resp.Device.ID
+	}
+	create(gin.H{"name": "ThinkPad X1 Carbon", "brand": "联想", "category": "laptop", "cpu": "Intel i7", "memory": "16GB"})
+	create(gin.H{"name": "小新Pro 14 笔记本", "brand": "联想", "category": "laptop", "memory": "32GB"})
+	create(gin.H{"name": "MacBook Pro", "brand": "Apple", "category": "laptop", "memory": "16 GB"})
+	create(gin.H{"name": "MatePad Pro", "brand": "华为", "category": "tablet"})
+	removed := create(gin.H{"name": "ThinkPad T14", "brand": "联想", "category": "laptop"})
+	s.Do(http.MethodDelete, fmt.Sprintf("/api/v1/admin/devices/%d", removed), token, nil).Status(http.StatusOK)
+
+	search := func(query string) searchResult {
+		t.Helper()
+		var result searchResult
+		s.Do(http.MethodGet, "/api/v1/devices/search?"+query, "", nil).Status(http.StatusOK).Decode(&result)
+		return result
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"q=" + url.QueryEscape("联想笔记本"), []string{"小新Pro 14 笔记本", "ThinkPad X1 Carbon"}},
+		{"q=thinkpad", []string{"ThinkPad X1 Carbon"}}, // 已下架的设备不可见
+		{"q=macb", []string{"MacBook Pro"}},
+		{"q=16g", []string{"ThinkPad X1 Carbon", "MacBook Pro"}}, // 相关度相同时按创建顺序
+		{"q=pro&category=tablet", []string{"MatePad Pro"}},
+		{"q=pro&brand=apple", []string{"MacBook Pro"}},
+		{"q=" + url.QueryEscape("戴尔"), []string{}},
+	}
+	for _, tt := range tests {
+		if got := search(tt.query).names(); strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("%s: %q, want %q", tt.query, got, tt.want)
+		}
+	}
+
+	result := search("q=" + url.QueryEscape("联想 carbon"))
+	if len(result.Devices) == 0 {
+		t.Fatal("没有搜索结果")
+	}
+	// 品牌统一为目录中的名称，别名同样参与搜索
+	highlights := result.Devices[0].Highlights
+	if highlights["name"] != "ThinkPad X1 <em>Carbon</em>" || highlights["brand"] != "Lenovo <em>联想</em>" {
+		t.Errorf("高亮不正确: %v", highlights)
+	}
+
+	result = search("q=pro&page=2&page_size=2")
+	if result.Pagination.Total != 3 || len(result.Devices) != 1 {
+		t.Errorf("分页不正确: total = %d, %q", result.Pagination.Total, result.names())
+	}
+
+	s.Do(http.MethodGet, "/api/v1/devices/search", "", nil).Status(http.StatusBadRequest).ErrorCode(apierror.InvalidRequest)
+	s.Do(http.MethodGet, "/api/v1/devices/search?q="+strings.Repeat("a", 101), "", nil).Status(http.StatusBadRequest)
+	s.Do(http.MethodGet, "/api/v1/devices/search?q=pro&page_size=100", "", nil).Status(http.StatusBadRequest)
+}
+
+func TestSuggestDevices(t *testing.T) {
+	s := NewServer(t)
+	user := s.CreateUser("alice", "user")
+	s.CreateDevice("MacBook Pro", "Apple", 12000)
+	air := s.CreateDevice("MacBook Air", "Apple", 6000)
+	s.CreateDevice("ThinkPad X1", "Lenovo", 8000)
+	s.CreateOrder(user, air)
+
+	suggest := func(query string) []string {
+		t.Helper()
+		var body struct {
+			Suggestions []struct {
+				Text       string `json:"text"`
+				Type       string `json:"type"`
+				Popularity int64  `json:"popularity"`
+			} `json:"suggestions"`
+		}
+		s.Do(http.MethodGet, "/api/v1/devices/suggest?"+query, "", nil).Status(http.StatusOK).Decode(&body)
+		var texts []string
+		for _, suggestion := range body.Suggestions {
+			texts = append(texts, suggestion.Type+":"+suggestion.Text)
+		}
+		return texts
+	}
+
+	// 有订单的设备排在前面
+	if got := suggest("q=mac"); strings.Join(got, "|") != "model:MacBook Air|model:MacBook Pro" {
+		t.Errorf("q=mac: %q", got)
+	}
+	if got := suggest("q=a&limit=1"); strings.Join(got, "|") != "brand:Apple" {
+		t.Errorf("q=a&limit=1: %q", got)
+	}
+
+	// 直接写入数据库的设备也会出现在补全中
+	s.CreateDevice("ThinkPad T14", "Lenovo", 5000)
+	if got := suggest("q=thinkpad"); len(got) != 2 {
+		t.Errorf("q=thinkpad: %q", got)
+	}
+
+	s.Do(http.MethodGet, "/api/v1/devices/suggest", "", nil).Status(http.StatusBadRequest)
+	s.Do(http.MethodGet, "/api/v1/devices/suggest?q=mac&limit=100", "", nil).Status(http.StatusBadRequest)
+}
@@ -0,0 +1,198 @@
+package apitest
+
+import (
+	"e-device-recycle-backend/apierror"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestProfile(t *testing.T) {
+	s := NewServer(t)
+	user := s.CreateUser("alice", "user")
+	token := s.Token(user)
+
+	var profile struct {
+		User struct {
+			Username string `json:"username"`
+			Email    string `json:"email"`
+		} `json:"user"`
+	}
+	s.Do(http.MethodGet, "/api/v1/user/profile", token, nil).Status(http.StatusOK).Decode(&profile)
+	if profile.User.Username != "alice" {
+		t.Fatalf("用户名 %q, want alice", profile.User.Username)
+	}
+
+	var updated struct {
+		User struct {
+			RealName string `json:"real_name"`
+			Role     string `json:"role"`
+		} `json:"user"`
+	}
+	s.Do(http.MethodPut, "/api/v1/user/profile", token, gin.H{
+		"real_name": "爱丽丝",
+		"role":      "admin",
+	}).Status(http.StatusOK).Decode(&updated)
+	if updated.User.RealName != "爱丽丝" {
+		t.Fatalf("姓名 %q, want 爱丽丝", updated.User.RealName)
+	}
+	// 角色等字段不允许用户修改
+	if updated.User.Role != "user" {
+		t.Fatalf("角色被修改为 %q", updated.User.Role)
+	}
+}
+
+func TestChangePassword(t *testing.T) {
+	s := NewServer(t)
+	user := s.CreateUser("alice", "user")
+	oldToken := s.Token(user)
+
+	s.Do(http.MethodPut, "/api/v1/user/password", oldToken, gin.H{
+		"old_password": "wrong-password",
+		"new_password": "new-password",
+	}).Status(http.StatusBadRequest)
+
+	var res struct {
+		Token string `json:"token"`
+	}
+	s.Do(http.MethodPut, "/api/v1/user/password", oldToken, gin.H{
+		"old_password": Password,
+		"new_password": "new-password",
+	}).Status(http.StatusOK).Decode(&res)
+
+	// 修改密码后旧令牌失效，新令牌可用
+	s.Do(http.MethodGet, "/api/v1/user/profile", oldToken, nil).Status(http.StatusUnauthorized)
+	s.Do(http.MethodGet, "/api/v1/user/profile", res.Token, nil).Status(http.StatusOK)
+	s.Do(http.MethodPost, "/api/v1/auth/login", "", gin.H{
+		"username": "alice",
+		"password": "new-password",
+	}).Status(http.StatusOK)
+}
+
+func TestIdentities(t *testing.T) {
+	s := NewServer(t)
+	s.EnableWeChat()
+	alice := s.CreateUser("alice", "user")
+	bob := s.CreateUser("bob", "user")
+	token := s.Token(alice)
+
+	s.Do(http.MethodPost, "/api/v1/user/identities/wechat", token, gin.H{"code": "alice#1"}).
+		Status(http.StatusOK)
+
+	var identities struct {
+		Identities []struct {
+			Provider string `json:"provider"`
+		} `json:"identities"`
+	}
+	s.Do(http.MethodGet, "/api/v1/user/identities", token, nil).Status(http.StatusOK).Decode(&identities)
+	if len(identities.Identities) != 1 || identities.Identities[0].Provider != "wechat" {
+		t.Fatalf("绑定信息不正确: %+v", identities)
+	}
+
+	// 已绑定的微信可以直接登录到该账号
+	var login struct {
+		User struct {
+			Username string `json:"username"`
+		} `json:"user"`
+	}
+	s.Do(http.MethodPost, "/api/v1/auth/oauth/wechat/login", "", gin.H{"code": "alice#2"}).
+		Status(http.StatusOK).Decode(&login)
+	if login.User.Username != "alice" {
+		t.Fatalf("微信登录到了 %q", login.User.Username)
+	}
+
+	// 同一微信不能绑定到其他用户
+	s.Do(http.MethodPost, "/api/v1/user/identities/wechat", s.Token(bob), gin.H{"code": "alice#3"}).
+		Status(http.StatusConflict)
+	s.Do(http.MethodPost, "/api/v1/user/identities/unknown", token, gin.H{"code": "alice#4"}).
+		Status(http.StatusNotFound)
+
+	s.Do(http.MethodDelete, "/api/v1/user/identities/wechat", token, nil).Status(http.StatusOK)
+	s.Do(http.MethodDelete, "/api/v1/user/identities/wechat", token, nil).Status(http.StatusNotFound)
+}
+
+func TestUnbindLastLoginMethod(t *testing.T) {
+	s := NewServer(t)
+	s.EnableWeChat()
+
+	// 微信登录创建的账号没有手机号和邮箱，密码是随机生成的
+	var login struct {
+		Token string `json:"token"`
+	}
+	s.Do(http.MethodPost, "/api/v1/auth/oauth/wechat/login", "", gin.H{"code": "carol#1"}).
+		Status(http.StatusOK).Decode(&login)
+
+	s.Do(http.MethodDelete, "/api/v1/user/identities/wechat", login.Token, nil).
+		Status(http.StatusConflict).ErrorCode(apierror.IdentityLastLogin)
+	s.Do(http.MethodDelete, "/api/v1/user/identities/unknown", login.Token, nil).
+		Status(http.StatusNotFound).ErrorCode(apierror.IdentityNotBound)
+
+	// 绑定手机号后可以通过忘记密码登录，允许解绑
+	s.Do(http.MethodPut, "/api/v1/user/profile", login.Token, gin.H{"phone": "13800000099"}).
+		Status(http.StatusOK)
+	s.Do(http.MethodDelete, "/api/v1/user/identities/wechat", login.Token, nil).Status(http.StatusOK)
+}
+
+func TestOptionalTwoFactor(t *testing.T) {
+	s := NewServer(t)
+
+	// 开启两步验证，返回密钥
+	enable := func(token string) string {
+		var setup struct {
+			Secret string `json:"secret"`
+		}
+		s.Do(http.MethodPost, "/api/v1/user/2fa/setup", token, nil).Status(http.StatusOK).Decode(&setup)
+		s.Do(http.MethodPost, "/api/v1/user/2fa/enable", token, gin.H{"code": "000000"}).
+			Status(http.StatusBadRequest)
+		s.Do(http.MethodPost, "/api/v1/user/2fa/enable", token, gin.H{"code": s.TOTPCode(setup.Secret, 0)}).
+			Status(http.StatusOK)
+		s.Do(http.MethodPost, "/api/v1/user/2fa/setup", token, nil).Status(http.StatusConflict)
+		return setup.Secret
+	}
+
+	alice := s.CreateUser("alice", "user")
+	token := s.Token(alice)
+	secret := enable(token)
+
+	login := s.Do(http.MethodPost, "/api/v1/auth/login", "", gin.H{
+		"username": "alice",
+		"password": Password,
+	}).Status(http.StatusOK).JSON()
+	if login["two_factor_required"] != true {
+		t.Fatal("开启后登录未要求两步验证")
+	}
+
+	s.Do(http.MethodPost, "/api/v1/user/2fa/disable", token, gin.H{
+		"password": "wrong-password",
+		"code":     s.TOTPCode(secret, 1),
+	}).Status(http.StatusBadRequest)
+	s.Do(http.MethodPost, "/api/v1/user/2fa/disable", token, gin.H{
+		"password": Password,
+		"code":     s.TOTPCode(secret, 1),
+	}).Status(http.StatusOK)
+	s.Do(http.MethodPost, "/api/v1/auth/login", "", gin.H{
+		"username": "alice",
+		"password": Password,
+	}).Status(http.StatusOK)
+
+	bob := s.CreateUser("bob", "user")
+	token = s.Token(bob)
+	secret = enable(token)
+
+	var regenerated struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	s.Do(http.MethodPost, "/api/v1/user/2fa/recovery-codes", token, gin.H{"code": s.TOTPCode(secret, 1)}).
+		Status(http.StatusOK).Decode(&regenerated)
+	if len(regenerated.RecoveryCodes) != 10 {
+		t.Fatalf("恢复码数量 %d, want 10", len(regenerated.RecoveryCodes))
+	}
+
+	// 必须开启两步验证的角色不能关闭
+	evaluator := s.CreateUser("evaluator", "evaluator")
+	s.Do(http.MethodPost, "/api/v1/user/2fa/disable", s.Token(evaluator), gin.H{
+		"password": Password,
+		"code":     "123456",
+	}).Status(http.StatusForbidden)
+}
@@ -0,0 +1,22 @@
+// 本地模拟微信登录接口
+//
+//	go run ./cmd/mockwechat
+//	WECHAT_API_BASE=http://localhost:9090 go run main.go
+package main
+
+import (
+	"e-device-recycle-backend/identity"
+	"log"
+	"net/http"
+	"os"
+)
+
+func main() {
+	addr := os.Getenv("MOCK_WECHAT_ADDR")
+	if addr == "" {
+		addr = ":9090"
+	}
+
+	log.Printf("模拟微信接口启动在: %s", addr)
+	log.Fatal(http.ListenAndServe(addr, identity.NewMockWeChatServer()))
+}
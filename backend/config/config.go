@@ -1,40 +1,253 @@
 package config
 
 import (
-	"os"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
 )
 
+// 运行环境
+const (
+	EnvDevelopment = "development"
+	EnvProduction  = "production"
+)
+
+// 配置按以下顺序加载，后者覆盖前者：默认值、YAML配置文件、环境变量、命令行参数。
+// 字段的yaml标签为配置文件中的键，env标签为对应的环境变量，secret标签的字段在打印时隐藏，
+// 并且可以通过 <环境变量>_FILE 从文件读取（如 DB_PASS_FILE=/run/secrets/db_pass）。
 type Config struct {
-	Port      string
-	DBHost    string
-	DBPort    string
-	DBUser    string
-	DBPass    string
-	DBName    string
-	JWTSecret string
-}
-
-var config *Config
-
-func Init() {
-	config = &Config{
-		Port:      getEnv("PORT", "8080"),
-		DBHost:    getEnv("DB_HOST", "localhost"),
-		DBPort:    getEnv("DB_PORT", "3306"),
-		DBUser:    getEnv("DB_USER", "root"),
-		DBPass:    getEnv("DB_PASS", ""),
-		DBName:    getEnv("DB_NAME", "device_recycle"),
-		JWTSecret: getEnv("JWT_SECRET", "device-recycle-secret-key"),
+	Env string `yaml:"env" env:"APP_ENV"` // development, production
+
+	Server       ServerConfig       `yaml:"server"`
+	Database     DatabaseConfig     `yaml:"database"`
+	Redis        RedisConfig        `yaml:"redis"`
+	Cache        CacheConfig        `yaml:"cache"`
+	Search       SearchConfig       `yaml:"search"`
+	Auth         AuthConfig         `yaml:"auth"`
+	WeChat       WeChatConfig       `yaml:"wechat"`
+	RateLimit    RateLimitConfig    `yaml:"rate_limit"`
+	Log          LogConfig          `yaml:"log"`
+	Metrics      MetricsConfig      `yaml:"metrics"`
+	Storage      StorageConfig      `yaml:"storage"`
+	Notification NotificationConfig `yaml:"notification"`
+}
+
+type ServerConfig struct {
+	Port            string `yaml:"port" env:"PORT"`
+	ReadTimeout     int    `yaml:"read_timeout_seconds" env:"HTTP_READ_TIMEOUT_SECONDS"`    // 读取请求超时（秒）
+	WriteTimeout    int    `yaml:"write_timeout_seconds" env:"HTTP_WRITE_TIMEOUT_SECONDS"`  // 写响应超时（秒），需大于最慢接口（如导出CSV）的耗时
+	IdleTimeout     int    `yaml:"idle_timeout_seconds" env:"HTTP_IDLE_TIMEOUT_SECONDS"`    // keep-alive空闲连接超时（秒）
+	ShutdownDelay   int    `yaml:"shutdown_delay_seconds" env:"SHUTDOWN_DELAY_SECONDS"`     // 退出时就绪检查失败后继续接收请求的时间（秒），留给负载均衡摘除实例
+	ShutdownTimeout int    `yaml:"shutdown_timeout_seconds" env:"SHUTDOWN_TIMEOUT_SECONDS"` // 退出时等待处理中请求完成的最长时间（秒），与ShutdownDelay之和需小于容器的停止超时
+	// 反向代理的IP或网段，只有来自这些地址的请求才使用X-Forwarded-For/X-Real-IP中的客户端IP，为空时不信任任何代理
+	TrustedProxies []string `yaml:"trusted_proxies" env:"TRUSTED_PROXIES"`
+}
+
+type DatabaseConfig struct {
+	Driver        string `yaml:"driver" env:"DB_DRIVER"` // mysql, postgres, sqlite
+	Host          string `yaml:"host" env:"DB_HOST"`
+	Port          string `yaml:"port" env:"DB_PORT"` // 为空时按类型使用默认端口
+	User          string `yaml:"user" env:"DB_USER"`
+	Password      string `yaml:"password" env:"DB_PASS" secret:"true"`
+	Name          string `yaml:"name" env:"DB_NAME"`
+	Path          string `yaml:"path" env:"DB_PATH"`                           // SQLite数据库文件，":memory:" 表示内存数据库
+	SSLMode       string `yaml:"sslmode" env:"DB_SSLMODE"`                     // PostgreSQL sslmode
+	LogLevel      string `yaml:"log_level" env:"DB_LOG_LEVEL"`                 // silent, error, warn, info（记录所有SQL）
+	SlowThreshold int    `yaml:"slow_threshold_ms" env:"DB_SLOW_THRESHOLD_MS"` // 慢查询阈值（毫秒），0表示不记录
+}
+
+type RedisConfig struct {
+	Host     string `yaml:"host" env:"REDIS_HOST"` // 为空时不使用Redis
+	Port     string `yaml:"port" env:"REDIS_PORT"`
+	Password string `yaml:"password" env:"REDIS_PASS" secret:"true"`
+	DB       int    `yaml:"db" env:"REDIS_DB"`
+}
+
+type CacheConfig struct {
+	Enabled          bool `yaml:"enabled" env:"CACHE_ENABLED"`                       // 是否缓存设备列表和详情，配置了Redis时多实例共享
+	DeviceTTLSeconds int  `yaml:"device_ttl_seconds" env:"CACHE_DEVICE_TTL_SECONDS"` // 设备缓存过期时间（秒），设备变更时立即失效
+
+	// 设备列表和详情响应的Cache-Control max-age（秒），客户端和nginx在此期间直接使用缓存，
+	// 过期后通过ETag重新验证；0表示每次都重新验证
+	HTTPMaxAgeSeconds int `yaml:"http_max_age_seconds" env:"CACHE_HTTP_MAX_AGE_SECONDS"`
+}
+
+type SearchConfig struct {
+	Engine               string `yaml:"engine" env:"SEARCH_ENGINE"`                                 // database（数据库倒排索引，多实例共享）, memory（进程内索引，BM25排序）
+	MemoryRefreshSeconds int    `yaml:"memory_refresh_seconds" env:"SEARCH_MEMORY_REFRESH_SECONDS"` // memory索引定期从数据库重建的间隔（秒），0表示只在启动时构建
+}
+
+type AuthConfig struct {
+	JWTSecret        string `yaml:"jwt_secret" env:"JWT_SECRET" secret:"true"`
+	PasswordResetURL string `yaml:"password_reset_url" env:"PASSWORD_RESET_URL"` // 重置密码页面地址，邮件链接会附带token参数
+
+	TwoFactorIssuer        string   `yaml:"two_factor_issuer" env:"TWO_FACTOR_ISSUER"`                 // 认证器App中显示的名称
+	TwoFactorRequiredRoles []string `yaml:"two_factor_required_roles" env:"TWO_FACTOR_REQUIRED_ROLES"` // 必须开启两步验证的角色
+
+	LoginMaxFailures   int `yaml:"login_max_failures" env:"LOGIN_MAX_FAILURES"`       // 同一用户名连续失败多少次后锁定
+	LoginIPMaxFailures int `yaml:"login_ip_max_failures" env:"LOGIN_IP_MAX_FAILURES"` // 同一IP失败多少次后锁定
+	LoginLockMinutes   int `yaml:"login_lock_minutes" env:"LOGIN_LOCK_MINUTES"`       // 锁定时长（分钟）
+}
+
+type WeChatConfig struct {
+	AppID     string `yaml:"app_id" env:"WECHAT_APP_ID"` // 为空时不开启微信登录
+	AppSecret string `yaml:"app_secret" env:"WECHAT_APP_SECRET" secret:"true"`
+	APIBase   string `yaml:"api_base" env:"WECHAT_API_BASE"` // 微信接口地址，本地开发可指向模拟服务
+}
+
+type RateLimitConfig struct {
+	Enabled bool     `yaml:"enabled" env:"RATE_LIMIT_ENABLED"`                 // 是否开启接口限流，配置了Redis时多实例共享限额
+	APIKeys []string `yaml:"api_keys" env:"RATE_LIMIT_API_KEYS" secret:"true"` // 公开接口按这些X-API-Key分别限流，其他请求按IP
+}
+
+type LogConfig struct {
+	Level  string `yaml:"level" env:"LOG_LEVEL"`   // debug, info, warn, error
+	Format string `yaml:"format" env:"LOG_FORMAT"` // json, text
+}
+
+type MetricsConfig struct {
+	Addr  string `yaml:"addr" env:"METRICS_ADDR"`                 // Prometheus指标独立监听地址，如 :9091；为空时挂在主端口
+	Token string `yaml:"token" env:"METRICS_TOKEN" secret:"true"` // 访问指标的Bearer令牌，主端口上必须配置，否则不开放指标
+}
+
+type StorageConfig struct {
+	UploadPath  string `yaml:"upload_path" env:"UPLOAD_PATH"`     // 上传文件目录
+	MaxUploadMB int    `yaml:"max_upload_mb" env:"MAX_UPLOAD_MB"` // 单个上传文件大小上限（MB）
+}
+
+type NotificationConfig struct {
+	Email EmailConfig `yaml:"email"`
+	SMS   SMSConfig   `yaml:"sms"`
+}
+
+type EmailConfig struct {
+	Driver       string `yaml:"driver" env:"EMAIL_DRIVER"` // log（输出到日志）, smtp
+	From         string `yaml:"from" env:"EMAIL_FROM"`
+	SMTPHost     string `yaml:"smtp_host" env:"SMTP_HOST"`
+	SMTPPort     int    `yaml:"smtp_port" env:"SMTP_PORT"`
+	SMTPUsername string `yaml:"smtp_username" env:"SMTP_USERNAME"`
+	SMTPPassword string `yaml:"smtp_password" env:"SMTP_PASSWORD" secret:"true"`
+}
+
+type SMSConfig struct {
+	Driver string `yaml:"driver" env:"SMS_DRIVER"` // log（输出到日志），暂未接入短信服务商
+}
+
+// 默认配置，适用于本地开发
+func Default() *Config {
+	return &Config{
+		Env: EnvDevelopment,
+		Server: ServerConfig{
+			Port:            "8080",
+			ReadTimeout:     30,
+			WriteTimeout:    120,
+			IdleTimeout:     120,
+			ShutdownDelay:   5,
+			ShutdownTimeout: 30,
+			TrustedProxies:  []string{"127.0.0.1", "::1"},
+		},
+		Database: DatabaseConfig{
+			Driver:        "mysql",
+			Host:          "localhost",
+			User:          "root",
+			Name:          "device_recycle",
+			Path:          "device_recycle.db",
+			SSLMode:       "disable",
+			LogLevel:      "warn",
+			SlowThreshold: 200,
+		},
+		Redis: RedisConfig{
+			Port: "6379",
+		},
+		Cache: CacheConfig{
+			Enabled:           true,
+			DeviceTTLSeconds:  60,
+			HTTPMaxAgeSeconds: 30,
+		},
+		Search: SearchConfig{
+			Engine:               "database",
+			MemoryRefreshSeconds: 300,
+		},
+		Auth: AuthConfig{
+			PasswordResetURL:       "http://localhost/#/pages/user/reset-password",
+			TwoFactorIssuer:        "电脑回收",
+			TwoFactorRequiredRoles: []string{"admin", "evaluator"},
+			LoginMaxFailures:       5,
+			LoginIPMaxFailures:     20,
+			LoginLockMinutes:       15,
+		},
+		WeChat: WeChatConfig{
+			APIBase: "https://api.weixin.qq.com",
+		},
+		RateLimit: RateLimitConfig{
+			Enabled: true,
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "json",
+		},
+		Storage: StorageConfig{
+			UploadPath:  "./uploads",
+			MaxUploadMB: 10,
+		},
+		Notification: NotificationConfig{
+			Email: EmailConfig{Driver: "log", SMTPPort: 587},
+			SMS:   SMSConfig{Driver: "log"},
+		},
 	}
 }
 
+var (
+	config   *Config
+	warnings []string
+)
+
+// 加载并校验配置，设置为全局配置。path为空时依次尝试环境变量CONFIG_FILE和当前目录的config.yaml
+func Init(path string, overrides ...func(*Config)) error {
+	cfg, err := Load(path, overrides...)
+	if err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	warnings = cfg.warnings()
+
+	// 开发环境未设置JWT密钥时生成临时密钥，重启后已签发的令牌失效
+	if cfg.Auth.JWTSecret == "" {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return err
+		}
+		cfg.Auth.JWTSecret = hex.EncodeToString(secret)
+		warnings = append(warnings, "未设置 auth.jwt_secret（JWT_SECRET），已生成临时密钥，重启后需要重新登录")
+	}
+
+	config = cfg
+	return nil
+}
+
 func GetConfig() *Config {
 	return config
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// 加载配置时发现的问题，由serve启动时输出到日志
+func Warnings() []string {
+	return warnings
+}
+
+// 是否为生产环境
+func (c *Config) IsProduction() bool {
+	return c.Env == EnvProduction
+}
+
+// 该角色是否必须开启两步验证
+func (c *Config) TwoFactorRequired(role string) bool {
+	for _, r := range c.Auth.TwoFactorRequiredRoles {
+		if strings.TrimSpace(r) == role {
+			return true
+		}
 	}
-	return defaultValue
+	return false
 }
@@ -0,0 +1,214 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 清除可能影响测试的环境变量
+func clearEnv(t *testing.T) {
+	t.Helper()
+	for _, name := range []string{"CONFIG_FILE", "APP_ENV", "PORT", "DB_DRIVER", "DB_HOST", "DB_PASS", "JWT_SECRET", "LOG_LEVEL", "TWO_FACTOR_REQUIRED_ROLES", "RATE_LIMIT_ENABLED"} {
+		t.Setenv(name, "")
+		t.Setenv(name+"_FILE", "")
+	}
+}
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadLayers(t *testing.T) {
+	clearEnv(t)
+	path := writeFile(t, `
+server:
+  port: "9000"
+database:
+  driver: postgres
+  host: db.internal
+log:
+  level: debug
+`)
+	t.Setenv("DB_HOST", "db.env")
+	t.Setenv("TWO_FACTOR_REQUIRED_ROLES", "admin, user")
+	t.Setenv("RATE_LIMIT_ENABLED", "false")
+
+	cfg, err := Load(path, func(cfg *Config) { cfg.Server.Port = "9100" })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Server.Port != "9100" {
+		t.Errorf("命令行参数应覆盖配置文件，port = %q", cfg.Server.Port)
+	}
+	if cfg.Database.Driver != "postgres" || cfg.Log.Level != "debug" {
+		t.Errorf("未读取配置文件: driver = %q, log.level = %q", cfg.Database.Driver, cfg.Log.Level)
+	}
+	if cfg.Database.Host != "db.env" {
+		t.Errorf("环境变量应覆盖配置文件，host = %q", cfg.Database.Host)
+	}
+	if got := strings.Join(cfg.Auth.TwoFactorRequiredRoles, ","); got != "admin,user" {
+		t.Errorf("two_factor_required_roles = %q", got)
+	}
+	if cfg.RateLimit.Enabled {
+		t.Error("RATE_LIMIT_ENABLED=false 未生效")
+	}
+	if cfg.Server.WriteTimeout != Default().Server.WriteTimeout {
+		t.Errorf("未配置的项应使用默认值，write_timeout = %d", cfg.Server.WriteTimeout)
+	}
+}
+
+func TestLoadUnknownField(t *testing.T) {
+	clearEnv(t)
+	path := writeFile(t, "database:\n  pasword: secret\n")
+
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "pasword") {
+		t.Fatalf("拼写错误的配置项应报错，得到 %v", err)
+	}
+}
+
+func TestLoadInvalidEnv(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("LOGIN_MAX_FAILURES", "five")
+
+	if _, err := Load(""); err == nil || !strings.Contains(err.Error(), "LOGIN_MAX_FAILURES") {
+		t.Fatalf("无效的环境变量应报错，得到 %v", err)
+	}
+}
+
+func TestLoadSecretFile(t *testing.T) {
+	clearEnv(t)
+	secretFile := filepath.Join(t.TempDir(), "db_pass")
+	if err := os.WriteFile(secretFile, []byte("from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DB_PASS", "from-env")
+	t.Setenv("DB_PASS_FILE", secretFile)
+
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Database.Password != "from-file" {
+		t.Errorf("DB_PASS_FILE 应优先于 DB_PASS，password = %q", cfg.Database.Password)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Default().Validate(); err != nil {
+		t.Fatalf("默认配置应通过校验: %v", err)
+	}
+
+	cfg := Default()
+	cfg.Server.Port = "abc"
+	cfg.Database.Driver = "oracle"
+	cfg.Notification.Email.Driver = "smtp"
+	cfg.Server.TrustedProxies = []string{"172.28.0.0/16", "nginx"}
+	cfg.Server.ShutdownDelay = -1
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("无效配置应校验失败")
+	}
+	// 一次返回所有问题
+	for _, want := range []string{"server.port", "server.shutdown_delay_seconds", "server.trusted_proxies", "database.driver", "notification.email.smtp_host"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("错误信息缺少 %s: %v", want, err)
+		}
+	}
+}
+
+func TestValidateProduction(t *testing.T) {
+	production := func() *Config {
+		cfg := Default()
+		cfg.Env = EnvProduction
+		cfg.Auth.JWTSecret = strings.Repeat("k", minJWTSecretLength)
+		cfg.Database.Password = "db-password"
+		return cfg
+	}
+
+	if err := production().Validate(); err != nil {
+		t.Fatalf("有效的生产配置应通过校验: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		want   string
+	}{
+		{"空JWT密钥", func(c *Config) { c.Auth.JWTSecret = "" }, "jwt_secret"},
+		{"示例JWT密钥", func(c *Config) { c.Auth.JWTSecret = "device-recycle-secret-key" }, "示例JWT密钥"},
+		{"JWT密钥过短", func(c *Config) { c.Auth.JWTSecret = "short-secret" }, "长度"},
+		{"空数据库密码", func(c *Config) { c.Database.Password = "" }, "数据库密码"},
+		{"记录所有SQL", func(c *Config) { c.Database.LogLevel = "info" }, "log_level=info"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := production()
+			tt.modify(cfg)
+			err := cfg.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("应校验失败并包含 %q，得到 %v", tt.want, err)
+			}
+		})
+	}
+
+	// SQLite不需要数据库密码
+	cfg := production()
+	cfg.Database.Driver = "sqlite"
+	cfg.Database.Password = ""
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("SQLite不需要数据库密码: %v", err)
+	}
+}
+
+func TestInitGeneratesDevelopmentSecret(t *testing.T) {
+	clearEnv(t)
+	if err := Init(""); err != nil {
+		t.Fatal(err)
+	}
+	if len(GetConfig().Auth.JWTSecret) < minJWTSecretLength {
+		t.Errorf("开发环境应生成临时JWT密钥，得到 %q", GetConfig().Auth.JWTSecret)
+	}
+
+	if err := Init("", func(c *Config) { c.Env = EnvProduction }); err == nil {
+		t.Error("生产环境未设置JWT密钥时应拒绝启动")
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	cfg := Default()
+	cfg.Auth.JWTSecret = "jwt-secret"
+	cfg.Database.Password = "db-password"
+	cfg.Notification.Email.SMTPPassword = "smtp-password"
+	cfg.RateLimit.APIKeys = []string{"api-key"}
+
+	data, err := cfg.Redacted().YAML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(data)
+	for _, secret := range []string{"jwt-secret", "db-password", "smtp-password", "api-key"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("输出中包含敏感配置 %q", secret)
+		}
+	}
+	if !strings.Contains(out, "jwt_secret: '******'") && !strings.Contains(out, `jwt_secret: "******"`) {
+		t.Errorf("已设置的密钥应显示为******:\n%s", out)
+	}
+	// 未设置的密钥保持为空，便于发现遗漏的配置
+	if !strings.Contains(out, `token: ""`) {
+		t.Errorf("未设置的密钥应保持为空:\n%s", out)
+	}
+
+	if cfg.Auth.JWTSecret != "jwt-secret" || cfg.RateLimit.APIKeys[0] != "api-key" {
+		t.Error("Redacted不应修改原配置")
+	}
+}
@@ -0,0 +1,176 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 未指定配置文件时默认读取的文件，不存在时跳过
+const DefaultFile = "config.yaml"
+
+// 按默认值、配置文件、环境变量、overrides（命令行参数）的顺序加载配置，不做校验
+func Load(path string, overrides ...func(*Config)) (*Config, error) {
+	cfg := Default()
+
+	if path == "" {
+		path = os.Getenv("CONFIG_FILE")
+	}
+	if path != "" {
+		if err := loadFile(cfg, path); err != nil {
+			return nil, err
+		}
+	} else if _, err := os.Stat(DefaultFile); err == nil {
+		if err := loadFile(cfg, DefaultFile); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := applyEnv(reflect.ValueOf(cfg).Elem()); err != nil {
+		return nil, err
+	}
+
+	for _, override := range overrides {
+		override(cfg)
+	}
+	return cfg, nil
+}
+
+// 读取YAML配置文件，未知的配置项视为错误，避免拼写错误被忽略
+func loadFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("读取配置文件失败: %w", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("解析配置文件 %s 失败: %w", path, err)
+	}
+	return nil
+}
+
+// 用环境变量覆盖配置，secret字段还支持从 <环境变量>_FILE 指定的文件读取
+func applyEnv(v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		value := v.Field(i)
+
+		if field.Type.Kind() == reflect.Struct {
+			if err := applyEnv(value); err != nil {
+				return err
+			}
+			continue
+		}
+
+		name := field.Tag.Get("env")
+		if name == "" {
+			continue
+		}
+
+		raw, ok := os.LookupEnv(name)
+		if file := os.Getenv(name + "_FILE"); file != "" && field.Tag.Get("secret") == "true" {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return fmt.Errorf("读取 %s_FILE 失败: %w", name, err)
+			}
+			raw, ok = strings.TrimSpace(string(data)), true
+		}
+		// 与之前的行为一致，空字符串视为未设置
+		if !ok || raw == "" {
+			continue
+		}
+
+		if err := setValue(value, raw); err != nil {
+			return fmt.Errorf("环境变量 %s 的值 %q 无效: %w", name, raw, err)
+		}
+	}
+	return nil
+}
+
+func setValue(v reflect.Value, raw string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return errors.New("需要整数")
+		}
+		v.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return errors.New("需要true或false")
+		}
+		v.SetBool(b)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("不支持的类型 %s", v.Kind())
+	}
+	return nil
+}
+
+// 返回隐藏了secret字段的副本，已设置的值显示为******
+func (c *Config) Redacted() *Config {
+	copied := *c
+	copied.Auth.TwoFactorRequiredRoles = append([]string(nil), c.Auth.TwoFactorRequiredRoles...)
+	redact(reflect.ValueOf(&copied).Elem())
+	return &copied
+}
+
+func redact(v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		value := v.Field(i)
+		if value.Kind() == reflect.Struct {
+			redact(value)
+			continue
+		}
+		if t.Field(i).Tag.Get("secret") != "true" {
+			continue
+		}
+		switch value.Kind() {
+		case reflect.String:
+			if value.String() != "" {
+				value.SetString("******")
+			}
+		case reflect.Slice:
+			// 替换为新切片，不修改原配置
+			if value.Len() > 0 {
+				masked := make([]string, value.Len())
+				for j := range masked {
+					masked[j] = "******"
+				}
+				value.Set(reflect.ValueOf(masked))
+			}
+		}
+	}
+}
+
+// 输出为YAML，格式与配置文件相同
+func (c *Config) YAML() ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(c); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
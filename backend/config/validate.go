@@ -0,0 +1,181 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// 示例配置和旧版本中使用过的JWT密钥，生产环境禁止使用
+var knownJWTSecrets = []string{
+	"device-recycle-secret-key",
+	"your-secret-key-here",
+	"your-secret-key",
+	"change-me",
+}
+
+// config.example.yaml 中的数据库密码
+const exampleDBPassword = "your_password"
+
+// 生产环境JWT密钥的最小长度
+const minJWTSecretLength = 32
+
+// 校验配置，返回所有问题而不是只返回第一个
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Env == EnvDevelopment || c.Env == EnvProduction, "env 必须为 development 或 production，当前为 %q", c.Env)
+
+	// 服务
+	check(validPort(c.Server.Port), "server.port 不是有效的端口: %q", c.Server.Port)
+	check(c.Server.ReadTimeout > 0, "server.read_timeout_seconds 必须大于0")
+	check(c.Server.WriteTimeout > 0, "server.write_timeout_seconds 必须大于0")
+	check(c.Server.IdleTimeout > 0, "server.idle_timeout_seconds 必须大于0")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout_seconds 必须大于0")
+	check(c.Server.ShutdownDelay >= 0, "server.shutdown_delay_seconds 不能小于0")
+	for _, proxy := range c.Server.TrustedProxies {
+		check(validIPOrCIDR(proxy), "server.trusted_proxies 包含无效的IP或网段 %q", proxy)
+	}
+
+	// 数据库
+	switch c.Database.Driver {
+	case "mysql", "postgres":
+		check(c.Database.Host != "", "database.host 不能为空")
+		check(c.Database.Name != "", "database.name 不能为空")
+		check(c.Database.Port == "" || validPort(c.Database.Port), "database.port 不是有效的端口: %q", c.Database.Port)
+	case "sqlite":
+		check(c.Database.Path != "", "database.path 不能为空")
+	default:
+		errs = append(errs, fmt.Errorf("database.driver 必须为 mysql、postgres 或 sqlite，当前为 %q", c.Database.Driver))
+	}
+	check(oneOf(c.Database.LogLevel, "silent", "error", "warn", "info"), "database.log_level 必须为 silent、error、warn 或 info")
+	check(c.Database.SlowThreshold >= 0, "database.slow_threshold_ms 不能为负数")
+
+	// Redis
+	if c.Redis.Host != "" {
+		check(validPort(c.Redis.Port), "redis.port 不是有效的端口: %q", c.Redis.Port)
+	}
+
+	if c.Cache.Enabled {
+		check(c.Cache.DeviceTTLSeconds > 0, "cache.device_ttl_seconds 必须大于0")
+	}
+	check(c.Cache.HTTPMaxAgeSeconds >= 0, "cache.http_max_age_seconds 不能为负数")
+
+	check(oneOf(c.Search.Engine, "database", "memory"), "search.engine 必须为 database 或 memory")
+	check(c.Search.MemoryRefreshSeconds >= 0, "search.memory_refresh_seconds 不能为负数")
+
+	// 认证
+	check(c.Auth.LoginMaxFailures > 0, "auth.login_max_failures 必须大于0")
+	check(c.Auth.LoginIPMaxFailures > 0, "auth.login_ip_max_failures 必须大于0")
+	check(c.Auth.LoginLockMinutes > 0, "auth.login_lock_minutes 必须大于0")
+	for _, role := range c.Auth.TwoFactorRequiredRoles {
+		check(oneOf(strings.TrimSpace(role), "user", "admin", "evaluator"), "auth.two_factor_required_roles 包含未知角色 %q", role)
+	}
+
+	if c.WeChat.AppID != "" {
+		check(c.WeChat.AppSecret != "", "配置了 wechat.app_id 时 wechat.app_secret 不能为空")
+	}
+
+	// 日志和指标
+	check(oneOf(strings.ToLower(c.Log.Level), "debug", "info", "warn", "warning", "error"), "log.level 必须为 debug、info、warn 或 error")
+	check(oneOf(strings.ToLower(c.Log.Format), "json", "text"), "log.format 必须为 json 或 text")
+	if c.Metrics.Addr != "" {
+		_, port, err := net.SplitHostPort(c.Metrics.Addr)
+		check(err == nil && validPort(port), "metrics.addr 不是有效的监听地址: %q", c.Metrics.Addr)
+	}
+
+	// 存储
+	check(c.Storage.UploadPath != "", "storage.upload_path 不能为空")
+	check(c.Storage.MaxUploadMB > 0, "storage.max_upload_mb 必须大于0")
+
+	// 通知
+	switch c.Notification.Email.Driver {
+	case "log":
+	case "smtp":
+		check(c.Notification.Email.SMTPHost != "", "notification.email.smtp_host 不能为空")
+		check(c.Notification.Email.SMTPPort > 0 && c.Notification.Email.SMTPPort < 65536, "notification.email.smtp_port 不是有效的端口")
+		check(c.Notification.Email.From != "", "notification.email.from 不能为空")
+	default:
+		errs = append(errs, fmt.Errorf("notification.email.driver 必须为 log 或 smtp，当前为 %q", c.Notification.Email.Driver))
+	}
+	check(c.Notification.SMS.Driver == "log", "notification.sms.driver 目前只支持 log")
+
+	if c.IsProduction() {
+		errs = append(errs, c.validateProduction()...)
+	}
+
+	return errors.Join(errs...)
+}
+
+// 生产环境额外的安全检查
+func (c *Config) validateProduction() []error {
+	var errs []error
+
+	secret := c.Auth.JWTSecret
+	switch {
+	case secret == "":
+		errs = append(errs, errors.New("生产环境必须设置 auth.jwt_secret（JWT_SECRET）"))
+	case oneOf(secret, knownJWTSecrets...):
+		errs = append(errs, errors.New("生产环境不能使用示例JWT密钥，请生成随机密钥，如: openssl rand -base64 48"))
+	case len(secret) < minJWTSecretLength:
+		errs = append(errs, fmt.Errorf("生产环境JWT密钥长度不能少于%d个字符", minJWTSecretLength))
+	}
+
+	if c.Database.Driver != "sqlite" {
+		switch c.Database.Password {
+		case "":
+			errs = append(errs, errors.New("生产环境数据库密码（database.password / DB_PASS）不能为空"))
+		case exampleDBPassword:
+			errs = append(errs, errors.New("生产环境不能使用示例数据库密码"))
+		}
+	}
+	if c.Database.LogLevel == "info" {
+		errs = append(errs, errors.New("生产环境不能记录所有SQL（database.log_level=info），SQL中可能包含敏感数据"))
+	}
+	return errs
+}
+
+// 不影响启动但需要注意的配置问题
+func (c *Config) warnings() []string {
+	var warnings []string
+	if c.IsProduction() {
+		if c.Notification.Email.Driver == "log" {
+			warnings = append(warnings, "邮件输出到日志（notification.email.driver=log），日志中会包含密码重置链接")
+		}
+		if c.Notification.SMS.Driver == "log" {
+			warnings = append(warnings, "短信输出到日志（notification.sms.driver=log），日志中会包含验证码")
+		}
+	}
+	if c.Metrics.Addr == "" && c.Metrics.Token == "" {
+		warnings = append(warnings, "未配置 metrics.addr 或 metrics.token，不开放Prometheus指标")
+	}
+	return warnings
+}
+
+func validPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n > 0 && n < 65536
+}
+
+func validIPOrCIDR(value string) bool {
+	if _, _, err := net.ParseCIDR(value); err == nil {
+		return true
+	}
+	return net.ParseIP(value) != nil
+}
+
+func oneOf(value string, options ...string) bool {
+	for _, option := range options {
+		if value == option {
+			return true
+		}
+	}
+	return false
+}
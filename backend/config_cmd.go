@@ -0,0 +1,48 @@
+package main
+
+import (
+	"e-device-recycle-backend/config"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// config 子命令：print [-redacted] | validate
+func runConfig(args []string, path string, overrides func(*config.Config)) {
+	command := "print"
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+
+	cfg, err := config.Load(path, overrides)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	switch command {
+	case "print":
+		fs := flag.NewFlagSet("config print", flag.ExitOnError)
+		redacted := fs.Bool("redacted", false, "隐藏密码、密钥等敏感配置")
+		fs.Parse(args)
+
+		if *redacted {
+			cfg = cfg.Redacted()
+		}
+		data, err := cfg.YAML()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Stdout.Write(data)
+	case "validate":
+		if err := cfg.Validate(); err != nil {
+			fmt.Fprintf(os.Stderr, "配置有误:\n%v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("配置有效")
+	default:
+		fmt.Fprintf(os.Stderr, "未知的config命令: %s，可用: print [-redacted] | validate\n", command)
+		os.Exit(2)
+	}
+}
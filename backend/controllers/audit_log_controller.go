@@ -0,0 +1,190 @@
+package controllers
+
+import (
+	"e-device-recycle-backend/apierror"
+	"e-device-recycle-backend/listquery"
+	"e-device-recycle-backend/models"
+	"e-device-recycle-backend/repositories"
+	"e-device-recycle-backend/services"
+	"e-device-recycle-backend/utils"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditLogController struct {
+	logs services.AuditLogService
+}
+
+func NewAuditLogController(logs services.AuditLogService) *AuditLogController {
+	return &AuditLogController{logs: logs}
+}
+
+// 导出CSV的最大行数
+const auditExportLimit = 50000
+
+// recordAudit写入审计日志使用的服务，由SetupRoutes设置
+var auditLogs services.AuditLogService
+
+func SetAuditLogService(logs services.AuditLogService) {
+	auditLogs = logs
+}
+
+// 记录管理操作，before/after为操作前后的模型（新建时before为nil，删除时after为nil）
+func recordAudit(c *gin.Context, action, entityType string, entityID uint, before, after interface{}) {
+	actorID, _ := c.Get("user_id")
+	actorName, _ := c.Get("username")
+	actorRole, _ := c.Get("role")
+
+	beforeSnapshot := utils.Snapshot(before)
+	afterSnapshot := utils.Snapshot(after)
+
+	entry := models.AuditLog{
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Before:     marshalAudit(beforeSnapshot),
+		After:      marshalAudit(afterSnapshot),
+		Diff:       marshalAudit(utils.DiffSnapshots(beforeSnapshot, afterSnapshot)),
+		IP:         c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+		Method:     c.Request.Method,
+		Path:       c.Request.URL.Path,
+		RequestID:  c.GetString("request_id"),
+	}
+	if id, ok := actorID.(uint); ok {
+		entry.ActorID = id
+	}
+	if name, ok := actorName.(string); ok {
+		entry.ActorName = name
+	}
+	if role, ok := actorRole.(string); ok {
+		entry.ActorRole = role
+	}
+
+	if err := auditLogs.Record(&entry); err != nil {
+		slog.ErrorContext(c.Request.Context(), "写入审计日志失败", "error", err, "action", action)
+	}
+}
+
+func marshalAudit(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// 获取审计日志（管理员）
+func (alc *AuditLogController) GetAuditLogs(c *gin.Context) {
+	params, ok := listParams(c, repositories.AuditLogListSpec)
+	if !ok {
+		return
+	}
+	filter, ok := alc.filter(c)
+	if !ok {
+		return
+	}
+
+	logs, total, err := alc.logs.List(filter, params)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"logs":       logs,
+		"pagination": pagination(params, total, logs),
+	})
+}
+
+// 导出审计日志为CSV（管理员）
+func (alc *AuditLogController) ExportAuditLogs(c *gin.Context) {
+	filter, ok := alc.filter(c)
+	if !ok {
+		return
+	}
+
+	// 读到第一批后才写出响应头，此前失败时仍可返回错误响应
+	var w *csv.Writer
+	err := alc.logs.Export(filter, auditExportLimit, func(batch []models.AuditLog) error {
+		if w == nil {
+			w = startAuditExport(c)
+		}
+		for _, entry := range batch {
+			w.Write([]string{
+				strconv.FormatUint(uint64(entry.ID), 10),
+				entry.CreatedAt.Format(time.RFC3339),
+				strconv.FormatUint(uint64(entry.ActorID), 10),
+				entry.ActorName,
+				entry.ActorRole,
+				entry.Action,
+				entry.EntityType,
+				strconv.FormatUint(uint64(entry.EntityID), 10),
+				entry.Diff,
+				entry.IP,
+				entry.Method,
+				entry.Path,
+				entry.RequestID,
+			})
+		}
+		w.Flush()
+		return w.Error()
+	})
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "导出审计日志失败", "error", err)
+		if w == nil {
+			apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
+			return
+		}
+		// 已经写出200和部分内容，中断连接，客户端不会把不完整的文件当作导出成功
+		panic(http.ErrAbortHandler)
+	}
+	if w == nil {
+		// 没有符合条件的日志，只导出表头
+		startAuditExport(c).Flush()
+	}
+}
+
+// 写出CSV响应头、BOM和表头行
+func startAuditExport(c *gin.Context) *csv.Writer {
+	filename := fmt.Sprintf("audit_logs_%s.csv", time.Now().Format("20060102150405"))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+
+	// 写入BOM，便于Excel正确识别中文
+	c.Writer.Write([]byte("\xEF\xBB\xBF"))
+	w := csv.NewWriter(c.Writer)
+	w.Write([]string{"id", "created_at", "actor_id", "actor_name", "actor_role", "action",
+		"entity_type", "entity_id", "diff", "ip", "method", "path", "request_id"})
+	return w
+}
+
+// 解析过滤参数
+func (alc *AuditLogController) filter(c *gin.Context) (repositories.AuditLogFilter, bool) {
+	filter := repositories.AuditLogFilter{
+		ActorID:    queryID(c, "actor_id"),
+		Action:     c.Query("action"),
+		EntityType: c.Query("entity_type"),
+		EntityID:   queryID(c, "entity_id"),
+	}
+
+	// 时间范围，格式 2006-01-02 或 RFC3339
+	created, err := listquery.ParseTimeRange(c.Request.URL.Query(), "start", "end")
+	if err != nil {
+		respondQueryError(c, err)
+		return filter, false
+	}
+	filter.Created = created
+
+	return filter, true
+}
@@ -0,0 +1,257 @@
+package controllers
+
+import (
+	"e-device-recycle-backend/apierror"
+	"e-device-recycle-backend/models"
+	"e-device-recycle-backend/repositories"
+	"e-device-recycle-backend/services"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 品牌、系列和型号目录：公开查询，管理员维护
+type CatalogController struct {
+	catalog services.CatalogService
+}
+
+func NewCatalogController(catalog services.CatalogService) *CatalogController {
+	return &CatalogController{catalog: catalog}
+}
+
+// 品牌列表
+func (cc *CatalogController) GetBrands(c *gin.Context) {
+	brands, err := cc.catalog.ListBrands()
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
+		return
+	}
+	setCacheControl(c)
+	c.JSON(http.StatusOK, gin.H{"brands": brands})
+}
+
+// 品牌详情，包含品牌下的系列和型号
+func (cc *CatalogController) GetBrand(c *gin.Context) {
+	id := paramID(c, "id")
+	brand, err := cc.catalog.GetBrand(id)
+	if err != nil {
+		respondCatalogError(c, err, services.ErrBrandNotFound)
+		return
+	}
+	series, err := cc.catalog.ListSeries(id)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
+		return
+	}
+	deviceModels, err := cc.catalog.ListModels(repositories.DeviceModelFilter{BrandID: id})
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
+		return
+	}
+
+	setCacheControl(c)
+	c.JSON(http.StatusOK, gin.H{
+		"brand":  brand,
+		"series": series,
+		"models": deviceModels,
+	})
+}
+
+// 创建品牌（管理员功能）
+func (cc *CatalogController) CreateBrand(c *gin.Context) {
+	var req models.BrandRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondValidation(c, err)
+		return
+	}
+
+	brand, err := cc.catalog.CreateBrand(req)
+	if err != nil {
+		respondCatalogError(c, err, nil)
+		return
+	}
+
+	recordAudit(c, "brand.create", "brand", brand.ID, nil, brand)
+	c.JSON(http.StatusCreated, gin.H{"message": "品牌创建成功", "brand": brand})
+}
+
+// 更新品牌（管理员功能），改名后设备上的品牌名称同步更新
+func (cc *CatalogController) UpdateBrand(c *gin.Context) {
+	var req models.BrandRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondValidation(c, err)
+		return
+	}
+
+	before, brand, err := cc.catalog.UpdateBrand(paramID(c, "id"), req)
+	if err != nil {
+		respondCatalogError(c, err, services.ErrBrandNotFound)
+		return
+	}
+
+	recordAudit(c, "brand.update", "brand", brand.ID, before, brand)
+	c.JSON(http.StatusOK, gin.H{"message": "品牌更新成功", "brand": brand})
+}
+
+// 删除品牌（管理员功能）
+func (cc *CatalogController) DeleteBrand(c *gin.Context) {
+	brand, err := cc.catalog.DeleteBrand(paramID(c, "id"))
+	if err != nil {
+		respondCatalogError(c, err, services.ErrBrandNotFound)
+		return
+	}
+
+	recordAudit(c, "brand.delete", "brand", brand.ID, brand, nil)
+	c.JSON(http.StatusOK, gin.H{"message": "品牌删除成功"})
+}
+
+// 系列列表，可按品牌过滤
+func (cc *CatalogController) GetSeries(c *gin.Context) {
+	series, err := cc.catalog.ListSeries(queryID(c, "brand_id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
+		return
+	}
+	setCacheControl(c)
+	c.JSON(http.StatusOK, gin.H{"series": series})
+}
+
+// 创建系列（管理员功能）
+func (cc *CatalogController) CreateSeries(c *gin.Context) {
+	var req models.DeviceSeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondValidation(c, err)
+		return
+	}
+
+	series, err := cc.catalog.CreateSeries(req)
+	if err != nil {
+		respondCatalogError(c, err, nil)
+		return
+	}
+
+	recordAudit(c, "series.create", "series", series.ID, nil, series)
+	c.JSON(http.StatusCreated, gin.H{"message": "系列创建成功", "series": series})
+}
+
+// 更新系列（管理员功能）
+func (cc *CatalogController) UpdateSeries(c *gin.Context) {
+	var req models.DeviceSeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondValidation(c, err)
+		return
+	}
+
+	before, series, err := cc.catalog.UpdateSeries(paramID(c, "id"), req)
+	if err != nil {
+		respondCatalogError(c, err, services.ErrSeriesNotFound)
+		return
+	}
+
+	recordAudit(c, "series.update", "series", series.ID, before, series)
+	c.JSON(http.StatusOK, gin.H{"message": "系列更新成功", "series": series})
+}
+
+// 删除系列（管理员功能）
+func (cc *CatalogController) DeleteSeries(c *gin.Context) {
+	series, err := cc.catalog.DeleteSeries(paramID(c, "id"))
+	if err != nil {
+		respondCatalogError(c, err, services.ErrSeriesNotFound)
+		return
+	}
+
+	recordAudit(c, "series.delete", "series", series.ID, series, nil)
+	c.JSON(http.StatusOK, gin.H{"message": "系列删除成功"})
+}
+
+// 型号列表，可按品牌和系列过滤
+func (cc *CatalogController) GetModels(c *gin.Context) {
+	deviceModels, err := cc.catalog.ListModels(repositories.DeviceModelFilter{
+		BrandID:  queryID(c, "brand_id"),
+		SeriesID: queryID(c, "series_id"),
+	})
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
+		return
+	}
+	setCacheControl(c)
+	c.JSON(http.StatusOK, gin.H{"models": deviceModels})
+}
+
+// 型号详情
+func (cc *CatalogController) GetModel(c *gin.Context) {
+	model, err := cc.catalog.GetModel(paramID(c, "id"))
+	if err != nil {
+		respondCatalogError(c, err, services.ErrModelNotFound)
+		return
+	}
+	setCacheControl(c)
+	c.JSON(http.StatusOK, gin.H{"model": model})
+}
+
+// 创建型号（管理员功能）
+func (cc *CatalogController) CreateModel(c *gin.Context) {
+	var req models.DeviceModelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondValidation(c, err)
+		return
+	}
+
+	model, err := cc.catalog.CreateModel(req)
+	if err != nil {
+		respondCatalogError(c, err, nil)
+		return
+	}
+
+	recordAudit(c, "model.create", "model", model.ID, nil, model)
+	c.JSON(http.StatusCreated, gin.H{"message": "型号创建成功", "model": model})
+}
+
+// 更新型号（管理员功能），改名或修改品牌后设备同步更新
+func (cc *CatalogController) UpdateModel(c *gin.Context) {
+	var req models.DeviceModelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondValidation(c, err)
+		return
+	}
+
+	before, model, err := cc.catalog.UpdateModel(paramID(c, "id"), req)
+	if err != nil {
+		respondCatalogError(c, err, services.ErrModelNotFound)
+		return
+	}
+
+	recordAudit(c, "model.update", "model", model.ID, before, model)
+	c.JSON(http.StatusOK, gin.H{"message": "型号更新成功", "model": model})
+}
+
+// 删除型号（管理员功能）
+func (cc *CatalogController) DeleteModel(c *gin.Context) {
+	model, err := cc.catalog.DeleteModel(paramID(c, "id"))
+	if err != nil {
+		respondCatalogError(c, err, services.ErrModelNotFound)
+		return
+	}
+
+	recordAudit(c, "model.delete", "model", model.ID, model, nil)
+	c.JSON(http.StatusOK, gin.H{"message": "型号删除成功"})
+}
+
+// 返回目录相关的业务错误。pathNotFound为路径中的记录不存在的错误，返回404；
+// 请求内容引用的品牌、系列、型号不存在时返回400
+func respondCatalogError(c *gin.Context, err, pathNotFound error) {
+	switch {
+	case pathNotFound != nil && errors.Is(err, pathNotFound):
+		respondServiceError(c, http.StatusNotFound, err)
+	case errors.Is(err, services.ErrBrandExists), errors.Is(err, services.ErrBrandInUse),
+		errors.Is(err, services.ErrSeriesExists), errors.Is(err, services.ErrSeriesInUse),
+		errors.Is(err, services.ErrModelExists), errors.Is(err, services.ErrModelInUse):
+		respondServiceError(c, http.StatusConflict, err)
+	case errors.Is(err, services.ErrBrandNotFound), errors.Is(err, services.ErrSeriesNotFound),
+		errors.Is(err, services.ErrModelNotFound), errors.Is(err, services.ErrSeriesBrandMismatch):
+		respondServiceError(c, http.StatusBadRequest, err)
+	default:
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
+	}
+}
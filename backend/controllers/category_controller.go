@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"e-device-recycle-backend/apierror"
+	"e-device-recycle-backend/models"
+	"e-device-recycle-backend/services"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 设备分类目录：公开查询，管理员维护
+type CategoryController struct {
+	categories services.CategoryService
+}
+
+func NewCategoryController(categories services.CategoryService) *CategoryController {
+	return &CategoryController{categories: categories}
+}
+
+// 分类列表，按排序值排序，客户端根据parent_id组织层级
+func (cc *CategoryController) GetCategories(c *gin.Context) {
+	categories, err := cc.categories.List()
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
+		return
+	}
+	setCacheControl(c)
+	c.JSON(http.StatusOK, gin.H{"categories": categories})
+}
+
+// 分类详情，包含子分类以及补全上级分类设置后的规格字段和定价参数
+func (cc *CategoryController) GetCategory(c *gin.Context) {
+	id := paramID(c, "id")
+	category, err := cc.categories.Get(id)
+	if err != nil {
+		respondCategoryError(c, err)
+		return
+	}
+	rules, err := cc.categories.Rules(id)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
+		return
+	}
+	categories, err := cc.categories.List()
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
+		return
+	}
+	children := []models.Category{}
+	for _, child := range categories {
+		if child.ParentID != nil && *child.ParentID == id {
+			children = append(children, child)
+		}
+	}
+
+	setCacheControl(c)
+	c.JSON(http.StatusOK, gin.H{
+		"category":    category,
+		"children":    children,
+		"spec_fields": rules.SpecFields,
+		"pricing":     rules.Pricing,
+	})
+}
+
+// 创建分类（管理员功能）
+func (cc *CategoryController) CreateCategory(c *gin.Context) {
+	var req models.CategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondValidation(c, err)
+		return
+	}
+
+	category, err := cc.categories.Create(req)
+	if err != nil {
+		respondCategoryError(c, err)
+		return
+	}
+
+	recordAudit(c, "category.create", "category", category.ID, nil, category)
+	c.JSON(http.StatusCreated, gin.H{"message": "分类创建成功", "category": category})
+}
+
+// 更新分类（管理员功能）
+func (cc *CategoryController) UpdateCategory(c *gin.Context) {
+	var req models.CategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondValidation(c, err)
+		return
+	}
+
+	before, category, err := cc.categories.Update(paramID(c, "id"), req)
+	if err != nil {
+		respondCategoryError(c, err)
+		return
+	}
+
+	recordAudit(c, "category.update", "category", category.ID, before, category)
+	c.JSON(http.StatusOK, gin.H{"message": "分类更新成功", "category": category})
+}
+
+// 删除分类（管理员功能）
+func (cc *CategoryController) DeleteCategory(c *gin.Context) {
+	category, err := cc.categories.Delete(paramID(c, "id"))
+	if err != nil {
+		respondCategoryError(c, err)
+		return
+	}
+
+	recordAudit(c, "category.delete", "category", category.ID, category, nil)
+	c.JSON(http.StatusOK, gin.H{"message": "分类删除成功"})
+}
+
+// 返回分类相关的业务错误，路径中的分类不存在返回404，请求中的上级分类不存在返回400
+func respondCategoryError(c *gin.Context, err error) {
+	var fieldErr *services.FieldError
+	switch {
+	case errors.As(err, &fieldErr):
+		apierror.RespondInvalidParam(c, fieldErr.Field, fieldErr.Rule, fieldErr.Param)
+	case errors.Is(err, services.ErrCategoryNotFound):
+		respondServiceError(c, http.StatusNotFound, err)
+	case errors.Is(err, services.ErrCategoryExists), errors.Is(err, services.ErrCategoryInUse):
+		respondServiceError(c, http.StatusConflict, err)
+	case errors.Is(err, services.ErrParentCategoryNotFound), errors.Is(err, services.ErrCategoryParentInvalid):
+		respondServiceError(c, http.StatusBadRequest, err)
+	default:
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
+	}
+}
@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"e-device-recycle-backend/config"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 设置公开目录接口的缓存响应头（ETag、Last-Modified、Cache-Control），
+// 请求的If-None-Match或If-Modified-Since表明客户端缓存仍然有效时返回304并返回true
+func notModified(c *gin.Context, etag string, lastModified time.Time) bool {
+	header := c.Writer.Header()
+	header.Set("ETag", etag)
+	if !lastModified.IsZero() {
+		header.Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+	setCacheControl(c)
+
+	// 同时提供两者时以If-None-Match为准
+	if match := c.GetHeader("If-None-Match"); match != "" {
+		if etagMatches(match, etag) {
+			c.Status(http.StatusNotModified)
+			return true
+		}
+		return false
+	}
+	if since := c.GetHeader("If-Modified-Since"); since != "" && !lastModified.IsZero() {
+		// HTTP日期精确到秒
+		if t, err := http.ParseTime(since); err == nil && !lastModified.Truncate(time.Second).After(t) {
+			c.Status(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+// 公开目录接口允许客户端和代理缓存cache.http_max_age_seconds秒
+func setCacheControl(c *gin.Context) {
+	if maxAge := config.GetConfig().Cache.HTTPMaxAgeSeconds; maxAge > 0 {
+		c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", maxAge))
+	} else {
+		c.Header("Cache-Control", "public, no-cache")
+	}
+}
+
+// If-None-Match使用弱比较：忽略W/前缀，"*"匹配任意值
+func etagMatches(header, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -1,119 +1,188 @@
 package controllers
 
 import (
+	"crypto/sha256"
+	"e-device-recycle-backend/apierror"
+	"e-device-recycle-backend/listquery"
 	"e-device-recycle-backend/models"
+	"e-device-recycle-backend/repositories"
+	"e-device-recycle-backend/services"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
-	"strconv"
+	"net/url"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
+	"github.com/gin-gonic/gin/binding"
 )
 
-type DeviceController struct{}
+type DeviceController struct {
+	devices services.DeviceService
+}
+
+func NewDeviceController(devices services.DeviceService) *DeviceController {
+	return &DeviceController{devices: devices}
+}
 
 // 获取设备列表
 func (dc *DeviceController) GetDevices(c *gin.Context) {
-	var devices []models.Device
-
-	// 分页参数
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
-	offset := (page - 1) * pageSize
+	params, ok := listParams(c, repositories.DeviceListSpec)
+	if !ok {
+		return
+	}
 
 	// 过滤参数
-	category := c.Query("category")
-	brand := c.Query("brand")
-	condition := c.Query("condition")
-
-	query := models.DB.Where("status = ?", "active")
-
-	if category != "" {
-		query = query.Where("category = ?", category)
+	filter := repositories.DeviceFilter{
+		Category:  c.Query("category"),
+		Brand:     c.Query("brand"),
+		BrandID:   queryID(c, "brand_id"),
+		SeriesID:  queryID(c, "series_id"),
+		ModelID:   queryID(c, "model_id"),
+		Condition: c.Query("condition"),
+		// 结构化规格
+		StorageType: c.Query("storage_type"),
+		CPUFamily:   c.Query("cpu_family"),
 	}
-	if brand != "" {
-		query = query.Where("brand LIKE ?", "%"+brand+"%")
+	ranges := []struct {
+		target             *listquery.Range
+		minParam, maxParam string
+	}{
+		{&filter.Price, "min_price", "max_price"},
+		{&filter.Year, "min_year", "max_year"},
+		{&filter.Memory, "min_memory", "max_memory"},
+		{&filter.Storage, "min_storage", "max_storage"},
+		{&filter.CPUGeneration, "min_cpu_generation", "max_cpu_generation"},
+		{&filter.Screen, "min_screen", "max_screen"},
 	}
-	if condition != "" {
-		query = query.Where("condition = ?", condition)
+	for _, r := range ranges {
+		var err error
+		if *r.target, err = listquery.ParseRange(c.Request.URL.Query(), r.minParam, r.maxParam); err != nil {
+			respondQueryError(c, err)
+			return
+		}
 	}
 
-	// 获取总数
-	var total int64
-	query.Model(&models.Device{}).Count(&total)
+	// 设备和目录未变化时返回304，不查询列表
+	if dc.listNotModified(c) {
+		return
+	}
 
-	// 获取数据
-	if err := query.Offset(offset).Limit(pageSize).Find(&devices).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取设备列表失败"})
+	devices, total, err := dc.devices.List(filter, params)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
 		return
 	}
 
 	// 转换响应
 	var deviceResponses []models.DeviceResponse
 	for _, device := range devices {
-		deviceResponses = append(deviceResponses, models.DeviceResponse{
-			ID:          device.ID,
-			Name:        device.Name,
-			Brand:       device.Brand,
-			Model:       device.Model,
-			Category:    device.Category,
-			CPU:         device.CPU,
-			Memory:      device.Memory,
-			Storage:     device.Storage,
-			Graphics:    device.Graphics,
-			Screen:      device.Screen,
-			Condition:   device.Condition,
-			YearBought:  device.YearBought,
-			BasePrice:   device.BasePrice,
-			Description: device.Description,
-			Images:      device.Images,
-			Status:      device.Status,
+		deviceResponses = append(deviceResponses, newDeviceResponse(device))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"devices":    deviceResponses,
+		"pagination": pagination(params, total, devices),
+	})
+}
+
+// 全文搜索设备，按相关度排序
+func (dc *DeviceController) SearchDevices(c *gin.Context) {
+	var req models.DeviceSearchRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		apierror.RespondValidation(c, err)
+		return
+	}
+	if req.Page == 0 {
+		req.Page = 1
+	}
+	if req.PageSize == 0 {
+		req.PageSize = 10
+	}
+
+	// 搜索结果只取决于设备、目录和查询参数
+	if dc.listNotModified(c) {
+		return
+	}
+
+	filter := repositories.DeviceFilter{
+		Category:  req.Category,
+		Brand:     req.Brand,
+		Condition: req.Condition,
+	}
+	results, total, err := dc.devices.Search(req.Q, filter, (req.Page-1)*req.PageSize, req.PageSize)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
+		return
+	}
+
+	hits := make([]models.DeviceSearchHit, 0, len(results))
+	for _, result := range results {
+		hits = append(hits, models.DeviceSearchHit{
+			Device:     newDeviceResponse(result.Device),
+			Score:      result.Score,
+			Highlights: result.Highlights,
 		})
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"devices": deviceResponses,
+		"devices": hits,
 		"pagination": gin.H{
-			"page":      page,
-			"page_size": pageSize,
+			"page":      req.Page,
+			"page_size": req.PageSize,
 			"total":     total,
-			"pages":     (total + int64(pageSize) - 1) / int64(pageSize),
+			"pages":     (total + int64(req.PageSize) - 1) / int64(req.PageSize),
 		},
 	})
 }
 
+// 搜索框补全：品牌、型号和设备名称，热门的排在前面
+func (dc *DeviceController) SuggestDevices(c *gin.Context) {
+	var req models.DeviceSuggestRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		apierror.RespondValidation(c, err)
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = 8
+	}
+
+	suggestions, err := dc.devices.Suggest(req.Q, req.Limit)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
+		return
+	}
+
+	response := make([]models.DeviceSuggestion, 0, len(suggestions))
+	for _, s := range suggestions {
+		response = append(response, models.DeviceSuggestion{Text: s.Text, Type: s.Type, Popularity: s.Popularity})
+	}
+
+	// 热度随订单变化，不提供ETag，只允许短时间缓存
+	setCacheControl(c)
+	c.JSON(http.StatusOK, gin.H{"suggestions": response})
+}
+
 // 获取设备详情
 func (dc *DeviceController) GetDevice(c *gin.Context) {
-	id := c.Param("id")
-
-	var device models.Device
-	if err := models.DB.Where("id = ? AND status = ?", id, "active").First(&device).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "设备不存在"})
+	device, err := dc.devices.Get(paramID(c, "id"))
+	if err != nil {
+		if errors.Is(err, services.ErrDeviceNotFound) {
+			respondServiceError(c, http.StatusNotFound, err)
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取设备信息失败"})
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
+		return
+	}
+
+	etag := fmt.Sprintf(`W/"device-%d-%x"`, device.ID, device.UpdatedAt.UnixNano())
+	if notModified(c, etag, device.UpdatedAt) {
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"device": models.DeviceResponse{
-			ID:          device.ID,
-			Name:        device.Name,
-			Brand:       device.Brand,
-			Model:       device.Model,
-			Category:    device.Category,
-			CPU:         device.CPU,
-			Memory:      device.Memory,
-			Storage:     device.Storage,
-			Graphics:    device.Graphics,
-			Screen:      device.Screen,
-			Condition:   device.Condition,
-			YearBought:  device.YearBought,
-			BasePrice:   device.BasePrice,
-			Description: device.Description,
-			Images:      device.Images,
-			Status:      device.Status,
-		},
+		"device": newDeviceResponse(*device),
 	})
 }
 
@@ -121,115 +190,135 @@ func (dc *DeviceController) GetDevice(c *gin.Context) {
 func (dc *DeviceController) CreateDevice(c *gin.Context) {
 	var req models.DeviceCreateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.RespondValidation(c, err)
 		return
 	}
 
-	device := models.Device{
-		Name:        req.Name,
-		Brand:       req.Brand,
-		Model:       req.Model,
-		Category:    req.Category,
-		CPU:         req.CPU,
-		Memory:      req.Memory,
-		Storage:     req.Storage,
-		Graphics:    req.Graphics,
-		Screen:      req.Screen,
-		Condition:   req.Condition,
-		YearBought:  req.YearBought,
-		BasePrice:   req.BasePrice,
-		Description: req.Description,
-		Images:      req.Images,
-		Status:      "active",
-	}
-
-	if err := models.DB.Create(&device).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建设备失败"})
+	device, err := dc.devices.Create(req)
+	if err != nil {
+		respondDeviceError(c, err)
 		return
 	}
 
+	recordAudit(c, "device.create", "device", device.ID, nil, device)
+
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "设备创建成功",
-		"device": models.DeviceResponse{
-			ID:          device.ID,
-			Name:        device.Name,
-			Brand:       device.Brand,
-			Model:       device.Model,
-			Category:    device.Category,
-			CPU:         device.CPU,
-			Memory:      device.Memory,
-			Storage:     device.Storage,
-			Graphics:    device.Graphics,
-			Screen:      device.Screen,
-			Condition:   device.Condition,
-			YearBought:  device.YearBought,
-			BasePrice:   device.BasePrice,
-			Description: device.Description,
-			Images:      device.Images,
-			Status:      device.Status,
-		},
+		"device":  newDeviceResponse(*device),
 	})
 }
 
 // 更新设备（管理员功能）
 func (dc *DeviceController) UpdateDevice(c *gin.Context) {
-	id := c.Param("id")
-
-	var device models.Device
-	if err := models.DB.First(&device, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "设备不存在"})
-		return
-	}
-
 	var updates map[string]interface{}
 	if err := c.ShouldBindJSON(&updates); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.RespondValidation(c, err)
+		return
+	}
+	if err := bindSpecsUpdate(updates); err != nil {
+		apierror.RespondValidation(c, err)
 		return
 	}
 
-	if err := models.DB.Model(&device).Updates(updates).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新设备失败"})
+	before, device, err := dc.devices.Update(paramID(c, "id"), updates)
+	if err != nil {
+		respondDeviceError(c, err)
 		return
 	}
 
+	recordAudit(c, "device.update", "device", device.ID, before, device)
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "设备更新成功",
-		"device": models.DeviceResponse{
-			ID:          device.ID,
-			Name:        device.Name,
-			Brand:       device.Brand,
-			Model:       device.Model,
-			Category:    device.Category,
-			CPU:         device.CPU,
-			Memory:      device.Memory,
-			Storage:     device.Storage,
-			Graphics:    device.Graphics,
-			Screen:      device.Screen,
-			Condition:   device.Condition,
-			YearBought:  device.YearBought,
-			BasePrice:   device.BasePrice,
-			Description: device.Description,
-			Images:      device.Images,
-			Status:      device.Status,
-		},
+		"device":  newDeviceResponse(*device),
 	})
 }
 
 // 删除设备（管理员功能）
 func (dc *DeviceController) DeleteDevice(c *gin.Context) {
-	id := c.Param("id")
-
-	var device models.Device
-	if err := models.DB.First(&device, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "设备不存在"})
-		return
-	}
-
 	// 软删除
-	if err := models.DB.Model(&device).Update("status", "inactive").Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除设备失败"})
+	before, device, err := dc.devices.Delete(paramID(c, "id"))
+	if err != nil {
+		if errors.Is(err, services.ErrDeviceNotFound) {
+			respondServiceError(c, http.StatusNotFound, err)
+			return
+		}
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
 		return
 	}
 
+	recordAudit(c, "device.delete", "device", device.ID, before, device)
+
 	c.JSON(http.StatusOK, gin.H{"message": "设备删除成功"})
 }
+
+// 将更新中的specs对象按创建设备时的规则解析校验为models.DeviceSpecs
+func bindSpecsUpdate(updates map[string]interface{}) error {
+	raw, ok := updates["specs"]
+	if !ok {
+		return nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	var override models.DeviceSpecs
+	if err := json.Unmarshal(data, &override); err != nil {
+		return err
+	}
+	if err := binding.Validator.ValidateStruct(&override); err != nil {
+		return err
+	}
+	updates["specs"] = override
+	return nil
+}
+
+// 设置列表的ETag和Last-Modified，客户端缓存仍然有效时返回304；
+// 按分类和品牌过滤的结果取决于目录，分类或品牌型号变更（包括删除）同样使缓存失效。
+// 已响应（304或查询失败）时返回true
+func (dc *DeviceController) listNotModified(c *gin.Context) bool {
+	lastModified, err := dc.devices.LastModified()
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
+		return true
+	}
+	catalog, err := dc.devices.CatalogStats()
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
+		return true
+	}
+	if catalog.LastModified.After(lastModified) {
+		lastModified = catalog.LastModified
+	}
+	return notModified(c, listETag(lastModified, catalog.Rows, c.Request.URL.Query()), lastModified)
+}
+
+// 列表的ETag由设备和目录的最后变更时间、目录行数和查询参数决定
+func listETag(lastModified time.Time, catalogRows int64, query url.Values) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d|%d|%s", lastModified.UnixNano(), catalogRows, query.Encode())))
+	return fmt.Sprintf(`W/"devices-%x"`, sum[:8])
+}
+
+func newDeviceResponse(device models.Device) models.DeviceResponse {
+	return models.DeviceResponse{
+		ID:          device.ID,
+		Name:        device.Name,
+		Brand:       device.Brand,
+		Model:       device.Model,
+		BrandID:     device.BrandID,
+		ModelID:     device.ModelID,
+		Category:    device.Category,
+		CPU:         device.CPU,
+		Memory:      device.Memory,
+		Storage:     device.Storage,
+		Graphics:    device.Graphics,
+		Screen:      device.Screen,
+		Specs:       device.Specs,
+		Condition:   device.Condition,
+		YearBought:  device.YearBought,
+		BasePrice:   device.BasePrice,
+		Description: device.Description,
+		Images:      device.Images,
+		Status:      device.Status,
+	}
+}
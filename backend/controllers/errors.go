@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"e-device-recycle-backend/apierror"
+	"e-device-recycle-backend/services"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 业务错误对应的错误码
+var serviceErrorCodes = []struct {
+	err  error
+	code apierror.Code
+}{
+	{services.ErrUserNotFound, apierror.UserNotFound},
+	{services.ErrUsernameTaken, apierror.UsernameTaken},
+	{services.ErrPhoneTaken, apierror.PhoneTaken},
+	{services.ErrInvalidPassword, apierror.WrongPassword},
+	{services.ErrUserDisabled, apierror.AccountDisabled},
+	{services.ErrResetTokenInvalid, apierror.ResetTokenInvalid},
+	{services.ErrResetCodeInvalid, apierror.ResetCodeInvalid},
+	{services.ErrResetTokenRequired, apierror.ResetTokenRequired},
+	{services.ErrResetContactRequired, apierror.ResetContactRequired},
+	{services.ErrDeviceNotFound, apierror.DeviceNotFound},
+	{services.ErrOrderNotFound, apierror.OrderNotFound},
+	{services.ErrOrderNotCancellable, apierror.OrderNotCancellable},
+	{services.ErrEvaluationNotFound, apierror.EvaluationNotFound},
+	{services.ErrEvaluationExists, apierror.EvaluationExists},
+	{services.ErrBrandNotFound, apierror.BrandNotFound},
+	{services.ErrBrandExists, apierror.BrandExists},
+	{services.ErrBrandInUse, apierror.BrandInUse},
+	{services.ErrSeriesNotFound, apierror.SeriesNotFound},
+	{services.ErrSeriesExists, apierror.SeriesExists},
+	{services.ErrSeriesInUse, apierror.SeriesInUse},
+	{services.ErrSeriesBrandMismatch, apierror.SeriesBrandMismatch},
+	{services.ErrModelNotFound, apierror.ModelNotFound},
+	{services.ErrModelExists, apierror.ModelExists},
+	{services.ErrModelInUse, apierror.ModelInUse},
+	{services.ErrCategoryNotFound, apierror.CategoryNotFound},
+	{services.ErrParentCategoryNotFound, apierror.CategoryNotFound},
+	{services.ErrCategoryExists, apierror.CategoryExists},
+	{services.ErrCategoryInUse, apierror.CategoryInUse},
+	{services.ErrCategoryParentInvalid, apierror.CategoryParentInvalid},
+	{services.ErrIdentityTaken, apierror.IdentityTaken},
+	{services.ErrIdentityProviderBound, apierror.IdentityProviderUsed},
+	{services.ErrIdentityNotBound, apierror.IdentityNotBound},
+	{services.ErrIdentityLastLogin, apierror.IdentityLastLogin},
+	{services.ErrTwoFactorEnabled, apierror.TwoFactorEnabled},
+	{services.ErrTwoFactorNotEnabled, apierror.TwoFactorNotEnabled},
+	{services.ErrTwoFactorSetupRequired, apierror.TwoFactorSetupRequired},
+	{services.ErrTwoFactorMandatory, apierror.TwoFactorMandatory},
+	{services.ErrTwoFactorCodeInvalid, apierror.TwoFactorCodeInvalid},
+	{services.ErrTwoFactorCodeRequired, apierror.TwoFactorCodeRequired},
+	{services.ErrRecoveryCodeInvalid, apierror.RecoveryCodeInvalid},
+}
+
+// 返回业务错误，未登记的错误作为服务器内部错误
+func respondServiceError(c *gin.Context, status int, err error) {
+	for _, entry := range serviceErrorCodes {
+		if errors.Is(err, entry.err) {
+			apierror.Respond(c, status, entry.code)
+			return
+		}
+	}
+	apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
+}
+
+// 创建、更新设备的业务错误：品牌、型号、分类不在目录中或规格字段不符合分类定义时返回400
+func respondDeviceError(c *gin.Context, err error) {
+	var fieldErr *services.FieldError
+	switch {
+	case errors.As(err, &fieldErr):
+		apierror.RespondInvalidParam(c, fieldErr.Field, fieldErr.Rule, fieldErr.Param)
+	case errors.Is(err, services.ErrDeviceNotFound):
+		respondServiceError(c, http.StatusNotFound, err)
+	case errors.Is(err, services.ErrBrandNotFound), errors.Is(err, services.ErrModelNotFound),
+		errors.Is(err, services.ErrCategoryNotFound):
+		respondServiceError(c, http.StatusBadRequest, err)
+	default:
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
+	}
+}
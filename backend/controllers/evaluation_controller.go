@@ -1,237 +1,148 @@
 package controllers
 
 import (
+	"e-device-recycle-backend/apierror"
 	"e-device-recycle-backend/models"
+	"e-device-recycle-backend/repositories"
+	"e-device-recycle-backend/services"
+	"errors"
 	"net/http"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
-type EvaluationController struct{}
+type EvaluationController struct {
+	evaluations services.EvaluationService
+}
+
+func NewEvaluationController(evaluations services.EvaluationService) *EvaluationController {
+	return &EvaluationController{evaluations: evaluations}
+}
 
 // 创建评估（管理员/评估师）
 func (ec *EvaluationController) CreateEvaluation(c *gin.Context) {
-	evaluatorID, _ := c.Get("user_id")
-
 	var req models.EvaluationCreateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	// 检查订单是否存在
-	var order models.RecycleOrder
-	if err := models.DB.First(&order, req.OrderID).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "订单不存在"})
-		return
-	}
-
-	// 检查是否已有评估
-	var existingEvaluation models.Evaluation
-	if err := models.DB.Where("order_id = ?", req.OrderID).First(&existingEvaluation).Error; err == nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "该订单已有评估记录"})
+		apierror.RespondValidation(c, err)
 		return
 	}
 
-	// 计算综合评分
-	overallScore := float64(req.AppearanceScore+req.FunctionScore+req.PerformanceScore) / 3.0
-
-	// 计算最终价格
-	finalPrice := req.MarketPrice * (1 - req.DepreciationRate) * (overallScore / 10.0)
-
-	// 创建评估
-	evaluation := models.Evaluation{
-		OrderID:          req.OrderID,
-		EvaluatorID:      evaluatorID.(uint),
-		AppearanceScore:  req.AppearanceScore,
-		FunctionScore:    req.FunctionScore,
-		PerformanceScore: req.PerformanceScore,
-		OverallScore:     overallScore,
-		MarketPrice:      req.MarketPrice,
-		DepreciationRate: req.DepreciationRate,
-		FinalPrice:       finalPrice,
-		EvaluationReport: req.EvaluationReport,
-		Images:           req.Images,
-		Status:           "completed",
-	}
-
-	if err := models.DB.Create(&evaluation).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建评估失败"})
+	result, err := ec.evaluations.Create(currentActor(c), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrOrderNotFound):
+			respondServiceError(c, http.StatusBadRequest, err)
+		case errors.Is(err, services.ErrEvaluationExists):
+			respondServiceError(c, http.StatusConflict, err)
+		default:
+			apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
+		}
 		return
 	}
 
-	// 更新订单状态和最终价格
-	models.DB.Model(&order).Updates(map[string]interface{}{
-		"status":      "evaluated",
-		"final_price": finalPrice,
-	})
-
-	// 预加载关联数据
-	models.DB.Preload("Evaluator").First(&evaluation, evaluation.ID)
+	recordAudit(c, "evaluation.create", "evaluation", result.Evaluation.ID, nil, result.Evaluation)
+	recordAudit(c, "order.evaluate", "order", result.Order.ID, result.OrderBefore, result.Order)
 
 	c.JSON(http.StatusCreated, gin.H{
 		"message":    "评估创建成功",
-		"evaluation": ec.convertToResponse(evaluation),
+		"evaluation": newEvaluationResponse(*result.Evaluation),
 	})
 }
 
 // 获取评估列表（管理员）
 func (ec *EvaluationController) GetEvaluations(c *gin.Context) {
-	// 分页参数
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
-	offset := (page - 1) * pageSize
+	params, ok := listParams(c, repositories.EvaluationListSpec)
+	if !ok {
+		return
+	}
 
 	// 过滤参数
-	status := c.Query("status")
-	evaluatorID := c.Query("evaluator_id")
-
-	query := models.DB.Model(&models.Evaluation{})
-
-	if status != "" {
-		query = query.Where("status = ?", status)
-	}
-	if evaluatorID != "" {
-		query = query.Where("evaluator_id = ?", evaluatorID)
+	filter := repositories.EvaluationFilter{
+		Status:      c.Query("status"),
+		EvaluatorID: queryID(c, "evaluator_id"),
 	}
 
-	// 获取总数
-	var total int64
-	query.Count(&total)
-
-	// 获取评估数据
-	var evaluations []models.Evaluation
-	if err := query.Preload("Order").Preload("Evaluator").
-		Order("created_at DESC").
-		Offset(offset).Limit(pageSize).
-		Find(&evaluations).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取评估列表失败"})
+	evaluations, total, err := ec.evaluations.List(filter, params)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
 		return
 	}
 
 	// 转换响应
 	var evaluationResponses []models.EvaluationResponse
 	for _, evaluation := range evaluations {
-		evaluationResponses = append(evaluationResponses, ec.convertToResponse(evaluation))
+		evaluationResponses = append(evaluationResponses, newEvaluationResponse(evaluation))
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"evaluations": evaluationResponses,
-		"pagination": gin.H{
-			"page":      page,
-			"page_size": pageSize,
-			"total":     total,
-			"pages":     (total + int64(pageSize) - 1) / int64(pageSize),
-		},
+		"pagination":  pagination(params, total, evaluations),
 	})
 }
 
 // 获取评估详情
 func (ec *EvaluationController) GetEvaluation(c *gin.Context) {
-	id := c.Param("id")
-
-	var evaluation models.Evaluation
-	if err := models.DB.Preload("Order").Preload("Evaluator").
-		First(&evaluation, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "评估不存在"})
+	evaluation, err := ec.evaluations.Get(paramID(c, "id"))
+	if err != nil {
+		if errors.Is(err, services.ErrEvaluationNotFound) {
+			respondServiceError(c, http.StatusNotFound, err)
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取评估信息失败"})
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"evaluation": ec.convertToResponse(evaluation),
+		"evaluation": newEvaluationResponse(*evaluation),
 	})
 }
 
-// 根据订单ID获取评估
+// 根据订单ID获取评估，普通用户只能查看自己订单的评估
 func (ec *EvaluationController) GetEvaluationByOrder(c *gin.Context) {
-	orderID := c.Param("order_id")
-
-	var evaluation models.Evaluation
-	if err := models.DB.Preload("Order").Preload("Evaluator").
-		Where("order_id = ?", orderID).First(&evaluation).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "该订单暂无评估"})
+	evaluation, err := ec.evaluations.GetByOrder(currentActor(c), paramID(c, "order_id"))
+	if err != nil {
+		if errors.Is(err, services.ErrEvaluationNotFound) {
+			apierror.Respond(c, http.StatusNotFound, apierror.EvaluationNotFound)
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取评估信息失败"})
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"evaluation": ec.convertToResponse(evaluation),
+		"evaluation": newEvaluationResponse(*evaluation),
 	})
 }
 
 // 更新评估
 func (ec *EvaluationController) UpdateEvaluation(c *gin.Context) {
-	id := c.Param("id")
-	evaluatorID, _ := c.Get("user_id")
-	role, _ := c.Get("role")
-
-	var evaluation models.Evaluation
-	query := models.DB.Where("id = ?", id)
-
-	// 普通评估师只能更新自己的评估
-	if role != "admin" {
-		query = query.Where("evaluator_id = ?", evaluatorID)
-	}
-
-	if err := query.First(&evaluation).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "评估不存在或无权限"})
-		return
-	}
-
 	var req models.EvaluationUpdateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.RespondValidation(c, err)
 		return
 	}
 
-	// 重新计算综合评分和最终价格
-	overallScore := float64(req.AppearanceScore+req.FunctionScore+req.PerformanceScore) / 3.0
-	finalPrice := req.MarketPrice * (1 - req.DepreciationRate) * (overallScore / 10.0)
-
-	// 更新评估
-	updates := map[string]interface{}{
-		"appearance_score":  req.AppearanceScore,
-		"function_score":    req.FunctionScore,
-		"performance_score": req.PerformanceScore,
-		"overall_score":     overallScore,
-		"market_price":      req.MarketPrice,
-		"depreciation_rate": req.DepreciationRate,
-		"final_price":       finalPrice,
-		"evaluation_report": req.EvaluationReport,
-		"images":            req.Images,
-		"status":            req.Status,
-	}
-
-	if err := models.DB.Model(&evaluation).Updates(updates).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新评估失败"})
+	result, err := ec.evaluations.Update(currentActor(c), paramID(c, "id"), req)
+	if err != nil {
+		if errors.Is(err, services.ErrEvaluationNotFound) {
+			apierror.Respond(c, http.StatusNotFound, apierror.EvaluationNotFound)
+			return
+		}
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
 		return
 	}
 
-	// 同时更新订单的最终价格
-	models.DB.Model(&models.RecycleOrder{}).
-		Where("id = ?", evaluation.OrderID).
-		Update("final_price", finalPrice)
-
-	// 重新加载评估数据
-	models.DB.Preload("Order").Preload("Evaluator").First(&evaluation, evaluation.ID)
+	recordAudit(c, "evaluation.update", "evaluation", result.Evaluation.ID, result.Before, result.Evaluation)
+	recordAudit(c, "order.reprice", "order", result.Order.ID, result.OrderBefore, result.Order)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message":    "评估更新成功",
-		"evaluation": ec.convertToResponse(evaluation),
+		"evaluation": newEvaluationResponse(*result.Evaluation),
 	})
 }
 
 // 转换为响应格式
-func (ec *EvaluationController) convertToResponse(evaluation models.Evaluation) models.EvaluationResponse {
+func newEvaluationResponse(evaluation models.Evaluation) models.EvaluationResponse {
 	response := models.EvaluationResponse{
 		ID:               evaluation.ID,
 		OrderID:          evaluation.OrderID,
@@ -251,16 +162,8 @@ func (ec *EvaluationController) convertToResponse(evaluation models.Evaluation)
 
 	// 添加评估师信息
 	if evaluation.Evaluator.ID != 0 {
-		response.Evaluator = &models.UserResponse{
-			ID:       evaluation.Evaluator.ID,
-			Username: evaluation.Evaluator.Username,
-			Phone:    evaluation.Evaluator.Phone,
-			Email:    evaluation.Evaluator.Email,
-			RealName: evaluation.Evaluator.RealName,
-			Avatar:   evaluation.Evaluator.Avatar,
-			Role:     evaluation.Evaluator.Role,
-			Status:   evaluation.Evaluator.Status,
-		}
+		evaluator := newUserResponse(evaluation.Evaluator)
+		response.Evaluator = &evaluator
 	}
 
 	return response
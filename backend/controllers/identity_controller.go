@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"e-device-recycle-backend/apierror"
+	"e-device-recycle-backend/identity"
+	"e-device-recycle-backend/models"
+	"e-device-recycle-backend/services"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type IdentityController struct {
+	identities services.IdentityService
+}
+
+func NewIdentityController(identities services.IdentityService) *IdentityController {
+	return &IdentityController{identities: identities}
+}
+
+// 第三方登录（微信小程序等），首次登录自动创建账号
+func (ic *IdentityController) Login(c *gin.Context) {
+	provider, ok := identity.Get(c.Param("provider"))
+	if !ok {
+		apierror.Respond(c, http.StatusNotFound, apierror.ProviderNotSupported)
+		return
+	}
+
+	var req models.OAuthLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondValidation(c, err)
+		return
+	}
+
+	ident, err := provider.Exchange(c.Request.Context(), req.Code)
+	if err != nil {
+		ic.exchangeError(c, err)
+		return
+	}
+
+	user, isNewUser, err := ic.identities.Login(ident)
+	if err != nil {
+		if errors.Is(err, services.ErrUserDisabled) {
+			respondServiceError(c, http.StatusForbidden, err)
+			return
+		}
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
+		return
+	}
+
+	respondLogin(c, *user, gin.H{"is_new_user": isNewUser})
+}
+
+// 获取已绑定的第三方账号
+func (ic *IdentityController) GetIdentities(c *gin.Context) {
+	identities, err := ic.identities.List(currentActor(c).UserID)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
+		return
+	}
+
+	responses := []models.UserIdentityResponse{}
+	for _, ident := range identities {
+		responses = append(responses, models.UserIdentityResponse{
+			Provider:  ident.Provider,
+			CreatedAt: ident.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"identities": responses})
+}
+
+// 为当前账号绑定第三方账号
+func (ic *IdentityController) Bind(c *gin.Context) {
+	provider, ok := identity.Get(c.Param("provider"))
+	if !ok {
+		apierror.Respond(c, http.StatusNotFound, apierror.ProviderNotSupported)
+		return
+	}
+
+	var req models.OAuthLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondValidation(c, err)
+		return
+	}
+
+	ident, err := provider.Exchange(c.Request.Context(), req.Code)
+	if err != nil {
+		ic.exchangeError(c, err)
+		return
+	}
+
+	created, err := ic.identities.Bind(currentActor(c).UserID, ident)
+	if err != nil {
+		if errors.Is(err, services.ErrIdentityTaken) || errors.Is(err, services.ErrIdentityProviderBound) {
+			respondServiceError(c, http.StatusConflict, err)
+			return
+		}
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
+		return
+	}
+	if !created {
+		c.JSON(http.StatusOK, gin.H{"message": "已绑定"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "绑定成功"})
+}
+
+// 解绑第三方账号
+func (ic *IdentityController) Unbind(c *gin.Context) {
+	if err := ic.identities.Unbind(currentActor(c).UserID, c.Param("provider")); err != nil {
+		switch {
+		case errors.Is(err, services.ErrIdentityNotBound):
+			respondServiceError(c, http.StatusNotFound, err)
+		case errors.Is(err, services.ErrIdentityLastLogin):
+			respondServiceError(c, http.StatusConflict, err)
+		default:
+			apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "解绑成功"})
+}
+
+func (ic *IdentityController) exchangeError(c *gin.Context, err error) {
+	if errors.Is(err, identity.ErrInvalidCode) {
+		apierror.Respond(c, http.StatusUnauthorized, apierror.OAuthCodeInvalid)
+		return
+	}
+	apierror.Respond(c, http.StatusBadGateway, apierror.ProviderUnavailable)
+}
@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"e-device-recycle-backend/apierror"
+	"e-device-recycle-backend/listquery"
+	"e-device-recycle-backend/services"
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 解析路径中的ID参数，格式错误时返回0（按记录不存在处理）
+func paramID(c *gin.Context, name string) uint {
+	id, err := strconv.ParseUint(c.Param(name), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return uint(id)
+}
+
+// 解析查询参数中的ID，为空或格式错误时返回0（不过滤）
+func queryID(c *gin.Context, name string) uint {
+	id, err := strconv.ParseUint(c.Query(name), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return uint(id)
+}
+
+// 当前登录用户
+func currentActor(c *gin.Context) services.Actor {
+	userID, _ := c.Get("user_id")
+	role, _ := c.Get("role")
+
+	actor := services.Actor{}
+	actor.UserID, _ = userID.(uint)
+	actor.Role, _ = role.(string)
+	return actor
+}
+
+// 解析列表的排序和分页参数，参数错误时返回错误响应和false
+func listParams(c *gin.Context, spec listquery.Spec) (listquery.Params, bool) {
+	params, err := listquery.Parse(c.Request.URL.Query(), spec)
+	if err != nil {
+		respondQueryError(c, err)
+		return params, false
+	}
+	return params, true
+}
+
+// 返回列表查询参数错误
+func respondQueryError(c *gin.Context, err error) {
+	var queryErr *listquery.Error
+	switch {
+	case !errors.As(err, &queryErr):
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidRequest)
+	case queryErr.Rule == listquery.RuleTime:
+		apierror.Respond(c, http.StatusBadRequest, apierror.InvalidTime, queryErr.Param)
+	default:
+		apierror.RespondInvalidParam(c, queryErr.Param, queryErr.Rule, queryErr.Value)
+	}
+}
+
+// 列表响应的分页信息，rows为本页的记录。还有下一页时返回next_cursor；
+// 游标分页时没有页码，不返回page和pages
+func pagination(params listquery.Params, total int64, rows interface{}) gin.H {
+	result := gin.H{
+		"page_size": params.PageSize,
+		"total":     total,
+	}
+	hasMore := true
+	if params.Cursor == nil {
+		result["page"] = params.Page
+		result["pages"] = (total + int64(params.PageSize) - 1) / int64(params.PageSize)
+		hasMore = int64(params.Offset()+reflect.ValueOf(rows).Len()) < total
+	}
+	if next := params.NextCursor(rows); next != "" && hasMore {
+		result["next_cursor"] = next
+	}
+	return result
+}
@@ -0,0 +1,248 @@
+package controllers
+
+import (
+	"e-device-recycle-backend/apierror"
+	"e-device-recycle-backend/models"
+	"e-device-recycle-backend/services"
+	"e-device-recycle-backend/utils"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TwoFactorController struct {
+	users     services.UserService
+	twoFactor services.TwoFactorService
+}
+
+func NewTwoFactorController(users services.UserService, twoFactor services.TwoFactorService) *TwoFactorController {
+	return &TwoFactorController{users: users, twoFactor: twoFactor}
+}
+
+// 登录第二步：校验验证码或恢复码，签发正式令牌
+func (tc *TwoFactorController) Verify(c *gin.Context) {
+	var req models.TwoFactorVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondValidation(c, err)
+		return
+	}
+
+	user, ok := tc.challengeUser(c, req.ChallengeToken, "2fa_verify")
+	if !ok {
+		return
+	}
+
+	// 验证码错误同样计入登录失败次数
+	if !checkLoginAllowed(c, tc.users, user.Username) {
+		return
+	}
+
+	if err := tc.twoFactor.Verify(user.ID, req.Code, req.RecoveryCode); err != nil {
+		switch {
+		case errors.Is(err, services.ErrTwoFactorCodeInvalid), errors.Is(err, services.ErrRecoveryCodeInvalid):
+			loginFailed(c, tc.users, user.Username, &user.ID, "invalid_2fa")
+			respondServiceError(c, http.StatusUnauthorized, err)
+		case errors.Is(err, services.ErrTwoFactorCodeRequired):
+			respondServiceError(c, http.StatusBadRequest, err)
+		default:
+			apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
+		}
+		return
+	}
+
+	tc.issueToken(c, user.ID, nil)
+}
+
+// 强制开启两步验证的账号在登录时生成密钥
+func (tc *TwoFactorController) ChallengeSetup(c *gin.Context) {
+	var req models.TwoFactorChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondValidation(c, err)
+		return
+	}
+
+	user, ok := tc.challengeUser(c, req.ChallengeToken, "2fa_setup")
+	if !ok {
+		return
+	}
+
+	tc.setup(c, user.ID)
+}
+
+// 强制开启两步验证的账号在登录时确认开启，返回恢复码和正式令牌
+func (tc *TwoFactorController) ChallengeEnable(c *gin.Context) {
+	var req struct {
+		models.TwoFactorChallengeRequest
+		models.TwoFactorCodeRequest
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondValidation(c, err)
+		return
+	}
+
+	user, ok := tc.challengeUser(c, req.ChallengeToken, "2fa_setup")
+	if !ok {
+		return
+	}
+
+	codes, ok := tc.enable(c, user.ID, req.Code)
+	if !ok {
+		return
+	}
+
+	tc.issueToken(c, user.ID, gin.H{"recovery_codes": codes})
+}
+
+// 生成两步验证密钥（未确认前不生效）
+func (tc *TwoFactorController) Setup(c *gin.Context) {
+	tc.setup(c, currentActor(c).UserID)
+}
+
+// 确认开启两步验证
+func (tc *TwoFactorController) Enable(c *gin.Context) {
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondValidation(c, err)
+		return
+	}
+
+	codes, ok := tc.enable(c, currentActor(c).UserID, req.Code)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "两步验证已开启，请妥善保存恢复码",
+		"recovery_codes": codes,
+	})
+}
+
+// 关闭两步验证（需要密码和验证码）
+func (tc *TwoFactorController) Disable(c *gin.Context) {
+	var req models.TwoFactorDisableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondValidation(c, err)
+		return
+	}
+
+	if err := tc.twoFactor.Disable(currentActor(c).UserID, req.Password, req.Code); err != nil {
+		switch {
+		case errors.Is(err, services.ErrUserNotFound):
+			respondServiceError(c, http.StatusNotFound, err)
+		case errors.Is(err, services.ErrTwoFactorMandatory):
+			respondServiceError(c, http.StatusForbidden, err)
+		case errors.Is(err, services.ErrTwoFactorNotEnabled), errors.Is(err, services.ErrInvalidPassword),
+			errors.Is(err, services.ErrTwoFactorCodeInvalid):
+			respondServiceError(c, http.StatusBadRequest, err)
+		default:
+			apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "两步验证已关闭"})
+}
+
+// 重新生成恢复码，旧恢复码全部失效
+func (tc *TwoFactorController) RegenerateRecoveryCodes(c *gin.Context) {
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondValidation(c, err)
+		return
+	}
+
+	codes, err := tc.twoFactor.RegenerateRecoveryCodes(currentActor(c).UserID, req.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrUserNotFound):
+			respondServiceError(c, http.StatusNotFound, err)
+		case errors.Is(err, services.ErrTwoFactorNotEnabled), errors.Is(err, services.ErrTwoFactorCodeInvalid):
+			respondServiceError(c, http.StatusBadRequest, err)
+		default:
+			apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+// 解析临时令牌并加载用户
+func (tc *TwoFactorController) challengeUser(c *gin.Context, token, purpose string) (*models.User, bool) {
+	claims, err := utils.ValidateChallengeJWT(token, purpose)
+	if err != nil {
+		apierror.Respond(c, http.StatusUnauthorized, apierror.ChallengeExpired)
+		return nil, false
+	}
+
+	user, err := tc.users.Get(claims.UserID)
+	if err != nil || user.TokenVersion != claims.Version || user.Status != "active" {
+		apierror.Respond(c, http.StatusUnauthorized, apierror.ChallengeExpired)
+		return nil, false
+	}
+
+	return user, true
+}
+
+func (tc *TwoFactorController) setup(c *gin.Context, userID uint) {
+	setup, err := tc.twoFactor.Setup(userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrUserNotFound):
+			respondServiceError(c, http.StatusNotFound, err)
+		case errors.Is(err, services.ErrTwoFactorEnabled):
+			respondServiceError(c, http.StatusConflict, err)
+		default:
+			apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, setup)
+}
+
+func (tc *TwoFactorController) enable(c *gin.Context, userID uint, code string) ([]string, bool) {
+	codes, err := tc.twoFactor.Enable(userID, code)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrUserNotFound):
+			respondServiceError(c, http.StatusNotFound, err)
+		case errors.Is(err, services.ErrTwoFactorEnabled):
+			respondServiceError(c, http.StatusConflict, err)
+		case errors.Is(err, services.ErrTwoFactorSetupRequired), errors.Is(err, services.ErrTwoFactorCodeInvalid):
+			respondServiceError(c, http.StatusBadRequest, err)
+		default:
+			apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
+		}
+		return nil, false
+	}
+
+	return codes, true
+}
+
+func (tc *TwoFactorController) issueToken(c *gin.Context, userID uint, extra gin.H) {
+	// 重新加载以返回最新的两步验证状态
+	user, err := tc.users.Get(userID)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
+		return
+	}
+
+	token, err := utils.GenerateJWT(user.ID, user.Username, user.Role, user.TokenVersion)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
+		return
+	}
+	loginSucceeded(c, user.Username)
+
+	response := gin.H{
+		"message": "登录成功",
+		"token":   token,
+		"user":    newUserResponse(*user),
+	}
+	for k, v := range extra {
+		response[k] = v
+	}
+	c.JSON(http.StatusOK, response)
+}
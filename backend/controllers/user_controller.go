@@ -1,204 +1,366 @@
 package controllers
 
 import (
+	"e-device-recycle-backend/apierror"
+	"e-device-recycle-backend/config"
 	"e-device-recycle-backend/models"
+	"e-device-recycle-backend/repositories"
+	"e-device-recycle-backend/security"
+	"e-device-recycle-backend/services"
 	"e-device-recycle-backend/utils"
+	"errors"
+	"log/slog"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
-type UserController struct{}
+type UserController struct {
+	users services.UserService
+}
+
+func NewUserController(users services.UserService) *UserController {
+	return &UserController{users: users}
+}
 
 // 用户注册
 func (uc *UserController) Register(c *gin.Context) {
 	var req models.UserRegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.RespondValidation(c, err)
 		return
 	}
 
-	// 检查用户名是否已存在
-	var existingUser models.User
-	if err := models.DB.Where("username = ?", req.Username).First(&existingUser).Error; err == nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "用户名已存在"})
+	user, err := uc.users.Register(req)
+	if err != nil {
+		if errors.Is(err, services.ErrUsernameTaken) || errors.Is(err, services.ErrPhoneTaken) {
+			respondServiceError(c, http.StatusConflict, err)
+			return
+		}
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
 		return
 	}
 
-	// 检查手机号是否已存在
-	if req.Phone != "" {
-		if err := models.DB.Where("phone = ?", req.Phone).First(&existingUser).Error; err == nil {
-			c.JSON(http.StatusConflict, gin.H{"error": "手机号已存在"})
-			return
-		}
+	// 生成JWT token
+	token, err := utils.GenerateJWT(user.ID, user.Username, user.Role, user.TokenVersion)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "注册成功",
+		"token":   token,
+		"user":    newUserResponse(*user),
+	})
+}
+
+// 用户登录
+func (uc *UserController) Login(c *gin.Context) {
+	var req models.UserLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondValidation(c, err)
+		return
+	}
+
+	// 检查是否处于锁定期
+	if !checkLoginAllowed(c, uc.users, req.Username) {
+		return
+	}
+
+	user, err := uc.users.Authenticate(req.Username, req.Password)
+	switch {
+	case errors.Is(err, services.ErrUserNotFound):
+		loginFailed(c, uc.users, req.Username, nil, "user_not_found")
+		apierror.Respond(c, http.StatusUnauthorized, apierror.InvalidCredentials)
+		return
+	case errors.Is(err, services.ErrInvalidPassword):
+		loginFailed(c, uc.users, req.Username, &user.ID, "wrong_password")
+		apierror.Respond(c, http.StatusUnauthorized, apierror.InvalidCredentials)
+		return
+	case errors.Is(err, services.ErrUserDisabled):
+		respondServiceError(c, http.StatusForbidden, err)
+		return
+	case err != nil:
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
+		return
 	}
 
-	// 密码加密
-	hashedPassword, err := utils.HashPassword(req.Password)
+	respondLogin(c, *user, nil)
+}
+
+// 获取用户信息
+func (uc *UserController) GetProfile(c *gin.Context) {
+	user, err := uc.users.Get(currentActor(c).UserID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "密码加密失败"})
+		apierror.Respond(c, http.StatusNotFound, apierror.UserNotFound)
 		return
 	}
 
-	// 创建用户
-	user := models.User{
-		Username: req.Username,
-		Password: hashedPassword,
-		Phone:    req.Phone,
-		Email:    req.Email,
-		RealName: req.RealName,
-		Role:     "user",
-		Status:   "active",
+	c.JSON(http.StatusOK, gin.H{
+		"user": newUserResponse(*user),
+	})
+}
+
+// 更新用户信息
+func (uc *UserController) UpdateProfile(c *gin.Context) {
+	var updates map[string]interface{}
+	if err := c.ShouldBindJSON(&updates); err != nil {
+		apierror.RespondValidation(c, err)
+		return
 	}
 
-	if err := models.DB.Create(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建用户失败"})
+	user, err := uc.users.UpdateProfile(currentActor(c).UserID, updates)
+	if err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			respondServiceError(c, http.StatusNotFound, err)
+			return
+		}
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
 		return
 	}
 
-	// 生成JWT token
-	token, err := utils.GenerateJWT(user.ID, user.Username, user.Role)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "更新成功",
+		"user":    newUserResponse(*user),
+	})
+}
+
+// 修改密码（需要原密码），其他设备上的登录状态将失效
+func (uc *UserController) ChangePassword(c *gin.Context) {
+	var req models.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondValidation(c, err)
+		return
+	}
+
+	user, err := uc.users.ChangePassword(currentActor(c).UserID, req.OldPassword, req.NewPassword)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成token失败"})
+		switch {
+		case errors.Is(err, services.ErrUserNotFound):
+			respondServiceError(c, http.StatusNotFound, err)
+		case errors.Is(err, services.ErrInvalidPassword):
+			apierror.Respond(c, http.StatusBadRequest, apierror.WrongOldPassword)
+		default:
+			apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
+		}
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"message": "注册成功",
+	// 为当前会话签发新令牌
+	token, err := utils.GenerateJWT(user.ID, user.Username, user.Role, user.TokenVersion)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "密码修改成功",
 		"token":   token,
-		"user": models.UserResponse{
-			ID:       user.ID,
-			Username: user.Username,
-			Phone:    user.Phone,
-			Email:    user.Email,
-			RealName: user.RealName,
-			Avatar:   user.Avatar,
-			Role:     user.Role,
-			Status:   user.Status,
-		},
 	})
 }
 
-// 用户登录
-func (uc *UserController) Login(c *gin.Context) {
-	var req models.UserLoginRequest
+// 忘记密码：通过邮件链接或短信验证码重置
+func (uc *UserController) ForgotPassword(c *gin.Context) {
+	var req models.ForgotPasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.RespondValidation(c, err)
 		return
 	}
 
-	// 查找用户
-	var user models.User
-	if err := models.DB.Where("username = ?", req.Username).First(&user).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "用户名或密码错误"})
+	if err := uc.users.RequestPasswordReset(req); err != nil {
+		if errors.Is(err, services.ErrResetContactRequired) {
+			respondServiceError(c, http.StatusBadRequest, err)
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "数据库查询失败"})
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
 		return
 	}
 
-	// 验证密码
-	if !utils.CheckPassword(req.Password, user.Password) {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "用户名或密码错误"})
+	// 无论账户是否存在都返回相同提示，避免泄露注册信息
+	c.JSON(http.StatusOK, gin.H{"message": "如果账户存在，重置方式已发送"})
+}
+
+// 重置密码，令牌一次性有效，重置后所有登录状态失效
+func (uc *UserController) ResetPassword(c *gin.Context) {
+	var req models.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondValidation(c, err)
 		return
 	}
 
-	// 检查用户状态
-	if user.Status != "active" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "账户已被禁用"})
+	if err := uc.users.ResetPassword(req); err != nil {
+		switch {
+		case errors.Is(err, services.ErrResetTokenInvalid),
+			errors.Is(err, services.ErrResetCodeInvalid),
+			errors.Is(err, services.ErrResetTokenRequired),
+			errors.Is(err, services.ErrUserNotFound):
+			respondServiceError(c, http.StatusBadRequest, err)
+		default:
+			apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
+		}
 		return
 	}
 
-	// 生成JWT token
-	token, err := utils.GenerateJWT(user.ID, user.Username, user.Role)
+	c.JSON(http.StatusOK, gin.H{"message": "密码重置成功，请重新登录"})
+}
+
+// 登录成功后的响应：开启或必须开启两步验证的账号先返回临时令牌，验证通过后再签发正式令牌。
+// 失败记录在签发正式令牌时才清除，只知道密码不能重置失败次数
+func respondLogin(c *gin.Context, user models.User, extra gin.H) {
+	if user.TOTPEnabled || config.GetConfig().TwoFactorRequired(user.Role) {
+		purpose := "2fa_verify"
+		message := "请输入两步验证码"
+		if !user.TOTPEnabled {
+			purpose = "2fa_setup"
+			message = "该账号必须开启两步验证"
+		}
+
+		challenge, err := utils.GenerateChallengeJWT(user.ID, user.Username, user.Role, user.TokenVersion, purpose)
+		if err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message":                   message,
+			"two_factor_required":       user.TOTPEnabled,
+			"two_factor_setup_required": !user.TOTPEnabled,
+			"challenge_token":           challenge,
+		})
+		return
+	}
+
+	token, err := utils.GenerateJWT(user.ID, user.Username, user.Role, user.TokenVersion)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成token失败"})
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
 		return
 	}
+	loginSucceeded(c, user.Username)
 
-	c.JSON(http.StatusOK, gin.H{
+	response := gin.H{
 		"message": "登录成功",
 		"token":   token,
-		"user": models.UserResponse{
-			ID:       user.ID,
-			Username: user.Username,
-			Phone:    user.Phone,
-			Email:    user.Email,
-			RealName: user.RealName,
-			Avatar:   user.Avatar,
-			Role:     user.Role,
-			Status:   user.Status,
-		},
-	})
+		"user":    newUserResponse(user),
+	}
+	for k, v := range extra {
+		response[k] = v
+	}
+	c.JSON(http.StatusOK, response)
 }
 
-// 获取用户信息
-func (uc *UserController) GetProfile(c *gin.Context) {
-	userID, _ := c.Get("user_id")
+func newUserResponse(user models.User) models.UserResponse {
+	return models.UserResponse{
+		ID:          user.ID,
+		Username:    user.Username,
+		Phone:       user.Phone,
+		Email:       user.Email,
+		RealName:    user.RealName,
+		Avatar:      user.Avatar,
+		Role:        user.Role,
+		Status:      user.Status,
+		TOTPEnabled: user.TOTPEnabled,
+	}
+}
 
-	var user models.User
-	if err := models.DB.First(&user, userID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
-		return
+// 检查用户名和IP是否处于锁定期，锁定时返回429
+func checkLoginAllowed(c *gin.Context, users services.UserService, username string) bool {
+	wait, err := security.Guard.Check(c.Request.Context(), username, c.ClientIP())
+	if err != nil {
+		// 计数存储不可用时不阻止登录
+		slog.ErrorContext(c.Request.Context(), "检查登录锁定状态失败", "error", err)
+		return true
+	}
+	if wait <= 0 {
+		return true
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"user": models.UserResponse{
-			ID:       user.ID,
-			Username: user.Username,
-			Phone:    user.Phone,
-			Email:    user.Email,
-			RealName: user.RealName,
-			Avatar:   user.Avatar,
-			Role:     user.Role,
-			Status:   user.Status,
-		},
+	recordLoginAttempt(c, users, &models.LoginAttempt{
+		Username:  username,
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		Reason:    "locked",
 	})
+
+	seconds := int(wait.Seconds() + 0.999)
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+		"error":       apierror.New(c, apierror.LoginLocked, seconds),
+		"retry_after": seconds,
+	})
+	return false
 }
 
-// 更新用户信息
-func (uc *UserController) UpdateProfile(c *gin.Context) {
-	userID, _ := c.Get("user_id")
+// 记录登录失败
+func loginFailed(c *gin.Context, users services.UserService, username string, userID *uint, reason string) {
+	if _, _, err := security.Guard.Fail(c.Request.Context(), username, c.ClientIP()); err != nil {
+		slog.ErrorContext(c.Request.Context(), "记录登录失败次数失败", "error", err)
+	}
+
+	recordLoginAttempt(c, users, &models.LoginAttempt{
+		Username:  username,
+		UserID:    userID,
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		Reason:    reason,
+	})
+}
 
-	var user models.User
-	if err := models.DB.First(&user, userID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+func recordLoginAttempt(c *gin.Context, users services.UserService, attempt *models.LoginAttempt) {
+	if err := users.RecordLoginAttempt(attempt); err != nil {
+		slog.ErrorContext(c.Request.Context(), "写入登录失败记录失败", "error", err)
+	}
+}
+
+// 签发正式令牌后清除该用户名的失败记录
+func loginSucceeded(c *gin.Context, username string) {
+	if err := security.Guard.Succeed(c.Request.Context(), username); err != nil {
+		slog.ErrorContext(c.Request.Context(), "清除登录失败记录失败", "error", err)
+	}
+}
+
+// 解除账号登录锁定（管理员），可通过ip参数同时解除IP锁定
+func (uc *UserController) UnlockUser(c *gin.Context) {
+	user, err := uc.users.Get(paramID(c, "id"))
+	if err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			respondServiceError(c, http.StatusNotFound, err)
+			return
+		}
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
 		return
 	}
 
-	var updates map[string]interface{}
-	if err := c.ShouldBindJSON(&updates); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := security.Guard.Unlock(c.Request.Context(), user.Username, c.Query("ip")); err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
 		return
 	}
 
-	// 过滤可更新的字段
-	allowedFields := []string{"phone", "email", "real_name", "avatar"}
-	filteredUpdates := make(map[string]interface{})
-	for _, field := range allowedFields {
-		if value, exists := updates[field]; exists {
-			filteredUpdates[field] = value
-		}
+	recordAudit(c, "user.unlock", "user", user.ID, nil, gin.H{"username": user.Username, "ip": c.Query("ip")})
+
+	c.JSON(http.StatusOK, gin.H{"message": "已解除锁定"})
+}
+
+// 获取登录失败记录（管理员）
+func (uc *UserController) GetLoginAttempts(c *gin.Context) {
+	params, ok := listParams(c, repositories.LoginAttemptListSpec)
+	if !ok {
+		return
 	}
 
-	if err := models.DB.Model(&user).Updates(filteredUpdates).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新失败"})
+	filter := repositories.LoginAttemptFilter{
+		Username: c.Query("username"),
+		IP:       c.Query("ip"),
+	}
+	attempts, total, err := uc.users.ListLoginAttempts(filter, params)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "更新成功",
-		"user": models.UserResponse{
-			ID:       user.ID,
-			Username: user.Username,
-			Phone:    user.Phone,
-			Email:    user.Email,
-			RealName: user.RealName,
-			Avatar:   user.Avatar,
-			Role:     user.Role,
-			Status:   user.Status,
-		},
+		"attempts":   attempts,
+		"pagination": pagination(params, total, attempts),
 	})
 }
@@ -0,0 +1,33 @@
+// Package docs 提供OpenAPI接口文档，文档与路由和模型的一致性由测试保证。
+package docs
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed openapi.yaml
+var spec []byte
+
+//go:embed index.html
+var index []byte
+
+// OpenAPI文档原文
+func Spec() []byte {
+	return spec
+}
+
+// 注册文档路由：/api/docs 为交互式文档页面，/api/docs/openapi.yaml 为文档原文
+func Register(r *gin.Engine) {
+	r.GET("/api/docs", func(c *gin.Context) {
+		c.Redirect(http.StatusMovedPermanently, "/api/docs/")
+	})
+	r.GET("/api/docs/", func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", index)
+	})
+	r.GET("/api/docs/openapi.yaml", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/yaml; charset=utf-8", spec)
+	})
+}
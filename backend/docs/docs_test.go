@@ -0,0 +1,417 @@
+package docs_test
+
+import (
+	"e-device-recycle-backend/apierror"
+	"e-device-recycle-backend/docs"
+	"e-device-recycle-backend/models"
+	"e-device-recycle-backend/repositories"
+	"e-device-recycle-backend/routes"
+	"e-device-recycle-backend/search"
+	"e-device-recycle-backend/services"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+)
+
+type object = map[string]interface{}
+
+func loadSpec(t *testing.T) object {
+	t.Helper()
+	var spec object
+	if err := yaml.Unmarshal(docs.Spec(), &spec); err != nil {
+		t.Fatalf("解析OpenAPI文档失败: %v", err)
+	}
+	return spec
+}
+
+// 按路径取值，如 lookup(spec, "components", "schemas", "Error")
+func lookup(v interface{}, keys ...string) (interface{}, bool) {
+	for _, key := range keys {
+		m, ok := v.(object)
+		if !ok {
+			return nil, false
+		}
+		if v, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// 解析 "#/components/..." 形式的引用
+func resolveRef(spec object, ref string) (object, bool) {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, false
+	}
+	v, ok := lookup(spec, strings.Split(strings.TrimPrefix(ref, "#/"), "/")...)
+	if !ok {
+		return nil, false
+	}
+	m, ok := v.(object)
+	return m, ok
+}
+
+func newRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	routes.SetupRoutes(r, services.New(repositories.New(nil), search.NewMemoryIndex()))
+	return r
+}
+
+func TestSpecReferences(t *testing.T) {
+	spec := loadSpec(t)
+	if version, _ := spec["openapi"].(string); !strings.HasPrefix(version, "3.") {
+		t.Fatalf("openapi版本 %q", version)
+	}
+
+	operationIDs := make(map[string]bool)
+	var walk func(path string, v interface{})
+	walk = func(path string, v interface{}) {
+		switch v := v.(type) {
+		case object:
+			if ref, ok := v["$ref"].(string); ok {
+				if _, ok := resolveRef(spec, ref); !ok {
+					t.Errorf("%s: 引用 %s 不存在", path, ref)
+				}
+			}
+			if id, ok := v["operationId"].(string); ok {
+				if operationIDs[id] {
+					t.Errorf("%s: operationId %s 重复", path, id)
+				}
+				operationIDs[id] = true
+			}
+			for key, child := range v {
+				walk(path+"/"+key, child)
+			}
+		case []interface{}:
+			for i, child := range v {
+				walk(fmt.Sprintf("%s/%d", path, i), child)
+			}
+		}
+	}
+	walk("#", spec)
+}
+
+// 文档中的接口与路由注册的接口一一对应
+func TestSpecCoversRoutes(t *testing.T) {
+	spec := loadSpec(t)
+	paths, _ := spec["paths"].(object)
+
+	documented := make(map[string]bool)
+	for path, item := range paths {
+		for method := range item.(object) {
+			switch method {
+			case "get", "post", "put", "patch", "delete":
+				documented[strings.ToUpper(method)+" "+path] = true
+			}
+		}
+	}
+
+	registered := make(map[string]bool)
+	for _, route := range newRouter().Routes() {
+		if !strings.HasPrefix(route.Path, "/api/v1/") {
+			continue
+		}
+		segments := strings.Split(strings.TrimPrefix(route.Path, "/api/v1"), "/")
+		for i, segment := range segments {
+			if strings.HasPrefix(segment, ":") {
+				segments[i] = "{" + segment[1:] + "}"
+			}
+		}
+		registered[route.Method+" "+strings.Join(segments, "/")] = true
+	}
+
+	for _, key := range sortedKeys(registered) {
+		if !documented[key] {
+			t.Errorf("接口 %s 未写入文档", key)
+		}
+	}
+	for _, key := range sortedKeys(documented) {
+		if !registered[key] {
+			t.Errorf("文档中的接口 %s 不存在", key)
+		}
+	}
+}
+
+// 文档中的数据结构与模型字段、类型和校验规则一致
+func TestSchemasMatchModels(t *testing.T) {
+	spec := loadSpec(t)
+
+	types := []interface{}{
+		apierror.Error{},
+		apierror.FieldError{},
+		models.UserResponse{},
+		models.UserRegisterRequest{},
+		models.UserLoginRequest{},
+		models.UserIdentityResponse{},
+		models.OAuthLoginRequest{},
+		models.ChangePasswordRequest{},
+		models.ForgotPasswordRequest{},
+		models.ResetPasswordRequest{},
+		models.TwoFactorChallengeRequest{},
+		models.TwoFactorVerifyRequest{},
+		models.TwoFactorCodeRequest{},
+		models.TwoFactorDisableRequest{},
+		models.TwoFactorSetupResponse{},
+		models.DeviceCreateRequest{},
+		models.DeviceResponse{},
+		models.DeviceSpecs{},
+		models.DeviceSearchHit{},
+		models.DeviceSuggestion{},
+		models.Brand{},
+		models.BrandRequest{},
+		models.DeviceSeries{},
+		models.DeviceSeriesRequest{},
+		models.DeviceModel{},
+		models.DeviceModelRequest{},
+		models.Category{},
+		models.SpecField{},
+		models.CategoryPricing{},
+		models.CategoryRequest{},
+		models.RecycleOrderCreateRequest{},
+		models.RecycleOrderUpdateRequest{},
+		models.RecycleOrderCancelRequest{},
+		models.RecycleOrderResponse{},
+		models.EvaluationCreateRequest{},
+		models.EvaluationUpdateRequest{},
+		models.EvaluationResponse{},
+		models.AuditLog{},
+		models.LoginAttempt{},
+	}
+
+	for _, v := range types {
+		typ := reflect.TypeOf(v)
+		t.Run(typ.Name(), func(t *testing.T) {
+			schema, ok := resolveRef(spec, "#/components/schemas/"+typ.Name())
+			if !ok {
+				t.Fatalf("文档缺少 %s", typ.Name())
+			}
+			checkSchema(t, spec, schema, typ)
+		})
+	}
+}
+
+// 文档中的错误码与错误码目录一致
+func TestErrorCodes(t *testing.T) {
+	spec := loadSpec(t)
+
+	documented := make(map[string]bool)
+	enum, _ := lookup(spec, "components", "schemas", "ErrorCode", "enum")
+	list, _ := enum.([]interface{})
+	for _, code := range list {
+		documented[code.(string)] = true
+	}
+
+	catalog := make(map[string]bool)
+	for _, code := range apierror.Codes() {
+		catalog[string(code)] = true
+	}
+
+	if !reflect.DeepEqual(documented, catalog) {
+		t.Errorf("错误码 %v, 文档为 %v", sortedKeys(catalog), sortedKeys(documented))
+	}
+}
+
+func checkSchema(t *testing.T, spec, schema object, typ reflect.Type) {
+	properties, _ := schema["properties"].(object)
+	fields := jsonFields(typ)
+
+	required := make(map[string]bool)
+	for name, field := range fields {
+		property, ok := properties[name].(object)
+		if !ok {
+			t.Errorf("缺少字段 %s", name)
+			continue
+		}
+		checkType(t, spec, name, property, field.Type)
+
+		// dive之后的规则校验数组元素
+		binding, items, _ := strings.Cut(field.Tag.Get("binding"), ",dive")
+		rules := strings.Split(binding, ",")
+		for _, rule := range rules {
+			if rule == "required" {
+				required[name] = true
+			}
+		}
+		checkBinding(t, spec, name, property, field.Type, rules)
+		if items != "" {
+			itemProperty, _ := property["items"].(object)
+			checkBinding(t, spec, name+"[]", itemProperty, field.Type.Elem(), strings.Split(items, ","))
+		}
+	}
+	for name := range properties {
+		if _, ok := fields[name]; !ok {
+			t.Errorf("文档中的字段 %s 不存在", name)
+		}
+	}
+
+	documented := make(map[string]bool)
+	if list, ok := schema["required"].([]interface{}); ok {
+		for _, name := range list {
+			documented[name.(string)] = true
+		}
+	}
+	if !reflect.DeepEqual(required, documented) && (len(required) > 0 || len(documented) > 0) {
+		t.Errorf("必填字段 %v, 文档为 %v", sortedKeys(required), sortedKeys(documented))
+	}
+}
+
+// 结构体的JSON字段，展开嵌入的结构体
+func jsonFields(typ reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			for k, v := range jsonFields(field.Type) {
+				fields[k] = v
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field
+	}
+	return fields
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func checkType(t *testing.T, spec object, name string, property object, typ reflect.Type) {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+		// 指向结构体的字段省略时不返回，用引用表示；指向基本类型的字段可能为null
+		if typ.Kind() != reflect.Struct || typ == timeType {
+			if property["nullable"] != true {
+				t.Errorf("字段 %s 可能为null，文档应标记nullable", name)
+			}
+		}
+	}
+
+	if typ.Kind() == reflect.Struct && typ != timeType {
+		if ref, _ := property["$ref"].(string); ref != "#/components/schemas/"+typ.Name() {
+			t.Errorf("字段 %s 应引用 %s, 文档为 %q", name, typ.Name(), ref)
+		}
+		return
+	}
+
+	// 枚举等可复用的类型通过引用定义
+	if ref, ok := property["$ref"].(string); ok {
+		property, _ = resolveRef(spec, ref)
+	}
+
+	var want, format string
+	switch typ.Kind() {
+	case reflect.String:
+		want = "string"
+	case reflect.Bool:
+		want = "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		want = "integer"
+	case reflect.Float32, reflect.Float64:
+		want = "number"
+	case reflect.Slice:
+		want = "array"
+	case reflect.Map:
+		want = "object"
+	case reflect.Struct:
+		want, format = "string", "date-time"
+	default:
+		t.Errorf("字段 %s 的类型 %s 未支持", name, typ)
+		return
+	}
+
+	if property["type"] != want {
+		t.Errorf("字段 %s 类型应为 %s, 文档为 %v", name, want, property["type"])
+	}
+	if format != "" && property["format"] != format {
+		t.Errorf("字段 %s 格式应为 %s, 文档为 %v", name, format, property["format"])
+	}
+}
+
+func checkBinding(t *testing.T, spec object, name string, property object, typ reflect.Type, rules []string) {
+	if ref, ok := property["$ref"].(string); ok {
+		property, _ = resolveRef(spec, ref)
+	}
+	isString := typ.Kind() == reflect.String
+	isArray := typ.Kind() == reflect.Slice
+
+	expect := func(key string, want interface{}) {
+		if got := fmt.Sprint(property[key]); got != fmt.Sprint(want) {
+			t.Errorf("字段 %s 的 %s 应为 %v, 文档为 %s", name, key, want, got)
+		}
+	}
+
+	for _, rule := range rules {
+		key, param, _ := strings.Cut(rule, "=")
+		switch key {
+		case "oneof":
+			expect("enum", strings.Fields(param))
+		case "email":
+			expect("format", "email")
+		case "url":
+			expect("format", "uri")
+		case "numeric":
+			if _, ok := property["pattern"]; !ok {
+				t.Errorf("字段 %s 只能为数字，文档应包含pattern", name)
+			}
+		case "len":
+			expect("minLength", param)
+			expect("maxLength", param)
+		case "min":
+			if isString {
+				expect("minLength", param)
+			} else if isArray {
+				expect("minItems", param)
+			} else {
+				expect("minimum", param)
+			}
+		case "max":
+			if isString {
+				expect("maxLength", param)
+			} else if isArray {
+				expect("maxItems", param)
+			} else {
+				expect("maximum", param)
+			}
+		}
+	}
+}
+
+func TestServeDocs(t *testing.T) {
+	r := newRouter()
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/docs/", nil))
+	if recorder.Code != http.StatusOK || !strings.Contains(recorder.Body.String(), "openapi.yaml") {
+		t.Fatalf("文档页面: 状态码 %d", recorder.Code)
+	}
+
+	recorder = httptest.NewRecorder()
+	r.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/docs/openapi.yaml", nil))
+	if recorder.Code != http.StatusOK || recorder.Body.String() != string(docs.Spec()) {
+		t.Fatalf("文档原文: 状态码 %d", recorder.Code)
+	}
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,38 @@
+package health
+
+import (
+	"context"
+	"e-device-recycle-backend/migrations"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// 数据库连接检查
+func Database(db *gorm.DB) Check {
+	return Check{Name: "database", Func: func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	}}
+}
+
+// 数据库迁移检查：有未执行的迁移时不接收请求
+func Migrations(db *gorm.DB) Check {
+	return Check{Name: "migrations", Func: func(ctx context.Context) error {
+		migrator, err := migrations.New(db.WithContext(ctx))
+		if err != nil {
+			return err
+		}
+		pending, err := migrator.Pending()
+		if err != nil {
+			return err
+		}
+		if len(pending) > 0 {
+			return fmt.Errorf("有%d个未执行的迁移", len(pending))
+		}
+		return nil
+	}}
+}
@@ -0,0 +1,64 @@
+// Package health 提供存活检查（/healthz）和就绪检查（/readyz）接口。
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 每项就绪检查的超时时间
+const checkTimeout = 2 * time.Second
+
+// 就绪检查项，Func返回nil表示正常
+type Check struct {
+	Name string
+	Func func(ctx context.Context) error
+}
+
+var shuttingDown atomic.Bool
+
+// 标记服务正在停止，之后就绪检查返回503，负载均衡不再转发新请求
+func SetShuttingDown() {
+	shuttingDown.Store(true)
+}
+
+// 注册健康检查路由
+func Register(r gin.IRoutes, checks ...Check) {
+	// 存活检查：进程能处理请求即返回200，不检查依赖，避免依赖故障时进程被反复重启
+	r.GET("/healthz", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+
+	// 就绪检查：依赖全部可用且不在停止中才返回200
+	r.GET("/readyz", func(c *gin.Context) {
+		if shuttingDown.Load() {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "shutting_down"})
+			return
+		}
+
+		status := http.StatusOK
+		results := make(gin.H, len(checks))
+		for _, check := range checks {
+			ctx, cancel := context.WithTimeout(c.Request.Context(), checkTimeout)
+			err := check.Func(ctx)
+			cancel()
+
+			if err != nil {
+				status = http.StatusServiceUnavailable
+				results[check.Name] = err.Error()
+			} else {
+				results[check.Name] = "ok"
+			}
+		}
+
+		body := gin.H{"status": "ok", "checks": results}
+		if status != http.StatusOK {
+			body["status"] = "unavailable"
+		}
+		c.JSON(status, body)
+	})
+}
@@ -0,0 +1,66 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func serve(r *gin.Engine, path string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec
+}
+
+func TestReadyz(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Cleanup(func() { shuttingDown.Store(false) })
+
+	var failing error
+	r := gin.New()
+	Register(r,
+		Check{Name: "ok", Func: func(ctx context.Context) error { return nil }},
+		Check{Name: "flaky", Func: func(ctx context.Context) error { return failing }},
+		// 超过超时时间的检查视为失败，不会阻塞就绪检查
+		Check{Name: "slow", Func: func(ctx context.Context) error {
+			if failing == nil {
+				return nil
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Minute):
+				return nil
+			}
+		}},
+	)
+
+	if rec := serve(r, "/readyz"); rec.Code != http.StatusOK {
+		t.Fatalf("状态码 %d: %s", rec.Code, rec.Body)
+	}
+
+	failing = errors.New("down")
+	start := time.Now()
+	rec := serve(r, "/readyz")
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("检查失败时状态码 %d", rec.Code)
+	}
+	if elapsed := time.Since(start); elapsed > checkTimeout+time.Second {
+		t.Fatalf("就绪检查耗时 %v", elapsed)
+	}
+
+	// 停止中时就绪检查失败，存活检查仍然正常
+	failing = nil
+	SetShuttingDown()
+	if rec := serve(r, "/readyz"); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("停止中状态码 %d", rec.Code)
+	}
+	if rec := serve(r, "/healthz"); rec.Code != http.StatusOK {
+		t.Fatalf("存活检查状态码 %d", rec.Code)
+	}
+}
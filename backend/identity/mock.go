@@ -0,0 +1,79 @@
+package identity
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// 模拟微信接口，用于本地开发和测试，无需访问外网
+//
+// 授权码规则：
+//   - 任意非空code映射到固定的openid，同一code重复使用返回40163
+//   - "invalid" 开头的code返回40029
+//   - 形如 "<name>@<union>" 的code，unionid由<union>部分决定，便于测试账号关联
+type MockWeChatServer struct {
+	mu   sync.Mutex
+	used map[string]bool
+}
+
+func NewMockWeChatServer() *MockWeChatServer {
+	return &MockWeChatServer{used: make(map[string]bool)}
+}
+
+func (m *MockWeChatServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.URL.Path != "/sns/jscode2session" {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]interface{}{"errcode": 404, "errmsg": "not found"})
+		return
+	}
+
+	query := r.URL.Query()
+	code := query.Get("js_code")
+	if query.Get("appid") == "" || query.Get("secret") == "" {
+		json.NewEncoder(w).Encode(map[string]interface{}{"errcode": 40013, "errmsg": "invalid appid"})
+		return
+	}
+	if code == "" || strings.HasPrefix(code, "invalid") {
+		json.NewEncoder(w).Encode(map[string]interface{}{"errcode": 40029, "errmsg": "invalid code"})
+		return
+	}
+
+	m.mu.Lock()
+	used := m.used[code]
+	m.used[code] = true
+	m.mu.Unlock()
+	if used {
+		json.NewEncoder(w).Encode(map[string]interface{}{"errcode": 40163, "errmsg": "code been used"})
+		return
+	}
+
+	// 去掉一次性后缀，使 "alice#1"、"alice#2" 对应同一用户
+	name := code
+	if i := strings.Index(name, "#"); i >= 0 {
+		name = name[:i]
+	}
+	union := ""
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, union = name[:i], name[i+1:]
+	}
+
+	resp := map[string]interface{}{
+		"openid":      "mock_" + shortHash(query.Get("appid")+":"+name),
+		"session_key": shortHash("session:" + code),
+	}
+	if union != "" {
+		resp["unionid"] = "mock_union_" + shortHash(union)
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func shortHash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])[:24]
+}
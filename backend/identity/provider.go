@@ -0,0 +1,36 @@
+package identity
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrInvalidCode = errors.New("无效的授权码")
+
+// 第三方平台返回的身份信息
+type Identity struct {
+	Provider   string
+	OpenID     string // 用户在当前应用下的唯一标识
+	UnionID    string // 同一开放平台下多个应用共享的标识，可能为空
+	SessionKey string
+}
+
+// 第三方身份提供方
+type Provider interface {
+	Name() string
+	// 使用客户端获取的授权码换取身份信息
+	Exchange(ctx context.Context, code string) (*Identity, error)
+}
+
+var providers = map[string]Provider{}
+
+// 注册身份提供方
+func Register(p Provider) {
+	providers[p.Name()] = p
+}
+
+// 获取身份提供方
+func Get(name string) (Provider, bool) {
+	p, ok := providers[name]
+	return p, ok
+}
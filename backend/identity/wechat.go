@@ -0,0 +1,88 @@
+package identity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const DefaultWeChatAPIBase = "https://api.weixin.qq.com"
+
+// 微信小程序登录（code2session）
+type WeChatProvider struct {
+	AppID     string
+	AppSecret string
+	APIBase   string // 接口地址，测试时可指向本地模拟服务
+	Client    *http.Client
+}
+
+func NewWeChatProvider(appID, appSecret, apiBase string) *WeChatProvider {
+	if apiBase == "" {
+		apiBase = DefaultWeChatAPIBase
+	}
+	return &WeChatProvider{
+		AppID:     appID,
+		AppSecret: appSecret,
+		APIBase:   strings.TrimRight(apiBase, "/"),
+		Client:    &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func (p *WeChatProvider) Name() string {
+	return "wechat"
+}
+
+type code2SessionResponse struct {
+	OpenID     string `json:"openid"`
+	SessionKey string `json:"session_key"`
+	UnionID    string `json:"unionid"`
+	ErrCode    int    `json:"errcode"`
+	ErrMsg     string `json:"errmsg"`
+}
+
+func (p *WeChatProvider) Exchange(ctx context.Context, code string) (*Identity, error) {
+	params := url.Values{}
+	params.Set("appid", p.AppID)
+	params.Set("secret", p.AppSecret)
+	params.Set("js_code", code)
+	params.Set("grant_type", "authorization_code")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.APIBase+"/sns/jscode2session?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求微信接口失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result code2SessionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("解析微信接口响应失败: %w", err)
+	}
+
+	switch result.ErrCode {
+	case 0:
+	case 40029, 40163: // code无效、code已被使用
+		return nil, ErrInvalidCode
+	default:
+		return nil, fmt.Errorf("微信接口错误 %d: %s", result.ErrCode, result.ErrMsg)
+	}
+
+	if result.OpenID == "" {
+		return nil, ErrInvalidCode
+	}
+
+	return &Identity{
+		Provider:   p.Name(),
+		OpenID:     result.OpenID,
+		UnionID:    result.UnionID,
+		SessionKey: result.SessionKey,
+	}, nil
+}
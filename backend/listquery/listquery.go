@@ -0,0 +1,275 @@
+// Package listquery 解析列表接口共用的查询参数，并将其应用到GORM查询：
+//
+//	sort=-base_price          按白名单中的字段排序，"-"表示降序，值相同时按ID排序
+//	page=2&page_size=20       页码分页，page_size不超过MaxPageSize
+//	cursor=<next_cursor>      游标分页，从上一页的最后一条记录之后开始，翻页期间数据变化不会重复或遗漏
+//
+// 数值和时间范围过滤见 ParseRange、ParseTimeRange。
+package listquery
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 每页最多返回的记录数
+const MaxPageSize = 100
+
+// 排序字段的值类型，决定游标中的值如何解析
+type Kind int
+
+const (
+	Number Kind = iota
+	Time
+	String
+)
+
+// 允许排序的字段
+type Field struct {
+	Name   string // 参数中的名称，与响应中的JSON字段一致
+	Column string // 数据库列
+	Kind   Kind
+	// 模型中对应的结构体字段，用于从最后一条记录生成游标
+	StructField string
+}
+
+// 列表接口支持的排序字段和默认值
+type Spec struct {
+	Fields          []Field
+	DefaultSort     string // 如 "-created_at"
+	DefaultPageSize int
+}
+
+type Sort struct {
+	Field Field
+	Desc  bool
+}
+
+func (s Sort) String() string {
+	if s.Desc {
+		return "-" + s.Field.Name
+	}
+	return s.Field.Name
+}
+
+// 解析后的排序和分页参数
+type Params struct {
+	Sort     Sort
+	Page     int
+	PageSize int
+	Cursor   *Cursor // 不为nil时使用游标分页，忽略Page
+}
+
+// 游标：上一页最后一条记录的排序字段值和ID
+type Cursor struct {
+	Value interface{}
+	ID    uint
+}
+
+// 游标编码后的内容，包含排序方式，排序改变后旧游标失效
+type cursorData struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    uint   `json:"id"`
+}
+
+// 查询参数错误，Rule与binding标签的规则名一致（oneof、min、max），
+// 格式错误为invalid，时间格式错误为time
+type Error struct {
+	Param string
+	Rule  string
+	Value string // 规则的参数，如oneof的可选值
+}
+
+const (
+	RuleInvalid = "invalid"
+	RuleTime    = "time"
+)
+
+func (e *Error) Error() string {
+	if e.Value != "" {
+		return fmt.Sprintf("参数%s不满足%s=%s", e.Param, e.Rule, e.Value)
+	}
+	return fmt.Sprintf("参数%s不满足%s", e.Param, e.Rule)
+}
+
+// 解析排序和分页参数
+func Parse(values url.Values, spec Spec) (Params, error) {
+	params := Params{Page: 1, PageSize: spec.DefaultPageSize}
+	if params.PageSize == 0 {
+		params.PageSize = 10
+	}
+
+	sort := values.Get("sort")
+	if sort == "" {
+		sort = spec.DefaultSort
+	}
+	var err error
+	if params.Sort, err = spec.parseSort(sort); err != nil {
+		return Params{}, err
+	}
+
+	if value := values.Get("page"); value != "" {
+		page, err := strconv.Atoi(value)
+		if err != nil {
+			return Params{}, &Error{Param: "page", Rule: RuleInvalid}
+		}
+		if page < 1 {
+			return Params{}, &Error{Param: "page", Rule: "min", Value: "1"}
+		}
+		params.Page = page
+	}
+	if value := values.Get("page_size"); value != "" {
+		pageSize, err := strconv.Atoi(value)
+		if err != nil {
+			return Params{}, &Error{Param: "page_size", Rule: RuleInvalid}
+		}
+		if pageSize < 1 {
+			return Params{}, &Error{Param: "page_size", Rule: "min", Value: "1"}
+		}
+		if pageSize > MaxPageSize {
+			return Params{}, &Error{Param: "page_size", Rule: "max", Value: strconv.Itoa(MaxPageSize)}
+		}
+		params.PageSize = pageSize
+	}
+
+	if value := values.Get("cursor"); value != "" {
+		cursor, err := decodeCursor(value, params.Sort)
+		if err != nil {
+			return Params{}, err
+		}
+		params.Cursor = cursor
+	}
+	return params, nil
+}
+
+func (spec Spec) parseSort(value string) (Sort, error) {
+	name := strings.TrimPrefix(value, "-")
+	for _, field := range spec.Fields {
+		if field.Name == name {
+			return Sort{Field: field, Desc: strings.HasPrefix(value, "-")}, nil
+		}
+	}
+	names := make([]string, 0, len(spec.Fields)*2)
+	for _, field := range spec.Fields {
+		names = append(names, field.Name, "-"+field.Name)
+	}
+	return Sort{}, &Error{Param: "sort", Rule: "oneof", Value: strings.Join(names, " ")}
+}
+
+func decodeCursor(value string, sort Sort) (*Cursor, error) {
+	invalid := &Error{Param: "cursor", Rule: RuleInvalid}
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, invalid
+	}
+	var decoded cursorData
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.Sort != sort.String() {
+		return nil, invalid
+	}
+
+	cursor := &Cursor{ID: decoded.ID}
+	switch sort.Field.Kind {
+	case Number:
+		cursor.Value, err = strconv.ParseFloat(decoded.Value, 64)
+	case Time:
+		cursor.Value, err = time.Parse(time.RFC3339Nano, decoded.Value)
+	default:
+		cursor.Value = decoded.Value
+	}
+	if err != nil {
+		return nil, invalid
+	}
+	return cursor, nil
+}
+
+// 添加排序、游标条件和分页，应在统计总数之后调用
+func (p Params) Apply(query *gorm.DB) *gorm.DB {
+	direction, op := "ASC", ">"
+	if p.Sort.Desc {
+		direction, op = "DESC", "<"
+	}
+	column := p.Sort.Field.Column
+
+	if p.Cursor != nil {
+		if column == "id" {
+			query = query.Where(fmt.Sprintf("id %s ?", op), p.Cursor.ID)
+		} else {
+			query = query.Where(fmt.Sprintf("(%s %s ? OR (%s = ? AND id %s ?))", column, op, column, op),
+				p.Cursor.Value, p.Cursor.Value, p.Cursor.ID)
+		}
+	} else {
+		query = query.Offset(p.Offset())
+	}
+
+	order := column + " " + direction
+	if column != "id" {
+		order += ", id " + direction
+	}
+	return query.Order(order).Limit(p.PageSize)
+}
+
+// 页码分页的偏移量，游标分页时为0
+func (p Params) Offset() int {
+	if p.Cursor != nil {
+		return 0
+	}
+	return (p.Page - 1) * p.PageSize
+}
+
+// 下一页的游标，rows为本页的记录（结构体切片）；本页不满时没有下一页，返回空字符串
+func (p Params) NextCursor(rows interface{}) string {
+	v := reflect.ValueOf(rows)
+	if v.Kind() != reflect.Slice || v.Len() == 0 || v.Len() < p.PageSize {
+		return ""
+	}
+	last := reflect.Indirect(v.Index(v.Len() - 1))
+
+	data := cursorData{
+		Sort: p.Sort.String(),
+		ID:   uint(last.FieldByName("ID").Uint()),
+	}
+	if p.Sort.Field.Column != "id" {
+		data.Value = formatValue(last.FieldByName(p.Sort.Field.StructField))
+	}
+	encoded, _ := json.Marshal(data)
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+func formatValue(v reflect.Value) string {
+	if t, ok := v.Interface().(time.Time); ok {
+		return t.Format(time.RFC3339Nano)
+	}
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	default:
+		return v.String()
+	}
+}
+
+// 用于缓存键等需要区分不同查询的场景
+func (p Params) Values() url.Values {
+	values := url.Values{}
+	values.Set("sort", p.Sort.String())
+	values.Set("page_size", strconv.Itoa(p.PageSize))
+	if p.Cursor != nil {
+		values.Set("cursor", fmt.Sprintf("%v|%d", p.Cursor.Value, p.Cursor.ID))
+	} else {
+		values.Set("page", strconv.Itoa(p.Page))
+	}
+	return values
+}
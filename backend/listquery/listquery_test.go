@@ -0,0 +1,141 @@
+package listquery
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+)
+
+type row struct {
+	ID        uint
+	Price     float64
+	CreatedAt time.Time
+}
+
+var spec = Spec{
+	Fields: []Field{
+		{Name: "price", Column: "price", Kind: Number, StructField: "Price"},
+		{Name: "created_at", Column: "created_at", Kind: Time, StructField: "CreatedAt"},
+	},
+	DefaultSort:     "-created_at",
+	DefaultPageSize: 20,
+}
+
+func parse(t *testing.T, query string) (Params, error) {
+	t.Helper()
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Parse(values, spec)
+}
+
+func TestParseDefaults(t *testing.T) {
+	params, err := parse(t, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params.Sort.String() != "-created_at" || params.Page != 1 || params.PageSize != 20 || params.Cursor != nil {
+		t.Errorf("默认参数 = %+v", params)
+	}
+
+	params, err = parse(t, "sort=price&page=3&page_size=50")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params.Sort.Field.Column != "price" || params.Sort.Desc || params.Offset() != 100 {
+		t.Errorf("参数 = %+v, offset = %d", params, params.Offset())
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		param string
+		rule  string
+	}{
+		{"sort=password", "sort", "oneof"},
+		{"page=0", "page", "min"},
+		{"page=abc", "page", RuleInvalid},
+		{"page_size=101", "page_size", "max"},
+		{"cursor=not-a-cursor", "cursor", RuleInvalid},
+	}
+	for _, tt := range tests {
+		_, err := parse(t, tt.query)
+		var queryErr *Error
+		if !errors.As(err, &queryErr) || queryErr.Param != tt.param || queryErr.Rule != tt.rule {
+			t.Errorf("%s: err = %v, want %s %s", tt.query, err, tt.param, tt.rule)
+		}
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 30, 0, 123456789, time.FixedZone("CST", 8*3600))
+	rows := []row{{ID: 1, Price: 10}, {ID: 7, Price: 99.5, CreatedAt: created}}
+
+	for _, sort := range []string{"-price", "created_at"} {
+		params, _ := parse(t, "page_size=2&sort="+sort)
+		next := params.NextCursor(rows)
+		if next == "" {
+			t.Fatalf("%s: 本页已满，应返回下一页的游标", sort)
+		}
+
+		params, err := parse(t, "page_size=2&sort="+sort+"&cursor="+next)
+		if err != nil {
+			t.Fatalf("%s: %v", sort, err)
+		}
+		if params.Cursor.ID != 7 {
+			t.Errorf("%s: cursor = %+v", sort, params.Cursor)
+		}
+		switch value := params.Cursor.Value.(type) {
+		case float64:
+			if value != 99.5 {
+				t.Errorf("cursor value = %v", value)
+			}
+		case time.Time:
+			if !value.Equal(created) {
+				t.Errorf("cursor value = %v, want %v", value, created)
+			}
+		}
+
+		// 排序改变后游标失效
+		if _, err := parse(t, "sort=price&cursor="+next); err == nil {
+			t.Errorf("%s: 排序改变后游标应失效", sort)
+		}
+	}
+
+	params, _ := parse(t, "page_size=3")
+	if next := params.NextCursor(rows); next != "" {
+		t.Errorf("本页不满时不应有下一页, next = %q", next)
+	}
+}
+
+func TestParseRanges(t *testing.T) {
+	values := url.Values{"min_price": {"100"}, "max_price": {"2000.5"}}
+	r, err := ParseRange(values, "min_price", "max_price")
+	if err != nil || *r.Min != 100 || *r.Max != 2000.5 {
+		t.Errorf("ParseRange = %+v, %v", r, err)
+	}
+	if r, err := ParseRange(url.Values{}, "min_price", "max_price"); err != nil || r.Min != nil || r.Max != nil {
+		t.Errorf("未指定范围 = %+v, %v", r, err)
+	}
+	if _, err := ParseRange(url.Values{"min_price": {"abc"}}, "min_price", "max_price"); err == nil {
+		t.Error("非数字应报错")
+	}
+	if _, err := ParseRange(url.Values{"min_price": {"10"}, "max_price": {"5"}}, "min_price", "max_price"); err == nil {
+		t.Error("最小值大于最大值应报错")
+	}
+
+	tr, err := ParseTimeRange(url.Values{"start": {"2024-05-01"}, "end": {"2024-05-31"}}, "start", "end")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tr.Start.Equal(time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local)) || !tr.End.Equal(time.Date(2024, 6, 1, 0, 0, 0, 0, time.Local)) {
+		t.Errorf("只有日期的结束时间应包含当天: %+v", tr)
+	}
+	var queryErr *Error
+	if _, err := ParseTimeRange(url.Values{"end": {"yesterday"}}, "start", "end"); !errors.As(err, &queryErr) || queryErr.Rule != RuleTime {
+		t.Errorf("时间格式错误: err = %v", err)
+	}
+}
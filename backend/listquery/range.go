@@ -0,0 +1,100 @@
+package listquery
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 数值范围（含两端），nil表示不限
+type Range struct {
+	Min *float64
+	Max *float64
+}
+
+// 解析如 min_price、max_price 的范围参数
+func ParseRange(values url.Values, minParam, maxParam string) (Range, error) {
+	var r Range
+	for _, param := range []string{minParam, maxParam} {
+		value := values.Get(param)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return Range{}, &Error{Param: param, Rule: RuleInvalid}
+		}
+		if param == minParam {
+			r.Min = &n
+		} else {
+			r.Max = &n
+		}
+	}
+	if r.Min != nil && r.Max != nil && *r.Min > *r.Max {
+		return Range{}, &Error{Param: maxParam, Rule: "min", Value: strconv.FormatFloat(*r.Min, 'f', -1, 64)}
+	}
+	return r, nil
+}
+
+func (r Range) Apply(query *gorm.DB, column string) *gorm.DB {
+	if r.Min != nil {
+		query = query.Where(fmt.Sprintf("%s >= ?", column), *r.Min)
+	}
+	if r.Max != nil {
+		query = query.Where(fmt.Sprintf("%s <= ?", column), *r.Max)
+	}
+	return query
+}
+
+// 时间范围 [Start, End)，零值表示不限
+type TimeRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+// 解析时间范围参数，格式为 2006-01-02 或 RFC3339；只有日期的结束时间包含当天
+func ParseTimeRange(values url.Values, startParam, endParam string) (TimeRange, error) {
+	var r TimeRange
+	for _, param := range []string{startParam, endParam} {
+		value := values.Get(param)
+		if value == "" {
+			continue
+		}
+		t, err := parseTime(value)
+		if err != nil {
+			return TimeRange{}, &Error{Param: param, Rule: RuleTime}
+		}
+		if param == startParam {
+			r.Start = t
+		} else {
+			if len(value) == len(dateLayout) {
+				t = t.AddDate(0, 0, 1)
+			}
+			r.End = t
+		}
+	}
+	return r, nil
+}
+
+const dateLayout = "2006-01-02"
+
+// 只有日期时按服务器本地时区解析
+func parseTime(value string) (time.Time, error) {
+	if len(value) == len(dateLayout) {
+		return time.ParseInLocation(dateLayout, value, time.Local)
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func (r TimeRange) Apply(query *gorm.DB, column string) *gorm.DB {
+	if !r.Start.IsZero() {
+		query = query.Where(fmt.Sprintf("%s >= ?", column), r.Start)
+	}
+	if !r.End.IsZero() {
+		query = query.Where(fmt.Sprintf("%s < ?", column), r.End)
+	}
+	return query
+}
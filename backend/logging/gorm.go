@@ -0,0 +1,85 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/utils"
+)
+
+// GORM日志，输出到slog：执行出错记为error，慢查询记为warn，info级别时记录所有SQL
+type GormLogger struct {
+	Level         logger.LogLevel
+	SlowThreshold time.Duration // 为0时不记录慢查询
+}
+
+func NewGormLogger(level logger.LogLevel, slowThreshold time.Duration) *GormLogger {
+	return &GormLogger{Level: level, SlowThreshold: slowThreshold}
+}
+
+// 解析GORM日志级别: silent / error / warn / info，无法识别时为warn
+func ParseGormLevel(level string) logger.LogLevel {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "silent":
+		return logger.Silent
+	case "error":
+		return logger.Error
+	case "info":
+		return logger.Info
+	default:
+		return logger.Warn
+	}
+}
+
+func (l *GormLogger) LogMode(level logger.LogLevel) logger.Interface {
+	copied := *l
+	copied.Level = level
+	return &copied
+}
+
+func (l *GormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	if l.Level >= logger.Info {
+		slog.InfoContext(ctx, fmt.Sprintf(msg, args...), "source", utils.FileWithLineNum())
+	}
+}
+
+func (l *GormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	if l.Level >= logger.Warn {
+		slog.WarnContext(ctx, fmt.Sprintf(msg, args...), "source", utils.FileWithLineNum())
+	}
+}
+
+func (l *GormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	if l.Level >= logger.Error {
+		slog.ErrorContext(ctx, fmt.Sprintf(msg, args...), "source", utils.FileWithLineNum())
+	}
+}
+
+func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.Level <= logger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	switch {
+	case err != nil && l.Level >= logger.Error && !errors.Is(err, gorm.ErrRecordNotFound):
+		sql, rows := fc()
+		slog.ErrorContext(ctx, "SQL执行失败", "error", err, "sql", sql, "rows", rows,
+			"duration_ms", elapsed.Milliseconds(), "source", utils.FileWithLineNum())
+	case l.SlowThreshold > 0 && elapsed > l.SlowThreshold && l.Level >= logger.Warn:
+		sql, rows := fc()
+		slog.WarnContext(ctx, "慢查询", "sql", sql, "rows", rows,
+			"duration_ms", elapsed.Milliseconds(), "threshold_ms", l.SlowThreshold.Milliseconds(),
+			"source", utils.FileWithLineNum())
+	case l.Level >= logger.Info:
+		sql, rows := fc()
+		slog.InfoContext(ctx, "SQL", "sql", sql, "rows", rows,
+			"duration_ms", elapsed.Milliseconds(), "source", utils.FileWithLineNum())
+	}
+}
@@ -0,0 +1,112 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// 将默认日志替换为写入缓冲区，返回每行解析后的记录
+func capture(t *testing.T, level slog.Level) func() []map[string]interface{} {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(NewHandler(&buf, level, "json")))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	return func() []map[string]interface{} {
+		var records []map[string]interface{}
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			if line == "" {
+				continue
+			}
+			var record map[string]interface{}
+			if err := json.Unmarshal([]byte(line), &record); err != nil {
+				t.Fatalf("日志不是JSON: %q", line)
+			}
+			records = append(records, record)
+		}
+		buf.Reset()
+		return records
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	cases := map[string]slog.Level{
+		"debug": slog.LevelDebug, "INFO": slog.LevelInfo, "warn": slog.LevelWarn,
+		"warning": slog.LevelWarn, "error": slog.LevelError, "": slog.LevelInfo, "verbose": slog.LevelInfo,
+	}
+	for input, want := range cases {
+		if got := ParseLevel(input); got != want {
+			t.Errorf("ParseLevel(%q) = %v, want %v", input, got, want)
+		}
+	}
+}
+
+func TestRequestIDFromContext(t *testing.T) {
+	records := capture(t, slog.LevelInfo)
+
+	ctx := WithRequestID(context.Background(), "req-1")
+	slog.InfoContext(ctx, "with id")
+	slog.Info("without id")
+	slog.Default().With("component", "test").InfoContext(ctx, "derived")
+
+	got := records()
+	if len(got) != 3 {
+		t.Fatalf("日志条数 %d", len(got))
+	}
+	if got[0]["request_id"] != "req-1" || got[2]["request_id"] != "req-1" || got[2]["component"] != "test" {
+		t.Fatalf("日志缺少请求ID: %v", got)
+	}
+	if _, has := got[1]["request_id"]; has {
+		t.Fatalf("无请求ID的日志不应带request_id: %v", got[1])
+	}
+}
+
+func TestGormLogger(t *testing.T) {
+	records := capture(t, slog.LevelDebug)
+	ctx := WithRequestID(context.Background(), "req-1")
+	sql := func() (string, int64) { return "SELECT 1", 1 }
+
+	l := NewGormLogger(ParseGormLevel("warn"), 100*time.Millisecond)
+
+	// 普通查询不记录
+	l.Trace(ctx, time.Now(), sql, nil)
+	if got := records(); len(got) != 0 {
+		t.Fatalf("warn级别不应记录普通SQL: %v", got)
+	}
+
+	// 慢查询
+	l.Trace(ctx, time.Now().Add(-time.Second), sql, nil)
+	got := records()
+	if len(got) != 1 || got[0]["level"] != "WARN" || got[0]["sql"] != "SELECT 1" || got[0]["request_id"] != "req-1" {
+		t.Fatalf("慢查询日志不正确: %v", got)
+	}
+
+	// 执行出错，记录不存在不算错误
+	l.Trace(ctx, time.Now(), sql, errors.New("boom"))
+	l.Trace(ctx, time.Now(), sql, gorm.ErrRecordNotFound)
+	got = records()
+	if len(got) != 1 || got[0]["level"] != "ERROR" || got[0]["error"] != "boom" {
+		t.Fatalf("错误日志不正确: %v", got)
+	}
+
+	// info级别记录所有SQL
+	l.LogMode(logger.Info).Trace(ctx, time.Now(), sql, nil)
+	if got := records(); len(got) != 1 || got[0]["msg"] != "SQL" {
+		t.Fatalf("info级别应记录所有SQL: %v", got)
+	}
+
+	// silent不记录任何内容
+	NewGormLogger(ParseGormLevel("silent"), 0).Trace(ctx, time.Now().Add(-time.Second), sql, errors.New("boom"))
+	if got := records(); len(got) != 0 {
+		t.Fatalf("silent级别不应记录: %v", got)
+	}
+}
@@ -2,39 +2,85 @@ package main
 
 import (
 	"e-device-recycle-backend/config"
-	"e-device-recycle-backend/models"
-	"e-device-recycle-backend/routes"
-	"log"
-
-	"github.com/gin-contrib/cors"
-	"github.com/gin-gonic/gin"
+	"flag"
+	"fmt"
+	"os"
 )
 
-func main() {
-	// 初始化配置
-	config.Init()
+const usage = `用法: main [-config 配置文件] [-env 运行环境] [命令] [参数]
+
+全局参数:
+  -config          YAML配置文件，默认读取环境变量CONFIG_FILE或当前目录的config.yaml
+  -env             运行环境: development | production，覆盖配置文件和APP_ENV
 
-	// 初始化数据库
-	models.InitDB()
+命令:
+  serve            启动HTTP服务（默认）
+  migrate          数据库迁移: up | down [n] | status
+  create-admin     创建管理员或评估师账号
+  reset-password   重置用户密码
+  seed             从YAML/JSON文件导入示例设备
+  purge            清理过期数据
+  reindex          重建设备搜索索引
+  sync-specs       从规格文本识别设备的结构化规格
+  config           查看和校验配置: print [-redacted] | validate
 
-	// 创建Gin引擎
-	r := gin.Default()
+使用 "main <命令> -h" 查看命令参数
+`
 
-	// 配置CORS
-	corsConfig := cors.DefaultConfig()
-	corsConfig.AllowAllOrigins = true
-	corsConfig.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization"}
-	r.Use(cors.New(corsConfig))
+func main() {
+	configFile := flag.String("config", "", "YAML配置文件")
+	env := flag.String("env", "", "运行环境: development | production")
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.Parse()
 
-	// 设置路由
-	routes.SetupRoutes(r)
+	// 命令行参数优先级最高
+	overrides := func(cfg *config.Config) {
+		if *env != "" {
+			cfg.Env = *env
+		}
+	}
 
-	// 启动服务器
-	port := config.GetConfig().Port
-	if port == "" {
-		port = "8080"
+	command := "serve"
+	args := flag.Args()
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
 	}
 
-	log.Printf("服务器启动在端口: %s", port)
-	log.Fatal(r.Run(":" + port))
+	// config命令在配置有误时也要能运行，不做启动校验
+	if command == "config" {
+		runConfig(args, *configFile, overrides)
+		return
+	}
+	if command == "help" {
+		fmt.Print(usage)
+		return
+	}
+
+	// 初始化配置，校验失败时拒绝启动
+	if err := config.Init(*configFile, overrides); err != nil {
+		fmt.Fprintf(os.Stderr, "配置有误:\n%v\n", err)
+		os.Exit(1)
+	}
+
+	switch command {
+	case "serve":
+		runServe(args)
+	case "migrate":
+		runMigrate(args)
+	case "create-admin":
+		runCreateAdmin(args)
+	case "reset-password":
+		runResetPassword(args)
+	case "seed":
+		runSeed(args)
+	case "purge":
+		runPurge(args)
+	case "reindex":
+		runReindex(args)
+	case "sync-specs":
+		runSyncSpecs(args)
+	default:
+		fmt.Fprintf(os.Stderr, "未知命令: %s\n\n%s", command, usage)
+		os.Exit(2)
+	}
 }
@@ -0,0 +1,123 @@
+// Package metrics 定义Prometheus指标：HTTP请求、数据库连接池和业务指标，
+// 通过 Handler 以Prometheus文本格式输出。
+package metrics
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "recycle"
+
+// 指标注册表，不使用全局默认注册表，避免第三方库注册的指标混入
+var Registry = prometheus.NewRegistry()
+
+// HTTP指标，route为路由模板（如 /api/v1/orders/:id），未匹配的路由记为unmatched
+var (
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP请求数",
+	}, []string{"method", "route", "status"})
+
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP请求耗时（秒）",
+		Buckets:   []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5},
+	}, []string{"method", "route"})
+
+	HTTPInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "http_requests_in_flight",
+		Help:      "正在处理的HTTP请求数",
+	})
+)
+
+// 业务指标
+var (
+	OrdersCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "orders_created_total",
+		Help:      "创建的回收订单数",
+	})
+
+	OrdersCancelled = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "orders_cancelled_total",
+		Help:      "取消的回收订单数，按取消原因",
+	}, []string{"reason"})
+
+	EvaluationsCompleted = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "evaluations_completed_total",
+		Help:      "完成的设备评估数",
+	})
+
+	// 订单完成时的打款金额，_sum为累计金额，_count为完成订单数
+	Payouts = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "payout_amount_yuan",
+		Help:      "订单完成时的打款金额（元）",
+		Buckets:   []float64{100, 300, 500, 1000, 2000, 3000, 5000, 8000, 12000, 20000},
+	})
+)
+
+// 缓存指标，result为hit、miss或error（缓存不可用，回源数据库）
+var CacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "cache_requests_total",
+	Help:      "缓存读取次数，按缓存名称和结果",
+}, []string{"cache", "result"})
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests, HTTPDuration, HTTPInFlight,
+		OrdersCreated, OrdersCancelled, EvaluationsCompleted, Payouts,
+		CacheRequests,
+	)
+}
+
+var (
+	dbMu        sync.Mutex
+	dbCollector prometheus.Collector
+)
+
+// 注册数据库连接池指标（go_sql_*），重复调用时替换之前注册的连接
+func RegisterDB(db *sql.DB, name string) {
+	dbMu.Lock()
+	defer dbMu.Unlock()
+
+	if dbCollector != nil {
+		Registry.Unregister(dbCollector)
+	}
+	dbCollector = collectors.NewDBStatsCollector(db, name)
+	Registry.MustRegister(dbCollector)
+}
+
+// 输出指标的HTTP处理器，token不为空时要求请求头 Authorization: Bearer <token>
+func Handler(token string) http.Handler {
+	handler := promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+	if token == "" {
+		return handler
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		provided := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
@@ -1,6 +1,8 @@
 package middleware
 
 import (
+	"e-device-recycle-backend/apierror"
+	"e-device-recycle-backend/models"
 	"e-device-recycle-backend/utils"
 	"net/http"
 	"strings"
@@ -14,24 +16,29 @@ func JWTAuth() gin.HandlerFunc {
 		// 从请求头获取token
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "缺少认证令牌"})
-			c.Abort()
+			apierror.Respond(c, http.StatusUnauthorized, apierror.Unauthorized)
 			return
 		}
 
 		// 检查Bearer前缀
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 		if tokenString == authHeader {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "认证令牌格式错误"})
-			c.Abort()
+			apierror.Respond(c, http.StatusUnauthorized, apierror.TokenInvalid)
 			return
 		}
 
 		// 验证token
 		claims, err := utils.ValidateJWT(tokenString)
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的认证令牌"})
-			c.Abort()
+		if err != nil || claims.Purpose != "" {
+			apierror.Respond(c, http.StatusUnauthorized, apierror.TokenInvalid)
+			return
+		}
+
+		// 检查令牌版本，修改或重置密码后旧令牌失效
+		var user models.User
+		if err := models.DB.Select("id", "status", "token_version").First(&user, claims.UserID).Error; err != nil ||
+			user.TokenVersion != claims.Version || user.Status != "active" {
+			apierror.Respond(c, http.StatusUnauthorized, apierror.TokenExpired)
 			return
 		}
 
@@ -48,8 +55,7 @@ func AdminAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		role, exists := c.Get("role")
 		if !exists || role != "admin" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "需要管理员权限"})
-			c.Abort()
+			apierror.Respond(c, http.StatusForbidden, apierror.Forbidden)
 			return
 		}
 		c.Next()
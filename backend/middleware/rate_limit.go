@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"e-device-recycle-backend/apierror"
+	"e-device-recycle-backend/store"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 限流策略，在routes中按路由分组声明
+type RateLimitPolicy struct {
+	Name  string                      // 策略名称，不同策略的计数互不影响
+	Limit int                         // 每个周期允许的请求数，同时作为突发容量
+	Per   time.Duration               // 周期
+	Key   func(c *gin.Context) string // 限流维度
+}
+
+var (
+	rateLimiter store.RateLimiter
+	apiKeys     map[[sha256.Size]byte]bool
+)
+
+// 设置限流器，未设置时不限流
+func SetRateLimiter(l store.RateLimiter) {
+	rateLimiter = l
+}
+
+// 设置已分配的API Key，只有这些Key单独限流
+func SetAPIKeys(keys []string) {
+	apiKeys = make(map[[sha256.Size]byte]bool, len(keys))
+	for _, key := range keys {
+		apiKeys[sha256.Sum256([]byte(key))] = true
+	}
+}
+
+// 按客户端IP限流
+func KeyByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// 按登录用户限流，未登录时按IP
+func KeyByUser(c *gin.Context) string {
+	if userID, exists := c.Get("user_id"); exists {
+		return fmt.Sprintf("user:%v", userID)
+	}
+	return KeyByIP(c)
+}
+
+// 按X-API-Key限流，未携带或不是已分配的Key时按IP，避免更换请求头绕过限流
+func KeyByAPIKey(c *gin.Context) string {
+	if apiKey := c.GetHeader("X-API-Key"); apiKey != "" {
+		if sum := sha256.Sum256([]byte(apiKey)); apiKeys[sum] {
+			return "key:" + hex.EncodeToString(sum[:8])
+		}
+	}
+	return KeyByIP(c)
+}
+
+// 令牌桶限流中间件
+func RateLimit(policy RateLimitPolicy) gin.HandlerFunc {
+	rate := float64(policy.Limit) / policy.Per.Seconds()
+	keyFunc := policy.Key
+	if keyFunc == nil {
+		keyFunc = KeyByIP
+	}
+
+	return func(c *gin.Context) {
+		if rateLimiter == nil {
+			c.Next()
+			return
+		}
+
+		key := policy.Name + ":" + keyFunc(c)
+		result, err := rateLimiter.Allow(c.Request.Context(), key, rate, policy.Limit)
+		if err != nil {
+			// 限流存储不可用时放行，避免影响正常业务
+			slog.ErrorContext(c.Request.Context(), "限流检查失败", "error", err, "policy", policy.Name)
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(policy.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+
+		if !result.Allowed {
+			seconds := int((result.RetryAfter + time.Second - 1) / time.Second)
+			c.Header("Retry-After", strconv.Itoa(seconds))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error":       apierror.New(c, apierror.RateLimited),
+				"retry_after": seconds,
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
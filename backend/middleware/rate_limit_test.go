@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"e-device-recycle-backend/store"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newRateLimitedRouter(t *testing.T, policy RateLimitPolicy) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	SetRateLimiter(store.NewMemoryRateLimiter())
+	SetAPIKeys([]string{"partner-key"})
+	t.Cleanup(func() {
+		SetRateLimiter(nil)
+		SetAPIKeys(nil)
+	})
+
+	r := gin.New()
+	r.GET("/", RateLimit(policy), func(c *gin.Context) { c.Status(http.StatusOK) })
+	return r
+}
+
+func get(r *gin.Engine, apiKey string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	if apiKey != "" {
+		req.Header.Set("X-API-Key", apiKey)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimit(t *testing.T) {
+	r := newRateLimitedRouter(t, RateLimitPolicy{Name: "test", Limit: 2, Per: time.Minute})
+
+	for i := 0; i < 2; i++ {
+		if w := get(r, ""); w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Limit") != "2" {
+			t.Fatalf("第%d次请求 = %d", i+1, w.Code)
+		}
+	}
+	w := get(r, "")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("超出限额 = %d, Retry-After %q", w.Code, w.Header().Get("Retry-After"))
+	}
+}
+
+func TestKeyByAPIKeyIgnoresUnknownKeys(t *testing.T) {
+	r := newRateLimitedRouter(t, RateLimitPolicy{Name: "public", Limit: 2, Per: time.Minute, Key: KeyByAPIKey})
+
+	// 每次更换未配置的Key仍按IP计数
+	for i := 0; i < 2; i++ {
+		if w := get(r, "random-"+string(rune('a'+i))); w.Code != http.StatusOK {
+			t.Fatalf("第%d次请求 = %d", i+1, w.Code)
+		}
+	}
+	if w := get(r, "random-z"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("更换未配置的Key = %d, want 429", w.Code)
+	}
+	if w := get(r, ""); w.Code != http.StatusTooManyRequests {
+		t.Errorf("不带Key = %d, want 429", w.Code)
+	}
+
+	// 已配置的Key使用独立限额
+	if w := get(r, "partner-key"); w.Code != http.StatusOK {
+		t.Errorf("已配置的Key = %d, want 200", w.Code)
+	}
+}
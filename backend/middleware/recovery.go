@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"e-device-recycle-backend/apierror"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 捕获panic并返回统一格式的错误
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(nil, func(c *gin.Context, recovered interface{}) {
+		// 处理函数主动中断连接（响应已部分写出时），交给net/http关闭连接
+		if recovered == http.ErrAbortHandler {
+			panic(recovered)
+		}
+		slog.ErrorContext(c.Request.Context(), "请求处理发生panic",
+			"panic", recovered, "path", c.Request.URL.Path, "stack", string(debug.Stack()))
+		apierror.Respond(c, http.StatusInternalServerError, apierror.Internal)
+	})
+}
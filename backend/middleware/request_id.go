@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"e-device-recycle-backend/logging"
+	"e-device-recycle-backend/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// 请求ID：沿用客户端或网关传入的X-Request-ID，没有或格式不合法时生成新的，
+// 写入响应头、gin上下文（request_id）和请求上下文，日志和错误响应中都会带上
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id, _ = utils.GenerateRandomToken(16)
+		}
+
+		c.Set("request_id", id)
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+		c.Header(RequestIDHeader, id)
+
+		c.Next()
+	}
+}
+
+// 只接受长度不超过64（与审计日志字段一致）的字母、数字和 -_.: 字符，避免日志注入
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"e-device-recycle-backend/config"
+	"e-device-recycle-backend/logging"
+	"e-device-recycle-backend/migrations"
+	"e-device-recycle-backend/models"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+)
+
+// migrate 子命令：up | down [n] | status | baseline [version]
+func runMigrate(args []string) {
+	// docker-compose在启动服务前执行迁移，日志使用与服务相同的格式
+	cfg := config.GetConfig()
+	logging.Init(cfg.Log.Level, cfg.Log.Format)
+	models.ConnectDB()
+
+	migrator, err := migrations.New(models.DB)
+	if err != nil {
+		fatal("加载数据库迁移失败", "error", err)
+	}
+
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	ctx := context.Background()
+	switch command {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			slog.Info("已执行迁移", "version", m.Version, "name", m.Name)
+		}
+		if err != nil {
+			fatal("数据库迁移失败", "error", err)
+		}
+		if len(applied) == 0 {
+			slog.Info("数据库已是最新版本")
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				fatal("回滚数量必须为正整数", "steps", args[1])
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		for _, m := range reverted {
+			slog.Info("已回滚迁移", "version", m.Version, "name", m.Name)
+		}
+		if err != nil {
+			fatal("回滚迁移失败", "error", err)
+		}
+	case "baseline":
+		// 未指定版本时根据表结构识别
+		var version int64
+		if len(args) > 1 {
+			if version, err = strconv.ParseInt(args[1], 10, 64); err != nil || version < 1 {
+				fatal("基线版本必须为正整数", "version", args[1])
+			}
+		} else {
+			if version, err = migrator.DetectBaseline(); err != nil {
+				fatal("识别基线版本失败", "error", err)
+			}
+			slog.Info("根据表结构识别基线版本", "version", version)
+		}
+		marked, err := migrator.Baseline(ctx, version)
+		if err != nil {
+			fatal("设置基线失败", "error", err)
+		}
+		for _, m := range marked {
+			slog.Info("已标记为执行", "version", m.Version, "name", m.Name)
+		}
+		slog.Info("已设置基线，请继续运行: migrate up")
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			fatal("读取迁移状态失败", "error", err)
+		}
+		for _, s := range statuses {
+			state := "未执行"
+			if s.Applied {
+				state = "已执行 " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d  %-30s %s\n", s.Version, s.Name, state)
+		}
+	default:
+		fatal("未知的migrate命令，可用: up, down [n], status, baseline [version]", "command", command)
+	}
+}
+
+// 记录错误并以状态码1退出
+func fatal(msg string, args ...interface{}) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
@@ -0,0 +1,458 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 迁移文件按数据库类型分目录存放（mysql、postgres、sqlite），
+// 命名：<版本号>_<名称>.up.sql / <版本号>_<名称>.down.sql
+//
+//go:embed mysql/*.sql postgres/*.sql sqlite/*.sql
+var files embed.FS
+
+var (
+	ErrLocked = errors.New("获取迁移锁超时，可能有其他实例正在迁移")
+	// 已有数据表但没有迁移记录，直接执行迁移会因表已存在而失败
+	ErrBaselineRequired = errors.New("数据库已有数据表但没有迁移记录（由旧版本AutoMigrate创建），请先运行: migrate baseline")
+)
+
+// 改用版本化迁移之前，AutoMigrate创建的数据库可能停留在以下任一版本，按各版本新增的数据表和字段识别
+var autoMigrateSchemas = []struct {
+	version int64
+	tables  []string
+	columns map[string][]string
+}{
+	{1, []string{"users", "devices", "recycle_orders", "evaluations"}, nil},
+	{2, []string{"password_reset_tokens", "user_identities", "recovery_codes", "login_attempts"},
+		map[string][]string{"users": {"token_version", "totp_secret", "totp_enabled", "totp_last_step"}}},
+	{3, []string{"audit_logs"}, nil},
+}
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+type Migrator struct {
+	db         *gorm.DB
+	dialect    string
+	migrations []Migration
+}
+
+func New(db *gorm.DB) (*Migrator, error) {
+	dialect := db.Dialector.Name()
+	migrations, err := load(files, dialect)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, dialect: dialect, migrations: migrations}, nil
+}
+
+// 读取指定方言目录下的迁移文件
+func load(fsys fs.FS, dialect string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dialect)
+	if err != nil {
+		return nil, fmt.Errorf("不支持的数据库类型 %s: %w", dialect, err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		name := entry.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+
+		base := strings.TrimSuffix(name, "."+direction+".sql")
+		parts := strings.SplitN(base, "_", 2)
+		version, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil || len(parts) != 2 {
+			return nil, fmt.Errorf("迁移文件名格式错误: %s", name)
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join(dialect, name))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: parts[1]}
+			byVersion[version] = m
+		} else if m.Name != parts[1] {
+			return nil, fmt.Errorf("迁移版本号重复: %d", version)
+		}
+		if direction == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("迁移 %d_%s 缺少up文件", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// 执行所有未应用的迁移
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.withLock(ctx, func() error {
+		required, err := m.BaselineRequired()
+		if err != nil {
+			return err
+		}
+		if required {
+			return ErrBaselineRequired
+		}
+
+		pending, err := m.Pending()
+		if err != nil {
+			return err
+		}
+		for _, migration := range pending {
+			err := m.transaction(ctx, func(tx *gorm.DB) error {
+				if err := exec(tx, migration.Up); err != nil {
+					return err
+				}
+				return tx.Exec(
+					"INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)",
+					migration.Version, migration.Name, time.Now(),
+				).Error
+			})
+			if err != nil {
+				return fmt.Errorf("执行迁移 %d_%s 失败: %w", migration.Version, migration.Name, err)
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// 把version及之前的迁移标记为已执行但不执行SQL，用于接管由AutoMigrate创建的已有数据库，
+// 之后的迁移仍由Up执行。只能在没有迁移记录时使用
+func (m *Migrator) Baseline(ctx context.Context, version int64) ([]Migration, error) {
+	var marked []Migration
+	err := m.withLock(ctx, func() error {
+		versions, err := m.appliedVersions()
+		if err != nil {
+			return err
+		}
+		if len(versions) > 0 {
+			return errors.New("数据库已有迁移记录，不需要设置基线")
+		}
+		if !m.db.Migrator().HasTable("users") {
+			return errors.New("数据库中没有数据表，请直接运行: migrate up")
+		}
+
+		var baseline []Migration
+		for _, migration := range m.migrations {
+			if migration.Version <= version {
+				baseline = append(baseline, migration)
+			}
+		}
+		if len(baseline) == 0 || baseline[len(baseline)-1].Version != version {
+			return fmt.Errorf("迁移版本 %d 不存在", version)
+		}
+
+		return m.transaction(ctx, func(tx *gorm.DB) error {
+			now := time.Now()
+			for _, migration := range baseline {
+				if err := tx.Exec(
+					"INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)",
+					migration.Version, migration.Name, now,
+				).Error; err != nil {
+					return err
+				}
+			}
+			marked = baseline
+			return nil
+		})
+	})
+	return marked, err
+}
+
+// 根据已有的表结构识别AutoMigrate创建的数据库对应的迁移版本，用作基线。
+// 某个版本的表和字段只存在一部分时无法判断，返回错误，需人工检查后指定版本
+func (m *Migrator) DetectBaseline() (int64, error) {
+	migrator := m.db.Migrator()
+	var version int64
+	complete := true
+	for _, schema := range autoMigrateSchemas {
+		present, missing := 0, 0
+		count := func(ok bool) {
+			if ok {
+				present++
+			} else {
+				missing++
+			}
+		}
+		for _, table := range schema.tables {
+			count(migrator.HasTable(table))
+		}
+		for table, columns := range schema.columns {
+			for _, column := range columns {
+				count(migrator.HasColumn(table, column))
+			}
+		}
+
+		switch {
+		case missing == 0 && complete:
+			version = schema.version
+		case present > 0:
+			return 0, fmt.Errorf("数据库表结构与迁移版本 %d 不完全一致，请检查后用 migrate baseline <版本号> 指定", schema.version)
+		default:
+			complete = false
+		}
+	}
+	if version == 0 {
+		return 0, errors.New("数据库中没有可识别的数据表，请直接运行: migrate up")
+	}
+	return version, nil
+}
+
+// 是否需要先设置基线：没有迁移记录但已有数据表
+func (m *Migrator) BaselineRequired() (bool, error) {
+	versions, err := m.appliedVersions()
+	if err != nil {
+		return false, err
+	}
+	return len(versions) == 0 && m.db.Migrator().HasTable("users"), nil
+}
+
+// 回滚最近的steps个迁移
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var reverted []Migration
+	err := m.withLock(ctx, func() error {
+		versions, err := m.appliedVersions()
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := versions[migration.Version]; !ok {
+				continue
+			}
+			if migration.Down == "" {
+				return fmt.Errorf("迁移 %d_%s 不支持回滚", migration.Version, migration.Name)
+			}
+			err := m.transaction(ctx, func(tx *gorm.DB) error {
+				if err := exec(tx, migration.Down); err != nil {
+					return err
+				}
+				return tx.Exec("DELETE FROM schema_migrations WHERE version = ?", migration.Version).Error
+			})
+			if err != nil {
+				return fmt.Errorf("回滚迁移 %d_%s 失败: %w", migration.Version, migration.Name, err)
+			}
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// 所有迁移及其应用状态
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	versions, err := m.appliedVersions()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, len(m.migrations))
+	for i, migration := range m.migrations {
+		statuses[i].Migration = migration
+		if appliedAt, ok := versions[migration.Version]; ok {
+			statuses[i].Applied = true
+			statuses[i].AppliedAt = &appliedAt
+		}
+	}
+	return statuses, nil
+}
+
+// 未应用的迁移
+func (m *Migrator) Pending() ([]Migration, error) {
+	versions, err := m.appliedVersions()
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if _, ok := versions[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+func (m *Migrator) appliedVersions() (map[int64]time.Time, error) {
+	if err := m.ensureTable(); err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		Version   int64
+		AppliedAt time.Time
+	}
+	if err := m.db.Raw("SELECT version, applied_at FROM schema_migrations").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	versions := make(map[int64]time.Time, len(rows))
+	for _, row := range rows {
+		versions[row.Version] = row.AppliedAt
+	}
+	return versions, nil
+}
+
+func (m *Migrator) ensureTable() error {
+	timeType := "DATETIME(3)"
+	switch m.dialect {
+	case "postgres":
+		timeType = "TIMESTAMP"
+	case "sqlite":
+		timeType = "DATETIME"
+	}
+
+	return m.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT NOT NULL PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		applied_at ` + timeType + ` NOT NULL
+	)`).Error
+}
+
+// MySQL的DDL会隐式提交，无法回滚；PostgreSQL和SQLite在事务中执行整个迁移
+func (m *Migrator) transaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	if m.dialect == "mysql" {
+		return fn(m.db.WithContext(ctx))
+	}
+	return m.db.WithContext(ctx).Transaction(fn)
+}
+
+// 逐条执行迁移文件中的语句
+func exec(tx *gorm.DB, script string) error {
+	for _, statement := range splitStatements(script) {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 使用数据库锁保证多个实例不会同时迁移
+func (m *Migrator) withLock(ctx context.Context, fn func() error) error {
+	// SQLite为单文件数据库，写入本身是互斥的
+	if m.dialect == "sqlite" {
+		return fn()
+	}
+
+	sqlDB, err := m.db.DB()
+	if err != nil {
+		return err
+	}
+
+	// 锁与连接绑定，需要在同一连接上获取和释放
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	lockCtx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	switch m.dialect {
+	case "postgres":
+		if _, err := conn.ExecContext(lockCtx, "SELECT pg_advisory_lock($1)", advisoryLockID); err != nil {
+			if errors.Is(lockCtx.Err(), context.DeadlineExceeded) {
+				return ErrLocked
+			}
+			return err
+		}
+		defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", advisoryLockID)
+	default:
+		var locked sql.NullInt64
+		if err := conn.QueryRowContext(lockCtx, "SELECT GET_LOCK('schema_migrations', 60)").Scan(&locked); err != nil {
+			return err
+		}
+		if !locked.Valid || locked.Int64 != 1 {
+			return ErrLocked
+		}
+		defer conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK('schema_migrations')")
+	}
+
+	return fn()
+}
+
+// PostgreSQL advisory lock 标识
+const advisoryLockID = 7265031
+
+// 按分号拆分SQL语句，忽略引号内的分号和注释行
+func splitStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+	var quote rune
+
+	for _, line := range strings.Split(script, "\n") {
+		if quote == 0 && strings.HasPrefix(strings.TrimSpace(line), "--") {
+			continue
+		}
+		for _, r := range line {
+			switch {
+			case quote != 0:
+				if r == quote {
+					quote = 0
+				}
+			case r == '\'' || r == '"' || r == '`':
+				quote = r
+			case r == ';':
+				if statement := strings.TrimSpace(current.String()); statement != "" {
+					statements = append(statements, statement)
+				}
+				current.Reset()
+				continue
+			}
+			current.WriteRune(r)
+		}
+		current.WriteRune('\n')
+	}
+
+	if statement := strings.TrimSpace(current.String()); statement != "" {
+		statements = append(statements, statement)
+	}
+	return statements
+}
@@ -0,0 +1,268 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var dbSeq int64
+
+func newMigrator(t *testing.T) *Migrator {
+	t.Helper()
+	dsn := fmt.Sprintf("file:migrations%d?mode=memory&cache=shared&_foreign_keys=1", atomic.AddInt64(&dbSeq, 1))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	m, err := New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func versions(migrations []Migration) []int64 {
+	result := make([]int64, len(migrations))
+	for i, m := range migrations {
+		result[i] = m.Version
+	}
+	return result
+}
+
+func TestSplitStatements(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		script string
+		want   []string
+	}{
+		{"多条语句", "CREATE TABLE a (id INT);\nCREATE TABLE b (id INT);\n", []string{"CREATE TABLE a (id INT)", "CREATE TABLE b (id INT)"}},
+		{"最后一条没有分号", "SELECT 1;\nSELECT 2", []string{"SELECT 1", "SELECT 2"}},
+		{"跨行语句", "ALTER TABLE a\n    ADD COLUMN b INT;", []string{"ALTER TABLE a\n    ADD COLUMN b INT"}},
+		{"忽略注释行", "-- 说明;\nSELECT 1;\n  -- 缩进的注释\n", []string{"SELECT 1"}},
+		{"引号内的分号", "INSERT INTO a VALUES ('x;y', \"p;q\", `r;s`);", []string{"INSERT INTO a VALUES ('x;y', \"p;q\", `r;s`)"}},
+		{"引号内的注释标记", "INSERT INTO a VALUES ('a\n-- b;');", []string{"INSERT INTO a VALUES ('a\n-- b;')"}},
+		{"空语句", ";;\n  ;\n", nil},
+	} {
+		if got := splitStatements(tc.script); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: splitStatements = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	migrations, err := load(fstest.MapFS{
+		"sqlite/0002_b.up.sql":   {Data: []byte("B")},
+		"sqlite/0001_a.up.sql":   {Data: []byte("A")},
+		"sqlite/0001_a.down.sql": {Data: []byte("-A")},
+		"sqlite/README.md":       {Data: []byte("忽略")},
+	}, "sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Migration{{Version: 1, Name: "a", Up: "A", Down: "-A"}, {Version: 2, Name: "b", Up: "B"}}
+	if !reflect.DeepEqual(migrations, want) {
+		t.Errorf("load = %+v, want %+v", migrations, want)
+	}
+
+	for name, fsys := range map[string]fstest.MapFS{
+		"缺少up文件":  {"sqlite/0001_a.down.sql": {}},
+		"版本号重复":   {"sqlite/0001_a.up.sql": {}, "sqlite/0001_b.up.sql": {}},
+		"文件名格式错误": {"sqlite/init.up.sql": {}},
+		"不支持的数据库": {"mysql/0001_a.up.sql": {}},
+	} {
+		if _, err := load(fsys, "sqlite"); err == nil {
+			t.Errorf("%s: 应返回错误", name)
+		}
+	}
+}
+
+// 内置的迁移文件：三种数据库的版本一致，都有down文件
+func TestEmbeddedMigrations(t *testing.T) {
+	var want []Migration
+	for _, dialect := range []string{"mysql", "postgres", "sqlite"} {
+		migrations, err := load(files, dialect)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range migrations {
+			if m.Down == "" {
+				t.Errorf("%s/%04d_%s 缺少down文件", dialect, m.Version, m.Name)
+			}
+		}
+		if want == nil {
+			want = migrations
+			continue
+		}
+		if len(migrations) != len(want) {
+			t.Fatalf("%s 有%d个迁移，want %d", dialect, len(migrations), len(want))
+		}
+		for i := range migrations {
+			if migrations[i].Version != want[i].Version || migrations[i].Name != want[i].Name {
+				t.Errorf("%s 第%d个迁移为 %d_%s，want %d_%s", dialect, i+1,
+					migrations[i].Version, migrations[i].Name, want[i].Version, want[i].Name)
+			}
+		}
+	}
+}
+
+func TestUpAndDown(t *testing.T) {
+	ctx := context.Background()
+	m := newMigrator(t)
+	total := len(m.migrations)
+
+	applied, err := m.Up(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != total {
+		t.Fatalf("执行了%d个迁移，want %d", len(applied), total)
+	}
+	if applied, _ := m.Up(ctx); len(applied) != 0 {
+		t.Errorf("再次执行了%d个迁移，want 0", len(applied))
+	}
+
+	// 回滚最近两个，再回滚到初始状态
+	reverted, err := m.Down(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := versions(reverted), versions(m.migrations[total-2:]); got[0] != want[1] || got[1] != want[0] {
+		t.Errorf("回滚了 %v，want 倒序的 %v", got, want)
+	}
+	if pending, _ := m.Pending(); len(pending) != 2 {
+		t.Errorf("回滚后有%d个未执行的迁移，want 2", len(pending))
+	}
+
+	if reverted, err := m.Down(ctx, total); err != nil || len(reverted) != total-2 {
+		t.Fatalf("全部回滚 = %d, %v, want %d", len(reverted), err, total-2)
+	}
+	if m.db.Migrator().HasTable("users") {
+		t.Error("全部回滚后users表仍存在")
+	}
+
+	// 回滚后可以重新执行
+	if applied, err := m.Up(ctx); err != nil || len(applied) != total {
+		t.Fatalf("重新执行 = %d, %v, want %d", len(applied), err, total)
+	}
+	statuses, err := m.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range statuses {
+		if !s.Applied || s.AppliedAt == nil {
+			t.Errorf("%d_%s 未标记为已执行", s.Version, s.Name)
+		}
+	}
+}
+
+func TestUpRollsBackFailedMigration(t *testing.T) {
+	ctx := context.Background()
+	m := newMigrator(t)
+	m.migrations = []Migration{
+		{Version: 1, Name: "ok", Up: "CREATE TABLE a (id INTEGER);"},
+		{Version: 2, Name: "broken", Up: "CREATE TABLE b (id INTEGER);\nINSERT INTO missing VALUES (1);"},
+	}
+
+	applied, err := m.Up(ctx)
+	if err == nil || !strings.Contains(err.Error(), "2_broken") {
+		t.Fatalf("err = %v, want 执行迁移 2_broken 失败", err)
+	}
+	if len(applied) != 1 {
+		t.Errorf("执行了%d个迁移，want 1", len(applied))
+	}
+	// SQLite在事务中执行，失败的迁移整体回滚
+	if m.db.Migrator().HasTable("b") {
+		t.Error("失败的迁移未回滚")
+	}
+	if pending, _ := m.Pending(); len(pending) != 1 || pending[0].Version != 2 {
+		t.Errorf("未执行的迁移 = %v, want [2]", versions(pending))
+	}
+}
+
+// 旧版本AutoMigrate只创建了0001中的表：识别为版本1，设置基线后执行之后的全部迁移
+func TestBaseline(t *testing.T) {
+	ctx := context.Background()
+	m := newMigrator(t)
+
+	if _, err := m.DetectBaseline(); err == nil {
+		t.Error("空数据库识别基线应返回错误")
+	}
+	if _, err := m.Baseline(ctx, 1); err == nil {
+		t.Error("空数据库设置基线应返回错误")
+	}
+
+	// 模拟AutoMigrate创建的表结构（User、Device、RecycleOrder、Evaluation）
+	if err := exec(m.db, m.migrations[0].Up); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.Up(ctx); !errors.Is(err, ErrBaselineRequired) {
+		t.Fatalf("Up err = %v, want ErrBaselineRequired", err)
+	}
+	if _, err := m.Baseline(ctx, 999); err == nil {
+		t.Error("不存在的基线版本应返回错误")
+	}
+
+	version, err := m.DetectBaseline()
+	if err != nil || version != 1 {
+		t.Fatalf("DetectBaseline = %d, %v, want 1", version, err)
+	}
+	marked, err := m.Baseline(ctx, version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := versions(marked); !reflect.DeepEqual(got, []int64{1}) {
+		t.Errorf("标记了 %v，want [1]", got)
+	}
+	if _, err := m.Baseline(ctx, version); err == nil {
+		t.Error("重复设置基线应返回错误")
+	}
+
+	// 之后的迁移补齐令牌版本、两步验证等字段和表
+	applied, err := m.Up(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != len(m.migrations)-1 || applied[0].Version != 2 {
+		t.Errorf("基线后执行了 %v", versions(applied))
+	}
+	if !m.db.Migrator().HasColumn("users", "token_version") || !m.db.Migrator().HasTable("login_attempts") {
+		t.Error("基线后的迁移未补齐表结构")
+	}
+}
+
+func TestDetectBaseline(t *testing.T) {
+	m := newMigrator(t)
+	for _, migration := range m.migrations[:3] {
+		if err := exec(m.db, migration.Up); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if version, err := m.DetectBaseline(); err != nil || version != 3 {
+		t.Errorf("DetectBaseline = %d, %v, want 3", version, err)
+	}
+
+	// 版本2的表结构不完整时无法判断
+	if err := m.db.Migrator().DropTable("recovery_codes"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.DetectBaseline(); err == nil {
+		t.Error("表结构不完整时应返回错误")
+	}
+}
@@ -0,0 +1,98 @@
+CREATE TABLE users (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    username VARCHAR(64) NOT NULL,
+    password VARCHAR(255) NOT NULL,
+    phone VARCHAR(32) NULL,
+    email VARCHAR(191) NULL,
+    real_name VARCHAR(64) NOT NULL DEFAULT '',
+    avatar VARCHAR(512) NOT NULL DEFAULT '',
+    role VARCHAR(32) NOT NULL DEFAULT 'user',
+    status VARCHAR(32) NOT NULL DEFAULT 'active',
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    deleted_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    UNIQUE KEY idx_users_username (username),
+    UNIQUE KEY idx_users_phone (phone),
+    UNIQUE KEY idx_users_email (email),
+    KEY idx_users_deleted_at (deleted_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE devices (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    name VARCHAR(191) NOT NULL,
+    brand VARCHAR(64) NOT NULL DEFAULT '',
+    model VARCHAR(128) NOT NULL DEFAULT '',
+    category VARCHAR(32) NOT NULL DEFAULT '',
+    cpu VARCHAR(128) NOT NULL DEFAULT '',
+    memory VARCHAR(64) NOT NULL DEFAULT '',
+    storage VARCHAR(64) NOT NULL DEFAULT '',
+    graphics VARCHAR(128) NOT NULL DEFAULT '',
+    screen VARCHAR(128) NOT NULL DEFAULT '',
+    `condition` VARCHAR(32) NOT NULL DEFAULT '',
+    year_bought BIGINT NOT NULL DEFAULT 0,
+    base_price DOUBLE NOT NULL DEFAULT 0,
+    description TEXT NULL,
+    images TEXT NULL,
+    status VARCHAR(32) NOT NULL DEFAULT 'active',
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    deleted_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    KEY idx_devices_category (category),
+    KEY idx_devices_brand (brand),
+    KEY idx_devices_status (status),
+    KEY idx_devices_deleted_at (deleted_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE recycle_orders (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    user_id BIGINT UNSIGNED NOT NULL,
+    device_id BIGINT UNSIGNED NOT NULL,
+    order_no VARCHAR(64) NOT NULL,
+    contact_name VARCHAR(64) NOT NULL,
+    contact_phone VARCHAR(32) NOT NULL,
+    pickup_address VARCHAR(512) NOT NULL,
+    pickup_time DATETIME(3) NULL,
+    device_info TEXT NULL,
+    images TEXT NULL,
+    estimated_price DOUBLE NOT NULL DEFAULT 0,
+    final_price DOUBLE NULL,
+    status VARCHAR(32) NOT NULL DEFAULT 'pending',
+    remark TEXT NULL,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    deleted_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    UNIQUE KEY idx_recycle_orders_order_no (order_no),
+    KEY idx_recycle_orders_user_id (user_id),
+    KEY idx_recycle_orders_device_id (device_id),
+    KEY idx_recycle_orders_status (status),
+    KEY idx_recycle_orders_deleted_at (deleted_at),
+    CONSTRAINT fk_recycle_orders_user FOREIGN KEY (user_id) REFERENCES users (id),
+    CONSTRAINT fk_recycle_orders_device FOREIGN KEY (device_id) REFERENCES devices (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE evaluations (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    order_id BIGINT UNSIGNED NOT NULL,
+    evaluator_id BIGINT UNSIGNED NULL,
+    appearance_score BIGINT NOT NULL DEFAULT 0,
+    function_score BIGINT NOT NULL DEFAULT 0,
+    performance_score BIGINT NOT NULL DEFAULT 0,
+    overall_score DOUBLE NOT NULL DEFAULT 0,
+    market_price DOUBLE NOT NULL DEFAULT 0,
+    depreciation_rate DOUBLE NOT NULL DEFAULT 0,
+    final_price DOUBLE NOT NULL DEFAULT 0,
+    evaluation_report TEXT NULL,
+    images TEXT NULL,
+    status VARCHAR(32) NOT NULL DEFAULT 'pending',
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    deleted_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    UNIQUE KEY idx_evaluations_order_id (order_id),
+    KEY idx_evaluations_evaluator_id (evaluator_id),
+    KEY idx_evaluations_deleted_at (deleted_at),
+    CONSTRAINT fk_evaluations_order FOREIGN KEY (order_id) REFERENCES recycle_orders (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
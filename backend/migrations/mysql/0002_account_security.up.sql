@@ -0,0 +1,58 @@
+ALTER TABLE users
+    ADD COLUMN token_version BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    ADD COLUMN totp_secret VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN totp_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN totp_last_step BIGINT NOT NULL DEFAULT 0;
+
+CREATE TABLE password_reset_tokens (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    user_id BIGINT UNSIGNED NOT NULL,
+    channel VARCHAR(16) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL,
+    attempts BIGINT NOT NULL DEFAULT 0,
+    expires_at DATETIME(3) NULL,
+    used_at DATETIME(3) NULL,
+    created_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    UNIQUE KEY idx_password_reset_tokens_token_hash (token_hash),
+    KEY idx_password_reset_tokens_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE user_identities (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    user_id BIGINT UNSIGNED NOT NULL,
+    provider VARCHAR(32) NOT NULL,
+    open_id VARCHAR(128) NOT NULL,
+    union_id VARCHAR(128) NOT NULL DEFAULT '',
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    UNIQUE KEY idx_identity_provider_openid (provider, open_id),
+    KEY idx_user_identities_user_id (user_id),
+    KEY idx_user_identities_union_id (union_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE recovery_codes (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    user_id BIGINT UNSIGNED NOT NULL,
+    code_hash VARCHAR(64) NOT NULL,
+    used_at DATETIME(3) NULL,
+    created_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    KEY idx_recovery_codes_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE login_attempts (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    username VARCHAR(64) NOT NULL DEFAULT '',
+    user_id BIGINT UNSIGNED NULL,
+    ip VARCHAR(64) NOT NULL DEFAULT '',
+    user_agent TEXT NULL,
+    reason VARCHAR(32) NOT NULL DEFAULT '',
+    created_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    KEY idx_login_attempts_username (username),
+    KEY idx_login_attempts_user_id (user_id),
+    KEY idx_login_attempts_ip (ip),
+    KEY idx_login_attempts_created_at (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,23 @@
+CREATE TABLE audit_logs (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    actor_id BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    actor_name VARCHAR(64) NOT NULL DEFAULT '',
+    actor_role VARCHAR(32) NOT NULL DEFAULT '',
+    action VARCHAR(64) NOT NULL,
+    entity_type VARCHAR(32) NOT NULL,
+    entity_id BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    `before` MEDIUMTEXT NULL,
+    `after` MEDIUMTEXT NULL,
+    diff MEDIUMTEXT NULL,
+    ip VARCHAR(64) NOT NULL DEFAULT '',
+    user_agent TEXT NULL,
+    method VARCHAR(10) NOT NULL DEFAULT '',
+    path VARCHAR(512) NOT NULL DEFAULT '',
+    request_id VARCHAR(64) NOT NULL DEFAULT '',
+    created_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    KEY idx_audit_logs_actor_id (actor_id),
+    KEY idx_audit_logs_action (action),
+    KEY idx_audit_entity (entity_type, entity_id),
+    KEY idx_audit_logs_created_at (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
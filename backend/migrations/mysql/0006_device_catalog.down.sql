@@ -0,0 +1,11 @@
+ALTER TABLE devices
+    DROP FOREIGN KEY fk_devices_model,
+    DROP FOREIGN KEY fk_devices_brand,
+    DROP KEY idx_devices_model_id,
+    DROP KEY idx_devices_brand_id,
+    DROP COLUMN model_id,
+    DROP COLUMN brand_id;
+
+DROP TABLE IF EXISTS device_models;
+DROP TABLE IF EXISTS device_series;
+DROP TABLE IF EXISTS brands;
@@ -0,0 +1,98 @@
+-- 品牌、系列、型号，别名为JSON数组，匹配时不区分大小写
+CREATE TABLE brands (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    name VARCHAR(64) NOT NULL,
+    aliases TEXT NULL,
+    logo VARCHAR(512) NOT NULL DEFAULT '',
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    UNIQUE KEY idx_brands_name (name)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE device_series (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    brand_id BIGINT UNSIGNED NOT NULL,
+    name VARCHAR(128) NOT NULL,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    UNIQUE KEY idx_device_series_brand_name (brand_id, name),
+    CONSTRAINT fk_device_series_brand FOREIGN KEY (brand_id) REFERENCES brands (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE device_models (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    brand_id BIGINT UNSIGNED NOT NULL,
+    series_id BIGINT UNSIGNED NULL,
+    name VARCHAR(128) NOT NULL,
+    aliases TEXT NULL,
+    launch_year BIGINT NOT NULL DEFAULT 0,
+    msrp DOUBLE NOT NULL DEFAULT 0,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    UNIQUE KEY idx_device_models_brand_name (brand_id, name),
+    KEY idx_device_models_series_id (series_id),
+    CONSTRAINT fk_device_models_brand FOREIGN KEY (brand_id) REFERENCES brands (id),
+    CONSTRAINT fk_device_models_series FOREIGN KEY (series_id) REFERENCES device_series (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+ALTER TABLE devices
+    ADD COLUMN brand_id BIGINT UNSIGNED NULL,
+    ADD COLUMN model_id BIGINT UNSIGNED NULL,
+    ADD KEY idx_devices_brand_id (brand_id),
+    ADD KEY idx_devices_model_id (model_id),
+    ADD CONSTRAINT fk_devices_brand FOREIGN KEY (brand_id) REFERENCES brands (id),
+    ADD CONSTRAINT fk_devices_model FOREIGN KEY (model_id) REFERENCES device_models (id);
+
+-- 预置常见品牌
+INSERT INTO brands (name, aliases, logo, created_at, updated_at) VALUES
+    ('Apple', '["苹果"]', '', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP),
+    ('Huawei', '["华为"]', '', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP),
+    ('Honor', '["荣耀"]', '', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP),
+    ('Xiaomi', '["小米"]', '', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP),
+    ('Lenovo', '["联想"]', '', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP),
+    ('Dell', '["戴尔"]', '', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP),
+    ('HP', '["惠普"]', '', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP),
+    ('ASUS', '["华硕"]', '', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP),
+    ('Acer', '["宏碁"]', '', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP),
+    ('Microsoft', '["微软"]', '', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP),
+    ('Samsung', '["三星"]', '', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP),
+    ('Sony', '["索尼"]', '', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP),
+    ('OPPO', '[]', '', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP),
+    ('vivo', '[]', '', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP);
+
+-- 现有设备的品牌：与品牌名称或别名相同（不区分大小写）的归入该品牌，其余按名称新建品牌
+INSERT INTO brands (name, aliases, logo, created_at, updated_at)
+SELECT MIN(TRIM(d.brand)), '[]', '', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP
+FROM devices d
+WHERE TRIM(d.brand) <> '' AND NOT EXISTS (
+    SELECT 1 FROM brands b
+    WHERE LOWER(b.name) = LOWER(TRIM(d.brand)) OR LOWER(b.aliases) LIKE CONCAT('%"', LOWER(TRIM(d.brand)), '"%')
+)
+GROUP BY LOWER(TRIM(d.brand));
+
+UPDATE devices SET brand_id = (
+    SELECT b.id FROM brands b
+    WHERE LOWER(b.name) = LOWER(TRIM(devices.brand)) OR LOWER(b.aliases) LIKE CONCAT('%"', LOWER(TRIM(devices.brand)), '"%')
+    ORDER BY b.id LIMIT 1
+)
+WHERE TRIM(brand) <> '';
+UPDATE devices SET brand = (SELECT b.name FROM brands b WHERE b.id = devices.brand_id)
+WHERE brand_id IS NOT NULL;
+
+-- 型号按品牌和名称（不区分大小写）新建
+INSERT INTO device_models (brand_id, name, aliases, launch_year, msrp, created_at, updated_at)
+SELECT brand_id, MIN(TRIM(model)), '[]', 0, 0, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP
+FROM devices
+WHERE brand_id IS NOT NULL AND TRIM(model) <> ''
+GROUP BY brand_id, LOWER(TRIM(model));
+
+UPDATE devices SET model_id = (
+    SELECT m.id FROM device_models m
+    WHERE m.brand_id = devices.brand_id AND LOWER(m.name) = LOWER(TRIM(devices.model))
+)
+WHERE brand_id IS NOT NULL AND TRIM(model) <> '';
+UPDATE devices SET model = (SELECT m.name FROM device_models m WHERE m.id = devices.model_id)
+WHERE model_id IS NOT NULL;
@@ -0,0 +1,41 @@
+-- 设备分类，devices.category保存分类代码。spec_fields为规格字段定义（JSON数组），
+-- pricing_*为定价参数，为空时沿用上级分类的设置
+CREATE TABLE categories (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    code VARCHAR(32) NOT NULL,
+    parent_id BIGINT UNSIGNED NULL,
+    name VARCHAR(64) NOT NULL,
+    icon VARCHAR(512) NOT NULL DEFAULT '',
+    sort_order BIGINT NOT NULL DEFAULT 0,
+    spec_fields TEXT NULL,
+    pricing_annual_depreciation DOUBLE NULL,
+    pricing_max_depreciation DOUBLE NULL,
+    pricing_min_price_ratio DOUBLE NULL,
+    pricing_default_base_price DOUBLE NULL,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    UNIQUE KEY idx_categories_code (code),
+    KEY idx_categories_parent_id (parent_id),
+    CONSTRAINT fk_categories_parent FOREIGN KEY (parent_id) REFERENCES categories (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 预置分类，定价参数与原先的固定规则相同：每年折旧10%，最多折旧80%，不低于基础价格的10%
+INSERT INTO categories (code, parent_id, name, icon, sort_order, spec_fields, pricing_annual_depreciation, pricing_max_depreciation, pricing_min_price_ratio, created_at, updated_at) VALUES
+    ('computer', NULL, '电脑', '', 1, '[{"key":"cpu","label":"处理器"},{"key":"memory","label":"内存"},{"key":"storage","label":"存储"},{"key":"graphics","label":"显卡"},{"key":"screen","label":"屏幕"}]', 0.1, 0.8, 0.1, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP),
+    ('mobile', NULL, '手机平板', '', 2, '[{"key":"cpu","label":"处理器"},{"key":"memory","label":"运行内存"},{"key":"storage","label":"存储"},{"key":"screen","label":"屏幕"}]', 0.1, 0.8, 0.1, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP);
+
+INSERT INTO categories (code, parent_id, name, icon, sort_order, spec_fields, created_at, updated_at)
+SELECT 'laptop', id, '笔记本电脑', '', 1, '[]', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP FROM categories WHERE code = 'computer';
+INSERT INTO categories (code, parent_id, name, icon, sort_order, spec_fields, created_at, updated_at)
+SELECT 'desktop', id, '台式机', '', 2, '[]', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP FROM categories WHERE code = 'computer';
+INSERT INTO categories (code, parent_id, name, icon, sort_order, spec_fields, created_at, updated_at)
+SELECT 'phone', id, '手机', '', 1, '[]', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP FROM categories WHERE code = 'mobile';
+INSERT INTO categories (code, parent_id, name, icon, sort_order, spec_fields, created_at, updated_at)
+SELECT 'tablet', id, '平板电脑', '', 2, '[]', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP FROM categories WHERE code = 'mobile';
+
+-- 现有设备中的其他分类按代码新建为顶级分类
+INSERT INTO categories (code, parent_id, name, icon, sort_order, spec_fields, created_at, updated_at)
+SELECT DISTINCT d.category, NULL, d.category, '', 100, '[]', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP
+FROM devices d
+WHERE d.category <> '' AND NOT EXISTS (SELECT 1 FROM categories c WHERE c.code = d.category);
@@ -0,0 +1,11 @@
+-- 结构化规格，由cpu、memory、storage、screen文本解析，无法识别的为NULL。
+-- 已有设备使用 sync-specs 命令解析填充
+ALTER TABLE devices
+    ADD COLUMN spec_memory_gb BIGINT NULL,
+    ADD COLUMN spec_storage_gb BIGINT NULL,
+    ADD COLUMN spec_storage_type VARCHAR(16) NOT NULL DEFAULT '',
+    ADD COLUMN spec_cpu_family VARCHAR(32) NOT NULL DEFAULT '',
+    ADD COLUMN spec_cpu_generation BIGINT NULL,
+    ADD COLUMN spec_screen_inches DOUBLE NULL,
+    ADD KEY idx_devices_spec_memory_gb (spec_memory_gb),
+    ADD KEY idx_devices_spec_storage_gb (spec_storage_gb);
@@ -0,0 +1,3 @@
+ALTER TABLE password_reset_tokens
+    DROP KEY idx_password_reset_tokens_token_hash,
+    ADD UNIQUE KEY idx_password_reset_tokens_token_hash (token_hash);
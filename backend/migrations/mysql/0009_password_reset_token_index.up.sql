@@ -0,0 +1,4 @@
+-- 短信验证码只有6位，已使用的记录会保留，哈希可能重复，令牌哈希改为普通索引
+ALTER TABLE password_reset_tokens
+    DROP KEY idx_password_reset_tokens_token_hash,
+    ADD KEY idx_password_reset_tokens_token_hash (token_hash);
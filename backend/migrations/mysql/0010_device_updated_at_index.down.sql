@@ -0,0 +1 @@
+ALTER TABLE devices DROP KEY idx_devices_updated_at;
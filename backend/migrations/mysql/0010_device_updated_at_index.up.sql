@@ -0,0 +1,2 @@
+-- 设备列表的Last-Modified和搜索建议按最近更新时间查询
+ALTER TABLE devices ADD KEY idx_devices_updated_at (updated_at);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS evaluations;
+DROP TABLE IF EXISTS recycle_orders;
+DROP TABLE IF EXISTS devices;
+DROP TABLE IF EXISTS users;
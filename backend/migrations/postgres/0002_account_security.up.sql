@@ -0,0 +1,54 @@
+ALTER TABLE users
+    ADD COLUMN token_version BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN totp_secret VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN totp_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN totp_last_step BIGINT NOT NULL DEFAULT 0;
+
+CREATE TABLE password_reset_tokens (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    channel VARCHAR(16) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL,
+    attempts BIGINT NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP(3) NULL,
+    used_at TIMESTAMP(3) NULL,
+    created_at TIMESTAMP(3) NULL
+);
+CREATE UNIQUE INDEX idx_password_reset_tokens_token_hash ON password_reset_tokens (token_hash);
+CREATE INDEX idx_password_reset_tokens_user_id ON password_reset_tokens (user_id);
+
+CREATE TABLE user_identities (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    provider VARCHAR(32) NOT NULL,
+    open_id VARCHAR(128) NOT NULL,
+    union_id VARCHAR(128) NOT NULL DEFAULT '',
+    created_at TIMESTAMP(3) NULL,
+    updated_at TIMESTAMP(3) NULL
+);
+CREATE UNIQUE INDEX idx_identity_provider_openid ON user_identities (provider, open_id);
+CREATE INDEX idx_user_identities_user_id ON user_identities (user_id);
+CREATE INDEX idx_user_identities_union_id ON user_identities (union_id);
+
+CREATE TABLE recovery_codes (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    code_hash VARCHAR(64) NOT NULL,
+    used_at TIMESTAMP(3) NULL,
+    created_at TIMESTAMP(3) NULL
+);
+CREATE INDEX idx_recovery_codes_user_id ON recovery_codes (user_id);
+
+CREATE TABLE login_attempts (
+    id BIGSERIAL PRIMARY KEY,
+    username VARCHAR(64) NOT NULL DEFAULT '',
+    user_id BIGINT NULL,
+    ip VARCHAR(64) NOT NULL DEFAULT '',
+    user_agent TEXT NULL,
+    reason VARCHAR(32) NOT NULL DEFAULT '',
+    created_at TIMESTAMP(3) NULL
+);
+CREATE INDEX idx_login_attempts_username ON login_attempts (username);
+CREATE INDEX idx_login_attempts_user_id ON login_attempts (user_id);
+CREATE INDEX idx_login_attempts_ip ON login_attempts (ip);
+CREATE INDEX idx_login_attempts_created_at ON login_attempts (created_at);
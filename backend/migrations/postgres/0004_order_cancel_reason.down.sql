@@ -0,0 +1,2 @@
+ALTER TABLE recycle_orders
+    DROP COLUMN cancel_reason;
@@ -0,0 +1,9 @@
+CREATE TABLE device_search_terms (
+    device_id BIGINT NOT NULL,
+    term VARCHAR(64) NOT NULL,
+    weight INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (device_id, term),
+    CONSTRAINT fk_device_search_terms_device FOREIGN KEY (device_id) REFERENCES devices (id) ON DELETE CASCADE
+);
+-- varchar_pattern_ops 使前缀匹配（LIKE 'abc%'）可以使用索引
+CREATE INDEX idx_device_search_terms_term ON device_search_terms (term varchar_pattern_ops);
@@ -0,0 +1,9 @@
+ALTER TABLE devices
+    DROP CONSTRAINT fk_devices_model,
+    DROP CONSTRAINT fk_devices_brand,
+    DROP COLUMN model_id,
+    DROP COLUMN brand_id;
+
+DROP TABLE IF EXISTS device_models;
+DROP TABLE IF EXISTS device_series;
+DROP TABLE IF EXISTS brands;
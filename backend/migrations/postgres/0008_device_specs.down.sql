@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_devices_spec_storage_gb;
+DROP INDEX IF EXISTS idx_devices_spec_memory_gb;
+ALTER TABLE devices
+    DROP COLUMN spec_screen_inches,
+    DROP COLUMN spec_cpu_generation,
+    DROP COLUMN spec_cpu_family,
+    DROP COLUMN spec_storage_type,
+    DROP COLUMN spec_storage_gb,
+    DROP COLUMN spec_memory_gb;
@@ -0,0 +1,94 @@
+CREATE TABLE users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    username VARCHAR(64) NOT NULL,
+    password VARCHAR(255) NOT NULL,
+    phone VARCHAR(32) NULL,
+    email VARCHAR(191) NULL,
+    real_name VARCHAR(64) NOT NULL DEFAULT '',
+    avatar VARCHAR(512) NOT NULL DEFAULT '',
+    role VARCHAR(32) NOT NULL DEFAULT 'user',
+    status VARCHAR(32) NOT NULL DEFAULT 'active',
+    created_at DATETIME NULL,
+    updated_at DATETIME NULL,
+    deleted_at DATETIME NULL
+);
+CREATE UNIQUE INDEX idx_users_username ON users (username);
+CREATE UNIQUE INDEX idx_users_phone ON users (phone);
+CREATE UNIQUE INDEX idx_users_email ON users (email);
+CREATE INDEX idx_users_deleted_at ON users (deleted_at);
+
+CREATE TABLE devices (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(191) NOT NULL,
+    brand VARCHAR(64) NOT NULL DEFAULT '',
+    model VARCHAR(128) NOT NULL DEFAULT '',
+    category VARCHAR(32) NOT NULL DEFAULT '',
+    cpu VARCHAR(128) NOT NULL DEFAULT '',
+    memory VARCHAR(64) NOT NULL DEFAULT '',
+    storage VARCHAR(64) NOT NULL DEFAULT '',
+    graphics VARCHAR(128) NOT NULL DEFAULT '',
+    screen VARCHAR(128) NOT NULL DEFAULT '',
+    "condition" VARCHAR(32) NOT NULL DEFAULT '',
+    year_bought BIGINT NOT NULL DEFAULT 0,
+    base_price REAL NOT NULL DEFAULT 0,
+    description TEXT NULL,
+    images TEXT NULL,
+    status VARCHAR(32) NOT NULL DEFAULT 'active',
+    created_at DATETIME NULL,
+    updated_at DATETIME NULL,
+    deleted_at DATETIME NULL
+);
+CREATE INDEX idx_devices_category ON devices (category);
+CREATE INDEX idx_devices_brand ON devices (brand);
+CREATE INDEX idx_devices_status ON devices (status);
+CREATE INDEX idx_devices_deleted_at ON devices (deleted_at);
+
+CREATE TABLE recycle_orders (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id BIGINT NOT NULL,
+    device_id BIGINT NOT NULL,
+    order_no VARCHAR(64) NOT NULL,
+    contact_name VARCHAR(64) NOT NULL,
+    contact_phone VARCHAR(32) NOT NULL,
+    pickup_address VARCHAR(512) NOT NULL,
+    pickup_time DATETIME NULL,
+    device_info TEXT NULL,
+    images TEXT NULL,
+    estimated_price REAL NOT NULL DEFAULT 0,
+    final_price REAL NULL,
+    status VARCHAR(32) NOT NULL DEFAULT 'pending',
+    remark TEXT NULL,
+    created_at DATETIME NULL,
+    updated_at DATETIME NULL,
+    deleted_at DATETIME NULL,
+    CONSTRAINT fk_recycle_orders_user FOREIGN KEY (user_id) REFERENCES users (id),
+    CONSTRAINT fk_recycle_orders_device FOREIGN KEY (device_id) REFERENCES devices (id)
+);
+CREATE UNIQUE INDEX idx_recycle_orders_order_no ON recycle_orders (order_no);
+CREATE INDEX idx_recycle_orders_user_id ON recycle_orders (user_id);
+CREATE INDEX idx_recycle_orders_device_id ON recycle_orders (device_id);
+CREATE INDEX idx_recycle_orders_status ON recycle_orders (status);
+CREATE INDEX idx_recycle_orders_deleted_at ON recycle_orders (deleted_at);
+
+CREATE TABLE evaluations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    order_id BIGINT NOT NULL,
+    evaluator_id BIGINT NULL,
+    appearance_score BIGINT NOT NULL DEFAULT 0,
+    function_score BIGINT NOT NULL DEFAULT 0,
+    performance_score BIGINT NOT NULL DEFAULT 0,
+    overall_score REAL NOT NULL DEFAULT 0,
+    market_price REAL NOT NULL DEFAULT 0,
+    depreciation_rate REAL NOT NULL DEFAULT 0,
+    final_price REAL NOT NULL DEFAULT 0,
+    evaluation_report TEXT NULL,
+    images TEXT NULL,
+    status VARCHAR(32) NOT NULL DEFAULT 'pending',
+    created_at DATETIME NULL,
+    updated_at DATETIME NULL,
+    deleted_at DATETIME NULL,
+    CONSTRAINT fk_evaluations_order FOREIGN KEY (order_id) REFERENCES recycle_orders (id)
+);
+CREATE UNIQUE INDEX idx_evaluations_order_id ON evaluations (order_id);
+CREATE INDEX idx_evaluations_evaluator_id ON evaluations (evaluator_id);
+CREATE INDEX idx_evaluations_deleted_at ON evaluations (deleted_at);
@@ -0,0 +1 @@
+ALTER TABLE recycle_orders DROP COLUMN cancel_reason;
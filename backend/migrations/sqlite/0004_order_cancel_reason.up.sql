@@ -0,0 +1 @@
+ALTER TABLE recycle_orders ADD COLUMN cancel_reason VARCHAR(32) NOT NULL DEFAULT '';
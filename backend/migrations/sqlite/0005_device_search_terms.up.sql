@@ -0,0 +1,8 @@
+CREATE TABLE device_search_terms (
+    device_id INTEGER NOT NULL,
+    term VARCHAR(64) NOT NULL,
+    weight INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (device_id, term),
+    CONSTRAINT fk_device_search_terms_device FOREIGN KEY (device_id) REFERENCES devices (id) ON DELETE CASCADE
+);
+CREATE INDEX idx_device_search_terms_term ON device_search_terms (term);
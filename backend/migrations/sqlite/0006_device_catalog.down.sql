@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_devices_model_id;
+DROP INDEX IF EXISTS idx_devices_brand_id;
+ALTER TABLE devices DROP COLUMN model_id;
+ALTER TABLE devices DROP COLUMN brand_id;
+
+DROP TABLE IF EXISTS device_models;
+DROP TABLE IF EXISTS device_series;
+DROP TABLE IF EXISTS brands;
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_devices_spec_storage_gb;
+DROP INDEX IF EXISTS idx_devices_spec_memory_gb;
+ALTER TABLE devices DROP COLUMN spec_screen_inches;
+ALTER TABLE devices DROP COLUMN spec_cpu_generation;
+ALTER TABLE devices DROP COLUMN spec_cpu_family;
+ALTER TABLE devices DROP COLUMN spec_storage_type;
+ALTER TABLE devices DROP COLUMN spec_storage_gb;
+ALTER TABLE devices DROP COLUMN spec_memory_gb;
@@ -0,0 +1,2 @@
+DROP INDEX idx_password_reset_tokens_token_hash;
+CREATE UNIQUE INDEX idx_password_reset_tokens_token_hash ON password_reset_tokens (token_hash);
@@ -0,0 +1,3 @@
+-- 短信验证码只有6位，已使用的记录会保留，哈希可能重复，令牌哈希改为普通索引
+DROP INDEX idx_password_reset_tokens_token_hash;
+CREATE INDEX idx_password_reset_tokens_token_hash ON password_reset_tokens (token_hash);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_devices_updated_at;
@@ -0,0 +1,2 @@
+-- 设备列表的Last-Modified和搜索建议按最近更新时间查询
+CREATE INDEX idx_devices_updated_at ON devices (updated_at);
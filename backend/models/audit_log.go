@@ -0,0 +1,38 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrAuditLogImmutable = errors.New("审计日志不允许修改或删除")
+
+// 管理操作审计日志，只允许新增
+type AuditLog struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	ActorID    uint      `json:"actor_id" gorm:"index"`
+	ActorName  string    `json:"actor_name" gorm:"size:64"`
+	ActorRole  string    `json:"actor_role" gorm:"size:32"`
+	Action     string    `json:"action" gorm:"size:64;index"`                       // 如 device.update、order.update
+	EntityType string    `json:"entity_type" gorm:"size:32;index:idx_audit_entity"` // device, order, evaluation, user
+	EntityID   uint      `json:"entity_id" gorm:"index:idx_audit_entity"`
+	Before     string    `json:"before" gorm:"type:text"` // 修改前数据，JSON字符串
+	After      string    `json:"after" gorm:"type:text"`  // 修改后数据，JSON字符串
+	Diff       string    `json:"diff" gorm:"type:text"`   // 变更字段 {"字段": [旧值, 新值]}
+	IP         string    `json:"ip" gorm:"size:64"`
+	UserAgent  string    `json:"user_agent"`
+	Method     string    `json:"method" gorm:"size:10"`
+	Path       string    `json:"path"`
+	RequestID  string    `json:"request_id" gorm:"size:64"`
+	CreatedAt  time.Time `json:"created_at" gorm:"index"`
+}
+
+func (a *AuditLog) BeforeUpdate(tx *gorm.DB) error {
+	return ErrAuditLogImmutable
+}
+
+func (a *AuditLog) BeforeDelete(tx *gorm.DB) error {
+	return ErrAuditLogImmutable
+}
@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// 品牌，设备的品牌名称统一为Name，别名（如中文名）用于识别用户输入，不区分大小写
+type Brand struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name" gorm:"not null"`
+	Aliases   []string  `json:"aliases" gorm:"serializer:json"`
+	Logo      string    `json:"logo"` // 图标URL
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// 品牌下的产品系列，如 MacBook Pro、ThinkPad
+type DeviceSeries struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	BrandID   uint      `json:"brand_id" gorm:"not null"`
+	Name      string    `json:"name" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// 型号，名称在品牌内唯一，别名同样不区分大小写
+type DeviceModel struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	BrandID    uint      `json:"brand_id" gorm:"not null"`
+	SeriesID   *uint     `json:"series_id"`
+	Name       string    `json:"name" gorm:"not null"`
+	Aliases    []string  `json:"aliases" gorm:"serializer:json"`
+	LaunchYear int       `json:"launch_year"` // 上市年份，0表示未知
+	MSRP       float64   `json:"msrp"`        // 首发官方售价，0表示未知
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type BrandRequest struct {
+	Name    string   `json:"name" binding:"required,max=64"`
+	Aliases []string `json:"aliases" binding:"max=20,dive,required,max=64"`
+	Logo    string   `json:"logo" binding:"omitempty,url,max=512"`
+}
+
+type DeviceSeriesRequest struct {
+	BrandID uint   `json:"brand_id" binding:"required"`
+	Name    string `json:"name" binding:"required,max=128"`
+}
+
+type DeviceModelRequest struct {
+	BrandID    uint     `json:"brand_id" binding:"required"`
+	SeriesID   *uint    `json:"series_id"`
+	Name       string   `json:"name" binding:"required,max=128"`
+	Aliases    []string `json:"aliases" binding:"max=20,dive,required,max=64"`
+	LaunchYear int      `json:"launch_year" binding:"omitempty,min=1970,max=2100"`
+	MSRP       float64  `json:"msrp" binding:"min=0"`
+}
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// 设备分类，设备的category字段保存分类代码。子分类沿用上级分类的规格字段和定价参数
+type Category struct {
+	ID         uint            `json:"id" gorm:"primaryKey"`
+	Code       string          `json:"code" gorm:"not null"` // 分类代码，如 laptop
+	ParentID   *uint           `json:"parent_id"`
+	Name       string          `json:"name" gorm:"not null"` // 显示名称
+	Icon       string          `json:"icon"`                 // 图标URL
+	SortOrder  int             `json:"sort_order"`
+	SpecFields []SpecField     `json:"spec_fields" gorm:"serializer:json"`
+	Pricing    CategoryPricing `json:"pricing" gorm:"embedded;embeddedPrefix:pricing_"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+}
+
+// 分类使用的规格字段，key为设备上的规格字段。
+// 结构化数值：memory、storage为容量（GB），screen为尺寸（英寸），cpu为处理器代数，graphics没有数值
+type SpecField struct {
+	Key      string `json:"key" binding:"required,oneof=cpu memory storage graphics screen"`
+	Label    string `json:"label" binding:"required,max=32"` // 显示名称
+	Required bool   `json:"required"`                        // 创建设备时必须填写，有数值的字段还必须能识别出数值
+	// 数值的允许范围，为空表示不限
+	Min *float64 `json:"min" binding:"omitempty,min=0"`
+	Max *float64 `json:"max" binding:"omitempty,min=0"`
+	// 按默认基础价格定价时，数值每比基准值多1，基础价格增加PricePerUnit（少则减少）
+	Baseline     *float64 `json:"baseline" binding:"omitempty,min=0"`
+	PricePerUnit float64  `json:"price_per_unit"`
+}
+
+// 分类的定价参数，为空时沿用上级分类的设置，顶级分类为空时使用默认值
+type CategoryPricing struct {
+	AnnualDepreciation *float64 `json:"annual_depreciation" binding:"omitempty,min=0,max=1"` // 每年折旧率，默认0.1
+	MaxDepreciation    *float64 `json:"max_depreciation" binding:"omitempty,min=0,max=1"`    // 最大折旧率，默认0.8
+	MinPriceRatio      *float64 `json:"min_price_ratio" binding:"omitempty,min=0,max=1"`     // 预估价格不低于基础价格的比例，默认0.1
+	DefaultBasePrice   *float64 `json:"default_base_price" binding:"omitempty,min=0"`        // 创建设备未填写基础价格时使用
+}
+
+type CategoryRequest struct {
+	Code       string          `json:"code" binding:"required,max=32"`
+	ParentID   *uint           `json:"parent_id"`
+	Name       string          `json:"name" binding:"required,max=64"`
+	Icon       string          `json:"icon" binding:"omitempty,url,max=512"`
+	SortOrder  int             `json:"sort_order"`
+	SpecFields []SpecField     `json:"spec_fields" binding:"max=20,unique=Key,dive"`
+	Pricing    CategoryPricing `json:"pricing"`
+}
@@ -2,48 +2,135 @@ package models
 
 import (
 	"e-device-recycle-backend/config"
+	"e-device-recycle-backend/logging"
+	"e-device-recycle-backend/migrations"
 	"fmt"
 	"log"
+	"log/slog"
+	"strings"
+	"time"
 
 	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
 var DB *gorm.DB
 
-func InitDB() {
-	cfg := config.GetConfig()
+// 根据配置创建数据库驱动
+func NewDialector(cfg *config.Config) (gorm.Dialector, error) {
+	switch cfg.Database.Driver {
+	case "mysql", "":
+		port := cfg.Database.Port
+		if port == "" {
+			port = "3306"
+		}
+		dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+			cfg.Database.User,
+			cfg.Database.Password,
+			cfg.Database.Host,
+			port,
+			cfg.Database.Name,
+		)
+		return mysql.Open(dsn), nil
+	case "postgres":
+		port := cfg.Database.Port
+		if port == "" {
+			port = "5432"
+		}
+		dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s TimeZone=Asia/Shanghai",
+			cfg.Database.Host,
+			port,
+			cfg.Database.User,
+			cfg.Database.Password,
+			cfg.Database.Name,
+			cfg.Database.SSLMode,
+		)
+		return postgres.Open(dsn), nil
+	case "sqlite":
+		// 内存数据库使用共享缓存，使同一进程内的连接访问同一个库
+		dsn := cfg.Database.Path
+		if dsn == ":memory:" {
+			dsn = "file::memory:?cache=shared"
+		}
+		return sqlite.Open(dsn + sqliteOptions(dsn)), nil
+	default:
+		return nil, fmt.Errorf("不支持的数据库类型: %s", cfg.Database.Driver)
+	}
+}
+
+// 开启外键约束并设置锁等待时间
+func sqliteOptions(dsn string) string {
+	if strings.Contains(dsn, "?") {
+		return "&_foreign_keys=1&_busy_timeout=5000"
+	}
+	return "?_foreign_keys=1&_busy_timeout=5000"
+}
 
-	// 构建数据库连接字符串
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		cfg.DBUser,
-		cfg.DBPass,
-		cfg.DBHost,
-		cfg.DBPort,
-		cfg.DBName,
-	)
+// 打开数据库连接
+func Open(cfg *config.Config, gormConfig *gorm.Config) (*gorm.DB, error) {
+	dialector, err := NewDialector(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := gorm.Open(dialector, gormConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite不支持并发写入，只使用一个连接
+	if db.Dialector.Name() == "sqlite" {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return nil, err
+		}
+		sqlDB.SetMaxOpenConns(1)
+	}
 
+	return db, nil
+}
+
+// 连接数据库
+func ConnectDB() {
 	var err error
-	DB, err = gorm.Open(mysql.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+	cfg := config.GetConfig()
+	DB, err = Open(cfg, &gorm.Config{
+		Logger: logging.NewGormLogger(logging.ParseGormLevel(cfg.Database.LogLevel),
+			time.Duration(cfg.Database.SlowThreshold)*time.Millisecond),
 	})
 
 	if err != nil {
 		log.Fatal("连接数据库失败:", err)
 	}
 
-	// 自动迁移数据库表
-	err = DB.AutoMigrate(
-		&User{},
-		&Device{},
-		&RecycleOrder{},
-		&Evaluation{},
-	)
+	slog.Info("数据库连接成功", "driver", DB.Dialector.Name())
+}
 
+// 连接数据库并检查表结构是否已迁移到最新版本
+func InitDB() {
+	ConnectDB()
+
+	migrator, err := migrations.New(DB)
 	if err != nil {
-		log.Fatal("数据库迁移失败:", err)
+		log.Fatal("加载数据库迁移失败:", err)
 	}
 
-	log.Println("数据库连接成功")
+	required, err := migrator.BaselineRequired()
+	if err != nil {
+		log.Fatal("检查数据库迁移失败:", err)
+	}
+	if required {
+		log.Fatalf("%v，再运行: ./main migrate up", migrations.ErrBaselineRequired)
+	}
+
+	pending, err := migrator.Pending()
+	if err != nil {
+		log.Fatal("检查数据库迁移失败:", err)
+	}
+	if len(pending) > 0 {
+		log.Fatalf("数据库有%d个未执行的迁移（最新版本 %d_%s），请先运行: ./main migrate up",
+			len(pending), pending[len(pending)-1].Version, pending[len(pending)-1].Name)
+	}
 }
@@ -8,15 +8,18 @@ import (
 
 type Device struct {
 	ID          uint           `json:"id" gorm:"primaryKey"`
-	Name        string         `json:"name" gorm:"not null"`           // 设备名称
-	Brand       string         `json:"brand"`                          // 品牌
-	Model       string         `json:"model"`                          // 型号
-	Category    string         `json:"category"`                       // 分类：laptop, desktop, tablet, phone
-	CPU         string         `json:"cpu"`                            // 处理器
-	Memory      string         `json:"memory"`                         // 内存
-	Storage     string         `json:"storage"`                        // 存储
-	Graphics    string         `json:"graphics"`                       // 显卡
-	Screen      string         `json:"screen"`                         // 屏幕
+	Name        string         `json:"name" gorm:"not null"`  // 设备名称
+	Brand       string         `json:"brand"`                 // 品牌，与品牌目录中的名称一致
+	Model       string         `json:"model"`                 // 型号，关联型号目录时与型号名称一致
+	BrandID     *uint          `json:"brand_id" gorm:"index"` // 品牌目录ID
+	ModelID     *uint          `json:"model_id" gorm:"index"` // 型号目录ID，未收录的型号为空
+	Category    string         `json:"category"`              // 分类代码，见分类目录
+	CPU         string         `json:"cpu"`                   // 处理器
+	Memory      string         `json:"memory"`                // 内存
+	Storage     string         `json:"storage"`               // 存储
+	Graphics    string         `json:"graphics"`              // 显卡
+	Screen      string         `json:"screen"`                // 屏幕
+	Specs       DeviceSpecs    `json:"specs" gorm:"embedded;embeddedPrefix:spec_"`
 	Condition   string         `json:"condition"`                      // 成色：excellent, good, fair, poor
 	YearBought  int            `json:"year_bought"`                    // 购买年份
 	BasePrice   float64        `json:"base_price"`                     // 基础回收价格
@@ -24,42 +27,85 @@ type Device struct {
 	Images      string         `json:"images"`                         // 图片URLs，JSON字符串
 	Status      string         `json:"status" gorm:"default:'active'"` // active, inactive
 	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
+	UpdatedAt   time.Time      `json:"updated_at" gorm:"index"` // 设备列表的Last-Modified按此查询
 	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
+// 结构化规格，由规格文本解析，用于过滤和定价；无法识别的数值为空
+type DeviceSpecs struct {
+	MemoryGB      *int     `json:"memory_gb" binding:"omitempty,min=1,max=4096"`        // 内存容量（GB）
+	StorageGB     *int     `json:"storage_gb" binding:"omitempty,min=1,max=1048576"`    // 存储总容量（GB）
+	StorageType   string   `json:"storage_type" binding:"omitempty,oneof=ssd hdd emmc"` // 存储类型
+	CPUFamily     string   `json:"cpu_family" binding:"max=32"`                         // 处理器系列，如 intel-core-i7、amd-ryzen-7、apple-m
+	CPUGeneration *int     `json:"cpu_generation" binding:"omitempty,min=1,max=100"`    // 处理器代数
+	ScreenInches  *float64 `json:"screen_inches" binding:"omitempty,min=1,max=100"`     // 屏幕尺寸（英寸）
+}
+
 type DeviceCreateRequest struct {
-	Name        string  `json:"name" binding:"required"`
-	Brand       string  `json:"brand" binding:"required"`
-	Model       string  `json:"model"`
-	Category    string  `json:"category" binding:"required,oneof=laptop desktop tablet phone"`
-	CPU         string  `json:"cpu"`
-	Memory      string  `json:"memory"`
-	Storage     string  `json:"storage"`
-	Graphics    string  `json:"graphics"`
-	Screen      string  `json:"screen"`
-	Condition   string  `json:"condition" binding:"required,oneof=excellent good fair poor"`
-	YearBought  int     `json:"year_bought" binding:"min=2000,max=2024"`
-	BasePrice   float64 `json:"base_price" binding:"min=0"`
-	Description string  `json:"description"`
-	Images      string  `json:"images"`
+	Name        string      `json:"name" binding:"required"`
+	Brand       string      `json:"brand" binding:"required_without=ModelID"` // 品牌名称或别名
+	Model       string      `json:"model"`                                    // 型号名称或别名
+	ModelID     uint        `json:"model_id"`                                 // 型号目录ID，指定时忽略brand和model
+	Category    string      `json:"category" binding:"required,max=32"`       // 分类代码，必须在分类目录中
+	CPU         string      `json:"cpu"`
+	Memory      string      `json:"memory"`
+	Storage     string      `json:"storage"`
+	Graphics    string      `json:"graphics"`
+	Screen      string      `json:"screen"`
+	Specs       DeviceSpecs `json:"specs"` // 结构化规格，填写的项覆盖从规格文本解析的结果
+	Condition   string      `json:"condition" binding:"required,oneof=excellent good fair poor"`
+	YearBought  int         `json:"year_bought" binding:"min=2000,max=2024"`
+	BasePrice   float64     `json:"base_price" binding:"min=0"` // 为0时使用分类的默认基础价格
+	Description string      `json:"description"`
+	Images      string      `json:"images"`
 }
 
 type DeviceResponse struct {
-	ID          uint    `json:"id"`
-	Name        string  `json:"name"`
-	Brand       string  `json:"brand"`
-	Model       string  `json:"model"`
-	Category    string  `json:"category"`
-	CPU         string  `json:"cpu"`
-	Memory      string  `json:"memory"`
-	Storage     string  `json:"storage"`
-	Graphics    string  `json:"graphics"`
-	Screen      string  `json:"screen"`
-	Condition   string  `json:"condition"`
-	YearBought  int     `json:"year_bought"`
-	BasePrice   float64 `json:"base_price"`
-	Description string  `json:"description"`
-	Images      string  `json:"images"`
-	Status      string  `json:"status"`
+	ID          uint        `json:"id"`
+	Name        string      `json:"name"`
+	Brand       string      `json:"brand"`
+	Model       string      `json:"model"`
+	BrandID     *uint       `json:"brand_id"`
+	ModelID     *uint       `json:"model_id"`
+	Category    string      `json:"category"`
+	CPU         string      `json:"cpu"`
+	Memory      string      `json:"memory"`
+	Storage     string      `json:"storage"`
+	Graphics    string      `json:"graphics"`
+	Screen      string      `json:"screen"`
+	Specs       DeviceSpecs `json:"specs"`
+	Condition   string      `json:"condition"`
+	YearBought  int         `json:"year_bought"`
+	BasePrice   float64     `json:"base_price"`
+	Description string      `json:"description"`
+	Images      string      `json:"images"`
+	Status      string      `json:"status"`
+}
+
+type DeviceSearchRequest struct {
+	Q         string `json:"q" form:"q" binding:"required,max=100"`
+	Category  string `json:"category" form:"category"`
+	Brand     string `json:"brand" form:"brand"`
+	Condition string `json:"condition" form:"condition" binding:"omitempty,oneof=excellent good fair poor"`
+	Page      int    `json:"page" form:"page" binding:"omitempty,min=1"`
+	PageSize  int    `json:"page_size" form:"page_size" binding:"omitempty,min=1,max=50"`
+}
+
+// 搜索结果，highlights为匹配到的字段，匹配部分用<em>标记，其余内容已做HTML转义
+type DeviceSearchHit struct {
+	Device     DeviceResponse    `json:"device"`
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights"`
+}
+
+type DeviceSuggestRequest struct {
+	Q     string `json:"q" form:"q" binding:"required,max=50"`
+	Limit int    `json:"limit" form:"limit" binding:"omitempty,min=1,max=20"`
+}
+
+// 搜索框补全候选，popularity为相关设备的订单数
+type DeviceSuggestion struct {
+	Text       string `json:"text"`
+	Type       string `json:"type"`
+	Popularity int64  `json:"popularity"`
 }
@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+)
+
+// 登录失败审计记录
+type LoginAttempt struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Username  string    `json:"username" gorm:"size:64;index"`
+	UserID    *uint     `json:"user_id" gorm:"index"` // 用户不存在时为空
+	IP        string    `json:"ip" gorm:"size:64;index"`
+	UserAgent string    `json:"user_agent"`
+	Reason    string    `json:"reason"` // user_not_found, wrong_password, invalid_2fa, locked
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
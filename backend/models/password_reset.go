@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+)
+
+type PasswordResetToken struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"index;not null"`
+	Channel   string     `json:"channel" gorm:"not null"`         // email, sms
+	TokenHash string     `json:"-" gorm:"index;size:64;not null"` // 令牌哈希，不保存明文；短信验证码可能重复
+	Attempts  int        `json:"attempts" gorm:"default:0"`       // 短信验证码错误次数
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type ChangePasswordRequest struct {
+	OldPassword string `json:"old_password" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
+
+type ForgotPasswordRequest struct {
+	Channel string `json:"channel" binding:"required,oneof=email sms"`
+	Email   string `json:"email" binding:"omitempty,email"`
+	Phone   string `json:"phone"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token"` // 邮件链接中的令牌
+	Phone       string `json:"phone"` // 短信方式：手机号+验证码
+	Code        string `json:"code"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
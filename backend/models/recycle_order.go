@@ -21,6 +21,7 @@ type RecycleOrder struct {
 	FinalPrice     *float64       `json:"final_price"`                          // 最终价格
 	Status         string         `json:"status" gorm:"default:'pending'"`      // pending, confirmed, picked_up, evaluated, completed, cancelled
 	Remark         string         `json:"remark"`                               // 备注
+	CancelReason   string         `json:"cancel_reason"`                        // 取消原因，见CancelReason常量
 	CreatedAt      time.Time      `json:"created_at"`
 	UpdatedAt      time.Time      `json:"updated_at"`
 	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
@@ -31,6 +32,15 @@ type RecycleOrder struct {
 	Evaluation *Evaluation `json:"evaluation,omitempty" gorm:"foreignKey:OrderID"`
 }
 
+// 取消原因：用户取消时选择，管理员将订单改为已取消时为admin
+const (
+	CancelReasonChangedMind   = "changed_mind"   // 不想卖了
+	CancelReasonPriceTooLow   = "price_too_low"  // 估价太低
+	CancelReasonSoldElsewhere = "sold_elsewhere" // 已在别处出售
+	CancelReasonOther         = "other"
+	CancelReasonAdmin         = "admin"
+)
+
 type RecycleOrderCreateRequest struct {
 	DeviceID      uint       `json:"device_id" binding:"required"`
 	ContactName   string     `json:"contact_name" binding:"required"`
@@ -49,6 +59,10 @@ type RecycleOrderUpdateRequest struct {
 	PickupTime *time.Time `json:"pickup_time"`
 }
 
+type RecycleOrderCancelRequest struct {
+	Reason string `json:"reason" binding:"omitempty,oneof=changed_mind price_too_low sold_elsewhere other"`
+}
+
 type RecycleOrderResponse struct {
 	ID             uint                `json:"id"`
 	UserID         uint                `json:"user_id"`
@@ -64,6 +78,7 @@ type RecycleOrderResponse struct {
 	FinalPrice     *float64            `json:"final_price"`
 	Status         string              `json:"status"`
 	Remark         string              `json:"remark"`
+	CancelReason   string              `json:"cancel_reason"`
 	CreatedAt      time.Time           `json:"created_at"`
 	User           *UserResponse       `json:"user,omitempty"`
 	Device         *DeviceResponse     `json:"device,omitempty"`
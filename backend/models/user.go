@@ -7,18 +7,22 @@ import (
 )
 
 type User struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	Username  string         `json:"username" gorm:"uniqueIndex;not null"`
-	Password  string         `json:"-" gorm:"not null"` // 密码不返回给前端
-	Phone     string         `json:"phone" gorm:"unique"`
-	Email     string         `json:"email" gorm:"unique"`
-	RealName  string         `json:"real_name"`
-	Avatar    string         `json:"avatar"`
-	Role      string         `json:"role" gorm:"default:'user'"`     // user, admin
-	Status    string         `json:"status" gorm:"default:'active'"` // active, banned
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	ID           uint           `json:"id" gorm:"primaryKey"`
+	Username     string         `json:"username" gorm:"uniqueIndex;not null"`
+	Password     string         `json:"-" gorm:"not null"` // 密码不返回给前端
+	Phone        string         `json:"phone" gorm:"unique"`
+	Email        string         `json:"email" gorm:"unique"`
+	RealName     string         `json:"real_name"`
+	Avatar       string         `json:"avatar"`
+	Role         string         `json:"role" gorm:"default:'user'"`     // user, evaluator, admin
+	Status       string         `json:"status" gorm:"default:'active'"` // active, banned
+	TokenVersion uint           `json:"-" gorm:"default:0"`             // 令牌版本，修改密码后递增使旧令牌失效
+	TOTPSecret   string         `json:"-"`                              // 两步验证密钥
+	TOTPEnabled  bool           `json:"totp_enabled" gorm:"default:false"`
+	TOTPLastStep int64          `json:"-" gorm:"default:0"` // 最近一次使用的时间步，防止验证码重放
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
 
 	// 关联
 	RecycleOrders []RecycleOrder `json:"recycle_orders,omitempty" gorm:"foreignKey:UserID"`
@@ -46,4 +50,6 @@ type UserResponse struct {
 	Avatar   string `json:"avatar"`
 	Role     string `json:"role"`
 	Status   string `json:"status"`
+
+	TOTPEnabled bool `json:"totp_enabled"` // 是否已开启两步验证
 }
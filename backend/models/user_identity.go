@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+)
+
+// 第三方账号绑定
+type UserIdentity struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"index;not null"`
+	Provider  string    `json:"provider" gorm:"size:32;not null;uniqueIndex:idx_identity_provider_openid"` // wechat
+	OpenID    string    `json:"-" gorm:"size:128;not null;uniqueIndex:idx_identity_provider_openid"`
+	UnionID   string    `json:"-" gorm:"size:128;index"` // 开放平台unionid，用于关联同一主体下的其他应用
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type OAuthLoginRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type UserIdentityResponse struct {
+	Provider  string    `json:"provider"`
+	CreatedAt time.Time `json:"created_at"`
+}
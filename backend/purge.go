@@ -0,0 +1,82 @@
+package main
+
+import (
+	"e-device-recycle-backend/models"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// purge 子命令：清理过期的令牌、旧的登录记录和已软删除的数据
+// 审计日志不可删除，不在清理范围内
+func runPurge(args []string) {
+	fs := flag.NewFlagSet("purge", flag.ExitOnError)
+	tokenDays := fs.Int("token-days", 7, "清理过期或已使用超过N天的重置密码令牌")
+	attemptDays := fs.Int("login-attempt-days", 90, "清理N天前的登录失败记录")
+	softDeletedDays := fs.Int("soft-deleted-days", 30, "彻底删除软删除超过N天的评估和订单，0表示不清理")
+	dryRun := fs.Bool("dry-run", false, "只统计数量，不删除")
+	fs.Parse(args)
+
+	models.InitDB()
+
+	now := time.Now()
+	tokenBefore := now.AddDate(0, 0, -*tokenDays)
+	attemptBefore := now.AddDate(0, 0, -*attemptDays)
+	deletedBefore := now.AddDate(0, 0, -*softDeletedDays)
+
+	type task struct {
+		name  string
+		query func(tx *gorm.DB) *gorm.DB
+	}
+	tasks := []task{
+		{"重置密码令牌", func(tx *gorm.DB) *gorm.DB {
+			return tx.Where("expires_at < ? OR used_at < ?", tokenBefore, tokenBefore).Delete(&models.PasswordResetToken{})
+		}},
+		{"已使用的恢复码", func(tx *gorm.DB) *gorm.DB {
+			return tx.Where("used_at < ?", tokenBefore).Delete(&models.RecoveryCode{})
+		}},
+		{"登录失败记录", func(tx *gorm.DB) *gorm.DB {
+			return tx.Where("created_at < ?", attemptBefore).Delete(&models.LoginAttempt{})
+		}},
+	}
+	if *softDeletedDays > 0 {
+		// 按外键依赖顺序删除：评估 -> 订单。
+		// 设备下架只改为inactive状态，不会软删除，保留给订单、评估和审计日志引用，不在清理范围内
+		tasks = append(tasks,
+			task{"已删除的评估", func(tx *gorm.DB) *gorm.DB {
+				return tx.Unscoped().Where("deleted_at < ?", deletedBefore).Delete(&models.Evaluation{})
+			}},
+			task{"已删除的订单", func(tx *gorm.DB) *gorm.DB {
+				return tx.Unscoped().Where("deleted_at < ?", deletedBefore).
+					Where("id NOT IN (?)", tx.Unscoped().Model(&models.Evaluation{}).Select("order_id")).
+					Delete(&models.RecycleOrder{})
+			}},
+		)
+	}
+
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		for _, t := range tasks {
+			result := t.query(tx.Session(&gorm.Session{NewDB: true}))
+			if result.Error != nil {
+				return fmt.Errorf("清理%s失败: %w", t.name, result.Error)
+			}
+			fmt.Printf("%s: %d条\n", t.name, result.RowsAffected)
+		}
+		if *dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err == errDryRun {
+		fmt.Println("dry-run模式，已回滚")
+		return
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+var errDryRun = fmt.Errorf("dry run")
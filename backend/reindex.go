@@ -0,0 +1,37 @@
+package main
+
+import (
+	"e-device-recycle-backend/config"
+	"e-device-recycle-backend/models"
+	"e-device-recycle-backend/repositories"
+	"e-device-recycle-backend/search"
+	"e-device-recycle-backend/services"
+	"flag"
+	"fmt"
+	"log"
+)
+
+// reindex 子命令：从数据库重建设备搜索索引
+func runReindex(args []string) {
+	fs := flag.NewFlagSet("reindex", flag.ExitOnError)
+	fs.Parse(args)
+
+	if config.GetConfig().Search.Engine != "database" {
+		fmt.Println("search.engine 不是 database，进程内索引在服务启动时构建，无需重建")
+		return
+	}
+
+	models.InitDB()
+
+	n, err := rebuildSearchIndex()
+	if err != nil {
+		log.Fatal("重建设备搜索索引失败:", err)
+	}
+	fmt.Printf("已重建设备搜索索引，共%d个设备\n", n)
+}
+
+// 重建数据库中的搜索索引
+func rebuildSearchIndex() (int, error) {
+	devices := services.NewDeviceService(repositories.New(models.DB), search.NewDBIndex(models.DB))
+	return devices.Reindex()
+}
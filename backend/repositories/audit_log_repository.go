@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"e-device-recycle-backend/listquery"
+	"e-device-recycle-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// 审计日志过滤条件，零值表示不过滤
+type AuditLogFilter struct {
+	ActorID    uint
+	Action     string
+	EntityType string
+	EntityID   uint
+	Created    listquery.TimeRange
+}
+
+// 审计日志按时间倒序，数据量大时使用游标分页
+var AuditLogListSpec = listquery.Spec{
+	Fields: []listquery.Field{
+		{Name: "created_at", Column: "created_at", Kind: listquery.Time, StructField: "CreatedAt"},
+	},
+	DefaultSort:     "-created_at",
+	DefaultPageSize: 20,
+}
+
+// 审计日志只允许新增
+type AuditLogRepository interface {
+	Create(log *models.AuditLog) error
+	List(filter AuditLogFilter, params listquery.Params) ([]models.AuditLog, int64, error)
+	// 按ID从新到旧返回ID小于beforeID的最多limit条，beforeID为0时从最新的开始
+	ListBefore(filter AuditLogFilter, beforeID uint, limit int) ([]models.AuditLog, error)
+}
+
+type auditLogRepository struct {
+	db *gorm.DB
+}
+
+func (r *auditLogRepository) Create(log *models.AuditLog) error {
+	return r.db.Create(log).Error
+}
+
+func (r *auditLogRepository) filter(filter AuditLogFilter) *gorm.DB {
+	query := r.db.Model(&models.AuditLog{})
+	if filter.ActorID != 0 {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.EntityType != "" {
+		query = query.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID != 0 {
+		query = query.Where("entity_id = ?", filter.EntityID)
+	}
+	return filter.Created.Apply(query, "created_at")
+}
+
+func (r *auditLogRepository) List(filter AuditLogFilter, params listquery.Params) ([]models.AuditLog, int64, error) {
+	query := r.filter(filter)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var logs []models.AuditLog
+	if err := params.Apply(query).Find(&logs).Error; err != nil {
+		return nil, 0, err
+	}
+	return logs, total, nil
+}
+
+func (r *auditLogRepository) ListBefore(filter AuditLogFilter, beforeID uint, limit int) ([]models.AuditLog, error) {
+	query := r.filter(filter).Order("id DESC").Limit(limit)
+	if beforeID != 0 {
+		query = query.Where("id < ?", beforeID)
+	}
+	var logs []models.AuditLog
+	err := query.Find(&logs).Error
+	return logs, err
+}
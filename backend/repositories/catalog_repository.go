@@ -0,0 +1,143 @@
+package repositories
+
+import (
+	"e-device-recycle-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// 型号列表过滤条件，0表示不过滤
+type DeviceModelFilter struct {
+	BrandID  uint
+	SeriesID uint
+}
+
+// 品牌、系列和型号目录，列表均按名称排序
+type CatalogRepository interface {
+	ListBrands() ([]models.Brand, error)
+	FindBrand(id uint) (*models.Brand, error)
+	CreateBrand(brand *models.Brand) error
+	SaveBrand(brand *models.Brand) error
+	DeleteBrand(id uint) error
+
+	// brandID为0时返回所有品牌的系列
+	ListSeries(brandID uint) ([]models.DeviceSeries, error)
+	FindSeries(id uint) (*models.DeviceSeries, error)
+	CreateSeries(series *models.DeviceSeries) error
+	SaveSeries(series *models.DeviceSeries) error
+	DeleteSeries(id uint) error
+
+	ListModels(filter DeviceModelFilter) ([]models.DeviceModel, error)
+	FindModel(id uint) (*models.DeviceModel, error)
+	CreateModel(model *models.DeviceModel) error
+	SaveModel(model *models.DeviceModel) error
+	DeleteModel(id uint) error
+
+	// 品牌和型号表的统计，用于判断目录是否变化（系列不影响设备过滤，不包含在内）
+	Stats() (TableStats, error)
+}
+
+type catalogRepository struct {
+	db *gorm.DB
+}
+
+func (r *catalogRepository) ListBrands() ([]models.Brand, error) {
+	var brands []models.Brand
+	err := r.db.Order("name").Find(&brands).Error
+	return brands, err
+}
+
+func (r *catalogRepository) FindBrand(id uint) (*models.Brand, error) {
+	var brand models.Brand
+	if err := r.db.First(&brand, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &brand, nil
+}
+
+func (r *catalogRepository) CreateBrand(brand *models.Brand) error {
+	return r.db.Create(brand).Error
+}
+
+func (r *catalogRepository) SaveBrand(brand *models.Brand) error {
+	return r.db.Save(brand).Error
+}
+
+func (r *catalogRepository) DeleteBrand(id uint) error {
+	return r.db.Delete(&models.Brand{}, id).Error
+}
+
+func (r *catalogRepository) ListSeries(brandID uint) ([]models.DeviceSeries, error) {
+	query := r.db.Order("name")
+	if brandID != 0 {
+		query = query.Where("brand_id = ?", brandID)
+	}
+	var series []models.DeviceSeries
+	err := query.Find(&series).Error
+	return series, err
+}
+
+func (r *catalogRepository) FindSeries(id uint) (*models.DeviceSeries, error) {
+	var series models.DeviceSeries
+	if err := r.db.First(&series, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &series, nil
+}
+
+func (r *catalogRepository) CreateSeries(series *models.DeviceSeries) error {
+	return r.db.Create(series).Error
+}
+
+func (r *catalogRepository) SaveSeries(series *models.DeviceSeries) error {
+	return r.db.Save(series).Error
+}
+
+func (r *catalogRepository) DeleteSeries(id uint) error {
+	return r.db.Delete(&models.DeviceSeries{}, id).Error
+}
+
+func (r *catalogRepository) ListModels(filter DeviceModelFilter) ([]models.DeviceModel, error) {
+	query := r.db.Order("name")
+	if filter.BrandID != 0 {
+		query = query.Where("brand_id = ?", filter.BrandID)
+	}
+	if filter.SeriesID != 0 {
+		query = query.Where("series_id = ?", filter.SeriesID)
+	}
+	var deviceModels []models.DeviceModel
+	err := query.Find(&deviceModels).Error
+	return deviceModels, err
+}
+
+func (r *catalogRepository) FindModel(id uint) (*models.DeviceModel, error) {
+	var model models.DeviceModel
+	if err := r.db.First(&model, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &model, nil
+}
+
+func (r *catalogRepository) CreateModel(model *models.DeviceModel) error {
+	return r.db.Create(model).Error
+}
+
+func (r *catalogRepository) SaveModel(model *models.DeviceModel) error {
+	return r.db.Save(model).Error
+}
+
+func (r *catalogRepository) DeleteModel(id uint) error {
+	return r.db.Delete(&models.DeviceModel{}, id).Error
+}
+
+func (r *catalogRepository) Stats() (TableStats, error) {
+	brands, err := tableStats(r.db, &models.Brand{})
+	if err != nil {
+		return TableStats{}, err
+	}
+	deviceModels, err := tableStats(r.db, &models.DeviceModel{})
+	if err != nil {
+		return TableStats{}, err
+	}
+	return brands.Add(deviceModels), nil
+}
@@ -0,0 +1,62 @@
+package repositories
+
+import (
+	"e-device-recycle-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// 设备分类目录
+type CategoryRepository interface {
+	// 所有分类，按排序值和ID排序
+	List() ([]models.Category, error)
+	FindByID(id uint) (*models.Category, error)
+	FindByCode(code string) (*models.Category, error)
+	Create(category *models.Category) error
+	Save(category *models.Category) error
+	Delete(id uint) error
+	// 分类表的统计，用于判断分类目录是否变化
+	Stats() (TableStats, error)
+}
+
+type categoryRepository struct {
+	db *gorm.DB
+}
+
+func (r *categoryRepository) List() ([]models.Category, error) {
+	var categories []models.Category
+	err := r.db.Order("sort_order").Order("id").Find(&categories).Error
+	return categories, err
+}
+
+func (r *categoryRepository) FindByID(id uint) (*models.Category, error) {
+	var category models.Category
+	if err := r.db.First(&category, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &category, nil
+}
+
+func (r *categoryRepository) FindByCode(code string) (*models.Category, error) {
+	var category models.Category
+	if err := r.db.Where("code = ?", code).First(&category).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &category, nil
+}
+
+func (r *categoryRepository) Create(category *models.Category) error {
+	return r.db.Create(category).Error
+}
+
+func (r *categoryRepository) Save(category *models.Category) error {
+	return r.db.Save(category).Error
+}
+
+func (r *categoryRepository) Delete(id uint) error {
+	return r.db.Delete(&models.Category{}, id).Error
+}
+
+func (r *categoryRepository) Stats() (TableStats, error) {
+	return tableStats(r.db, &models.Category{})
+}
@@ -0,0 +1,186 @@
+package repositories
+
+import (
+	"e-device-recycle-backend/listquery"
+	"e-device-recycle-backend/models"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 设备列表过滤条件，空值表示不过滤
+type DeviceFilter struct {
+	Status     string
+	Category   string
+	Categories []string // 属于其中之一，服务把Category展开为该分类及其子分类
+	Brand      string   // 模糊匹配，不区分大小写
+	BrandID    uint
+	SeriesID   uint
+	ModelID    uint
+	Condition  string
+	Price      listquery.Range // 基础回收价格
+	Year       listquery.Range // 购买年份
+	// 结构化规格，没有识别出对应规格的设备不满足范围条件
+	Memory        listquery.Range // 内存（GB）
+	Storage       listquery.Range // 存储（GB）
+	StorageType   string
+	CPUFamily     string
+	CPUGeneration listquery.Range
+	Screen        listquery.Range // 屏幕尺寸（英寸）
+}
+
+// 设备列表的排序字段，默认按ID（创建顺序）
+var DeviceListSpec = listquery.Spec{
+	Fields: []listquery.Field{
+		{Name: "id", Column: "id", Kind: listquery.Number, StructField: "ID"},
+		{Name: "name", Column: "name", Kind: listquery.String, StructField: "Name"},
+		{Name: "base_price", Column: "base_price", Kind: listquery.Number, StructField: "BasePrice"},
+		{Name: "year_bought", Column: "year_bought", Kind: listquery.Number, StructField: "YearBought"},
+		{Name: "created_at", Column: "created_at", Kind: listquery.Time, StructField: "CreatedAt"},
+	},
+	DefaultSort:     "id",
+	DefaultPageSize: 10,
+}
+
+type DeviceRepository interface {
+	FindByID(id uint) (*models.Device, error)
+	// 查找指定状态的设备
+	FindByIDAndStatus(id uint, status string) (*models.Device, error)
+	List(filter DeviceFilter, params listquery.Params) ([]models.Device, int64, error)
+	// 符合过滤条件的设备数（含已下架）
+	Count(filter DeviceFilter) (int64, error)
+	// 所有设备（含已下架），用于重建搜索索引
+	ListAll() ([]models.Device, error)
+	// 按ID查找符合过滤条件的设备，不保证顺序
+	ListByIDs(ids []uint, filter DeviceFilter) ([]models.Device, error)
+	// 所有设备中最近的更新时间，没有设备时返回零值
+	LastModified() (time.Time, error)
+	Create(device *models.Device) error
+	Update(device *models.Device, updates map[string]interface{}) error
+}
+
+type deviceRepository struct {
+	db *gorm.DB
+}
+
+func (r *deviceRepository) FindByID(id uint) (*models.Device, error) {
+	var device models.Device
+	if err := r.db.First(&device, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &device, nil
+}
+
+func (r *deviceRepository) FindByIDAndStatus(id uint, status string) (*models.Device, error) {
+	var device models.Device
+	if err := r.db.Where("id = ? AND status = ?", id, status).First(&device).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &device, nil
+}
+
+func (r *deviceRepository) List(filter DeviceFilter, params listquery.Params) ([]models.Device, int64, error) {
+	query := applyDeviceFilter(r.db.Model(&models.Device{}), filter)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var devices []models.Device
+	if err := params.Apply(query).Find(&devices).Error; err != nil {
+		return nil, 0, err
+	}
+	return devices, total, nil
+}
+
+func (r *deviceRepository) Count(filter DeviceFilter) (int64, error) {
+	var total int64
+	err := applyDeviceFilter(r.db.Model(&models.Device{}), filter).Count(&total).Error
+	return total, err
+}
+
+func (r *deviceRepository) ListAll() ([]models.Device, error) {
+	var devices []models.Device
+	err := r.db.Order("id").Find(&devices).Error
+	return devices, err
+}
+
+func (r *deviceRepository) ListByIDs(ids []uint, filter DeviceFilter) ([]models.Device, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var devices []models.Device
+	err := applyDeviceFilter(r.db.Where("id IN ?", ids), filter).Find(&devices).Error
+	return devices, err
+}
+
+func applyDeviceFilter(query *gorm.DB, filter DeviceFilter) *gorm.DB {
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Category != "" {
+		query = query.Where("category = ?", filter.Category)
+	}
+	if len(filter.Categories) > 0 {
+		query = query.Where("category IN ?", filter.Categories)
+	}
+	if filter.Brand != "" {
+		// PostgreSQL的LIKE区分大小写，统一转为小写比较
+		query = query.Where("LOWER(brand) LIKE ?", "%"+strings.ToLower(filter.Brand)+"%")
+	}
+	if filter.BrandID != 0 {
+		query = query.Where("brand_id = ?", filter.BrandID)
+	}
+	if filter.SeriesID != 0 {
+		query = query.Where("model_id IN (SELECT id FROM device_models WHERE series_id = ?)", filter.SeriesID)
+	}
+	if filter.ModelID != 0 {
+		query = query.Where("model_id = ?", filter.ModelID)
+	}
+	if filter.Condition != "" {
+		// condition是MySQL保留字，使用map条件由GORM按方言加引号
+		query = query.Where(map[string]interface{}{"condition": filter.Condition})
+	}
+	query = filter.Price.Apply(query, "base_price")
+	query = filter.Year.Apply(query, "year_bought")
+	query = filter.Memory.Apply(query, "spec_memory_gb")
+	query = filter.Storage.Apply(query, "spec_storage_gb")
+	if filter.StorageType != "" {
+		query = query.Where("spec_storage_type = ?", filter.StorageType)
+	}
+	if filter.CPUFamily != "" {
+		query = query.Where("spec_cpu_family = ?", filter.CPUFamily)
+	}
+	query = filter.CPUGeneration.Apply(query, "spec_cpu_generation")
+	query = filter.Screen.Apply(query, "spec_screen_inches")
+	return query
+}
+
+func (r *deviceRepository) LastModified() (time.Time, error) {
+	// 不使用MAX()，SQLite的聚合结果没有列类型，无法扫描为时间
+	var device models.Device
+	err := r.db.Select("updated_at").Order("updated_at DESC").Take(&device).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return time.Time{}, nil
+	}
+	return device.UpdatedAt, err
+}
+
+func (r *deviceRepository) Create(device *models.Device) error {
+	return r.db.Create(device).Error
+}
+
+func (r *deviceRepository) Update(device *models.Device, updates map[string]interface{}) error {
+	if err := r.db.Model(&models.Device{ID: device.ID}).Updates(updates).Error; err != nil {
+		return err
+	}
+	reloaded, err := r.FindByID(device.ID)
+	if err != nil {
+		return err
+	}
+	*device = *reloaded
+	return nil
+}
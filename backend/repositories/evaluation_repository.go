@@ -0,0 +1,100 @@
+package repositories
+
+import (
+	"e-device-recycle-backend/listquery"
+	"e-device-recycle-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// 评估列表过滤条件，零值表示不过滤
+type EvaluationFilter struct {
+	Status      string
+	EvaluatorID uint
+}
+
+// 评估列表的排序字段，默认最新的在前
+var EvaluationListSpec = listquery.Spec{
+	Fields: []listquery.Field{
+		{Name: "created_at", Column: "created_at", Kind: listquery.Time, StructField: "CreatedAt"},
+		{Name: "overall_score", Column: "overall_score", Kind: listquery.Number, StructField: "OverallScore"},
+		{Name: "final_price", Column: "final_price", Kind: listquery.Number, StructField: "FinalPrice"},
+	},
+	DefaultSort:     "-created_at",
+	DefaultPageSize: 10,
+}
+
+// 评估查询均预加载订单和评估师信息
+type EvaluationRepository interface {
+	FindByID(id uint) (*models.Evaluation, error)
+	FindByOrderID(orderID uint) (*models.Evaluation, error)
+	List(filter EvaluationFilter, params listquery.Params) ([]models.Evaluation, int64, error)
+	Create(evaluation *models.Evaluation) error
+	Update(evaluation *models.Evaluation, updates map[string]interface{}) error
+}
+
+type evaluationRepository struct {
+	db *gorm.DB
+}
+
+func (r *evaluationRepository) preload() *gorm.DB {
+	return r.db.Preload("Order").Preload("Evaluator")
+}
+
+func (r *evaluationRepository) FindByID(id uint) (*models.Evaluation, error) {
+	var evaluation models.Evaluation
+	if err := r.preload().First(&evaluation, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &evaluation, nil
+}
+
+func (r *evaluationRepository) FindByOrderID(orderID uint) (*models.Evaluation, error) {
+	var evaluation models.Evaluation
+	if err := r.preload().Where("order_id = ?", orderID).First(&evaluation).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &evaluation, nil
+}
+
+func (r *evaluationRepository) List(filter EvaluationFilter, params listquery.Params) ([]models.Evaluation, int64, error) {
+	query := r.db.Model(&models.Evaluation{})
+
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.EvaluatorID != 0 {
+		query = query.Where("evaluator_id = ?", filter.EvaluatorID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var evaluations []models.Evaluation
+	if err := params.Apply(query.Preload("Order").Preload("Evaluator")).
+		Find(&evaluations).Error; err != nil {
+		return nil, 0, err
+	}
+	return evaluations, total, nil
+}
+
+func (r *evaluationRepository) Create(evaluation *models.Evaluation) error {
+	if err := r.db.Create(evaluation).Error; err != nil {
+		return err
+	}
+	return r.preload().First(evaluation, evaluation.ID).Error
+}
+
+func (r *evaluationRepository) Update(evaluation *models.Evaluation, updates map[string]interface{}) error {
+	if err := r.db.Model(&models.Evaluation{ID: evaluation.ID}).Updates(updates).Error; err != nil {
+		return err
+	}
+	reloaded, err := r.FindByID(evaluation.ID)
+	if err != nil {
+		return err
+	}
+	*evaluation = *reloaded
+	return nil
+}
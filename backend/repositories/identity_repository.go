@@ -0,0 +1,55 @@
+package repositories
+
+import (
+	"e-device-recycle-backend/models"
+
+	"gorm.io/gorm"
+)
+
+type IdentityRepository interface {
+	FindByOpenID(provider, openID string) (*models.UserIdentity, error)
+	// 同一开放平台下已绑定的任一身份
+	FindByUnionID(unionID string) (*models.UserIdentity, error)
+	ListByUser(userID uint) ([]models.UserIdentity, error)
+	Create(identity *models.UserIdentity) error
+	Update(identity *models.UserIdentity, updates map[string]interface{}) error
+	Delete(id uint) error
+}
+
+type identityRepository struct {
+	db *gorm.DB
+}
+
+func (r *identityRepository) FindByOpenID(provider, openID string) (*models.UserIdentity, error) {
+	return r.findOne(r.db.Where("provider = ? AND open_id = ?", provider, openID))
+}
+
+func (r *identityRepository) FindByUnionID(unionID string) (*models.UserIdentity, error) {
+	return r.findOne(r.db.Where("union_id = ?", unionID))
+}
+
+func (r *identityRepository) findOne(query *gorm.DB) (*models.UserIdentity, error) {
+	var identity models.UserIdentity
+	if err := query.First(&identity).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &identity, nil
+}
+
+func (r *identityRepository) ListByUser(userID uint) ([]models.UserIdentity, error) {
+	var identities []models.UserIdentity
+	err := r.db.Where("user_id = ?", userID).Order("id").Find(&identities).Error
+	return identities, err
+}
+
+func (r *identityRepository) Create(identity *models.UserIdentity) error {
+	return r.db.Create(identity).Error
+}
+
+func (r *identityRepository) Update(identity *models.UserIdentity, updates map[string]interface{}) error {
+	return r.db.Model(identity).Updates(updates).Error
+}
+
+func (r *identityRepository) Delete(id uint) error {
+	return r.db.Delete(&models.UserIdentity{}, id).Error
+}
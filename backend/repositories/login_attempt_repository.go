@@ -0,0 +1,56 @@
+package repositories
+
+import (
+	"e-device-recycle-backend/listquery"
+	"e-device-recycle-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// 登录失败记录过滤条件，零值表示不过滤
+type LoginAttemptFilter struct {
+	Username string
+	IP       string
+}
+
+var LoginAttemptListSpec = listquery.Spec{
+	Fields: []listquery.Field{
+		{Name: "created_at", Column: "created_at", Kind: listquery.Time, StructField: "CreatedAt"},
+	},
+	DefaultSort:     "-created_at",
+	DefaultPageSize: 20,
+}
+
+type LoginAttemptRepository interface {
+	Create(attempt *models.LoginAttempt) error
+	List(filter LoginAttemptFilter, params listquery.Params) ([]models.LoginAttempt, int64, error)
+}
+
+type loginAttemptRepository struct {
+	db *gorm.DB
+}
+
+func (r *loginAttemptRepository) Create(attempt *models.LoginAttempt) error {
+	return r.db.Create(attempt).Error
+}
+
+func (r *loginAttemptRepository) List(filter LoginAttemptFilter, params listquery.Params) ([]models.LoginAttempt, int64, error) {
+	query := r.db.Model(&models.LoginAttempt{})
+	if filter.Username != "" {
+		query = query.Where("username = ?", filter.Username)
+	}
+	if filter.IP != "" {
+		query = query.Where("ip = ?", filter.IP)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var attempts []models.LoginAttempt
+	if err := params.Apply(query).Find(&attempts).Error; err != nil {
+		return nil, 0, err
+	}
+	return attempts, total, nil
+}
@@ -0,0 +1,116 @@
+package repositories
+
+import (
+	"e-device-recycle-backend/listquery"
+	"e-device-recycle-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// 订单列表过滤条件，零值表示不过滤
+type OrderFilter struct {
+	UserID  uint
+	Status  string
+	Created listquery.TimeRange // 下单时间
+}
+
+// 订单列表的排序字段，默认最新的在前
+var OrderListSpec = listquery.Spec{
+	Fields: []listquery.Field{
+		{Name: "created_at", Column: "created_at", Kind: listquery.Time, StructField: "CreatedAt"},
+		{Name: "updated_at", Column: "updated_at", Kind: listquery.Time, StructField: "UpdatedAt"},
+		{Name: "estimated_price", Column: "estimated_price", Kind: listquery.Number, StructField: "EstimatedPrice"},
+	},
+	DefaultSort:     "-created_at",
+	DefaultPageSize: 10,
+}
+
+// 订单查询均预加载用户、设备和评估信息
+type OrderRepository interface {
+	FindByID(id uint) (*models.RecycleOrder, error)
+	List(filter OrderFilter, params listquery.Params) ([]models.RecycleOrder, int64, error)
+	// 各设备未取消的订单数，没有订单的设备不在结果中
+	CountByDevice() (map[uint]int64, error)
+	Create(order *models.RecycleOrder) error
+	Update(order *models.RecycleOrder, updates map[string]interface{}) error
+}
+
+type orderRepository struct {
+	db *gorm.DB
+}
+
+func (r *orderRepository) preload() *gorm.DB {
+	return r.db.Preload("User").Preload("Device").Preload("Evaluation")
+}
+
+func (r *orderRepository) FindByID(id uint) (*models.RecycleOrder, error) {
+	var order models.RecycleOrder
+	if err := r.preload().First(&order, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &order, nil
+}
+
+func (r *orderRepository) List(filter OrderFilter, params listquery.Params) ([]models.RecycleOrder, int64, error) {
+	query := r.db.Model(&models.RecycleOrder{})
+
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	query = filter.Created.Apply(query, "created_at")
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var orders []models.RecycleOrder
+	if err := params.Apply(query.Preload("User").Preload("Device").Preload("Evaluation")).
+		Find(&orders).Error; err != nil {
+		return nil, 0, err
+	}
+	return orders, total, nil
+}
+
+func (r *orderRepository) CountByDevice() (map[uint]int64, error) {
+	var rows []struct {
+		DeviceID uint
+		Count    int64
+	}
+	if err := r.db.Model(&models.RecycleOrder{}).
+		Select("device_id, COUNT(*) AS count").
+		Where("status <> ?", "cancelled").
+		Group("device_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		counts[row.DeviceID] = row.Count
+	}
+	return counts, nil
+}
+
+func (r *orderRepository) Create(order *models.RecycleOrder) error {
+	if err := r.db.Create(order).Error; err != nil {
+		return err
+	}
+	return r.preload().First(order, order.ID).Error
+}
+
+func (r *orderRepository) Update(order *models.RecycleOrder, updates map[string]interface{}) error {
+	// 使用新的模型执行更新并重新加载，避免调用方保存的更新前副本共享指针字段
+	if err := r.db.Model(&models.RecycleOrder{ID: order.ID}).Updates(updates).Error; err != nil {
+		return err
+	}
+	reloaded, err := r.FindByID(order.ID)
+	if err != nil {
+		return err
+	}
+	*order = *reloaded
+	return nil
+}
@@ -0,0 +1,123 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 记录不存在，各仓储统一返回该错误，调用方无需依赖GORM
+var ErrNotFound = errors.New("记录不存在")
+
+// 仓储集合，Transaction中的回调拿到的是绑定同一事务的仓储
+type Repositories interface {
+	Users() UserRepository
+	PasswordResets() PasswordResetRepository
+	Identities() IdentityRepository
+	RecoveryCodes() RecoveryCodeRepository
+	LoginAttempts() LoginAttemptRepository
+	Devices() DeviceRepository
+	Catalog() CatalogRepository
+	Categories() CategoryRepository
+	Orders() OrderRepository
+	Evaluations() EvaluationRepository
+	AuditLogs() AuditLogRepository
+	Transaction(fn func(repos Repositories) error) error
+}
+
+type gormRepositories struct {
+	db *gorm.DB
+}
+
+// 基于GORM的仓储实现
+func New(db *gorm.DB) Repositories {
+	return &gormRepositories{db: db}
+}
+
+func (r *gormRepositories) Users() UserRepository {
+	return &userRepository{db: r.db}
+}
+
+func (r *gormRepositories) PasswordResets() PasswordResetRepository {
+	return &passwordResetRepository{db: r.db}
+}
+
+func (r *gormRepositories) Identities() IdentityRepository {
+	return &identityRepository{db: r.db}
+}
+
+func (r *gormRepositories) RecoveryCodes() RecoveryCodeRepository {
+	return &recoveryCodeRepository{db: r.db}
+}
+
+func (r *gormRepositories) LoginAttempts() LoginAttemptRepository {
+	return &loginAttemptRepository{db: r.db}
+}
+
+func (r *gormRepositories) Devices() DeviceRepository {
+	return &deviceRepository{db: r.db}
+}
+
+func (r *gormRepositories) Catalog() CatalogRepository {
+	return &catalogRepository{db: r.db}
+}
+
+func (r *gormRepositories) Categories() CategoryRepository {
+	return &categoryRepository{db: r.db}
+}
+
+func (r *gormRepositories) Orders() OrderRepository {
+	return &orderRepository{db: r.db}
+}
+
+func (r *gormRepositories) Evaluations() EvaluationRepository {
+	return &evaluationRepository{db: r.db}
+}
+
+func (r *gormRepositories) AuditLogs() AuditLogRepository {
+	return &auditLogRepository{db: r.db}
+}
+
+func (r *gormRepositories) Transaction(fn func(repos Repositories) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return fn(&gormRepositories{db: tx})
+	})
+}
+
+// 将GORM的记录不存在错误转换为ErrNotFound
+func translate(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// 表的行数和最后修改时间，新增、修改和删除记录都会改变其中之一
+type TableStats struct {
+	Rows         int64
+	LastModified time.Time
+}
+
+// 合并多张表的统计，行数相加，最后修改时间取最晚的
+func (s TableStats) Add(other TableStats) TableStats {
+	s.Rows += other.Rows
+	if other.LastModified.After(s.LastModified) {
+		s.LastModified = other.LastModified
+	}
+	return s
+}
+
+func tableStats(db *gorm.DB, model interface{}) (TableStats, error) {
+	var stats TableStats
+	if err := db.Model(model).Count(&stats.Rows).Error; err != nil || stats.Rows == 0 {
+		return stats, err
+	}
+	// 不使用MAX()，SQLite的聚合结果没有列类型，无法扫描为时间
+	var updatedAt []time.Time
+	err := db.Model(model).Order("updated_at DESC").Limit(1).Pluck("updated_at", &updatedAt).Error
+	if err == nil && len(updatedAt) > 0 {
+		stats.LastModified = updatedAt[0]
+	}
+	return stats, err
+}
@@ -0,0 +1,43 @@
+package repositories
+
+import (
+	"e-device-recycle-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type RecoveryCodeRepository interface {
+	// 删除用户的全部恢复码并保存新的恢复码哈希，应在事务中调用
+	Replace(userID uint, codeHashes []string) error
+	DeleteAll(userID uint) error
+	// 将未使用的恢复码标记为已使用，没有匹配的恢复码时返回false
+	Use(userID uint, codeHash string, at time.Time) (bool, error)
+}
+
+type recoveryCodeRepository struct {
+	db *gorm.DB
+}
+
+func (r *recoveryCodeRepository) Replace(userID uint, codeHashes []string) error {
+	if err := r.DeleteAll(userID); err != nil {
+		return err
+	}
+	records := make([]models.RecoveryCode, len(codeHashes))
+	for i, hash := range codeHashes {
+		records[i] = models.RecoveryCode{UserID: userID, CodeHash: hash}
+	}
+	return r.db.Create(&records).Error
+}
+
+func (r *recoveryCodeRepository) DeleteAll(userID uint) error {
+	return r.db.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error
+}
+
+func (r *recoveryCodeRepository) Use(userID uint, codeHash string, at time.Time) (bool, error) {
+	// 条件更新，防止并发重复使用
+	result := r.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", at)
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,167 @@
+package repositories
+
+import (
+	"e-device-recycle-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type UserRepository interface {
+	FindByID(id uint) (*models.User, error)
+	FindByUsername(username string) (*models.User, error)
+	// 按邮箱或手机号查找正常状态的用户
+	FindActiveByEmail(email string) (*models.User, error)
+	FindActiveByPhone(phone string) (*models.User, error)
+	FindByPhone(phone string) (*models.User, error)
+	Create(user *models.User) error
+	Update(user *models.User, updates map[string]interface{}) error
+	// 更新密码哈希并递增令牌版本，使已签发的令牌失效
+	UpdatePassword(user *models.User, hashedPassword string) error
+	// 记录最近使用的TOTP时间步，只有step大于已记录的值时才更新，防止同一验证码重复使用
+	AdvanceTOTPStep(id uint, step int64) (bool, error)
+}
+
+type userRepository struct {
+	db *gorm.DB
+}
+
+func (r *userRepository) FindByID(id uint) (*models.User, error) {
+	var user models.User
+	if err := r.db.First(&user, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &user, nil
+}
+
+func (r *userRepository) FindByUsername(username string) (*models.User, error) {
+	return r.findOne(r.db.Where("username = ?", username))
+}
+
+func (r *userRepository) FindActiveByEmail(email string) (*models.User, error) {
+	return r.findOne(r.db.Where("email = ? AND status = ?", email, "active"))
+}
+
+func (r *userRepository) FindActiveByPhone(phone string) (*models.User, error) {
+	return r.findOne(r.db.Where("phone = ? AND status = ?", phone, "active"))
+}
+
+func (r *userRepository) FindByPhone(phone string) (*models.User, error) {
+	return r.findOne(r.db.Where("phone = ?", phone))
+}
+
+func (r *userRepository) findOne(query *gorm.DB) (*models.User, error) {
+	var user models.User
+	if err := query.First(&user).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &user, nil
+}
+
+func (r *userRepository) Create(user *models.User) error {
+	// 未填写的手机号、邮箱存为NULL，避免唯一索引冲突
+	query := r.db
+	if user.Phone == "" {
+		query = query.Omit("Phone")
+	}
+	if user.Email == "" {
+		query = query.Omit("Email")
+	}
+	return query.Create(user).Error
+}
+
+func (r *userRepository) Update(user *models.User, updates map[string]interface{}) error {
+	if err := r.db.Model(&models.User{ID: user.ID}).Updates(updates).Error; err != nil {
+		return err
+	}
+	reloaded, err := r.FindByID(user.ID)
+	if err != nil {
+		return err
+	}
+	*user = *reloaded
+	return nil
+}
+
+func (r *userRepository) UpdatePassword(user *models.User, hashedPassword string) error {
+	return r.Update(user, map[string]interface{}{
+		"password":      hashedPassword,
+		"token_version": gorm.Expr("token_version + 1"),
+	})
+}
+
+func (r *userRepository) AdvanceTOTPStep(id uint, step int64) (bool, error) {
+	result := r.db.Model(&models.User{}).
+		Where("id = ? AND totp_last_step < ?", id, step).
+		Update("totp_last_step", step)
+	return result.RowsAffected > 0, result.Error
+}
+
+type PasswordResetRepository interface {
+	Create(token *models.PasswordResetToken) error
+	// 使用户之前未使用的令牌失效
+	InvalidateAll(userID uint, at time.Time) error
+	FindByHash(tokenHash, channel string) (*models.PasswordResetToken, error)
+	// 用户最近一次未使用的令牌
+	FindLatestUnused(userID uint, channel string) (*models.PasswordResetToken, error)
+	// 记录一次验证码错误，达到上限时作废
+	RecordFailure(token *models.PasswordResetToken, maxAttempts int) error
+	// 用户在since之后获取的令牌的验证码错误次数之和（包括已作废的令牌）
+	CountFailures(userID uint, channel string, since time.Time) (int64, error)
+	// 标记为已使用，令牌已被使用时返回false
+	MarkUsed(id uint, at time.Time) (bool, error)
+}
+
+type passwordResetRepository struct {
+	db *gorm.DB
+}
+
+func (r *passwordResetRepository) Create(token *models.PasswordResetToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *passwordResetRepository) InvalidateAll(userID uint, at time.Time) error {
+	return r.db.Model(&models.PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", at).Error
+}
+
+func (r *passwordResetRepository) FindByHash(tokenHash, channel string) (*models.PasswordResetToken, error) {
+	var token models.PasswordResetToken
+	if err := r.db.Where("token_hash = ? AND channel = ?", tokenHash, channel).First(&token).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &token, nil
+}
+
+func (r *passwordResetRepository) FindLatestUnused(userID uint, channel string) (*models.PasswordResetToken, error) {
+	var token models.PasswordResetToken
+	if err := r.db.Where("user_id = ? AND channel = ? AND used_at IS NULL", userID, channel).
+		Order("created_at DESC").First(&token).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &token, nil
+}
+
+func (r *passwordResetRepository) RecordFailure(token *models.PasswordResetToken, maxAttempts int) error {
+	updates := map[string]interface{}{"attempts": gorm.Expr("attempts + 1")}
+	if token.Attempts+1 >= maxAttempts {
+		updates["used_at"] = time.Now()
+	}
+	return r.db.Model(token).Updates(updates).Error
+}
+
+func (r *passwordResetRepository) CountFailures(userID uint, channel string, since time.Time) (int64, error) {
+	var total int64
+	err := r.db.Model(&models.PasswordResetToken{}).Select("COALESCE(SUM(attempts), 0)").
+		Where("user_id = ? AND channel = ? AND created_at > ?", userID, channel, since).
+		Scan(&total).Error
+	return total, err
+}
+
+func (r *passwordResetRepository) MarkUsed(id uint, at time.Time) (bool, error) {
+	// 条件更新，防止并发重复使用
+	result := r.db.Model(&models.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", at)
+	return result.RowsAffected > 0, result.Error
+}
@@ -1,18 +1,63 @@
 package routes
 
 import (
+	"e-device-recycle-backend/apierror"
 	"e-device-recycle-backend/controllers"
+	"e-device-recycle-backend/docs"
+	"e-device-recycle-backend/health"
 	"e-device-recycle-backend/middleware"
+	"e-device-recycle-backend/models"
+	"e-device-recycle-backend/services"
+	"e-device-recycle-backend/store"
+	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRoutes(r *gin.Engine) {
+// 限流策略
+var (
+	// 登录、找回密码等认证接口，按IP
+	authRateLimit = middleware.RateLimitPolicy{Name: "auth", Limit: 20, Per: time.Minute, Key: middleware.KeyByIP}
+	// 注册接口，按IP
+	registerRateLimit = middleware.RateLimitPolicy{Name: "register", Limit: 10, Per: time.Hour, Key: middleware.KeyByIP}
+	// 公开的设备查询接口，携带API Key时按Key，否则按IP
+	publicRateLimit = middleware.RateLimitPolicy{Name: "public", Limit: 120, Per: time.Minute, Key: middleware.KeyByAPIKey}
+	// 登录用户接口，按用户
+	userRateLimit = middleware.RateLimitPolicy{Name: "user", Limit: 300, Per: time.Minute, Key: middleware.KeyByUser}
+	// 管理员接口，按用户
+	adminRateLimit = middleware.RateLimitPolicy{Name: "admin", Limit: 600, Per: time.Minute, Key: middleware.KeyByUser}
+)
+
+func SetupRoutes(r *gin.Engine, svc *services.Services) {
 	// 创建控制器实例
-	userController := &controllers.UserController{}
-	deviceController := &controllers.DeviceController{}
-	recycleOrderController := &controllers.RecycleOrderController{}
-	evaluationController := &controllers.EvaluationController{}
+	userController := controllers.NewUserController(svc.Users)
+	deviceController := controllers.NewDeviceController(svc.Devices)
+	catalogController := controllers.NewCatalogController(svc.Catalog)
+	categoryController := controllers.NewCategoryController(svc.Categories)
+	recycleOrderController := controllers.NewRecycleOrderController(svc.Orders)
+	evaluationController := controllers.NewEvaluationController(svc.Evaluations)
+	identityController := controllers.NewIdentityController(svc.Identities)
+	twoFactorController := controllers.NewTwoFactorController(svc.Users, svc.TwoFactor)
+	auditLogController := controllers.NewAuditLogController(svc.AuditLogs)
+
+	// 管理操作写入审计日志
+	controllers.SetAuditLogService(svc.AuditLogs)
+
+	// 接口文档
+	docs.Register(r)
+
+	// 存活和就绪检查
+	health.Register(r,
+		health.Database(models.DB),
+		health.Migrations(models.DB),
+		health.Check{Name: "redis", Func: store.Ping},
+	)
+
+	// 未匹配的路由同样返回统一格式的错误
+	r.NoRoute(func(c *gin.Context) {
+		apierror.Respond(c, http.StatusNotFound, apierror.NotFound)
+	})
 
 	// API版本分组
 	v1 := r.Group("/api/v1")
@@ -21,28 +66,60 @@ func SetupRoutes(r *gin.Engine) {
 	{
 		// 用户认证
 		auth := v1.Group("/auth")
+		auth.Use(middleware.RateLimit(authRateLimit))
 		{
-			auth.POST("/register", userController.Register)
+			auth.POST("/register", middleware.RateLimit(registerRateLimit), userController.Register)
 			auth.POST("/login", userController.Login)
+			auth.POST("/password/forgot", userController.ForgotPassword)
+			auth.POST("/password/reset", userController.ResetPassword)
+			auth.POST("/oauth/:provider/login", identityController.Login)
+			auth.POST("/2fa/verify", twoFactorController.Verify)
+			auth.POST("/2fa/setup", twoFactorController.ChallengeSetup)
+			auth.POST("/2fa/enable", twoFactorController.ChallengeEnable)
 		}
 
 		// 设备信息（公开查看）
 		devices := v1.Group("/devices")
+		devices.Use(middleware.RateLimit(publicRateLimit))
 		{
 			devices.GET("/", deviceController.GetDevices)
+			devices.GET("/search", deviceController.SearchDevices)
+			devices.GET("/suggest", deviceController.SuggestDevices)
 			devices.GET("/:id", deviceController.GetDevice)
 		}
+
+		// 品牌、系列、型号和分类目录
+		catalog := v1.Group("/catalog")
+		catalog.Use(middleware.RateLimit(publicRateLimit))
+		{
+			catalog.GET("/brands", catalogController.GetBrands)
+			catalog.GET("/brands/:id", catalogController.GetBrand)
+			catalog.GET("/series", catalogController.GetSeries)
+			catalog.GET("/models", catalogController.GetModels)
+			catalog.GET("/models/:id", catalogController.GetModel)
+			catalog.GET("/categories", categoryController.GetCategories)
+			catalog.GET("/categories/:id", categoryController.GetCategory)
+		}
 	}
 
 	// 需要认证的路由
 	protected := v1.Group("")
 	protected.Use(middleware.JWTAuth())
+	protected.Use(middleware.RateLimit(userRateLimit))
 	{
 		// 用户相关
 		user := protected.Group("/user")
 		{
 			user.GET("/profile", userController.GetProfile)
 			user.PUT("/profile", userController.UpdateProfile)
+			user.PUT("/password", userController.ChangePassword)
+			user.GET("/identities", identityController.GetIdentities)
+			user.POST("/identities/:provider", identityController.Bind)
+			user.DELETE("/identities/:provider", identityController.Unbind)
+			user.POST("/2fa/setup", twoFactorController.Setup)
+			user.POST("/2fa/enable", twoFactorController.Enable)
+			user.POST("/2fa/disable", twoFactorController.Disable)
+			user.POST("/2fa/recovery-codes", twoFactorController.RegenerateRecoveryCodes)
 		}
 
 		// 回收订单
@@ -65,7 +142,15 @@ func SetupRoutes(r *gin.Engine) {
 	admin := v1.Group("/admin")
 	admin.Use(middleware.JWTAuth())
 	admin.Use(middleware.AdminAuth())
+	admin.Use(middleware.RateLimit(adminRateLimit))
 	{
+		// 用户管理
+		users := admin.Group("/users")
+		{
+			users.POST("/:id/unlock", userController.UnlockUser)
+		}
+		admin.GET("/login-attempts", userController.GetLoginAttempts)
+
 		// 设备管理
 		devices := admin.Group("/devices")
 		{
@@ -74,6 +159,23 @@ func SetupRoutes(r *gin.Engine) {
 			devices.DELETE("/:id", deviceController.DeleteDevice)
 		}
 
+		// 品牌、系列、型号和分类目录管理
+		catalog := admin.Group("/catalog")
+		{
+			catalog.POST("/brands", catalogController.CreateBrand)
+			catalog.PUT("/brands/:id", catalogController.UpdateBrand)
+			catalog.DELETE("/brands/:id", catalogController.DeleteBrand)
+			catalog.POST("/series", catalogController.CreateSeries)
+			catalog.PUT("/series/:id", catalogController.UpdateSeries)
+			catalog.DELETE("/series/:id", catalogController.DeleteSeries)
+			catalog.POST("/models", catalogController.CreateModel)
+			catalog.PUT("/models/:id", catalogController.UpdateModel)
+			catalog.DELETE("/models/:id", catalogController.DeleteModel)
+			catalog.POST("/categories", categoryController.CreateCategory)
+			catalog.PUT("/categories/:id", categoryController.UpdateCategory)
+			catalog.DELETE("/categories/:id", categoryController.DeleteCategory)
+		}
+
 		// 订单管理
 		orders := admin.Group("/orders")
 		{
@@ -89,5 +191,12 @@ func SetupRoutes(r *gin.Engine) {
 			evaluations.GET("/:id", evaluationController.GetEvaluation)
 			evaluations.PUT("/:id", evaluationController.UpdateEvaluation)
 		}
+
+		// 审计日志
+		auditLogs := admin.Group("/audit-logs")
+		{
+			auditLogs.GET("/", auditLogController.GetAuditLogs)
+			auditLogs.GET("/export", auditLogController.ExportAuditLogs)
+		}
 	}
 }
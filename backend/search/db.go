@@ -0,0 +1,113 @@
+package search
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// 数据库中的倒排索引，每个设备的每个词一行，多实例共享
+type DBIndex struct {
+	db *gorm.DB
+}
+
+type termRow struct {
+	DeviceID uint   `gorm:"primaryKey;autoIncrement:false"`
+	Term     string `gorm:"primaryKey"`
+	Weight   int
+}
+
+func (termRow) TableName() string {
+	return "device_search_terms"
+}
+
+func NewDBIndex(db *gorm.DB) *DBIndex {
+	return &DBIndex{db: db}
+}
+
+func (d *DBIndex) Put(ctx context.Context, docs ...Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(docs))
+	for i, doc := range docs {
+		ids[i] = doc.ID
+	}
+
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("device_id IN ?", ids).Delete(&termRow{}).Error; err != nil {
+			return err
+		}
+		return insertRows(tx, docs)
+	})
+}
+
+func (d *DBIndex) Delete(ctx context.Context, ids ...uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return d.db.WithContext(ctx).Where("device_id IN ?", ids).Delete(&termRow{}).Error
+}
+
+func (d *DBIndex) Rebuild(ctx context.Context, docs []Document) error {
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&termRow{}).Error; err != nil {
+			return err
+		}
+		return insertRows(tx, docs)
+	})
+}
+
+func insertRows(tx *gorm.DB, docs []Document) error {
+	var rows []termRow
+	for _, doc := range docs {
+		for term, weight := range doc.termWeights() {
+			rows = append(rows, termRow{DeviceID: doc.ID, Term: term, Weight: weight})
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return tx.CreateInBatches(rows, 500).Error
+}
+
+func (d *DBIndex) Size(ctx context.Context) (int64, error) {
+	var n int64
+	err := d.db.WithContext(ctx).Model(&termRow{}).Distinct("device_id").Count(&n).Error
+	return n, err
+}
+
+// 按匹配的词数和权重之和排序，不计算词频的逆文档频率
+func (d *DBIndex) Search(ctx context.Context, query string, limit int) ([]Hit, error) {
+	terms := queryTerms(query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	condition := d.db.Where("term IN ?", terms)
+	if prefix := prefixTerm(query, terms); prefix != "" {
+		condition = condition.Or("term LIKE ?", prefix+"%")
+	}
+
+	var rows []struct {
+		DeviceID uint
+		Score    float64
+		Matched  int
+	}
+	err := d.db.WithContext(ctx).Model(&termRow{}).
+		Select("device_id, SUM(weight) AS score, COUNT(*) AS matched").
+		Where(condition).
+		Group("device_id").
+		Order("matched DESC, score DESC, device_id ASC").
+		Limit(limit).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	hits := make([]Hit, len(rows))
+	for i, row := range rows {
+		hits[i] = Hit{ID: row.DeviceID, Score: row.Score, Matched: row.Matched}
+	}
+	return hits, nil
+}
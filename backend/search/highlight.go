@@ -0,0 +1,68 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+// 高亮文本中与搜索内容匹配的部分，用<em>包裹，其余内容做HTML转义。没有匹配时返回false
+func Highlight(text, query string) (string, bool) {
+	// 按子串匹配，未输完的词（如 "macb"）也会高亮
+	terms := queryTerms(query)
+
+	runes := []rune(text)
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	marked := make([]bool, len(runes))
+	found := false
+	for _, term := range terms {
+		for _, variant := range highlightVariants(term) {
+			if markAll(lower, []rune(variant), marked) {
+				found = true
+			}
+		}
+	}
+	if !found {
+		return "", false
+	}
+
+	var b strings.Builder
+	for i, r := range runes {
+		if marked[i] && (i == 0 || !marked[i-1]) {
+			b.WriteString("<em>")
+		}
+		b.WriteString(html.EscapeString(string(r)))
+		if marked[i] && (i == len(runes)-1 || !marked[i+1]) {
+			b.WriteString("</em>")
+		}
+	}
+	return b.String(), true
+}
+
+// 容量在原文中可能写作 "16G"、"16GB" 或 "16 GB"
+func highlightVariants(term string) []string {
+	number, unit, ok := splitCapacity(term)
+	if !ok {
+		return []string{term}
+	}
+	short := unit[:1]
+	return []string{number + unit, number + short, number + " " + unit, number + " " + short}
+}
+
+// 标记text中所有出现term的位置
+func markAll(text, term []rune, marked []bool) bool {
+	found := false
+	for i := 0; i+len(term) <= len(text); i++ {
+		if string(text[i:i+len(term)]) == string(term) {
+			for j := i; j < i+len(term); j++ {
+				marked[j] = true
+			}
+			found = true
+		}
+	}
+	return found
+}
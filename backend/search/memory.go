@@ -0,0 +1,181 @@
+package search
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// BM25参数
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// 进程内倒排索引，使用BM25计算相关度。多实例部署时各实例的索引只随本实例的写操作更新，
+// 需要定期重建（search.memory_refresh_seconds）
+type MemoryIndex struct {
+	mu       sync.RWMutex
+	postings map[string]map[uint]int // 词 -> 文档ID -> 权重
+	docs     map[uint]memoryDoc
+	totalLen int
+}
+
+type memoryDoc struct {
+	terms  []string
+	length int // 所有词的权重之和
+}
+
+func NewMemoryIndex() *MemoryIndex {
+	return &MemoryIndex{
+		postings: make(map[string]map[uint]int),
+		docs:     make(map[uint]memoryDoc),
+	}
+}
+
+func (m *MemoryIndex) Put(ctx context.Context, docs ...Document) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, doc := range docs {
+		m.remove(doc.ID)
+
+		weights := doc.termWeights()
+		entry := memoryDoc{terms: make([]string, 0, len(weights))}
+		for term, weight := range weights {
+			if m.postings[term] == nil {
+				m.postings[term] = make(map[uint]int)
+			}
+			m.postings[term][doc.ID] = weight
+			entry.terms = append(entry.terms, term)
+			entry.length += weight
+		}
+		m.docs[doc.ID] = entry
+		m.totalLen += entry.length
+	}
+	return nil
+}
+
+func (m *MemoryIndex) Delete(ctx context.Context, ids ...uint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, id := range ids {
+		m.remove(id)
+	}
+	return nil
+}
+
+func (m *MemoryIndex) remove(id uint) {
+	doc, ok := m.docs[id]
+	if !ok {
+		return
+	}
+	for _, term := range doc.terms {
+		delete(m.postings[term], id)
+		if len(m.postings[term]) == 0 {
+			delete(m.postings, term)
+		}
+	}
+	m.totalLen -= doc.length
+	delete(m.docs, id)
+}
+
+// 在新索引中构建完成后再替换，重建期间搜索不受影响
+func (m *MemoryIndex) Rebuild(ctx context.Context, docs []Document) error {
+	rebuilt := NewMemoryIndex()
+	if err := rebuilt.Put(ctx, docs...); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.postings, m.docs, m.totalLen = rebuilt.postings, rebuilt.docs, rebuilt.totalLen
+	return nil
+}
+
+func (m *MemoryIndex) Size(ctx context.Context) (int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return int64(len(m.docs)), nil
+}
+
+func (m *MemoryIndex) Search(ctx context.Context, query string, limit int) ([]Hit, error) {
+	terms := queryTerms(query)
+	prefix := prefixTerm(query, terms)
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if len(m.docs) == 0 {
+		return nil, nil
+	}
+	n := float64(len(m.docs))
+	avgLen := float64(m.totalLen) / n
+
+	hits := make(map[uint]*Hit)
+	for _, term := range terms {
+		// 每个搜索词对每个文档只计一次匹配，前缀匹配取得分最高的词
+		best := make(map[uint]float64)
+		for _, indexed := range m.expand(term, term == prefix) {
+			postings := m.postings[indexed]
+			idf := math.Log(1 + (n-float64(len(postings))+0.5)/(float64(len(postings))+0.5))
+			for id, weight := range postings {
+				tf := float64(weight)
+				docLen := float64(m.docs[id].length)
+				score := idf * tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*docLen/avgLen))
+				if score > best[id] {
+					best[id] = score
+				}
+			}
+		}
+		for id, score := range best {
+			hit := hits[id]
+			if hit == nil {
+				hit = &Hit{ID: id}
+				hits[id] = hit
+			}
+			hit.Score += score
+			hit.Matched++
+		}
+	}
+
+	result := make([]Hit, 0, len(hits))
+	for _, hit := range hits {
+		result = append(result, *hit)
+	}
+	sortHits(result)
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// 搜索词对应的索引词，前缀匹配时包括所有以它开头的词
+func (m *MemoryIndex) expand(term string, prefix bool) []string {
+	if !prefix {
+		return []string{term}
+	}
+	var terms []string
+	for indexed := range m.postings {
+		if strings.HasPrefix(indexed, term) {
+			terms = append(terms, indexed)
+		}
+	}
+	return terms
+}
+
+// 匹配的搜索词越多越靠前，其次按得分，得分相同时按ID保证顺序稳定
+func sortHits(hits []Hit) {
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Matched != hits[j].Matched {
+			return hits[i].Matched > hits[j].Matched
+		}
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID < hits[j].ID
+	})
+}
@@ -0,0 +1,72 @@
+// Package search 提供设备目录的全文搜索：中英文分词、相关度排序和高亮。
+// 索引可替换：DBIndex 将倒排索引保存在数据库中，多实例共享；MemoryIndex 为进程内索引，
+// 启动时从数据库构建，使用BM25计算相关度。
+package search
+
+import (
+	"context"
+	"e-device-recycle-backend/models"
+	"strings"
+)
+
+// 参与搜索的字段及权重，品牌、型号和名称的匹配比配置参数更重要
+var FieldWeights = map[string]int{
+	"name":    3,
+	"brand":   3,
+	"model":   3,
+	"cpu":     2,
+	"memory":  1,
+	"storage": 1,
+}
+
+// 被索引的设备
+type Document struct {
+	ID     uint
+	Fields map[string]string
+}
+
+// 设备的搜索文档，brandAliases为品牌的别名，与品牌名称一起作为品牌字段
+func DeviceDocument(device models.Device, brandAliases ...string) Document {
+	brand := strings.Join(append([]string{device.Brand}, brandAliases...), " ")
+	return Document{
+		ID: device.ID,
+		Fields: map[string]string{
+			"name":    device.Name,
+			"brand":   brand,
+			"model":   device.Model,
+			"cpu":     device.CPU,
+			"memory":  device.Memory,
+			"storage": device.Storage,
+		},
+	}
+}
+
+// 文档中每个词的权重：词在字段中出现的次数乘以字段权重
+func (d Document) termWeights() map[string]int {
+	weights := make(map[string]int)
+	for field, text := range d.Fields {
+		for _, term := range documentTerms(text) {
+			weights[term] += FieldWeights[field]
+		}
+	}
+	return weights
+}
+
+// 搜索命中的文档
+type Hit struct {
+	ID      uint
+	Score   float64
+	Matched int // 匹配的搜索词数量
+}
+
+type Index interface {
+	// 添加或替换文档
+	Put(ctx context.Context, docs ...Document) error
+	Delete(ctx context.Context, ids ...uint) error
+	// 按相关度从高到低返回匹配的文档，最多limit个：匹配的搜索词越多越靠前，其次按得分排序
+	Search(ctx context.Context, query string, limit int) ([]Hit, error)
+	// 清空索引并重新添加所有文档
+	Rebuild(ctx context.Context, docs []Document) error
+	// 已索引的文档数
+	Size(ctx context.Context) (int64, error)
+}
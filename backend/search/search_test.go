@@ -0,0 +1,129 @@
+package search
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestDocumentTerms(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"ThinkPad X1 Carbon", []string{"thinkpad", "x1", "x", "1", "carbon"}},
+		{"联想笔记本", []string{"联想", "想笔", "笔记", "记本"}},
+		{"华为", []string{"华为"}},
+		{"16 GB", []string{"16gb"}},
+		{"16G内存", []string{"16gb", "内存"}},
+		{"1T SSD", []string{"1tb", "ssd"}},
+		{"iPhone 15 Pro", []string{"iphone", "15", "pro"}},
+		{"2.5英寸", []string{"2.5", "英寸"}},
+	}
+	for _, tt := range tests {
+		if got := documentTerms(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("documentTerms(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestPrefixTerm(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"think", "think"},
+		{"think ", ""},
+		{"联想 think", "think"},
+		{"联想", ""},
+		{"x", ""},
+	}
+	for _, tt := range tests {
+		if got := prefixTerm(tt.query, queryTerms(tt.query)); got != tt.want {
+			t.Errorf("prefixTerm(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		text, query string
+		want        string
+		ok          bool
+	}{
+		{"ThinkPad X1", "thinkpad", "<em>ThinkPad</em> X1", true},
+		{"联想笔记本", "笔记本", "联想<em>笔记本</em>", true},
+		{"16GB", "16g", "<em>16GB</em>", true},
+		{"<b>Dell</b>", "dell", "&lt;b&gt;<em>Dell</em>&lt;/b&gt;", true},
+		{"MacBook", "dell", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := Highlight(tt.text, tt.query)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Highlight(%q, %q) = %q, %v, want %q, %v", tt.text, tt.query, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func testDocuments() []Document {
+	return []Document{
+		{ID: 1, Fields: map[string]string{"name": "ThinkPad X1 Carbon", "brand": "联想", "cpu": "Intel i7", "memory": "16GB"}},
+		{ID: 2, Fields: map[string]string{"name": "MacBook Pro", "brand": "Apple", "cpu": "M2", "memory": "16 GB"}},
+		{ID: 3, Fields: map[string]string{"name": "小新Pro 14", "brand": "联想", "cpu": "AMD R7", "memory": "32GB"}},
+		{ID: 4, Fields: map[string]string{"name": "Surface Pro", "brand": "Microsoft", "cpu": "Intel i5", "memory": "8GB"}},
+	}
+}
+
+func hitIDs(hits []Hit) []uint {
+	ids := make([]uint, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ID
+	}
+	return ids
+}
+
+func TestMemoryIndexSearch(t *testing.T) {
+	ctx := context.Background()
+	index := NewMemoryIndex()
+	if err := index.Rebuild(ctx, testDocuments()); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		query string
+		want  []uint
+	}{
+		{"联想", []uint{3, 1}},           // 匹配程度相同时字段内容越短越相关
+		{"联想 pro", []uint{3, 1, 2, 4}}, // 同时匹配两个词的排在前面
+		{"16g", []uint{2, 1}},
+		{"thin", []uint{1}}, // 最后一个词按前缀匹配
+		{"thin ", nil},      // 以空格结尾时不按前缀匹配
+		{"MACBOOK", []uint{2}},
+		{"macbook m2 16g", []uint{2, 1}},
+		{"华为", nil},
+	}
+	for _, tt := range tests {
+		hits, err := index.Search(ctx, tt.query, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hitIDs(hits); !reflect.DeepEqual(got, tt.want) && (len(got) > 0 || len(tt.want) > 0) {
+			t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+
+	// 更新和删除
+	if err := index.Put(ctx, Document{ID: 4, Fields: map[string]string{"name": "ThinkPad T14", "brand": "联想"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := index.Delete(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	hits, _ := index.Search(ctx, "thinkpad", 10)
+	if got := hitIDs(hits); !reflect.DeepEqual(got, []uint{4}) {
+		t.Errorf("更新后 Search(thinkpad) = %v, want [4]", got)
+	}
+	if size, _ := index.Size(ctx); size != 3 {
+		t.Errorf("Size = %d, want 3", size)
+	}
+}
@@ -0,0 +1,157 @@
+package search
+
+import (
+	"e-device-recycle-backend/models"
+	"sort"
+	"strings"
+)
+
+// 补全候选的类型，文本相同时按此顺序只保留一个
+const (
+	SuggestionBrand = "brand"
+	SuggestionModel = "model"
+	SuggestionName  = "name"
+)
+
+var suggestionTypeOrder = map[string]int{
+	SuggestionBrand: 0,
+	SuggestionModel: 1,
+	SuggestionName:  2,
+}
+
+// 补全候选，Popularity为相关设备的订单数
+type Suggestion struct {
+	Text       string
+	Type       string
+	Popularity int64
+}
+
+// 补全前缀索引，构建后只读，可并发查询。
+// 候选的完整文本和其中每个词开头的部分都可以前缀匹配（输入 "pro" 能补全 "MacBook Pro"）
+type SuggestIndex struct {
+	items   []Suggestion
+	entries []suggestEntry // 按key排序
+}
+
+type suggestEntry struct {
+	key   string
+	whole bool // key为完整文本，而不是从中间的词开始
+	item  int  // items中的下标
+}
+
+// 在售设备的品牌、型号和名称，热度为各设备订单数之和
+func DeviceSuggestions(devices []models.Device, orderCounts map[uint]int64) []Suggestion {
+	byKey := make(map[string]*Suggestion)
+	var keys []string
+	add := func(text, typ string, orders int64) {
+		text = strings.Join(strings.Fields(text), " ")
+		if text == "" {
+			return
+		}
+		key := normalizeSuggestion(text)
+		s, ok := byKey[key]
+		if !ok {
+			s = &Suggestion{Text: text, Type: typ}
+			byKey[key] = s
+			keys = append(keys, key)
+		} else if suggestionTypeOrder[typ] < suggestionTypeOrder[s.Type] {
+			s.Text, s.Type = text, typ
+		}
+		s.Popularity += orders
+	}
+
+	for _, device := range devices {
+		if device.Status != "active" {
+			continue
+		}
+		orders := orderCounts[device.ID]
+		add(device.Brand, SuggestionBrand, orders)
+		add(device.Model, SuggestionModel, orders)
+		// 名称与型号相同时只计一次
+		if normalizeSuggestion(device.Name) != normalizeSuggestion(device.Model) {
+			add(device.Name, SuggestionName, orders)
+		}
+	}
+
+	suggestions := make([]Suggestion, len(keys))
+	for i, key := range keys {
+		suggestions[i] = *byKey[key]
+	}
+	return suggestions
+}
+
+func NewSuggestIndex(suggestions []Suggestion) *SuggestIndex {
+	index := &SuggestIndex{items: append([]Suggestion(nil), suggestions...)}
+	for i, s := range index.items {
+		words := strings.Fields(normalizeSuggestion(s.Text))
+		for j := range words {
+			index.entries = append(index.entries, suggestEntry{
+				key:   strings.Join(words[j:], " "),
+				whole: j == 0,
+				item:  i,
+			})
+		}
+	}
+	sort.Slice(index.entries, func(i, j int) bool { return index.entries[i].key < index.entries[j].key })
+	return index
+}
+
+// 以prefix开头的候选：完整文本匹配的排在从中间的词匹配的前面，其次按热度，再按类型和文本
+func (s *SuggestIndex) Lookup(prefix string, limit int) []Suggestion {
+	// 保留输入末尾的空格，"pro " 只匹配以pro这个词开头的候选
+	trailingSpace := strings.HasSuffix(prefix, " ")
+	prefix = normalizeSuggestion(prefix)
+	if prefix == "" {
+		return nil
+	}
+	if trailingSpace {
+		prefix += " "
+	}
+
+	type match struct {
+		Suggestion
+		whole bool
+	}
+	matched := make(map[int]*match)
+	start := sort.Search(len(s.entries), func(i int) bool { return s.entries[i].key >= prefix })
+	for i := start; i < len(s.entries) && strings.HasPrefix(s.entries[i].key, prefix); i++ {
+		e := s.entries[i]
+		if m, ok := matched[e.item]; ok {
+			m.whole = m.whole || e.whole
+			continue
+		}
+		matched[e.item] = &match{Suggestion: s.items[e.item], whole: e.whole}
+	}
+
+	results := make([]match, 0, len(matched))
+	for _, m := range matched {
+		results = append(results, *m)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.whole != b.whole {
+			return a.whole
+		}
+		if a.Popularity != b.Popularity {
+			return a.Popularity > b.Popularity
+		}
+		if a.Type != b.Type {
+			return suggestionTypeOrder[a.Type] < suggestionTypeOrder[b.Type]
+		}
+		return a.Text < b.Text
+	})
+
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	suggestions := make([]Suggestion, len(results))
+	for i, m := range results {
+		suggestions[i] = m.Suggestion
+	}
+	return suggestions
+}
+
+// 补全按小写、合并空白后的文本匹配
+func normalizeSuggestion(text string) string {
+	return strings.Join(strings.Fields(strings.ToLower(text)), " ")
+}
@@ -0,0 +1,61 @@
+package search
+
+import (
+	"e-device-recycle-backend/models"
+	"reflect"
+	"testing"
+)
+
+func TestSuggestIndexLookup(t *testing.T) {
+	devices := []models.Device{
+		{ID: 1, Name: "MacBook Pro 14", Brand: "Apple", Model: "MacBook Pro", Status: "active"},
+		{ID: 2, Name: "MacBook Air", Brand: "Apple", Model: "MacBook Air", Status: "active"},
+		{ID: 3, Name: "小新Pro 14 笔记本", Brand: "联想", Model: "小新Pro 14", Status: "active"},
+		{ID: 4, Name: "Mate 60", Brand: "华为", Model: "Mate 60", Status: "active"},
+		{ID: 5, Name: "Macintosh Classic", Brand: "apple", Status: "inactive"},
+	}
+	orders := map[uint]int64{1: 2, 2: 5, 3: 1, 5: 100}
+	index := NewSuggestIndex(DeviceSuggestions(devices, orders))
+
+	texts := func(suggestions []Suggestion) []string {
+		var texts []string
+		for _, s := range suggestions {
+			texts = append(texts, s.Text)
+		}
+		return texts
+	}
+
+	tests := []struct {
+		prefix string
+		limit  int
+		want   []string
+	}{
+		// 整体以输入开头的在前，其次按订单数
+		{"mac", 0, []string{"MacBook Air", "MacBook Pro", "MacBook Pro 14"}},
+		{"MACBOOK P", 0, []string{"MacBook Pro", "MacBook Pro 14"}},
+		{"ap", 0, []string{"Apple"}},
+		{"pro", 0, []string{"MacBook Pro", "MacBook Pro 14"}},
+		{"pro ", 0, []string{"MacBook Pro 14"}},
+		{"联", 0, []string{"联想"}},
+		{"笔记", 0, []string{"小新Pro 14 笔记本"}},
+		{"m", 2, []string{"MacBook Air", "MacBook Pro"}},
+		{"dell", 0, nil},
+		{" ", 0, nil},
+	}
+	for _, tt := range tests {
+		if got := texts(index.Lookup(tt.prefix, tt.limit)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Lookup(%q) = %q, want %q", tt.prefix, got, tt.want)
+		}
+	}
+
+	// 品牌的热度为所有在售设备的订单数之和，已下架设备不计
+	apple := index.Lookup("apple", 1)
+	if len(apple) != 1 || apple[0].Type != SuggestionBrand || apple[0].Popularity != 7 {
+		t.Errorf("Lookup(apple) = %+v, want brand with popularity 7", apple)
+	}
+	// 名称与型号相同时只保留型号
+	mate := index.Lookup("mate", 0)
+	if len(mate) != 1 || mate[0].Type != SuggestionModel {
+		t.Errorf("Lookup(mate) = %+v, want one model", mate)
+	}
+}
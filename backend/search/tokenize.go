@@ -0,0 +1,177 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// 单个词的最大长度（字符），超出的词不索引
+const maxTermLength = 64
+
+// 容量单位，"16G"、"16 GB" 统一为 "16gb"
+var unitAliases = map[string]string{
+	"g":  "gb",
+	"gb": "gb",
+	"t":  "tb",
+	"tb": "tb",
+}
+
+// 将文本切分为词：英文和数字按连续字母数字切分并转为小写，中文等按相邻两字切分（二元组），
+// 单独的一个汉字保留为一个词。"13.3"这样的小数保持完整，容量统一单位写法
+func splitWords(text string) []string {
+	runes := []rune(strings.ToLower(text))
+	var words []string
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case isCJK(r):
+			j := i
+			for j < len(runes) && isCJK(runes[j]) {
+				j++
+			}
+			if j-i == 1 {
+				words = append(words, string(runes[i]))
+			}
+			for k := i; k+1 < j; k++ {
+				words = append(words, string(runes[k:k+2]))
+			}
+			i = j
+		case isWordRune(r):
+			j := i
+			for j < len(runes) && (isWordRune(runes[j]) || isDecimalPoint(runes, j)) {
+				j++
+			}
+			words = append(words, string(runes[i:j]))
+			i = j
+		default:
+			i++
+		}
+	}
+
+	return normalizeUnits(words)
+}
+
+// 文档的词：除完整的词外，字母和数字混合的词（如 "rtx4060"、"i7"）再按字母/数字边界拆分，
+// 使搜索 "4060" 也能匹配；容量（如 "16gb"）不拆分
+func documentTerms(text string) []string {
+	var terms []string
+	for _, word := range splitWords(text) {
+		if len([]rune(word)) > maxTermLength {
+			continue
+		}
+		terms = append(terms, word)
+		if isCapacity(word) {
+			continue
+		}
+		if parts := splitAlnum(word); len(parts) > 1 {
+			terms = append(terms, parts...)
+		}
+	}
+	return terms
+}
+
+// 搜索词去重，保持原有顺序
+func queryTerms(query string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, word := range splitWords(query) {
+		if len([]rune(word)) > maxTermLength || seen[word] {
+			continue
+		}
+		seen[word] = true
+		terms = append(terms, word)
+	}
+	return terms
+}
+
+// 最后一个搜索词作为前缀匹配（用户可能还没输完），只用于至少两个字符的英文/数字词，
+// 搜索内容以空白结尾时表示已输入完整，不做前缀匹配
+func prefixTerm(query string, terms []string) string {
+	if len(terms) == 0 || strings.TrimRightFunc(query, unicode.IsSpace) != query {
+		return ""
+	}
+	last := terms[len(terms)-1]
+	if len(last) < 2 || !isWordRune([]rune(last)[0]) {
+		return ""
+	}
+	return last
+}
+
+// 合并 "16 gb" 为 "16gb"，统一 "16g" 为 "16gb"
+func normalizeUnits(words []string) []string {
+	var result []string
+	for i := 0; i < len(words); i++ {
+		word := words[i]
+		if isDigits(word) && i+1 < len(words) {
+			if unit, ok := unitAliases[words[i+1]]; ok {
+				result = append(result, word+unit)
+				i++
+				continue
+			}
+		}
+		if number, unit, ok := splitCapacity(word); ok {
+			word = number + unitAliases[unit]
+		}
+		result = append(result, word)
+	}
+	return result
+}
+
+// 拆分 "16g" 为 "16" 和 "g"
+func splitCapacity(word string) (number, unit string, ok bool) {
+	i := strings.IndexFunc(word, func(r rune) bool { return !isDigit(r) && r != '.' })
+	if i <= 0 {
+		return "", "", false
+	}
+	number, unit = word[:i], word[i:]
+	if _, known := unitAliases[unit]; !known || !isDigit(rune(number[0])) {
+		return "", "", false
+	}
+	return number, unit, true
+}
+
+func isCapacity(word string) bool {
+	_, _, ok := splitCapacity(word)
+	return ok
+}
+
+// 按字母和数字的边界拆分，如 "rtx4060ti" -> ["rtx", "4060", "ti"]
+func splitAlnum(word string) []string {
+	var parts []string
+	runes := []rune(word)
+	start := 0
+	for i := 1; i <= len(runes); i++ {
+		if i == len(runes) || isDigit(runes[i]) != isDigit(runes[i-1]) && runes[i] != '.' && runes[i-1] != '.' {
+			parts = append(parts, string(runes[start:i]))
+			start = i
+		}
+	}
+	return parts
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+func isWordRune(r rune) bool {
+	return (unicode.IsLetter(r) || unicode.IsDigit(r)) && !isCJK(r)
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if !isDigit(r) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// 位于两个数字之间的小数点
+func isDecimalPoint(runes []rune, i int) bool {
+	return runes[i] == '.' && i > 0 && i+1 < len(runes) && isDigit(runes[i-1]) && isDigit(runes[i+1])
+}
@@ -0,0 +1,107 @@
+package security
+
+import (
+	"context"
+	"e-device-recycle-backend/store"
+	"strings"
+	"time"
+)
+
+// 登录防暴力破解：按用户名和IP统计失败次数，逐步延迟并临时锁定
+type LoginGuard struct {
+	store store.CounterStore
+
+	Window        time.Duration // 失败次数统计窗口
+	DelayAfter    int64         // 失败多少次后开始延迟
+	BaseDelay     time.Duration // 首次延迟时间，之后每次翻倍
+	MaxDelay      time.Duration
+	MaxFailures   int64 // 同一用户名失败多少次后锁定
+	IPMaxFailures int64 // 同一IP失败多少次后锁定
+	LockDuration  time.Duration
+}
+
+func NewLoginGuard(s store.CounterStore) *LoginGuard {
+	return &LoginGuard{
+		store:         s,
+		Window:        15 * time.Minute,
+		DelayAfter:    3,
+		BaseDelay:     time.Second,
+		MaxDelay:      30 * time.Second,
+		MaxFailures:   5,
+		IPMaxFailures: 20,
+		LockDuration:  15 * time.Minute,
+	}
+}
+
+// 全局登录保护，由main初始化
+var Guard *LoginGuard
+
+func userKey(username string) string {
+	return "login:user:" + strings.ToLower(strings.TrimSpace(username))
+}
+
+func ipKey(ip string) string {
+	return "login:ip:" + ip
+}
+
+// 检查是否允许尝试登录，返回需要等待的时间
+func (g *LoginGuard) Check(ctx context.Context, username, ip string) (time.Duration, error) {
+	wait, err := g.store.LockTTL(ctx, "lock:"+userKey(username))
+	if err != nil || wait > 0 {
+		return wait, err
+	}
+	return g.store.LockTTL(ctx, "lock:"+ipKey(ip))
+}
+
+// 记录一次失败，返回该用户名的累计失败次数和需要等待的时间
+func (g *LoginGuard) Fail(ctx context.Context, username, ip string) (int64, time.Duration, error) {
+	failures, err := g.store.Incr(ctx, userKey(username), g.Window)
+	if err != nil {
+		return 0, 0, err
+	}
+	ipFailures, err := g.store.Incr(ctx, ipKey(ip), g.Window)
+	if err != nil {
+		return failures, 0, err
+	}
+
+	var wait time.Duration
+	switch {
+	case failures >= g.MaxFailures:
+		wait = g.LockDuration
+	case failures >= g.DelayAfter:
+		wait = g.BaseDelay << uint(failures-g.DelayAfter)
+		if wait > g.MaxDelay {
+			wait = g.MaxDelay
+		}
+	}
+	if wait > 0 {
+		if err := g.store.Lock(ctx, "lock:"+userKey(username), wait); err != nil {
+			return failures, 0, err
+		}
+	}
+
+	if ipFailures >= g.IPMaxFailures {
+		if err := g.store.Lock(ctx, "lock:"+ipKey(ip), g.LockDuration); err != nil {
+			return failures, wait, err
+		}
+		if g.LockDuration > wait {
+			wait = g.LockDuration
+		}
+	}
+
+	return failures, wait, nil
+}
+
+// 登录成功后清除该用户名的失败记录
+func (g *LoginGuard) Succeed(ctx context.Context, username string) error {
+	return g.store.Delete(ctx, userKey(username), "lock:"+userKey(username))
+}
+
+// 管理员解除锁定，ip为空时只解除用户名锁定
+func (g *LoginGuard) Unlock(ctx context.Context, username, ip string) error {
+	keys := []string{userKey(username), "lock:" + userKey(username)}
+	if ip != "" {
+		keys = append(keys, ipKey(ip), "lock:"+ipKey(ip))
+	}
+	return g.store.Delete(ctx, keys...)
+}
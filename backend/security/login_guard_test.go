@@ -0,0 +1,97 @@
+package security
+
+import (
+	"context"
+	"e-device-recycle-backend/store"
+	"testing"
+	"time"
+)
+
+func newGuard() *LoginGuard {
+	g := NewLoginGuard(store.NewMemoryCounterStore())
+	g.DelayAfter = 2
+	g.BaseDelay = time.Second
+	g.MaxDelay = 3 * time.Second
+	g.MaxFailures = 4
+	g.IPMaxFailures = 6
+	g.LockDuration = time.Minute
+	return g
+}
+
+func TestLoginGuardDelayAndLock(t *testing.T) {
+	ctx := context.Background()
+	g := newGuard()
+
+	// 第2次失败开始延迟并逐次翻倍，不超过MaxDelay，第4次锁定
+	for i, want := range []time.Duration{0, time.Second, 2 * time.Second, time.Minute} {
+		failures, wait, err := g.Fail(ctx, "Alice", "192.0.2.1")
+		if err != nil || failures != int64(i+1) || wait != want {
+			t.Errorf("第%d次失败 = %d, %v, %v, want %d, %v", i+1, failures, wait, err, i+1, want)
+		}
+	}
+
+	// 用户名不区分大小写和首尾空格
+	if wait, err := g.Check(ctx, " alice ", "192.0.2.2"); err != nil || wait <= 0 {
+		t.Errorf("Check = %v, %v, want 锁定", wait, err)
+	}
+	// 其他用户名不受影响
+	if wait, _ := g.Check(ctx, "bob", "192.0.2.1"); wait != 0 {
+		t.Errorf("Check(bob) = %v, want 0", wait)
+	}
+
+	if err := g.Succeed(ctx, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if wait, _ := g.Check(ctx, "alice", "192.0.2.1"); wait != 0 {
+		t.Errorf("Succeed后 Check = %v, want 0", wait)
+	}
+	if failures, _, _ := g.Fail(ctx, "alice", "192.0.2.1"); failures != 1 {
+		t.Errorf("Succeed后 failures = %d, want 1", failures)
+	}
+}
+
+func TestLoginGuardMaxDelay(t *testing.T) {
+	ctx := context.Background()
+	g := newGuard()
+	g.MaxFailures = 10
+
+	var wait time.Duration
+	for i := 0; i < 5; i++ {
+		_, wait, _ = g.Fail(ctx, "alice", "192.0.2.1")
+	}
+	if wait != g.MaxDelay {
+		t.Errorf("wait = %v, want %v", wait, g.MaxDelay)
+	}
+}
+
+func TestLoginGuardIPLock(t *testing.T) {
+	ctx := context.Background()
+	g := newGuard()
+
+	// 同一IP尝试不同用户名，达到IPMaxFailures后锁定IP
+	var wait time.Duration
+	for i := 0; i < 6; i++ {
+		_, wait, _ = g.Fail(ctx, string(rune('a'+i))+"-user", "192.0.2.1")
+	}
+	if wait != g.LockDuration {
+		t.Errorf("wait = %v, want %v", wait, g.LockDuration)
+	}
+	if wait, _ := g.Check(ctx, "other", "192.0.2.1"); wait <= 0 {
+		t.Error("IP未锁定")
+	}
+	if wait, _ := g.Check(ctx, "other", "192.0.2.9"); wait != 0 {
+		t.Errorf("其他IP Check = %v, want 0", wait)
+	}
+
+	// 只解除用户名时IP仍然锁定
+	g.Unlock(ctx, "other", "")
+	if wait, _ := g.Check(ctx, "other", "192.0.2.1"); wait <= 0 {
+		t.Error("未指定IP时不应解除IP锁定")
+	}
+	if err := g.Unlock(ctx, "other", "192.0.2.1"); err != nil {
+		t.Fatal(err)
+	}
+	if wait, _ := g.Check(ctx, "other", "192.0.2.1"); wait != 0 {
+		t.Errorf("解除后 Check = %v, want 0", wait)
+	}
+}
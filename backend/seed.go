@@ -0,0 +1,140 @@
+package main
+
+import (
+	"e-device-recycle-backend/config"
+	"e-device-recycle-backend/models"
+	"e-device-recycle-backend/repositories"
+	"e-device-recycle-backend/services"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"gopkg.in/yaml.v3"
+)
+
+// 种子数据文件格式
+type seedFile struct {
+	Devices []models.DeviceCreateRequest `json:"devices"`
+}
+
+// seed 子命令：从YAML/JSON文件导入设备，已存在的设备（名称、品牌、型号相同）跳过。
+// 品牌和型号按品牌目录识别，品牌不在目录中时导入失败
+func runSeed(args []string) {
+	fs := flag.NewFlagSet("seed", flag.ExitOnError)
+	file := fs.String("file", "scripts/seed/devices.yaml", "种子数据文件（.yaml/.yml/.json）")
+	update := fs.Bool("update", false, "更新已存在的设备")
+	dryRun := fs.Bool("dry-run", false, "只校验文件，不写入数据库")
+	fs.Parse(args)
+
+	data, err := loadSeedFile(*file)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// 使用与接口相同的校验规则
+	for i, req := range data.Devices {
+		if err := binding.Validator.ValidateStruct(req); err != nil {
+			log.Fatalf("第%d个设备(%s)数据无效: %v", i+1, req.Name, err)
+		}
+	}
+	if *dryRun {
+		fmt.Printf("校验通过，共%d个设备\n", len(data.Devices))
+		return
+	}
+
+	// 与接口使用同一个设备服务创建和更新，搜索引擎为database时同时更新索引；
+	// 进程内索引在服务启动时构建
+	models.InitDB()
+	svc := services.New(repositories.New(models.DB), newSearchIndex(config.GetConfig()))
+
+	var created, updated, skipped int
+	for _, req := range data.Devices {
+		brand, model, err := svc.Catalog.Resolve(req.ModelID, req.Brand, req.Model)
+		if err != nil {
+			log.Fatalf("设备 %s 的品牌或型号无效: %v", req.Name, err)
+		}
+		modelName := strings.TrimSpace(req.Model)
+		if model != nil {
+			modelName = model.Name
+		}
+
+		var existing models.Device
+		err = models.DB.Where("name = ? AND brand = ? AND model = ?", req.Name, brand.Name, modelName).
+			First(&existing).Error
+		switch {
+		case err != nil:
+			if _, err := svc.Devices.Create(req); err != nil {
+				log.Fatalf("创建设备 %s 失败: %v", req.Name, err)
+			}
+			created++
+		case *update:
+			if _, _, err := svc.Devices.Update(existing.ID, seedUpdates(req)); err != nil {
+				log.Fatalf("更新设备 %s 失败: %v", req.Name, err)
+			}
+			updated++
+		default:
+			skipped++
+		}
+	}
+
+	fmt.Printf("导入完成: 新增%d个, 更新%d个, 跳过%d个\n", created, updated, skipped)
+}
+
+// 用种子数据覆盖已存在的设备并重新上架，未填写基础价格时保留原价格
+func seedUpdates(req models.DeviceCreateRequest) map[string]interface{} {
+	updates := map[string]interface{}{
+		"name":        req.Name,
+		"brand":       req.Brand,
+		"model":       req.Model,
+		"model_id":    float64(req.ModelID),
+		"category":    req.Category,
+		"cpu":         req.CPU,
+		"memory":      req.Memory,
+		"storage":     req.Storage,
+		"graphics":    req.Graphics,
+		"screen":      req.Screen,
+		"specs":       req.Specs,
+		"condition":   req.Condition,
+		"year_bought": req.YearBought,
+		"description": req.Description,
+		"images":      req.Images,
+		"status":      "active",
+	}
+	if req.BasePrice > 0 {
+		updates["base_price"] = req.BasePrice
+	}
+	return updates
+}
+
+// 读取种子文件，YAML先转换为JSON以复用请求结构体的json标签
+func loadSeedFile(path string) (*seedFile, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取种子文件失败: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		var raw interface{}
+		if err := yaml.Unmarshal(content, &raw); err != nil {
+			return nil, fmt.Errorf("解析YAML失败: %w", err)
+		}
+		if content, err = json.Marshal(raw); err != nil {
+			return nil, fmt.Errorf("解析YAML失败: %w", err)
+		}
+	case ".json":
+	default:
+		return nil, fmt.Errorf("不支持的文件格式: %s", path)
+	}
+
+	var data seedFile
+	if err := json.Unmarshal(content, &data); err != nil {
+		return nil, fmt.Errorf("解析种子文件失败: %w", err)
+	}
+	return &data, nil
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"context"
+	"e-device-recycle-backend/config"
+	"e-device-recycle-backend/health"
+	"e-device-recycle-backend/identity"
+	"e-device-recycle-backend/logging"
+	"e-device-recycle-backend/metrics"
+	"e-device-recycle-backend/middleware"
+	"e-device-recycle-backend/models"
+	"e-device-recycle-backend/repositories"
+	"e-device-recycle-backend/routes"
+	"e-device-recycle-backend/search"
+	"e-device-recycle-backend/security"
+	"e-device-recycle-backend/services"
+	"e-device-recycle-backend/store"
+	"e-device-recycle-backend/utils"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// serve 子命令：启动HTTP服务
+func runServe(args []string) {
+	cfg := config.GetConfig()
+
+	// 初始化结构化日志
+	logging.Init(cfg.Log.Level, cfg.Log.Format)
+	for _, warning := range config.Warnings() {
+		slog.Warn(warning)
+	}
+
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	port := fs.String("port", cfg.Server.Port, "监听端口，覆盖配置文件和PORT")
+	fs.Parse(args)
+
+	if cfg.IsProduction() {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	// 初始化数据库
+	models.InitDB()
+
+	// 数据库连接池指标
+	if sqlDB, err := models.DB.DB(); err == nil {
+		metrics.RegisterDB(sqlDB, cfg.Database.Name)
+	}
+
+	// 初始化Redis（可选）
+	store.InitRedis()
+
+	// 登录防暴力破解
+	security.Guard = security.NewLoginGuard(store.NewCounterStore())
+	security.Guard.MaxFailures = int64(cfg.Auth.LoginMaxFailures)
+	security.Guard.IPMaxFailures = int64(cfg.Auth.LoginIPMaxFailures)
+	security.Guard.LockDuration = time.Duration(cfg.Auth.LoginLockMinutes) * time.Minute
+
+	// 接口限流
+	if cfg.RateLimit.Enabled {
+		middleware.SetRateLimiter(store.NewRateLimiter())
+		middleware.SetAPIKeys(cfg.RateLimit.APIKeys)
+	}
+
+	// 邮件通知
+	if email := cfg.Notification.Email; email.Driver == "smtp" {
+		utils.SetNotifier(utils.SMTPNotifier{
+			Host:     email.SMTPHost,
+			Port:     email.SMTPPort,
+			Username: email.SMTPUsername,
+			Password: email.SMTPPassword,
+			From:     email.From,
+		})
+	}
+
+	// 注册第三方登录
+	if cfg.WeChat.AppID != "" {
+		identity.Register(identity.NewWeChatProvider(cfg.WeChat.AppID, cfg.WeChat.AppSecret, cfg.WeChat.APIBase))
+	}
+
+	// 创建Gin引擎
+	r := gin.New()
+	if err := r.SetTrustedProxies(trustedProxies(cfg)); err != nil {
+		slog.Error("server.trusted_proxies 配置无效", "error", err)
+		os.Exit(1)
+	}
+	r.MaxMultipartMemory = int64(cfg.Storage.MaxUploadMB) << 20
+	r.Use(middleware.RequestID(), middleware.AccessLog(), middleware.Metrics(), middleware.Recovery())
+
+	// 配置CORS
+	corsConfig := cors.DefaultConfig()
+	corsConfig.AllowAllOrigins = true
+	corsConfig.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization", "X-API-Key", "Accept-Language", "X-Request-ID"}
+	corsConfig.ExposeHeaders = []string{"Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining", "Content-Language", "X-Request-ID"}
+	r.Use(cors.New(corsConfig))
+
+	// 组装仓储和业务服务，注入到控制器
+	index := newSearchIndex(cfg)
+	repos := repositories.New(models.DB)
+	svc := services.New(repos, index)
+	buildSearchIndex(svc.Devices, index)
+	if cfg.Cache.Enabled {
+		svc.Devices = services.NewCachedDeviceService(svc.Devices, store.NewCache(), time.Duration(cfg.Cache.DeviceTTLSeconds)*time.Second)
+		// 目录变更时通过带缓存的设备服务同步，使设备缓存失效
+		svc.Catalog = services.NewCatalogService(repos, svc.Devices)
+		svc.Categories = services.NewCategoryService(repos, svc.Devices)
+	}
+
+	// 设置路由
+	routes.SetupRoutes(r, svc)
+
+	// 启动服务器
+	servers := []*http.Server{newHTTPServer(":"+*port, r, cfg)}
+
+	// Prometheus指标：优先使用独立端口，否则在主端口上通过令牌保护
+	switch {
+	case cfg.Metrics.Addr != "":
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics.Handler(cfg.Metrics.Token))
+		servers = append(servers, newHTTPServer(cfg.Metrics.Addr, mux, cfg))
+	case cfg.Metrics.Token != "":
+		r.GET("/metrics", gin.WrapH(metrics.Handler(cfg.Metrics.Token)))
+	}
+
+	// 收到SIGINT/SIGTERM时优雅退出
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// memory索引定期重建，同步其他实例的修改
+	if cfg.Search.Engine == "memory" && cfg.Search.MemoryRefreshSeconds > 0 {
+		go refreshSearchIndex(ctx, svc.Devices, time.Duration(cfg.Search.MemoryRefreshSeconds)*time.Second)
+	}
+
+	errs := make(chan error, len(servers))
+	for _, srv := range servers {
+		go func(srv *http.Server) {
+			slog.Info("服务器启动", "addr", srv.Addr)
+			if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				errs <- fmt.Errorf("监听 %s 失败: %w", srv.Addr, err)
+			}
+		}(srv)
+	}
+
+	select {
+	case <-ctx.Done():
+		slog.Info("收到退出信号，开始停止服务")
+	case err := <-errs:
+		slog.Error("服务器异常退出", "error", err)
+	}
+	stop()
+
+	shutdown(servers, time.Duration(cfg.Server.ShutdownDelay)*time.Second, time.Duration(cfg.Server.ShutdownTimeout)*time.Second)
+}
+
+// 只有来自反向代理的请求才按X-Forwarded-For识别客户端IP，否则客户端可伪造IP绕过限流和登录锁定
+func trustedProxies(cfg *config.Config) []string {
+	if len(cfg.Server.TrustedProxies) == 0 {
+		return nil
+	}
+	return cfg.Server.TrustedProxies
+}
+
+// 根据配置创建设备搜索索引
+func newSearchIndex(cfg *config.Config) search.Index {
+	if cfg.Search.Engine == "memory" {
+		return search.NewMemoryIndex()
+	}
+	return search.NewDBIndex(models.DB)
+}
+
+// memory索引启动时构建；database索引为空（如刚执行迁移）时从现有设备构建
+func buildSearchIndex(devices services.DeviceService, index search.Index) {
+	if _, ok := index.(*search.DBIndex); ok {
+		if size, err := index.Size(context.Background()); err != nil || size > 0 {
+			return
+		}
+	}
+
+	start := time.Now()
+	n, err := devices.Reindex()
+	if err != nil {
+		slog.Error("构建设备搜索索引失败，可执行 reindex 命令重试", "error", err)
+		return
+	}
+	slog.Info("设备搜索索引已构建", "devices", n, "duration_ms", time.Since(start).Milliseconds())
+}
+
+func refreshSearchIndex(ctx context.Context, devices services.DeviceService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := devices.Reindex(); err != nil {
+				slog.Error("重建设备搜索索引失败", "error", err)
+			}
+		}
+	}
+}
+
+// 创建带超时设置的HTTP服务器
+func newHTTPServer(addr string, handler http.Handler, cfg *config.Config) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       time.Duration(cfg.Server.ReadTimeout) * time.Second,
+		WriteTimeout:      time.Duration(cfg.Server.WriteTimeout) * time.Second,
+		IdleTimeout:       time.Duration(cfg.Server.IdleTimeout) * time.Second,
+	}
+}
+
+// 优雅退出：先让就绪检查失败，delay内继续处理请求，等负载均衡和Nginx发现实例不可用后
+// 再停止接收新连接并等待处理中的请求完成，最后关闭Redis和数据库连接
+func shutdown(servers []*http.Server, delay, timeout time.Duration) {
+	health.SetShuttingDown()
+	if delay > 0 {
+		slog.Info("就绪检查已失败，等待负载均衡摘除实例", "delay", delay)
+		time.Sleep(delay)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, srv := range servers {
+		wg.Add(1)
+		go func(srv *http.Server) {
+			defer wg.Done()
+			if err := srv.Shutdown(ctx); err != nil {
+				slog.Error("等待请求处理完成超时，强制关闭连接", "addr", srv.Addr, "error", err)
+				srv.Close()
+			}
+		}(srv)
+	}
+	wg.Wait()
+
+	if store.Redis != nil {
+		if err := store.Redis.Close(); err != nil {
+			slog.Error("关闭Redis连接失败", "error", err)
+		}
+	}
+	if sqlDB, err := models.DB.DB(); err == nil {
+		if err := sqlDB.Close(); err != nil {
+			slog.Error("关闭数据库连接失败", "error", err)
+		}
+	}
+
+	slog.Info("服务已停止")
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"e-device-recycle-backend/listquery"
+	"e-device-recycle-backend/models"
+	"e-device-recycle-backend/repositories"
+)
+
+// 导出时每批读取的行数
+const auditExportBatch = 500
+
+type AuditLogService interface {
+	Record(entry *models.AuditLog) error
+	List(filter repositories.AuditLogFilter, params listquery.Params) ([]models.AuditLog, int64, error)
+	// 按ID从新到旧分批读取最多limit条，fn返回错误时停止
+	Export(filter repositories.AuditLogFilter, limit int, fn func(batch []models.AuditLog) error) error
+}
+
+type auditLogService struct {
+	repos repositories.Repositories
+}
+
+func NewAuditLogService(repos repositories.Repositories) AuditLogService {
+	return &auditLogService{repos: repos}
+}
+
+func (s *auditLogService) Record(entry *models.AuditLog) error {
+	return s.repos.AuditLogs().Create(entry)
+}
+
+func (s *auditLogService) List(filter repositories.AuditLogFilter, params listquery.Params) ([]models.AuditLog, int64, error) {
+	return s.repos.AuditLogs().List(filter, params)
+}
+
+func (s *auditLogService) Export(filter repositories.AuditLogFilter, limit int, fn func(batch []models.AuditLog) error) error {
+	// 每批从上一批最后一条之后开始（FindInBatches只支持按ID升序）
+	var lastID uint
+	for written := 0; written < limit; {
+		size := min(auditExportBatch, limit-written)
+		batch, err := s.repos.AuditLogs().ListBefore(filter, lastID, size)
+		if err != nil {
+			return err
+		}
+		if len(batch) > 0 {
+			if err := fn(batch); err != nil {
+				return err
+			}
+		}
+		if len(batch) < size {
+			return nil
+		}
+		written += len(batch)
+		lastID = batch[len(batch)-1].ID
+	}
+	return nil
+}
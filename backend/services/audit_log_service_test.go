@@ -0,0 +1,51 @@
+package services
+
+import (
+	"e-device-recycle-backend/models"
+	"e-device-recycle-backend/repositories"
+	"testing"
+)
+
+func TestAuditLogExport(t *testing.T) {
+	repos := newFakeRepositories()
+	svc := NewAuditLogService(repos)
+	for i := 0; i < 1200; i++ {
+		action := "device.update"
+		if i%2 == 0 {
+			action = "device.create"
+		}
+		if err := svc.Record(&models.AuditLog{Action: action, EntityType: "device"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 按ID从新到旧分批导出，不超过上限
+	export := func(filter repositories.AuditLogFilter, limit int) (ids []uint, batches int) {
+		err := svc.Export(filter, limit, func(batch []models.AuditLog) error {
+			batches++
+			for _, entry := range batch {
+				ids = append(ids, entry.ID)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ids, batches
+	}
+
+	ids, batches := export(repositories.AuditLogFilter{}, 1100)
+	if len(ids) != 1100 || batches != 3 {
+		t.Errorf("导出 %d 条 %d 批, want 1100 条 3 批", len(ids), batches)
+	}
+	for i := 1; i < len(ids); i++ {
+		if ids[i] >= ids[i-1] {
+			t.Fatalf("ids[%d] = %d 不小于 ids[%d] = %d", i, ids[i], i-1, ids[i-1])
+		}
+	}
+
+	ids, batches = export(repositories.AuditLogFilter{Action: "device.create"}, 50000)
+	if len(ids) != 600 || batches != 2 {
+		t.Errorf("按操作过滤导出 %d 条 %d 批, want 600 条 2 批", len(ids), batches)
+	}
+}
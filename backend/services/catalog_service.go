@@ -0,0 +1,450 @@
+package services
+
+import (
+	"e-device-recycle-backend/models"
+	"e-device-recycle-backend/repositories"
+	"errors"
+	"strings"
+)
+
+// 品牌、系列和型号目录（管理员维护）
+type CatalogService interface {
+	ListBrands() ([]models.Brand, error)
+	GetBrand(id uint) (*models.Brand, error)
+	CreateBrand(req models.BrandRequest) (*models.Brand, error)
+	// 更新品牌，返回更新前后的品牌；改名后同步设备上的品牌名称
+	UpdateBrand(id uint, req models.BrandRequest) (before, after *models.Brand, err error)
+	// 删除没有系列、型号和设备的品牌
+	DeleteBrand(id uint) (*models.Brand, error)
+
+	ListSeries(brandID uint) ([]models.DeviceSeries, error)
+	CreateSeries(req models.DeviceSeriesRequest) (*models.DeviceSeries, error)
+	UpdateSeries(id uint, req models.DeviceSeriesRequest) (before, after *models.DeviceSeries, err error)
+	// 删除没有型号的系列
+	DeleteSeries(id uint) (*models.DeviceSeries, error)
+
+	ListModels(filter repositories.DeviceModelFilter) ([]models.DeviceModel, error)
+	GetModel(id uint) (*models.DeviceModel, error)
+	CreateModel(req models.DeviceModelRequest) (*models.DeviceModel, error)
+	// 更新型号，返回更新前后的型号；改名或修改品牌后同步设备
+	UpdateModel(id uint, req models.DeviceModelRequest) (before, after *models.DeviceModel, err error)
+	// 删除没有设备使用的型号
+	DeleteModel(id uint) (*models.DeviceModel, error)
+
+	// 按型号ID或品牌、型号的名称和别名识别设备的品牌和型号，型号未收录时返回nil
+	Resolve(modelID uint, brand, model string) (*models.Brand, *models.DeviceModel, error)
+}
+
+type catalogService struct {
+	repos   repositories.Repositories
+	devices DeviceService
+}
+
+// devices用于目录变更后同步设备，传入带缓存的设备服务时同时使缓存失效
+func NewCatalogService(repos repositories.Repositories, devices DeviceService) CatalogService {
+	return &catalogService{repos: repos, devices: devices}
+}
+
+func (s *catalogService) ListBrands() ([]models.Brand, error) {
+	return s.repos.Catalog().ListBrands()
+}
+
+func (s *catalogService) GetBrand(id uint) (*models.Brand, error) {
+	brand, err := s.repos.Catalog().FindBrand(id)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrBrandNotFound
+	}
+	return brand, err
+}
+
+func (s *catalogService) CreateBrand(req models.BrandRequest) (*models.Brand, error) {
+	brand := models.Brand{
+		Name:    strings.TrimSpace(req.Name),
+		Aliases: cleanAliases(req.Name, req.Aliases),
+		Logo:    req.Logo,
+	}
+	if err := s.checkBrandNames(&brand); err != nil {
+		return nil, err
+	}
+	if err := s.repos.Catalog().CreateBrand(&brand); err != nil {
+		return nil, err
+	}
+	if err := s.syncDevices(); err != nil {
+		return nil, err
+	}
+	return &brand, nil
+}
+
+func (s *catalogService) UpdateBrand(id uint, req models.BrandRequest) (*models.Brand, *models.Brand, error) {
+	brand, err := s.GetBrand(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	before := *brand
+	brand.Name = strings.TrimSpace(req.Name)
+	brand.Aliases = cleanAliases(req.Name, req.Aliases)
+	brand.Logo = req.Logo
+	if err := s.checkBrandNames(brand); err != nil {
+		return nil, nil, err
+	}
+	if err := s.repos.Catalog().SaveBrand(brand); err != nil {
+		return nil, nil, err
+	}
+	if err := s.syncDevices(); err != nil {
+		return nil, nil, err
+	}
+	return &before, brand, nil
+}
+
+func (s *catalogService) DeleteBrand(id uint) (*models.Brand, error) {
+	brand, err := s.GetBrand(id)
+	if err != nil {
+		return nil, err
+	}
+
+	series, err := s.repos.Catalog().ListSeries(id)
+	if err != nil {
+		return nil, err
+	}
+	deviceModels, err := s.repos.Catalog().ListModels(repositories.DeviceModelFilter{BrandID: id})
+	if err != nil {
+		return nil, err
+	}
+	devices, err := s.repos.Devices().Count(repositories.DeviceFilter{BrandID: id})
+	if err != nil {
+		return nil, err
+	}
+	if len(series) > 0 || len(deviceModels) > 0 || devices > 0 {
+		return nil, ErrBrandInUse
+	}
+
+	if err := s.repos.Catalog().DeleteBrand(id); err != nil {
+		return nil, err
+	}
+	if err := s.syncDevices(); err != nil {
+		return nil, err
+	}
+	return brand, nil
+}
+
+// 品牌的名称和别名不能与其他品牌的名称或别名相同
+func (s *catalogService) checkBrandNames(brand *models.Brand) error {
+	brands, err := s.repos.Catalog().ListBrands()
+	if err != nil {
+		return err
+	}
+	keys := catalogKeys(brand.Name, brand.Aliases)
+	for _, other := range brands {
+		if other.ID != brand.ID && keys.overlaps(catalogKeys(other.Name, other.Aliases)) {
+			return ErrBrandExists
+		}
+	}
+	return nil
+}
+
+func (s *catalogService) ListSeries(brandID uint) ([]models.DeviceSeries, error) {
+	return s.repos.Catalog().ListSeries(brandID)
+}
+
+func (s *catalogService) getSeries(id uint) (*models.DeviceSeries, error) {
+	series, err := s.repos.Catalog().FindSeries(id)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrSeriesNotFound
+	}
+	return series, err
+}
+
+func (s *catalogService) CreateSeries(req models.DeviceSeriesRequest) (*models.DeviceSeries, error) {
+	series := models.DeviceSeries{BrandID: req.BrandID, Name: strings.TrimSpace(req.Name)}
+	if err := s.checkSeries(&series); err != nil {
+		return nil, err
+	}
+	if err := s.repos.Catalog().CreateSeries(&series); err != nil {
+		return nil, err
+	}
+	return &series, nil
+}
+
+func (s *catalogService) UpdateSeries(id uint, req models.DeviceSeriesRequest) (*models.DeviceSeries, *models.DeviceSeries, error) {
+	series, err := s.getSeries(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	before := *series
+	series.BrandID = req.BrandID
+	series.Name = strings.TrimSpace(req.Name)
+	if series.BrandID != before.BrandID {
+		// 系列下的型号属于原品牌，不能直接移动到其他品牌
+		deviceModels, err := s.repos.Catalog().ListModels(repositories.DeviceModelFilter{SeriesID: id})
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(deviceModels) > 0 {
+			return nil, nil, ErrSeriesInUse
+		}
+	}
+	if err := s.checkSeries(series); err != nil {
+		return nil, nil, err
+	}
+	if err := s.repos.Catalog().SaveSeries(series); err != nil {
+		return nil, nil, err
+	}
+	return &before, series, nil
+}
+
+func (s *catalogService) DeleteSeries(id uint) (*models.DeviceSeries, error) {
+	series, err := s.getSeries(id)
+	if err != nil {
+		return nil, err
+	}
+	deviceModels, err := s.repos.Catalog().ListModels(repositories.DeviceModelFilter{SeriesID: id})
+	if err != nil {
+		return nil, err
+	}
+	if len(deviceModels) > 0 {
+		return nil, ErrSeriesInUse
+	}
+	if err := s.repos.Catalog().DeleteSeries(id); err != nil {
+		return nil, err
+	}
+	return series, nil
+}
+
+// 品牌必须存在，系列名称在品牌内唯一（不区分大小写）
+func (s *catalogService) checkSeries(series *models.DeviceSeries) error {
+	if _, err := s.GetBrand(series.BrandID); err != nil {
+		return err
+	}
+	existing, err := s.repos.Catalog().ListSeries(series.BrandID)
+	if err != nil {
+		return err
+	}
+	for _, other := range existing {
+		if other.ID != series.ID && catalogKey(other.Name) == catalogKey(series.Name) {
+			return ErrSeriesExists
+		}
+	}
+	return nil
+}
+
+func (s *catalogService) ListModels(filter repositories.DeviceModelFilter) ([]models.DeviceModel, error) {
+	return s.repos.Catalog().ListModels(filter)
+}
+
+func (s *catalogService) GetModel(id uint) (*models.DeviceModel, error) {
+	model, err := s.repos.Catalog().FindModel(id)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrModelNotFound
+	}
+	return model, err
+}
+
+func (s *catalogService) CreateModel(req models.DeviceModelRequest) (*models.DeviceModel, error) {
+	model := models.DeviceModel{}
+	setModelFields(&model, req)
+	if err := s.checkModel(&model); err != nil {
+		return nil, err
+	}
+	if err := s.repos.Catalog().CreateModel(&model); err != nil {
+		return nil, err
+	}
+	if err := s.syncDevices(); err != nil {
+		return nil, err
+	}
+	return &model, nil
+}
+
+func (s *catalogService) UpdateModel(id uint, req models.DeviceModelRequest) (*models.DeviceModel, *models.DeviceModel, error) {
+	model, err := s.GetModel(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	before := *model
+	setModelFields(model, req)
+	if err := s.checkModel(model); err != nil {
+		return nil, nil, err
+	}
+	if err := s.repos.Catalog().SaveModel(model); err != nil {
+		return nil, nil, err
+	}
+	if err := s.syncDevices(); err != nil {
+		return nil, nil, err
+	}
+	return &before, model, nil
+}
+
+func (s *catalogService) DeleteModel(id uint) (*models.DeviceModel, error) {
+	model, err := s.GetModel(id)
+	if err != nil {
+		return nil, err
+	}
+	devices, err := s.repos.Devices().Count(repositories.DeviceFilter{ModelID: id})
+	if err != nil {
+		return nil, err
+	}
+	if devices > 0 {
+		return nil, ErrModelInUse
+	}
+	if err := s.repos.Catalog().DeleteModel(id); err != nil {
+		return nil, err
+	}
+	if err := s.syncDevices(); err != nil {
+		return nil, err
+	}
+	return model, nil
+}
+
+func setModelFields(model *models.DeviceModel, req models.DeviceModelRequest) {
+	model.BrandID = req.BrandID
+	model.SeriesID = req.SeriesID
+	model.Name = strings.TrimSpace(req.Name)
+	model.Aliases = cleanAliases(req.Name, req.Aliases)
+	model.LaunchYear = req.LaunchYear
+	model.MSRP = req.MSRP
+}
+
+// 品牌和系列必须存在且匹配，名称和别名在品牌内唯一
+func (s *catalogService) checkModel(model *models.DeviceModel) error {
+	if _, err := s.GetBrand(model.BrandID); err != nil {
+		return err
+	}
+	if model.SeriesID != nil {
+		series, err := s.getSeries(*model.SeriesID)
+		if err != nil {
+			return err
+		}
+		if series.BrandID != model.BrandID {
+			return ErrSeriesBrandMismatch
+		}
+	}
+
+	existing, err := s.repos.Catalog().ListModels(repositories.DeviceModelFilter{BrandID: model.BrandID})
+	if err != nil {
+		return err
+	}
+	keys := catalogKeys(model.Name, model.Aliases)
+	for _, other := range existing {
+		if other.ID != model.ID && keys.overlaps(catalogKeys(other.Name, other.Aliases)) {
+			return ErrModelExists
+		}
+	}
+	return nil
+}
+
+// 品牌或型号变更后使设备列表缓存失效（别名变化会影响按品牌过滤的结果），并同步设备上的名称
+func (s *catalogService) syncDevices() error {
+	s.devices.CatalogChanged()
+	_, err := s.devices.SyncCatalog()
+	return err
+}
+
+func (s *catalogService) Resolve(modelID uint, brand, model string) (*models.Brand, *models.DeviceModel, error) {
+	return resolveCatalog(s.repos, modelID, 0, brand, model)
+}
+
+// 识别设备的品牌和型号：指定modelID或brandID时按ID查找，否则按名称或别名识别。
+// 品牌必须在目录中，型号未收录时返回nil，设备保留填写的型号名称
+func resolveCatalog(repos repositories.Repositories, modelID, brandID uint, brandName, modelName string) (*models.Brand, *models.DeviceModel, error) {
+	catalog := repos.Catalog()
+	if modelID != 0 {
+		model, err := catalog.FindModel(modelID)
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, nil, ErrModelNotFound
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		brandID = model.BrandID
+		brand, err := catalog.FindBrand(brandID)
+		return brand, model, err
+	}
+
+	var brand *models.Brand
+	var err error
+	if brandID != 0 {
+		if brand, err = catalog.FindBrand(brandID); errors.Is(err, repositories.ErrNotFound) {
+			err = ErrBrandNotFound
+		}
+	} else {
+		brand, err = resolveBrand(repos, brandName)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if strings.TrimSpace(modelName) == "" {
+		return brand, nil, nil
+	}
+	model, err := resolveModel(repos, brand.ID, modelName)
+	return brand, model, err
+}
+
+// 按名称或别名查找品牌，不区分大小写
+func resolveBrand(repos repositories.Repositories, name string) (*models.Brand, error) {
+	brands, err := repos.Catalog().ListBrands()
+	if err != nil {
+		return nil, err
+	}
+	key := catalogKey(name)
+	for i := range brands {
+		if catalogKeys(brands[i].Name, brands[i].Aliases)[key] {
+			return &brands[i], nil
+		}
+	}
+	return nil, ErrBrandNotFound
+}
+
+// 按名称或别名查找品牌下的型号，不区分大小写；未收录的型号返回nil
+func resolveModel(repos repositories.Repositories, brandID uint, name string) (*models.DeviceModel, error) {
+	deviceModels, err := repos.Catalog().ListModels(repositories.DeviceModelFilter{BrandID: brandID})
+	if err != nil {
+		return nil, err
+	}
+	key := catalogKey(name)
+	for i := range deviceModels {
+		if catalogKeys(deviceModels[i].Name, deviceModels[i].Aliases)[key] {
+			return &deviceModels[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// 名称比较时忽略大小写和多余的空白
+func catalogKey(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
+
+type keySet map[string]bool
+
+func catalogKeys(name string, aliases []string) keySet {
+	keys := keySet{catalogKey(name): true}
+	for _, alias := range aliases {
+		keys[catalogKey(alias)] = true
+	}
+	return keys
+}
+
+func (k keySet) overlaps(other keySet) bool {
+	for key := range k {
+		if other[key] {
+			return true
+		}
+	}
+	return false
+}
+
+// 去掉与名称相同和重复的别名
+func cleanAliases(name string, aliases []string) []string {
+	seen := keySet{catalogKey(name): true}
+	cleaned := []string{}
+	for _, alias := range aliases {
+		alias = strings.Join(strings.Fields(alias), " ")
+		if key := catalogKey(alias); key != "" && !seen[key] {
+			seen[key] = true
+			cleaned = append(cleaned, alias)
+		}
+	}
+	return cleaned
+}
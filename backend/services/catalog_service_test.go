@@ -0,0 +1,126 @@
+package services
+
+import (
+	"e-device-recycle-backend/models"
+	"e-device-recycle-backend/repositories"
+	"e-device-recycle-backend/search"
+	"errors"
+	"testing"
+)
+
+func TestCatalogNormalizesDeviceBrandAndModel(t *testing.T) {
+	repos := newFakeRepositories()
+	devices := NewDeviceService(repos, search.NewMemoryIndex())
+	catalog := NewCatalogService(repos, devices)
+
+	huawei, err := catalog.CreateBrand(models.BrandRequest{Name: "Huawei", Aliases: []string{"华为", "huawei", "华为"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(huawei.Aliases) != 1 {
+		t.Errorf("Aliases = %v, want [华为]", huawei.Aliases)
+	}
+	if _, err := catalog.CreateBrand(models.BrandRequest{Name: "HUAWEI "}); !errors.Is(err, ErrBrandExists) {
+		t.Errorf("同名品牌: err = %v, want ErrBrandExists", err)
+	}
+	if _, err := catalog.CreateBrand(models.BrandRequest{Name: "Honor", Aliases: []string{"华为"}}); !errors.Is(err, ErrBrandExists) {
+		t.Errorf("别名冲突: err = %v, want ErrBrandExists", err)
+	}
+
+	mate, err := catalog.CreateModel(models.DeviceModelRequest{
+		BrandID: huawei.ID, Name: "Mate 60 Pro", Aliases: []string{"mate60pro"}, LaunchYear: 2023, MSRP: 6999,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 按别名识别品牌和型号，不区分大小写
+	device, err := devices.Create(models.DeviceCreateRequest{Name: "华为旗舰", Brand: "华为", Model: "MATE60PRO", Category: "phone", Condition: "good"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if device.Brand != "Huawei" || *device.BrandID != huawei.ID || device.Model != "Mate 60 Pro" || *device.ModelID != mate.ID {
+		t.Errorf("device = %s/%s (%v/%v), want Huawei/Mate 60 Pro", device.Brand, device.Model, device.BrandID, device.ModelID)
+	}
+	// 未收录的型号保留填写的名称
+	other, err := devices.Create(models.DeviceCreateRequest{Name: "MatePad", Brand: "huawei", Model: "MatePad 11", Category: "tablet", Condition: "good"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.Model != "MatePad 11" || other.ModelID != nil {
+		t.Errorf("未收录型号: %s (%v)", other.Model, other.ModelID)
+	}
+	if _, err := devices.Create(models.DeviceCreateRequest{Name: "x", Brand: "Nokia", Category: "phone", Condition: "good"}); !errors.Is(err, ErrBrandNotFound) {
+		t.Errorf("未知品牌: err = %v, want ErrBrandNotFound", err)
+	}
+	if _, err := devices.Create(models.DeviceCreateRequest{Name: "x", ModelID: 9999, Category: "phone", Condition: "good"}); !errors.Is(err, ErrModelNotFound) {
+		t.Errorf("未知型号: err = %v, want ErrModelNotFound", err)
+	}
+
+	// 按品牌别名过滤
+	if _, total, _ := devices.List(repositories.DeviceFilter{Brand: "华为"}, firstPage); total != 2 {
+		t.Errorf("按别名过滤 total = %d, want 2", total)
+	}
+
+	// 改名后同步设备
+	if _, _, err := catalog.UpdateModel(mate.ID, models.DeviceModelRequest{BrandID: huawei.ID, Name: "Mate60 Pro", LaunchYear: 2023}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := catalog.UpdateBrand(huawei.ID, models.BrandRequest{Name: "HUAWEI", Aliases: []string{"华为"}}); err != nil {
+		t.Fatal(err)
+	}
+	synced, _ := repos.Devices().FindByID(device.ID)
+	if synced.Brand != "HUAWEI" || synced.Model != "Mate60 Pro" {
+		t.Errorf("同步后 = %s/%s, want HUAWEI/Mate60 Pro", synced.Brand, synced.Model)
+	}
+
+	// 修改设备品牌时重新识别
+	_, updated, err := devices.Update(other.ID, map[string]interface{}{"brand": "苹果"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Brand != "Apple" || updated.Model != "MatePad 11" || updated.ModelID != nil {
+		t.Errorf("修改品牌后 = %s/%s (%v)", updated.Brand, updated.Model, updated.ModelID)
+	}
+
+	// 仍在使用的型号和品牌不能删除
+	if _, err := catalog.DeleteModel(mate.ID); !errors.Is(err, ErrModelInUse) {
+		t.Errorf("DeleteModel err = %v, want ErrModelInUse", err)
+	}
+	if _, err := catalog.DeleteBrand(huawei.ID); !errors.Is(err, ErrBrandInUse) {
+		t.Errorf("DeleteBrand err = %v, want ErrBrandInUse", err)
+	}
+}
+
+func TestCatalogSeriesBelongsToBrand(t *testing.T) {
+	repos := newFakeRepositories()
+	catalog := NewCatalogService(repos, NewDeviceService(repos, search.NewMemoryIndex()))
+
+	brands, _ := catalog.ListBrands()
+	apple, lenovo := brands[0], brands[1]
+
+	series, err := catalog.CreateSeries(models.DeviceSeriesRequest{BrandID: apple.ID, Name: "MacBook Pro"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := catalog.CreateSeries(models.DeviceSeriesRequest{BrandID: apple.ID, Name: "macbook pro"}); !errors.Is(err, ErrSeriesExists) {
+		t.Errorf("同名系列: err = %v, want ErrSeriesExists", err)
+	}
+	if _, err := catalog.CreateSeries(models.DeviceSeriesRequest{BrandID: 9999, Name: "X"}); !errors.Is(err, ErrBrandNotFound) {
+		t.Errorf("品牌不存在: err = %v, want ErrBrandNotFound", err)
+	}
+
+	if _, err := catalog.CreateModel(models.DeviceModelRequest{BrandID: lenovo.ID, SeriesID: &series.ID, Name: "X1"}); !errors.Is(err, ErrSeriesBrandMismatch) {
+		t.Errorf("系列不属于品牌: err = %v, want ErrSeriesBrandMismatch", err)
+	}
+	if _, err := catalog.CreateModel(models.DeviceModelRequest{BrandID: apple.ID, SeriesID: &series.ID, Name: "MacBook Pro 14 2023"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := catalog.DeleteSeries(series.ID); !errors.Is(err, ErrSeriesInUse) {
+		t.Errorf("DeleteSeries err = %v, want ErrSeriesInUse", err)
+	}
+	if _, _, err := catalog.UpdateSeries(series.ID, models.DeviceSeriesRequest{BrandID: lenovo.ID, Name: "MacBook Pro"}); !errors.Is(err, ErrSeriesInUse) {
+		t.Errorf("移动到其他品牌: err = %v, want ErrSeriesInUse", err)
+	}
+}
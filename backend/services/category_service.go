@@ -0,0 +1,360 @@
+package services
+
+import (
+	"e-device-recycle-backend/models"
+	"e-device-recycle-backend/repositories"
+	"e-device-recycle-backend/specs"
+	"errors"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// 设备分类目录（管理员维护）
+type CategoryService interface {
+	List() ([]models.Category, error)
+	Get(id uint) (*models.Category, error)
+	// 分类的有效设置，规格字段和定价参数已按上级分类补全
+	Rules(id uint) (*CategoryRules, error)
+	// 按分类代码查询有效设置，分类不存在时返回ErrCategoryNotFound
+	RulesByCode(code string) (*CategoryRules, error)
+	Create(req models.CategoryRequest) (*models.Category, error)
+	// 更新分类，返回更新前后的分类；有设备的分类不能修改代码
+	Update(id uint, req models.CategoryRequest) (before, after *models.Category, err error)
+	// 删除没有子分类和设备的分类
+	Delete(id uint) (*models.Category, error)
+}
+
+// 分类的有效设置：规格字段包含上级分类定义的字段，定价参数为空的沿用上级分类，顶级分类为空时使用默认值
+type CategoryRules struct {
+	SpecFields []models.SpecField     `json:"spec_fields"`
+	Pricing    models.CategoryPricing `json:"pricing"`
+}
+
+// 分类代码只能包含小写字母、数字、下划线和连字符，以字母开头
+var categoryCodePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+type categoryService struct {
+	repos   repositories.Repositories
+	devices DeviceService
+}
+
+// devices用于分类目录变化后使设备列表缓存失效
+func NewCategoryService(repos repositories.Repositories, devices DeviceService) CategoryService {
+	return &categoryService{repos: repos, devices: devices}
+}
+
+func (s *categoryService) List() ([]models.Category, error) {
+	return s.repos.Categories().List()
+}
+
+func (s *categoryService) Get(id uint) (*models.Category, error) {
+	category, err := s.repos.Categories().FindByID(id)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrCategoryNotFound
+	}
+	return category, err
+}
+
+func (s *categoryService) Rules(id uint) (*CategoryRules, error) {
+	tree, err := loadCategories(s.repos)
+	if err != nil {
+		return nil, err
+	}
+	category, ok := tree.byID[id]
+	if !ok {
+		return nil, ErrCategoryNotFound
+	}
+	return tree.rules(category), nil
+}
+
+func (s *categoryService) RulesByCode(code string) (*CategoryRules, error) {
+	return categoryRules(s.repos, code)
+}
+
+func (s *categoryService) Create(req models.CategoryRequest) (*models.Category, error) {
+	var category models.Category
+	if err := s.apply(&category, req); err != nil {
+		return nil, err
+	}
+	if err := s.repos.Categories().Create(&category); err != nil {
+		return nil, err
+	}
+	s.devices.CatalogChanged()
+	return &category, nil
+}
+
+func (s *categoryService) Update(id uint, req models.CategoryRequest) (*models.Category, *models.Category, error) {
+	category, err := s.Get(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	before := *category
+	if err := s.apply(category, req); err != nil {
+		return nil, nil, err
+	}
+	if category.Code != before.Code {
+		devices, err := s.repos.Devices().Count(repositories.DeviceFilter{Category: before.Code})
+		if err != nil {
+			return nil, nil, err
+		}
+		if devices > 0 {
+			return nil, nil, ErrCategoryInUse
+		}
+	}
+
+	if err := s.repos.Categories().Save(category); err != nil {
+		return nil, nil, err
+	}
+	// 修改上级分类后按分类过滤的结果会变化
+	s.devices.CatalogChanged()
+	return &before, category, nil
+}
+
+func (s *categoryService) Delete(id uint) (*models.Category, error) {
+	tree, err := loadCategories(s.repos)
+	if err != nil {
+		return nil, err
+	}
+	category, ok := tree.byID[id]
+	if !ok {
+		return nil, ErrCategoryNotFound
+	}
+
+	devices, err := s.repos.Devices().Count(repositories.DeviceFilter{Category: category.Code})
+	if err != nil {
+		return nil, err
+	}
+	if len(tree.children[id]) > 0 || devices > 0 {
+		return nil, ErrCategoryInUse
+	}
+
+	if err := s.repos.Categories().Delete(id); err != nil {
+		return nil, err
+	}
+	s.devices.CatalogChanged()
+	return category, nil
+}
+
+// 校验请求并写入分类
+func (s *categoryService) apply(category *models.Category, req models.CategoryRequest) error {
+	code := strings.TrimSpace(req.Code)
+	if !categoryCodePattern.MatchString(code) {
+		return &FieldError{Field: "code", Rule: "invalid"}
+	}
+
+	tree, err := loadCategories(s.repos)
+	if err != nil {
+		return err
+	}
+	if other, ok := tree.byCode[code]; ok && other.ID != category.ID {
+		return ErrCategoryExists
+	}
+	if req.ParentID != nil {
+		parent, ok := tree.byID[*req.ParentID]
+		if !ok {
+			return ErrParentCategoryNotFound
+		}
+		// 上级分类不能是自己或自己的子分类
+		for _, ancestor := range tree.ancestors(parent) {
+			if ancestor.ID == category.ID {
+				return ErrCategoryParentInvalid
+			}
+		}
+	}
+
+	for _, field := range req.SpecFields {
+		if field.Min != nil && field.Max != nil && *field.Min > *field.Max {
+			return &FieldError{Field: "spec_fields", Rule: "invalid"}
+		}
+	}
+
+	category.Code = code
+	category.ParentID = req.ParentID
+	category.Name = strings.TrimSpace(req.Name)
+	category.Icon = req.Icon
+	category.SortOrder = req.SortOrder
+	category.SpecFields = req.SpecFields
+	if category.SpecFields == nil {
+		category.SpecFields = []models.SpecField{}
+	}
+	category.Pricing = req.Pricing
+	return nil
+}
+
+// 分类目录，用于补全上级分类的设置和展开子分类
+type categoryTree struct {
+	byID     map[uint]*models.Category
+	byCode   map[string]*models.Category
+	children map[uint][]uint
+}
+
+func loadCategories(repos repositories.Repositories) (*categoryTree, error) {
+	categories, err := repos.Categories().List()
+	if err != nil {
+		return nil, err
+	}
+	tree := &categoryTree{
+		byID:     make(map[uint]*models.Category, len(categories)),
+		byCode:   make(map[string]*models.Category, len(categories)),
+		children: make(map[uint][]uint),
+	}
+	for i := range categories {
+		category := &categories[i]
+		tree.byID[category.ID] = category
+		tree.byCode[category.Code] = category
+		if category.ParentID != nil {
+			tree.children[*category.ParentID] = append(tree.children[*category.ParentID], category.ID)
+		}
+	}
+	return tree, nil
+}
+
+// 从顶级分类到category的路径
+func (t *categoryTree) ancestors(category *models.Category) []*models.Category {
+	var path []*models.Category
+	seen := make(map[uint]bool)
+	for c := category; c != nil && !seen[c.ID]; {
+		seen[c.ID] = true
+		path = append([]*models.Category{c}, path...)
+		if c.ParentID == nil {
+			break
+		}
+		c = t.byID[*c.ParentID]
+	}
+	return path
+}
+
+// category及其所有子分类的代码
+func (t *categoryTree) descendants(category *models.Category) []string {
+	codes := []string{category.Code}
+	for _, id := range t.children[category.ID] {
+		if child, ok := t.byID[id]; ok {
+			codes = append(codes, t.descendants(child)...)
+		}
+	}
+	return codes
+}
+
+func (t *categoryTree) rules(category *models.Category) *CategoryRules {
+	rules := &CategoryRules{SpecFields: []models.SpecField{}}
+	index := make(map[string]int)
+	var pricing models.CategoryPricing
+	for _, c := range t.ancestors(category) {
+		// 子分类中同名的字段覆盖上级分类的定义
+		for _, field := range c.SpecFields {
+			if i, ok := index[field.Key]; ok {
+				rules.SpecFields[i] = field
+				continue
+			}
+			index[field.Key] = len(rules.SpecFields)
+			rules.SpecFields = append(rules.SpecFields, field)
+		}
+		pricing = overridePricing(pricing, c.Pricing)
+	}
+	rules.Pricing = overridePricing(defaultCategoryPricing(), pricing)
+	return rules
+}
+
+func overridePricing(base, override models.CategoryPricing) models.CategoryPricing {
+	if override.AnnualDepreciation != nil {
+		base.AnnualDepreciation = override.AnnualDepreciation
+	}
+	if override.MaxDepreciation != nil {
+		base.MaxDepreciation = override.MaxDepreciation
+	}
+	if override.MinPriceRatio != nil {
+		base.MinPriceRatio = override.MinPriceRatio
+	}
+	if override.DefaultBasePrice != nil {
+		base.DefaultBasePrice = override.DefaultBasePrice
+	}
+	return base
+}
+
+func defaultCategoryPricing() models.CategoryPricing {
+	annual, max, minRatio := DefaultPricing.AnnualDepreciation, DefaultPricing.MaxDepreciation, DefaultPricing.MinPriceRatio
+	return models.CategoryPricing{AnnualDepreciation: &annual, MaxDepreciation: &max, MinPriceRatio: &minRatio}
+}
+
+// 分类有效设置中的定价参数
+func (r *CategoryRules) PricingRules() PricingRules {
+	return PricingRules{
+		AnnualDepreciation: *r.Pricing.AnnualDepreciation,
+		MaxDepreciation:    *r.Pricing.MaxDepreciation,
+		MinPriceRatio:      *r.Pricing.MinPriceRatio,
+	}
+}
+
+// 按分类的规格字段定义检查设备：必填的字段不能为空，内存、存储和屏幕还必须能识别出数值，
+// 识别出的数值（处理器为代数）不能超出字段的范围
+func (r *CategoryRules) CheckDevice(device *models.Device) error {
+	texts := deviceSpecs(device)
+	for _, field := range r.SpecFields {
+		value, ok := specs.Value(device.Specs, field.Key)
+		if !ok {
+			if field.Required && strings.TrimSpace(texts[field.Key]) == "" {
+				return &FieldError{Field: field.Key, Rule: "required"}
+			}
+			if field.Required && field.Key != "cpu" && field.Key != "graphics" {
+				return &FieldError{Field: field.Key, Rule: "invalid"}
+			}
+			continue
+		}
+		if field.Min != nil && value < *field.Min {
+			return &FieldError{Field: field.Key, Rule: "min", Param: formatFloat(*field.Min)}
+		}
+		if field.Max != nil && value > *field.Max {
+			return &FieldError{Field: field.Key, Rule: "max", Param: formatFloat(*field.Max)}
+		}
+	}
+	return nil
+}
+
+// 未填写基础价格时使用的价格：分类的默认基础价格按规格数值与基准值的差调整，不低于0。
+// 分类没有默认基础价格时返回false
+func (r *CategoryRules) BasePrice(s models.DeviceSpecs) (float64, bool) {
+	if r.Pricing.DefaultBasePrice == nil {
+		return 0, false
+	}
+	price := *r.Pricing.DefaultBasePrice
+	for _, field := range r.SpecFields {
+		if field.Baseline == nil || field.PricePerUnit == 0 {
+			continue
+		}
+		if value, ok := specs.Value(s, field.Key); ok {
+			price += (value - *field.Baseline) * field.PricePerUnit
+		}
+	}
+	return math.Max(0, price), true
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// 分类的有效设置，分类不存在时返回ErrCategoryNotFound
+func categoryRules(repos repositories.Repositories, code string) (*CategoryRules, error) {
+	tree, err := loadCategories(repos)
+	if err != nil {
+		return nil, err
+	}
+	category, ok := tree.byCode[code]
+	if !ok {
+		return nil, ErrCategoryNotFound
+	}
+	return tree.rules(category), nil
+}
+
+// 设备的规格字段值，键与SpecField.Key相同
+func deviceSpecs(device *models.Device) map[string]string {
+	return map[string]string{
+		"cpu":      device.CPU,
+		"memory":   device.Memory,
+		"storage":  device.Storage,
+		"graphics": device.Graphics,
+		"screen":   device.Screen,
+	}
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"e-device-recycle-backend/models"
+	"e-device-recycle-backend/repositories"
+	"e-device-recycle-backend/search"
+	"errors"
+	"testing"
+)
+
+func floatPtr(v float64) *float64 { return &v }
+
+func TestCategoryRulesInheritParent(t *testing.T) {
+	repos := newFakeRepositories()
+	devices := NewDeviceService(repos, search.NewMemoryIndex())
+	categories := NewCategoryService(repos, devices)
+
+	wearable, err := categories.Create(models.CategoryRequest{
+		Code: "wearable", Name: "穿戴设备",
+		SpecFields: []models.SpecField{{Key: "screen", Label: "屏幕"}, {Key: "storage", Label: "存储"}},
+		Pricing:    models.CategoryPricing{AnnualDepreciation: floatPtr(0.3), DefaultBasePrice: floatPtr(1000)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	watch, err := categories.Create(models.CategoryRequest{
+		Code: "watch", Name: "智能手表", ParentID: &wearable.ID,
+		SpecFields: []models.SpecField{{Key: "screen", Label: "表盘", Required: true}},
+		Pricing:    models.CategoryPricing{MinPriceRatio: floatPtr(0.2)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 子分类覆盖同名字段，其余沿用上级分类和默认值
+	rules, err := categories.Rules(watch.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules.SpecFields) != 2 || rules.SpecFields[0].Label != "表盘" || !rules.SpecFields[0].Required {
+		t.Errorf("SpecFields = %+v", rules.SpecFields)
+	}
+	if want := (PricingRules{AnnualDepreciation: 0.3, MaxDepreciation: 0.8, MinPriceRatio: 0.2}); rules.PricingRules() != want {
+		t.Errorf("PricingRules = %+v, want %+v", rules.PricingRules(), want)
+	}
+
+	// 创建设备时检查必填规格并使用默认基础价格
+	req := models.DeviceCreateRequest{Name: "Watch", Brand: "Apple", Category: "watch", Condition: "good", YearBought: 2023}
+	var fieldErr *FieldError
+	if _, err := devices.Create(req); !errors.As(err, &fieldErr) || fieldErr.Field != "screen" {
+		t.Errorf("缺少必填规格: err = %v", err)
+	}
+	req.Screen = "1.9英寸"
+	device, err := devices.Create(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if device.BasePrice != 1000 {
+		t.Errorf("BasePrice = %v, want 1000", device.BasePrice)
+	}
+	req.Category = "toaster"
+	if _, err := devices.Create(req); !errors.Is(err, ErrCategoryNotFound) {
+		t.Errorf("未知分类: err = %v, want ErrCategoryNotFound", err)
+	}
+
+	// 按上级分类过滤包含子分类的设备
+	if _, total, _ := devices.List(repositories.DeviceFilter{Category: "wearable"}, firstPage); total != 1 {
+		t.Errorf("按上级分类过滤 total = %d, want 1", total)
+	}
+
+	// 订单按分类的定价参数估价
+	repos.users[customer.UserID] = &models.User{ID: customer.UserID, Role: "user", Status: "active"}
+	order := createOrder(t, NewOrderService(repos, fixedNow), customer, device.ID)
+	if want := rules.PricingRules().Estimate(1000, "good", 2023, 2024); !almostEqual(order.EstimatedPrice, want) {
+		t.Errorf("EstimatedPrice = %v, want %v", order.EstimatedPrice, want)
+	}
+}
+
+func TestCategoryHierarchyConstraints(t *testing.T) {
+	repos := newFakeRepositories()
+	devices := NewDeviceService(repos, search.NewMemoryIndex())
+	categories := NewCategoryService(repos, devices)
+
+	list, _ := categories.List()
+	computer := list[0]
+	laptop, err := repos.Categories().FindByCode("laptop")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := categories.Create(models.CategoryRequest{Code: "Laptop", Name: "x"}); err == nil {
+		t.Error("大写代码应被拒绝")
+	}
+	if _, err := categories.Create(models.CategoryRequest{Code: "laptop", Name: "x"}); !errors.Is(err, ErrCategoryExists) {
+		t.Errorf("重复代码: err = %v, want ErrCategoryExists", err)
+	}
+	missing := uint(9999)
+	if _, err := categories.Create(models.CategoryRequest{Code: "x", Name: "x", ParentID: &missing}); !errors.Is(err, ErrParentCategoryNotFound) {
+		t.Errorf("上级分类不存在: err = %v, want ErrParentCategoryNotFound", err)
+	}
+	// 不能把分类移动到自己的子分类下
+	if _, _, err := categories.Update(computer.ID, models.CategoryRequest{Code: "computer", Name: "电脑", ParentID: &laptop.ID}); !errors.Is(err, ErrCategoryParentInvalid) {
+		t.Errorf("循环层级: err = %v, want ErrCategoryParentInvalid", err)
+	}
+
+	// 有子分类或设备的分类不能删除，有设备的分类不能修改代码
+	if _, err := categories.Delete(computer.ID); !errors.Is(err, ErrCategoryInUse) {
+		t.Errorf("删除有子分类的分类: err = %v, want ErrCategoryInUse", err)
+	}
+	if _, err := devices.Create(models.DeviceCreateRequest{Name: "MacBook", Brand: "Apple", Category: "laptop", Condition: "good", YearBought: 2023}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := categories.Update(laptop.ID, models.CategoryRequest{Code: "notebook", Name: "笔记本", ParentID: &computer.ID}); !errors.Is(err, ErrCategoryInUse) {
+		t.Errorf("修改使用中的代码: err = %v, want ErrCategoryInUse", err)
+	}
+	if _, err := categories.Delete(laptop.ID); !errors.Is(err, ErrCategoryInUse) {
+		t.Errorf("删除有设备的分类: err = %v, want ErrCategoryInUse", err)
+	}
+
+	desktop, _ := repos.Categories().FindByCode("desktop")
+	if _, err := categories.Delete(desktop.ID); err != nil {
+		t.Errorf("删除空分类: %v", err)
+	}
+}
+
+func TestCategorySpecRangesAndPricing(t *testing.T) {
+	repos := newFakeRepositories()
+	devices := NewDeviceService(repos, search.NewMemoryIndex())
+	categories := NewCategoryService(repos, devices)
+
+	if _, err := categories.Create(models.CategoryRequest{
+		Code: "gaming", Name: "游戏本",
+		SpecFields: []models.SpecField{{Key: "memory", Label: "内存", Min: floatPtr(32), Max: floatPtr(16)}},
+	}); err == nil {
+		t.Error("min大于max应被拒绝")
+	}
+
+	// 内存必须能识别且不少于8GB；默认价格按16GB内存、512GB存储计算
+	if _, err := categories.Create(models.CategoryRequest{
+		Code: "ultrabook", Name: "轻薄本",
+		SpecFields: []models.SpecField{
+			{Key: "memory", Label: "内存", Required: true, Min: floatPtr(8), Baseline: floatPtr(16), PricePerUnit: 50},
+			{Key: "storage", Label: "存储", Baseline: floatPtr(512), PricePerUnit: 2},
+		},
+		Pricing: models.CategoryPricing{DefaultBasePrice: floatPtr(5000)},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	req := models.DeviceCreateRequest{Name: "轻薄本", Brand: "Lenovo", Category: "ultrabook", Condition: "good", Memory: "板载内存"}
+	var fieldErr *FieldError
+	if _, err := devices.Create(req); !errors.As(err, &fieldErr) || fieldErr.Field != "memory" || fieldErr.Rule != "invalid" {
+		t.Errorf("无法识别的内存: err = %v", err)
+	}
+	req.Memory = "4GB"
+	if _, err := devices.Create(req); !errors.As(err, &fieldErr) || fieldErr.Rule != "min" || fieldErr.Param != "8" {
+		t.Errorf("内存低于下限: err = %v", err)
+	}
+
+	req.Memory, req.Storage = "32GB", "1TB SSD"
+	device, err := devices.Create(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 5000 + 16*50 + 512*2.0; device.BasePrice != want {
+		t.Errorf("BasePrice = %v, want %v", device.BasePrice, want)
+	}
+	// 填写了基础价格时不调整
+	req.BasePrice = 3000
+	if priced, err := devices.Create(req); err != nil {
+		t.Fatal(err)
+	} else if priced.BasePrice != 3000 {
+		t.Errorf("BasePrice = %v, want 3000", priced.BasePrice)
+	}
+
+	// 更新规格时同样检查
+	if _, _, err := devices.Update(device.ID, map[string]interface{}{"memory": "4GB"}); !errors.As(err, &fieldErr) || fieldErr.Rule != "min" {
+		t.Errorf("更新内存低于下限: err = %v", err)
+	}
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"context"
+	"e-device-recycle-backend/listquery"
+	"e-device-recycle-backend/models"
+	"e-device-recycle-backend/repositories"
+	"e-device-recycle-backend/store"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 设备缓存的版本号键，设备变更时加1，使所有列表和详情缓存失效
+const deviceCacheVersionKey = "devices:version"
+
+// 带缓存的设备服务：缓存在售设备列表和详情，创建、更新、下架设备时失效
+type cachedDeviceService struct {
+	DeviceService
+	cache  store.Cache
+	loader *store.Loader
+	ttl    time.Duration
+}
+
+// 缓存列表结果
+type deviceListCache struct {
+	Devices []models.Device `json:"devices"`
+	Total   int64           `json:"total"`
+}
+
+func NewCachedDeviceService(inner DeviceService, cache store.Cache, ttl time.Duration) DeviceService {
+	return &cachedDeviceService{
+		DeviceService: inner,
+		cache:         cache,
+		loader:        store.NewLoader(cache, "devices"),
+		ttl:           ttl,
+	}
+}
+
+func (s *cachedDeviceService) List(filter repositories.DeviceFilter, params listquery.Params) ([]models.Device, int64, error) {
+	ctx := context.Background()
+
+	values := params.Values()
+	for name, value := range filterValues(filter) {
+		values[name] = value
+	}
+	key := fmt.Sprintf("devices:v%d:list:%s", s.version(ctx), values.Encode())
+
+	var cached deviceListCache
+	err := s.loader.Load(ctx, key, s.ttl, &cached, func() (interface{}, error) {
+		devices, total, err := s.DeviceService.List(filter, params)
+		return deviceListCache{Devices: devices, Total: total}, err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return cached.Devices, cached.Total, nil
+}
+
+func (s *cachedDeviceService) Get(id uint) (*models.Device, error) {
+	ctx := context.Background()
+	key := fmt.Sprintf("devices:v%d:detail:%d", s.version(ctx), id)
+
+	var device models.Device
+	err := s.loader.Load(ctx, key, s.ttl, &device, func() (interface{}, error) {
+		return s.DeviceService.Get(id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &device, nil
+}
+
+func (s *cachedDeviceService) LastModified() (time.Time, error) {
+	ctx := context.Background()
+	key := fmt.Sprintf("devices:v%d:last_modified", s.version(ctx))
+
+	var lastModified time.Time
+	err := s.loader.Load(ctx, key, s.ttl, &lastModified, func() (interface{}, error) {
+		return s.DeviceService.LastModified()
+	})
+	return lastModified, err
+}
+
+func (s *cachedDeviceService) CatalogStats() (repositories.TableStats, error) {
+	ctx := context.Background()
+	key := fmt.Sprintf("devices:v%d:catalog_stats", s.version(ctx))
+
+	var stats repositories.TableStats
+	err := s.loader.Load(ctx, key, s.ttl, &stats, func() (interface{}, error) {
+		return s.DeviceService.CatalogStats()
+	})
+	return stats, err
+}
+
+func (s *cachedDeviceService) CatalogChanged() {
+	s.invalidate()
+}
+
+func (s *cachedDeviceService) Create(req models.DeviceCreateRequest) (*models.Device, error) {
+	device, err := s.DeviceService.Create(req)
+	if err == nil {
+		s.invalidate()
+	}
+	return device, err
+}
+
+func (s *cachedDeviceService) Update(id uint, updates map[string]interface{}) (*models.Device, *models.Device, error) {
+	before, after, err := s.DeviceService.Update(id, updates)
+	if err == nil {
+		s.invalidate()
+	}
+	return before, after, err
+}
+
+func (s *cachedDeviceService) Delete(id uint) (*models.Device, *models.Device, error) {
+	before, after, err := s.DeviceService.Delete(id)
+	if err == nil {
+		s.invalidate()
+	}
+	return before, after, err
+}
+
+func (s *cachedDeviceService) SyncCatalog() (int, error) {
+	synced, err := s.DeviceService.SyncCatalog()
+	if synced > 0 {
+		s.invalidate()
+	}
+	return synced, err
+}
+
+func (s *cachedDeviceService) SyncSpecs(overwrite bool) (int, error) {
+	synced, err := s.DeviceService.SyncSpecs(overwrite)
+	if synced > 0 {
+		s.invalidate()
+	}
+	return synced, err
+}
+
+// 当前缓存版本号，读取失败时返回0，此时Loader也无法读取缓存，会直接回源
+func (s *cachedDeviceService) version(ctx context.Context) int64 {
+	data, err := s.cache.Get(ctx, deviceCacheVersionKey)
+	if err != nil {
+		if !errors.Is(err, store.ErrCacheMiss) {
+			slog.WarnContext(ctx, "读取设备缓存版本失败", "error", err)
+		}
+		return 0
+	}
+	version, _ := strconv.ParseInt(string(data), 10, 64)
+	return version
+}
+
+// 版本号加1，旧版本的缓存不再被读取，等待过期后清除
+func (s *cachedDeviceService) invalidate() {
+	if _, err := s.cache.Incr(context.Background(), deviceCacheVersionKey); err != nil {
+		slog.Error("设备缓存失效失败，缓存将在过期后更新", "error", err)
+	}
+}
+
+// 列表缓存键中的过滤条件，包含DeviceFilter的所有字段，新增的过滤字段不会遗漏；
+// 品牌不区分大小写，统一转为小写
+func filterValues(filter repositories.DeviceFilter) url.Values {
+	filter.Brand = strings.ToLower(filter.Brand)
+	values := url.Values{}
+	v := reflect.ValueOf(filter)
+	for i := 0; i < v.NumField(); i++ {
+		name := strings.ToLower(v.Type().Field(i).Name)
+		switch field := v.Field(i).Interface().(type) {
+		case string:
+			if field != "" {
+				values.Set(name, field)
+			}
+		case uint:
+			if field != 0 {
+				values.Set(name, strconv.FormatUint(uint64(field), 10))
+			}
+		case []string:
+			for _, item := range field {
+				values.Add(name, item)
+			}
+		case listquery.Range:
+			setRange(values, name, field)
+		default:
+			panic(fmt.Sprintf("设备列表缓存键不支持过滤字段 %s 的类型 %T", name, field))
+		}
+	}
+	return values
+}
+
+func setRange(values url.Values, name string, r listquery.Range) {
+	if r.Min != nil {
+		values.Set("min_"+name, strconv.FormatFloat(*r.Min, 'f', -1, 64))
+	}
+	if r.Max != nil {
+		values.Set("max_"+name, strconv.FormatFloat(*r.Max, 'f', -1, 64))
+	}
+}
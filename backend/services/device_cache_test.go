@@ -0,0 +1,252 @@
+package services
+
+import (
+	"context"
+	"e-device-recycle-backend/listquery"
+	"e-device-recycle-backend/models"
+	"e-device-recycle-backend/repositories"
+	"e-device-recycle-backend/search"
+	"e-device-recycle-backend/store"
+	"errors"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 统计回源次数的设备服务
+type countingDeviceService struct {
+	DeviceService
+	lists, gets int32
+	block       chan struct{} // 不为nil时List等待关闭后才返回
+}
+
+func (s *countingDeviceService) List(filter repositories.DeviceFilter, params listquery.Params) ([]models.Device, int64, error) {
+	atomic.AddInt32(&s.lists, 1)
+	if s.block != nil {
+		<-s.block
+	}
+	return s.DeviceService.List(filter, params)
+}
+
+func (s *countingDeviceService) Get(id uint) (*models.Device, error) {
+	atomic.AddInt32(&s.gets, 1)
+	return s.DeviceService.Get(id)
+}
+
+func newCachedDevices(t *testing.T) (DeviceService, *countingDeviceService) {
+	t.Helper()
+	inner := &countingDeviceService{DeviceService: NewDeviceService(newFakeRepositories(), search.NewMemoryIndex())}
+	return NewCachedDeviceService(inner, store.NewMemoryCache(100), time.Minute), inner
+}
+
+func TestCachedDeviceServiceHitsAndInvalidates(t *testing.T) {
+	svc, inner := newCachedDevices(t)
+
+	device, err := svc.Create(models.DeviceCreateRequest{Name: "ThinkPad X1", Brand: "Lenovo", Category: "laptop", Condition: "good"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, total, err := svc.List(repositories.DeviceFilter{Brand: "Lenovo"}, firstPage); err != nil || total != 1 {
+			t.Fatalf("List: total = %d, err = %v", total, err)
+		}
+		got, err := svc.Get(device.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Name != "ThinkPad X1" || got.Status != "active" {
+			t.Errorf("缓存的设备 = %+v", got)
+		}
+	}
+	if inner.lists != 1 || inner.gets != 1 {
+		t.Errorf("回源次数 list = %d, get = %d, want 1, 1", inner.lists, inner.gets)
+	}
+
+	// 品牌大小写不同使用同一个缓存，其他过滤条件单独缓存
+	svc.List(repositories.DeviceFilter{Brand: "lenovo"}, firstPage)
+	svc.List(repositories.DeviceFilter{Brand: "Lenovo"}, listquery.Params{Page: 2, PageSize: 10})
+	if inner.lists != 2 {
+		t.Errorf("回源次数 list = %d, want 2", inner.lists)
+	}
+
+	// 更新后重新读取
+	if _, _, err := svc.Update(device.ID, map[string]interface{}{"name": "ThinkPad X1 Carbon"}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := svc.Get(device.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "ThinkPad X1 Carbon" {
+		t.Errorf("更新后 Name = %q", got.Name)
+	}
+
+	// 下架后列表和详情都不可见
+	if _, _, err := svc.Delete(device.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Get(device.ID); !errors.Is(err, ErrDeviceNotFound) {
+		t.Errorf("下架后 err = %v, want ErrDeviceNotFound", err)
+	}
+	if _, total, _ := svc.List(repositories.DeviceFilter{Brand: "Lenovo"}, firstPage); total != 0 {
+		t.Errorf("下架后列表 total = %d", total)
+	}
+}
+
+func TestCachedDeviceServiceCreateInvalidatesList(t *testing.T) {
+	svc, _ := newCachedDevices(t)
+
+	if _, total, _ := svc.List(repositories.DeviceFilter{}, firstPage); total != 0 {
+		t.Fatalf("total = %d", total)
+	}
+	if _, err := svc.Create(models.DeviceCreateRequest{Name: "iPad Air", Brand: "Apple", Category: "tablet", Condition: "good"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, total, _ := svc.List(repositories.DeviceFilter{}, firstPage); total != 1 {
+		t.Errorf("创建后列表 total = %d, want 1", total)
+	}
+}
+
+func TestCachedDeviceServiceCategoryChangeInvalidatesList(t *testing.T) {
+	repos := newFakeRepositories()
+	svc := NewCachedDeviceService(NewDeviceService(repos, search.NewMemoryIndex()), store.NewMemoryCache(100), time.Minute)
+	categories := NewCategoryService(repos, svc)
+
+	if _, err := svc.Create(models.DeviceCreateRequest{Name: "ThinkPad X1", Brand: "Lenovo", Category: "laptop", Condition: "good"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, total, _ := svc.List(repositories.DeviceFilter{Category: "mobile"}, firstPage); total != 0 {
+		t.Fatalf("total = %d", total)
+	}
+	before, _ := svc.CatalogStats()
+
+	// 把笔记本移到手机平板下，设备本身没有变化
+	laptop, _ := repos.Categories().FindByCode("laptop")
+	mobile, _ := repos.Categories().FindByCode("mobile")
+	if _, _, err := categories.Update(laptop.ID, models.CategoryRequest{Code: "laptop", Name: laptop.Name, ParentID: &mobile.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if _, total, _ := svc.List(repositories.DeviceFilter{Category: "mobile"}, firstPage); total != 1 {
+		t.Errorf("移动分类后 total = %d, want 1", total)
+	}
+
+	// 新增分类后目录统计随之更新
+	if _, err := categories.Create(models.CategoryRequest{Code: "wearable", Name: "穿戴设备"}); err != nil {
+		t.Fatal(err)
+	}
+	if after, _ := svc.CatalogStats(); after.Rows != before.Rows+1 {
+		t.Errorf("目录行数 = %d, want %d", after.Rows, before.Rows+1)
+	}
+}
+
+func TestCachedDeviceServiceSingleFlight(t *testing.T) {
+	svc, inner := newCachedDevices(t)
+	inner.block = make(chan struct{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, err := svc.List(repositories.DeviceFilter{}, firstPage); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+
+	// 等待第一个请求开始回源，其余请求应等待它的结果
+	for atomic.LoadInt32(&inner.lists) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(inner.block)
+	wg.Wait()
+
+	if inner.lists != 1 {
+		t.Errorf("并发未命中回源 %d 次, want 1", inner.lists)
+	}
+}
+
+// 缓存不可用时直接回源
+type brokenCache struct{}
+
+func (brokenCache) Get(ctx context.Context, key string) ([]byte, error) {
+	return nil, errors.New("connection refused")
+}
+func (brokenCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return errors.New("connection refused")
+}
+func (brokenCache) Delete(ctx context.Context, keys ...string) error {
+	return errors.New("connection refused")
+}
+func (brokenCache) Incr(ctx context.Context, key string) (int64, error) {
+	return 0, errors.New("connection refused")
+}
+
+func TestCachedDeviceServiceCacheUnavailable(t *testing.T) {
+	svc := NewCachedDeviceService(NewDeviceService(newFakeRepositories(), search.NewMemoryIndex()), brokenCache{}, time.Minute)
+
+	device, err := svc.Create(models.DeviceCreateRequest{Name: "MacBook Air", Brand: "Apple", Category: "laptop", Condition: "good"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Get(device.ID); err != nil {
+		t.Errorf("缓存不可用时应回源: %v", err)
+	}
+	if _, total, err := svc.List(repositories.DeviceFilter{}, firstPage); err != nil || total != 1 {
+		t.Errorf("缓存不可用时应回源: total = %d, err = %v", total, err)
+	}
+}
+
+func TestCachedDeviceListBySpecs(t *testing.T) {
+	svc, inner := newCachedDevices(t)
+	for _, memory := range []string{"8GB", "32GB"} {
+		if _, err := svc.Create(models.DeviceCreateRequest{Name: "ThinkPad " + memory, Brand: "Lenovo", Category: "laptop", Condition: "good", Memory: memory}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, total, _ := svc.List(repositories.DeviceFilter{}, firstPage); total != 2 {
+		t.Errorf("不过滤 total = %d, want 2", total)
+	}
+	min := 16.0
+	if _, total, _ := svc.List(repositories.DeviceFilter{Memory: listquery.Range{Min: &min}}, firstPage); total != 1 {
+		t.Errorf("min_memory=16 total = %d, want 1", total)
+	}
+	if inner.lists != 2 {
+		t.Errorf("回源次数 list = %d, want 2", inner.lists)
+	}
+}
+
+// DeviceFilter的每个字段都要进入缓存键，且不同字段的键互不相同
+func TestDeviceFilterCacheKeyCoversAllFields(t *testing.T) {
+	empty := filterValues(repositories.DeviceFilter{}).Encode()
+	keys := make(map[string]string)
+	one := 1.0
+	v := reflect.ValueOf(&repositories.DeviceFilter{}).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		filter := repositories.DeviceFilter{}
+		field := reflect.ValueOf(&filter).Elem().Field(i)
+		switch field.Interface().(type) {
+		case string:
+			field.SetString("x")
+		case uint:
+			field.SetUint(1)
+		case []string:
+			field.Set(reflect.ValueOf([]string{"x"}))
+		case listquery.Range:
+			field.Set(reflect.ValueOf(listquery.Range{Min: &one}))
+		}
+		name, key := v.Type().Field(i).Name, filterValues(filter).Encode()
+		if key == empty {
+			t.Errorf("字段 %s 不在缓存键中", name)
+		}
+		if other, ok := keys[key]; ok {
+			t.Errorf("字段 %s 和 %s 的缓存键相同: %s", name, other, key)
+		}
+		keys[key] = name
+	}
+}
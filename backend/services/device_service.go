@@ -0,0 +1,518 @@
+package services
+
+import (
+	"context"
+	"e-device-recycle-backend/listquery"
+	"e-device-recycle-backend/models"
+	"e-device-recycle-backend/repositories"
+	"e-device-recycle-backend/search"
+	"e-device-recycle-backend/specs"
+	"errors"
+	"log/slog"
+	"reflect"
+	"strings"
+	"time"
+)
+
+type DeviceService interface {
+	// 在售设备列表
+	List(filter repositories.DeviceFilter, params listquery.Params) ([]models.Device, int64, error)
+	// 在售设备详情
+	Get(id uint) (*models.Device, error)
+	// 设备目录最后变更时间（含已下架设备），用于生成列表的ETag
+	LastModified() (time.Time, error)
+	Create(req models.DeviceCreateRequest) (*models.Device, error)
+	// 更新设备，返回更新前后的设备
+	Update(id uint, updates map[string]interface{}) (before, after *models.Device, err error)
+	// 下架设备（软删除），返回删除前后的设备
+	Delete(id uint) (before, after *models.Device, err error)
+	// 全文搜索在售设备，按相关度排序
+	Search(query string, filter repositories.DeviceFilter, offset, limit int) ([]DeviceSearchResult, int64, error)
+	// 从数据库重建搜索索引，返回索引的设备数
+	Reindex() (int, error)
+	// 搜索框补全：以prefix开头的在售设备品牌、型号和名称，按订单数排序
+	Suggest(prefix string, limit int) ([]search.Suggestion, error)
+	// 品牌、型号或分类目录变更后，按目录更新设备上的品牌、型号名称，返回更新的设备数
+	SyncCatalog() (int, error)
+	// 分类和品牌型号目录的统计，按分类和品牌过滤的结果取决于目录，与LastModified一起用于生成列表的ETag
+	CatalogStats() (repositories.TableStats, error)
+	// 分类或品牌型号目录变更后调用，使设备列表缓存失效
+	CatalogChanged()
+	// 从规格文本识别结构化规格，overwrite为false时只处理还没有结构化规格的设备，返回更新的设备数
+	SyncSpecs(overwrite bool) (int, error)
+}
+
+// 搜索结果，Highlights为匹配到的字段，匹配部分用<em>标记
+type DeviceSearchResult struct {
+	Device     models.Device
+	Score      float64
+	Highlights map[string]string
+}
+
+// 搜索最多取相关度最高的这么多个设备再按条件过滤和分页
+const maxSearchCandidates = 1000
+
+type deviceService struct {
+	repos       repositories.Repositories
+	index       search.Index
+	suggestions *deviceSuggestions
+}
+
+func NewDeviceService(repos repositories.Repositories, index search.Index) DeviceService {
+	return &deviceService{repos: repos, index: index, suggestions: &deviceSuggestions{}}
+}
+
+func (s *deviceService) List(filter repositories.DeviceFilter, params listquery.Params) ([]models.Device, int64, error) {
+	filter.Status = "active"
+	if err := s.resolveFilter(&filter); err != nil {
+		return nil, 0, err
+	}
+	return s.repos.Devices().List(filter, params)
+}
+
+// 按目录解析过滤条件中的分类和品牌
+func (s *deviceService) resolveFilter(filter *repositories.DeviceFilter) error {
+	if err := s.resolveCategoryFilter(filter); err != nil {
+		return err
+	}
+	return s.resolveBrandFilter(filter)
+}
+
+// 分类在目录中时按该分类及其子分类过滤，否则按分类代码精确匹配
+func (s *deviceService) resolveCategoryFilter(filter *repositories.DeviceFilter) error {
+	if filter.Category == "" {
+		return nil
+	}
+	tree, err := loadCategories(s.repos)
+	if err != nil {
+		return err
+	}
+	if category, ok := tree.byCode[filter.Category]; ok {
+		filter.Category, filter.Categories = "", tree.descendants(category)
+	}
+	return nil
+}
+
+// 品牌名称能在品牌目录中识别（名称或别名，不区分大小写）时按品牌ID过滤，否则按名称模糊匹配
+func (s *deviceService) resolveBrandFilter(filter *repositories.DeviceFilter) error {
+	if filter.Brand == "" || filter.BrandID != 0 {
+		return nil
+	}
+	brand, err := resolveBrand(s.repos, filter.Brand)
+	if errors.Is(err, ErrBrandNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	filter.Brand, filter.BrandID = "", brand.ID
+	return nil
+}
+
+func (s *deviceService) Get(id uint) (*models.Device, error) {
+	device, err := s.repos.Devices().FindByIDAndStatus(id, "active")
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrDeviceNotFound
+	}
+	return device, err
+}
+
+func (s *deviceService) LastModified() (time.Time, error) {
+	return s.repos.Devices().LastModified()
+}
+
+func (s *deviceService) CatalogStats() (repositories.TableStats, error) {
+	categories, err := s.repos.Categories().Stats()
+	if err != nil {
+		return repositories.TableStats{}, err
+	}
+	catalog, err := s.repos.Catalog().Stats()
+	if err != nil {
+		return repositories.TableStats{}, err
+	}
+	return categories.Add(catalog), nil
+}
+
+// 没有缓存，无需处理
+func (s *deviceService) CatalogChanged() {}
+
+func (s *deviceService) Create(req models.DeviceCreateRequest) (*models.Device, error) {
+	brand, model, err := resolveCatalog(s.repos, req.ModelID, 0, req.Brand, req.Model)
+	if err != nil {
+		return nil, err
+	}
+
+	device := models.Device{
+		Name:        req.Name,
+		Brand:       brand.Name,
+		Model:       strings.TrimSpace(req.Model),
+		BrandID:     &brand.ID,
+		Category:    req.Category,
+		CPU:         req.CPU,
+		Memory:      req.Memory,
+		Storage:     req.Storage,
+		Graphics:    req.Graphics,
+		Screen:      req.Screen,
+		Specs:       specs.Merge(specs.Parse(req.CPU, req.Memory, req.Storage, req.Screen), req.Specs),
+		Condition:   req.Condition,
+		YearBought:  req.YearBought,
+		BasePrice:   req.BasePrice,
+		Description: req.Description,
+		Images:      req.Images,
+		Status:      "active",
+	}
+	if model != nil {
+		device.Model, device.ModelID = model.Name, &model.ID
+	}
+
+	// 按分类定义检查规格字段，未填写基础价格时按分类的默认价格和规格计算
+	rules, err := categoryRules(s.repos, req.Category)
+	if err != nil {
+		return nil, err
+	}
+	if err := rules.CheckDevice(&device); err != nil {
+		return nil, err
+	}
+	if price, ok := rules.BasePrice(device.Specs); ok && device.BasePrice == 0 {
+		device.BasePrice = price
+	}
+
+	if err := s.repos.Devices().Create(&device); err != nil {
+		return nil, err
+	}
+	s.updateIndex(device)
+	return &device, nil
+}
+
+func (s *deviceService) Update(id uint, updates map[string]interface{}) (*models.Device, *models.Device, error) {
+	return s.update(id, updates)
+}
+
+func (s *deviceService) Delete(id uint) (*models.Device, *models.Device, error) {
+	return s.update(id, map[string]interface{}{"status": "inactive"})
+}
+
+func (s *deviceService) update(id uint, updates map[string]interface{}) (*models.Device, *models.Device, error) {
+	device, err := s.repos.Devices().FindByID(id)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, nil, ErrDeviceNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.resolveCatalogUpdates(device, updates); err != nil {
+		return nil, nil, err
+	}
+	if err := s.checkCategoryUpdates(device, updates); err != nil {
+		return nil, nil, err
+	}
+
+	before := *device
+	if err := s.repos.Devices().Update(device, updates); err != nil {
+		return nil, nil, err
+	}
+	s.updateIndex(*device)
+	return &before, device, nil
+}
+
+func (s *deviceService) Search(query string, filter repositories.DeviceFilter, offset, limit int) ([]DeviceSearchResult, int64, error) {
+	ctx := context.Background()
+	hits, err := s.index.Search(ctx, query, maxSearchCandidates)
+	if err != nil || len(hits) == 0 {
+		return nil, 0, err
+	}
+
+	ids := make([]uint, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ID
+	}
+	// 索引中包含已下架的设备，由数据库按状态和过滤条件筛选
+	filter.Status = "active"
+	if err := s.resolveFilter(&filter); err != nil {
+		return nil, 0, err
+	}
+	devices, err := s.repos.Devices().ListByIDs(ids, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	byID := make(map[uint]models.Device, len(devices))
+	for _, device := range devices {
+		byID[device.ID] = device
+	}
+
+	var results []DeviceSearchResult
+	for _, hit := range hits {
+		if device, ok := byID[hit.ID]; ok {
+			results = append(results, DeviceSearchResult{Device: device, Score: hit.Score})
+		}
+	}
+
+	total := int64(len(results))
+	if offset >= len(results) {
+		return nil, total, nil
+	}
+	results = results[offset:]
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	aliases, err := s.brandAliases()
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range results {
+		results[i].Highlights = make(map[string]string)
+		for field, text := range deviceDocument(results[i].Device, aliases).Fields {
+			if highlighted, ok := search.Highlight(text, query); ok {
+				results[i].Highlights[field] = highlighted
+			}
+		}
+	}
+	return results, total, nil
+}
+
+func (s *deviceService) Reindex() (int, error) {
+	devices, err := s.repos.Devices().ListAll()
+	if err != nil {
+		return 0, err
+	}
+	aliases, err := s.brandAliases()
+	if err != nil {
+		return 0, err
+	}
+	docs := make([]search.Document, len(devices))
+	for i, device := range devices {
+		docs[i] = deviceDocument(device, aliases)
+	}
+	if err := s.index.Rebuild(context.Background(), docs); err != nil {
+		return 0, err
+	}
+	return len(docs), nil
+}
+
+func (s *deviceService) Suggest(prefix string, limit int) ([]search.Suggestion, error) {
+	index, err := s.suggestions.current(s.repos)
+	if err != nil {
+		return nil, err
+	}
+	return index.Lookup(prefix, limit), nil
+}
+
+// 更新设备的搜索索引，失败时只记录日志，可通过 reindex 命令重建
+func (s *deviceService) updateIndex(device models.Device) {
+	s.suggestions.invalidate()
+	aliases, err := s.brandAliases()
+	if err == nil {
+		err = s.index.Put(context.Background(), deviceDocument(device, aliases))
+	}
+	if err != nil {
+		slog.Error("更新设备搜索索引失败", "device_id", device.ID, "error", err)
+	}
+}
+
+// 各品牌的别名
+func (s *deviceService) brandAliases() (map[uint][]string, error) {
+	brands, err := s.repos.Catalog().ListBrands()
+	if err != nil {
+		return nil, err
+	}
+	aliases := make(map[uint][]string, len(brands))
+	for _, brand := range brands {
+		aliases[brand.ID] = brand.Aliases
+	}
+	return aliases, nil
+}
+
+// 设备的搜索文档，品牌别名也参与搜索（搜索"苹果"能找到Apple的设备）
+func deviceDocument(device models.Device, brandAliases map[uint][]string) search.Document {
+	if device.BrandID == nil {
+		return search.DeviceDocument(device)
+	}
+	return search.DeviceDocument(device, brandAliases[*device.BrandID]...)
+}
+
+// 更新中包含品牌或型号（brand、model、brand_id、model_id）时，按目录重新识别并同时更新这四个字段
+func (s *deviceService) resolveCatalogUpdates(device *models.Device, updates map[string]interface{}) error {
+	changed := false
+	for _, key := range []string{"brand", "model", "brand_id", "model_id"} {
+		if _, ok := updates[key]; ok {
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	// JSON中的数字解析为float64，model_id为null时按型号名称重新识别
+	modelID, _ := updates["model_id"].(float64)
+	brandID, _ := updates["brand_id"].(float64)
+	brandName, hasBrand := updates["brand"].(string)
+	modelName, hasModel := updates["model"].(string)
+	if !hasModel {
+		modelName = device.Model
+	}
+	if brandID == 0 && !hasBrand {
+		// 未修改品牌
+		if device.BrandID != nil {
+			brandID = float64(*device.BrandID)
+		} else {
+			brandName = device.Brand
+		}
+	}
+
+	brand, model, err := resolveCatalog(s.repos, uint(modelID), uint(brandID), brandName, modelName)
+	if err != nil {
+		return err
+	}
+	updates["brand"] = brand.Name
+	updates["brand_id"] = brand.ID
+	updates["model"] = strings.TrimSpace(modelName)
+	updates["model_id"] = nil
+	if model != nil {
+		updates["model"] = model.Name
+		updates["model_id"] = model.ID
+	}
+	return nil
+}
+
+// 更新中包含规格文本时重新识别对应的结构化规格，包含分类或规格时按更新后的分类定义检查
+func (s *deviceService) checkCategoryUpdates(device *models.Device, updates map[string]interface{}) error {
+	updated := *device
+	changed := false
+	for key, value := range updates {
+		text, _ := value.(string)
+		switch key {
+		case "category":
+			updated.Category = text
+		case "cpu":
+			updated.CPU = text
+			updated.Specs.CPUFamily, updated.Specs.CPUGeneration = specs.ParseCPU(text)
+			updates["spec_cpu_family"] = updated.Specs.CPUFamily
+			updates["spec_cpu_generation"] = updated.Specs.CPUGeneration
+		case "memory":
+			updated.Memory = text
+			updated.Specs.MemoryGB = specs.ParseMemory(text)
+			updates["spec_memory_gb"] = updated.Specs.MemoryGB
+		case "storage":
+			updated.Storage = text
+			updated.Specs.StorageGB, updated.Specs.StorageType = specs.ParseStorage(text)
+			updates["spec_storage_gb"] = updated.Specs.StorageGB
+			updates["spec_storage_type"] = updated.Specs.StorageType
+		case "graphics":
+			updated.Graphics = text
+		case "screen":
+			updated.Screen = text
+			updated.Specs.ScreenInches = specs.ParseScreen(text)
+			updates["spec_screen_inches"] = updated.Specs.ScreenInches
+		default:
+			continue
+		}
+		changed = true
+	}
+	// 直接填写的规格覆盖由文本识别的结果，放在循环之后以免被同一请求中的文本字段覆盖
+	if override, ok := updates["specs"].(models.DeviceSpecs); ok {
+		delete(updates, "specs")
+		updated.Specs = specs.Merge(updated.Specs, override)
+		updates["spec_memory_gb"] = updated.Specs.MemoryGB
+		updates["spec_storage_gb"] = updated.Specs.StorageGB
+		updates["spec_storage_type"] = updated.Specs.StorageType
+		updates["spec_cpu_family"] = updated.Specs.CPUFamily
+		updates["spec_cpu_generation"] = updated.Specs.CPUGeneration
+		updates["spec_screen_inches"] = updated.Specs.ScreenInches
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+
+	rules, err := categoryRules(s.repos, updated.Category)
+	if err != nil {
+		return err
+	}
+	return rules.CheckDevice(&updated)
+}
+
+func (s *deviceService) SyncSpecs(overwrite bool) (int, error) {
+	devices, err := s.repos.Devices().ListAll()
+	if err != nil {
+		return 0, err
+	}
+
+	synced := 0
+	for _, device := range devices {
+		if !overwrite && device.Specs != (models.DeviceSpecs{}) {
+			continue
+		}
+		parsed := specs.Parse(device.CPU, device.Memory, device.Storage, device.Screen)
+		if reflect.DeepEqual(parsed, device.Specs) {
+			continue
+		}
+		if err := s.repos.Devices().Update(&device, map[string]interface{}{
+			"spec_memory_gb":      parsed.MemoryGB,
+			"spec_storage_gb":     parsed.StorageGB,
+			"spec_storage_type":   parsed.StorageType,
+			"spec_cpu_family":     parsed.CPUFamily,
+			"spec_cpu_generation": parsed.CPUGeneration,
+			"spec_screen_inches":  parsed.ScreenInches,
+		}); err != nil {
+			return synced, err
+		}
+		synced++
+	}
+	return synced, nil
+}
+
+func (s *deviceService) SyncCatalog() (int, error) {
+	devices, err := s.repos.Devices().ListAll()
+	if err != nil {
+		return 0, err
+	}
+	brands, err := s.repos.Catalog().ListBrands()
+	if err != nil {
+		return 0, err
+	}
+	deviceModels, err := s.repos.Catalog().ListModels(repositories.DeviceModelFilter{})
+	if err != nil {
+		return 0, err
+	}
+	brandNames := make(map[uint]string, len(brands))
+	for _, brand := range brands {
+		brandNames[brand.ID] = brand.Name
+	}
+	modelsByID := make(map[uint]models.DeviceModel, len(deviceModels))
+	for _, model := range deviceModels {
+		modelsByID[model.ID] = model
+	}
+
+	synced := 0
+	for _, device := range devices {
+		updates := make(map[string]interface{})
+		if device.ModelID != nil {
+			if model, ok := modelsByID[*device.ModelID]; ok {
+				if device.Model != model.Name {
+					updates["model"] = model.Name
+				}
+				// 型号可能被移到其他品牌
+				if device.BrandID == nil || *device.BrandID != model.BrandID {
+					updates["brand_id"] = model.BrandID
+					device.BrandID = &model.BrandID
+				}
+			}
+		}
+		if device.BrandID != nil {
+			if name, ok := brandNames[*device.BrandID]; ok && device.Brand != name {
+				updates["brand"] = name
+			}
+		}
+		if len(updates) == 0 {
+			continue
+		}
+		if err := s.repos.Devices().Update(&device, updates); err != nil {
+			return synced, err
+		}
+		s.updateIndex(device)
+		synced++
+	}
+	return synced, nil
+}
@@ -0,0 +1,215 @@
+package services
+
+import (
+	"e-device-recycle-backend/listquery"
+	"e-device-recycle-backend/models"
+	"e-device-recycle-backend/repositories"
+	"e-device-recycle-backend/search"
+	"errors"
+	"testing"
+)
+
+func TestDeviceDeleteHidesDevice(t *testing.T) {
+	repos := newFakeRepositories()
+	svc := NewDeviceService(repos, search.NewMemoryIndex())
+
+	device, err := svc.Create(models.DeviceCreateRequest{Name: "ThinkPad X1", Brand: "Lenovo", Category: "laptop", Condition: "good"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if device.Status != "active" {
+		t.Errorf("Status = %q, want active", device.Status)
+	}
+
+	before, after, err := svc.Delete(device.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if before.Status != "active" || after.Status != "inactive" {
+		t.Errorf("状态 %q -> %q, want active -> inactive", before.Status, after.Status)
+	}
+
+	// 下架后公开接口不可见
+	if _, err := svc.Get(device.ID); !errors.Is(err, ErrDeviceNotFound) {
+		t.Errorf("err = %v, want ErrDeviceNotFound", err)
+	}
+	devices, total, err := svc.List(repositories.DeviceFilter{}, firstPage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 0 || len(devices) != 0 {
+		t.Errorf("下架设备仍在列表中: total = %d", total)
+	}
+
+	if _, _, err := svc.Update(9999, map[string]interface{}{"name": "x"}); !errors.Is(err, ErrDeviceNotFound) {
+		t.Errorf("不存在的设备: err = %v, want ErrDeviceNotFound", err)
+	}
+}
+
+func TestDeviceSearchFollowsUpdates(t *testing.T) {
+	repos := newFakeRepositories()
+	svc := NewDeviceService(repos, search.NewMemoryIndex())
+
+	device, err := svc.Create(models.DeviceCreateRequest{Name: "ThinkPad X1", Brand: "联想", Category: "laptop", Condition: "good"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Create(models.DeviceCreateRequest{Name: "iPad Air", Brand: "Apple", Category: "tablet", Condition: "good"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 品牌按目录统一为Lenovo，别名同样可以搜索
+	if device.Brand != "Lenovo" {
+		t.Errorf("Brand = %q, want Lenovo", device.Brand)
+	}
+	results, total, err := svc.Search("联想", repositories.DeviceFilter{}, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || results[0].Device.ID != device.ID || results[0].Highlights["brand"] != "Lenovo <em>联想</em>" {
+		t.Fatalf("Search = %+v, total = %d", results, total)
+	}
+	if _, total, _ := svc.Search("联想", repositories.DeviceFilter{Category: "tablet"}, 0, 10); total != 0 {
+		t.Errorf("按分类过滤后 total = %d, want 0", total)
+	}
+
+	// 更新后按新名称搜索
+	if _, _, err := svc.Update(device.ID, map[string]interface{}{"name": "ThinkPad T14"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, total, _ := svc.Search("x1", repositories.DeviceFilter{}, 0, 10); total != 0 {
+		t.Errorf("旧名称仍能搜到: total = %d", total)
+	}
+	if _, total, _ := svc.Search("t14", repositories.DeviceFilter{}, 0, 10); total != 1 {
+		t.Errorf("新名称搜不到: total = %d", total)
+	}
+
+	// 下架后不可见
+	if _, _, err := svc.Delete(device.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, total, _ := svc.Search("thinkpad", repositories.DeviceFilter{}, 0, 10); total != 0 {
+		t.Errorf("下架设备仍能搜到: total = %d", total)
+	}
+
+	// 重建索引包含所有设备，由数据库按状态过滤
+	if n, err := svc.Reindex(); err != nil || n != 2 {
+		t.Errorf("Reindex = %d, %v, want 2", n, err)
+	}
+	if _, total, _ := svc.Search("ipad", repositories.DeviceFilter{}, 0, 10); total != 1 {
+		t.Errorf("重建后 total = %d, want 1", total)
+	}
+}
+
+func TestDeviceSuggestRebuildsOnChange(t *testing.T) {
+	repos := newFakeRepositories()
+	svc := NewDeviceService(repos, search.NewMemoryIndex())
+
+	air, err := svc.Create(models.DeviceCreateRequest{Name: "MacBook Air", Brand: "Apple", Model: "MacBook Air", Category: "laptop", Condition: "good"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	repos.Orders().Create(&models.RecycleOrder{DeviceID: air.ID, Status: "pending"})
+	repos.Orders().Create(&models.RecycleOrder{DeviceID: air.ID, Status: "cancelled"})
+
+	suggestions, err := svc.Suggest("mac", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(suggestions) != 1 || suggestions[0].Text != "MacBook Air" || suggestions[0].Popularity != 1 {
+		t.Fatalf("Suggest = %+v", suggestions)
+	}
+
+	// 新增和下架设备后立即生效
+	pro, err := svc.Create(models.DeviceCreateRequest{Name: "MacBook Pro", Brand: "Apple", Model: "MacBook Pro", Category: "laptop", Condition: "good"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if suggestions, _ := svc.Suggest("macbook p", 10); len(suggestions) != 1 || suggestions[0].Text != "MacBook Pro" {
+		t.Errorf("新增设备后 Suggest = %+v", suggestions)
+	}
+	if _, _, err := svc.Delete(pro.ID); err != nil {
+		t.Fatal(err)
+	}
+	if suggestions, _ := svc.Suggest("macbook p", 10); len(suggestions) != 0 {
+		t.Errorf("下架设备后 Suggest = %+v", suggestions)
+	}
+}
+
+func TestDeviceStructuredSpecs(t *testing.T) {
+	repos := newFakeRepositories()
+	svc := NewDeviceService(repos, search.NewMemoryIndex())
+
+	memory := 32
+	device, err := svc.Create(models.DeviceCreateRequest{
+		Name: "ThinkPad X1", Brand: "Lenovo", Category: "laptop", Condition: "good",
+		CPU: "Intel i7-1260P", Memory: "16GB", Storage: "1TB SSD", Screen: "14英寸",
+		Specs: models.DeviceSpecs{MemoryGB: &memory},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := device.Specs
+	if *s.MemoryGB != 32 || *s.StorageGB != 1024 || s.StorageType != "ssd" ||
+		s.CPUFamily != "intel-core-i7" || *s.CPUGeneration != 12 || *s.ScreenInches != 14 {
+		t.Errorf("Specs = %+v", s)
+	}
+	if _, err := svc.Create(models.DeviceCreateRequest{Name: "旧电脑", Brand: "Lenovo", Category: "desktop", Condition: "fair", Memory: "4GB"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 按结构化规格过滤，没有识别出规格的设备不满足范围条件
+	min := 16.0
+	if devices, total, _ := svc.List(repositories.DeviceFilter{Memory: listquery.Range{Min: &min}}, firstPage); total != 1 || devices[0].ID != device.ID {
+		t.Errorf("min_memory=16 total = %d, want 1", total)
+	}
+	if _, total, _ := svc.List(repositories.DeviceFilter{Storage: listquery.Range{Min: &min}}, firstPage); total != 1 {
+		t.Errorf("min_storage=16 total = %d, want 1", total)
+	}
+
+	// 修改规格文本时重新识别
+	_, updated, err := svc.Update(device.ID, map[string]interface{}{"memory": "8GB"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *updated.Specs.MemoryGB != 8 || *updated.Specs.StorageGB != 1024 {
+		t.Errorf("更新后 Specs = %+v", updated.Specs)
+	}
+}
+
+func TestDeviceSyncSpecs(t *testing.T) {
+	repos := newFakeRepositories()
+	svc := NewDeviceService(repos, search.NewMemoryIndex())
+
+	// 迁移前已有的设备没有结构化规格
+	old := &models.Device{Name: "MacBook Air", Category: "laptop", CPU: "Apple M1", Memory: "8GB", Storage: "256GB SSD", Status: "active"}
+	if err := repos.Devices().Create(old); err != nil {
+		t.Fatal(err)
+	}
+	generation := 3
+	manual := &models.Device{Name: "MacBook Pro", Category: "laptop", CPU: "Apple M2", Status: "active",
+		Specs: models.DeviceSpecs{CPUFamily: "apple-m", CPUGeneration: &generation}}
+	if err := repos.Devices().Create(manual); err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := svc.SyncSpecs(false); err != nil || n != 1 {
+		t.Fatalf("SyncSpecs(false) = %d, %v, want 1", n, err)
+	}
+	synced, _ := repos.Devices().FindByID(old.ID)
+	if synced.Specs.CPUFamily != "apple-m" || *synced.Specs.CPUGeneration != 1 || *synced.Specs.MemoryGB != 8 || *synced.Specs.StorageGB != 256 {
+		t.Errorf("Specs = %+v", synced.Specs)
+	}
+	if n, _ := svc.SyncSpecs(false); n != 0 {
+		t.Errorf("重复执行更新了%d个设备", n)
+	}
+
+	// overwrite覆盖已有的结构化规格
+	if n, err := svc.SyncSpecs(true); err != nil || n != 1 {
+		t.Fatalf("SyncSpecs(true) = %d, %v, want 1", n, err)
+	}
+	synced, _ = repos.Devices().FindByID(manual.ID)
+	if *synced.Specs.CPUGeneration != 2 {
+		t.Errorf("CPUGeneration = %d, want 2", *synced.Specs.CPUGeneration)
+	}
+}
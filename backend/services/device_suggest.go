@@ -0,0 +1,52 @@
+package services
+
+import (
+	"e-device-recycle-backend/repositories"
+	"e-device-recycle-backend/search"
+	"sync"
+	"time"
+)
+
+// 订单热度变化不影响设备目录的变更时间，补全索引最长这么久重建一次
+const suggestRefreshInterval = 10 * time.Minute
+
+// 搜索框补全的前缀索引，设备目录变化（包括其他实例的修改）或超过刷新间隔后在下次查询时重建
+type deviceSuggestions struct {
+	mu           sync.Mutex
+	index        *search.SuggestIndex
+	lastModified time.Time // 构建索引时设备目录的最后变更时间
+	builtAt      time.Time
+}
+
+func (d *deviceSuggestions) current(repos repositories.Repositories) (*search.SuggestIndex, error) {
+	lastModified, err := repos.Devices().LastModified()
+	if err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.index != nil && lastModified.Equal(d.lastModified) && time.Since(d.builtAt) < suggestRefreshInterval {
+		return d.index, nil
+	}
+
+	devices, err := repos.Devices().ListAll()
+	if err != nil {
+		return nil, err
+	}
+	orderCounts, err := repos.Orders().CountByDevice()
+	if err != nil {
+		return nil, err
+	}
+	d.index = search.NewSuggestIndex(search.DeviceSuggestions(devices, orderCounts))
+	d.lastModified = lastModified
+	d.builtAt = time.Now()
+	return d.index, nil
+}
+
+// 本实例修改设备后立即重建，不依赖变更时间的精度
+func (d *deviceSuggestions) invalidate() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.index = nil
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"e-device-recycle-backend/listquery"
+	"e-device-recycle-backend/metrics"
+	"e-device-recycle-backend/models"
+	"e-device-recycle-backend/repositories"
+	"errors"
+)
+
+// 评估操作结果，同时包含评估和关联订单修改前后的数据，用于审计
+type EvaluationResult struct {
+	Before      *models.Evaluation // 新建时为nil
+	Evaluation  *models.Evaluation
+	OrderBefore *models.RecycleOrder
+	Order       *models.RecycleOrder
+}
+
+type EvaluationService interface {
+	// 创建评估并将订单更新为已评估
+	Create(actor Actor, req models.EvaluationCreateRequest) (*EvaluationResult, error)
+	List(filter repositories.EvaluationFilter, params listquery.Params) ([]models.Evaluation, int64, error)
+	Get(id uint) (*models.Evaluation, error)
+	// 订单的评估，普通用户只能查看自己订单的评估
+	GetByOrder(actor Actor, orderID uint) (*models.Evaluation, error)
+	// 更新评估并同步订单最终价格，非管理员只能更新自己的评估
+	Update(actor Actor, id uint, req models.EvaluationUpdateRequest) (*EvaluationResult, error)
+}
+
+type evaluationService struct {
+	repos repositories.Repositories
+}
+
+func NewEvaluationService(repos repositories.Repositories) EvaluationService {
+	return &evaluationService{repos: repos}
+}
+
+func (s *evaluationService) Create(actor Actor, req models.EvaluationCreateRequest) (*EvaluationResult, error) {
+	result := &EvaluationResult{}
+	err := s.repos.Transaction(func(repos repositories.Repositories) error {
+		order, err := repos.Orders().FindByID(req.OrderID)
+		if errors.Is(err, repositories.ErrNotFound) {
+			return ErrOrderNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		// 每个订单只能有一条评估
+		if _, err := repos.Evaluations().FindByOrderID(req.OrderID); err == nil {
+			return ErrEvaluationExists
+		} else if !errors.Is(err, repositories.ErrNotFound) {
+			return err
+		}
+
+		overallScore, finalPrice := EvaluatePrice(req.AppearanceScore, req.FunctionScore, req.PerformanceScore,
+			req.MarketPrice, req.DepreciationRate)
+
+		evaluation := models.Evaluation{
+			OrderID:          req.OrderID,
+			EvaluatorID:      actor.UserID,
+			AppearanceScore:  req.AppearanceScore,
+			FunctionScore:    req.FunctionScore,
+			PerformanceScore: req.PerformanceScore,
+			OverallScore:     overallScore,
+			MarketPrice:      req.MarketPrice,
+			DepreciationRate: req.DepreciationRate,
+			FinalPrice:       finalPrice,
+			EvaluationReport: req.EvaluationReport,
+			Images:           req.Images,
+			Status:           "completed",
+		}
+		if err := repos.Evaluations().Create(&evaluation); err != nil {
+			return err
+		}
+
+		// 更新订单状态和最终价格
+		orderBefore := *order
+		if err := repos.Orders().Update(order, map[string]interface{}{
+			"status":      "evaluated",
+			"final_price": finalPrice,
+		}); err != nil {
+			return err
+		}
+
+		result.Evaluation = &evaluation
+		result.OrderBefore = &orderBefore
+		result.Order = order
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	metrics.EvaluationsCompleted.Inc()
+	return result, nil
+}
+
+func (s *evaluationService) List(filter repositories.EvaluationFilter, params listquery.Params) ([]models.Evaluation, int64, error) {
+	return s.repos.Evaluations().List(filter, params)
+}
+
+func (s *evaluationService) Get(id uint) (*models.Evaluation, error) {
+	evaluation, err := s.repos.Evaluations().FindByID(id)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrEvaluationNotFound
+	}
+	return evaluation, err
+}
+
+func (s *evaluationService) GetByOrder(actor Actor, orderID uint) (*models.Evaluation, error) {
+	evaluation, err := s.repos.Evaluations().FindByOrderID(orderID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrEvaluationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 普通用户查看他人订单的评估时按不存在处理
+	if actor.Role == "user" && evaluation.Order.UserID != actor.UserID {
+		return nil, ErrEvaluationNotFound
+	}
+	return evaluation, nil
+}
+
+func (s *evaluationService) Update(actor Actor, id uint, req models.EvaluationUpdateRequest) (*EvaluationResult, error) {
+	result := &EvaluationResult{}
+	err := s.repos.Transaction(func(repos repositories.Repositories) error {
+		evaluation, err := repos.Evaluations().FindByID(id)
+		if errors.Is(err, repositories.ErrNotFound) {
+			return ErrEvaluationNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		// 普通评估师只能更新自己的评估
+		if !actor.IsAdmin() && evaluation.EvaluatorID != actor.UserID {
+			return ErrEvaluationNotFound
+		}
+
+		// 重新计算综合评分和最终价格
+		overallScore, finalPrice := EvaluatePrice(req.AppearanceScore, req.FunctionScore, req.PerformanceScore,
+			req.MarketPrice, req.DepreciationRate)
+
+		before := *evaluation
+		if err := repos.Evaluations().Update(evaluation, map[string]interface{}{
+			"appearance_score":  req.AppearanceScore,
+			"function_score":    req.FunctionScore,
+			"performance_score": req.PerformanceScore,
+			"overall_score":     overallScore,
+			"market_price":      req.MarketPrice,
+			"depreciation_rate": req.DepreciationRate,
+			"final_price":       finalPrice,
+			"evaluation_report": req.EvaluationReport,
+			"images":            req.Images,
+			"status":            req.Status,
+		}); err != nil {
+			return err
+		}
+
+		// 同时更新订单的最终价格
+		order, err := repos.Orders().FindByID(evaluation.OrderID)
+		if err != nil {
+			return err
+		}
+		orderBefore := *order
+		if err := repos.Orders().Update(order, map[string]interface{}{"final_price": finalPrice}); err != nil {
+			return err
+		}
+
+		result.Before = &before
+		result.Evaluation = evaluation
+		result.OrderBefore = &orderBefore
+		result.Order = order
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Before.Status != "completed" && result.Evaluation.Status == "completed" {
+		metrics.EvaluationsCompleted.Inc()
+	}
+	return result, nil
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"e-device-recycle-backend/metrics"
+	"e-device-recycle-backend/models"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+var evaluator = Actor{UserID: 4, Role: "evaluator"}
+
+func newEvaluationFixture(t *testing.T) (*fakeRepositories, EvaluationService, *models.RecycleOrder) {
+	t.Helper()
+	repos, orders, device := newOrderFixture(t)
+	repos.users[evaluator.UserID] = &models.User{ID: evaluator.UserID, Role: evaluator.Role, Status: "active"}
+
+	order := createOrder(t, orders, customer, device.ID)
+	return repos, NewEvaluationService(repos), order
+}
+
+func evaluationRequest(orderID uint) models.EvaluationCreateRequest {
+	return models.EvaluationCreateRequest{
+		OrderID:          orderID,
+		AppearanceScore:  8,
+		FunctionScore:    9,
+		PerformanceScore: 7,
+		MarketPrice:      8000,
+		DepreciationRate: 0.25,
+	}
+}
+
+func TestEvaluationCreateUpdatesOrder(t *testing.T) {
+	repos, svc, order := newEvaluationFixture(t)
+
+	result, err := svc.Create(evaluator, evaluationRequest(order.ID))
+	if err != nil {
+		t.Fatalf("创建评估失败: %v", err)
+	}
+
+	_, wantPrice := EvaluatePrice(8, 9, 7, 8000, 0.25)
+	if result.Evaluation.EvaluatorID != evaluator.UserID {
+		t.Errorf("EvaluatorID = %d, want %d", result.Evaluation.EvaluatorID, evaluator.UserID)
+	}
+	if result.Evaluation.Status != "completed" {
+		t.Errorf("评估状态 = %q, want completed", result.Evaluation.Status)
+	}
+	if !almostEqual(result.Evaluation.FinalPrice, wantPrice) {
+		t.Errorf("评估价格 = %v, want %v", result.Evaluation.FinalPrice, wantPrice)
+	}
+
+	// 订单进入已评估状态，最终价格与评估一致
+	if result.OrderBefore.Status != "pending" || result.Order.Status != "evaluated" {
+		t.Errorf("订单状态 %q -> %q, want pending -> evaluated", result.OrderBefore.Status, result.Order.Status)
+	}
+	stored := repos.orders[order.ID]
+	if stored.FinalPrice == nil || !almostEqual(*stored.FinalPrice, wantPrice) {
+		t.Errorf("订单最终价格 = %v, want %v", stored.FinalPrice, wantPrice)
+	}
+}
+
+func TestEvaluationCreateRejectsDuplicate(t *testing.T) {
+	_, svc, order := newEvaluationFixture(t)
+
+	completed := testutil.ToFloat64(metrics.EvaluationsCompleted)
+	if _, err := svc.Create(evaluator, evaluationRequest(order.ID)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Create(admin, evaluationRequest(order.ID)); !errors.Is(err, ErrEvaluationExists) {
+		t.Errorf("重复评估: err = %v, want ErrEvaluationExists", err)
+	}
+
+	// 只统计成功创建的评估
+	if got := testutil.ToFloat64(metrics.EvaluationsCompleted); got != completed+1 {
+		t.Errorf("完成评估指标 = %v, want %v", got, completed+1)
+	}
+}
+
+func TestEvaluationCreateRequiresOrder(t *testing.T) {
+	_, svc, _ := newEvaluationFixture(t)
+
+	if _, err := svc.Create(evaluator, evaluationRequest(9999)); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("err = %v, want ErrOrderNotFound", err)
+	}
+}
+
+func TestEvaluationUpdateAuthorization(t *testing.T) {
+	repos, svc, order := newEvaluationFixture(t)
+	result, err := svc.Create(evaluator, evaluationRequest(order.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := models.EvaluationUpdateRequest{
+		AppearanceScore:  10,
+		FunctionScore:    10,
+		PerformanceScore: 10,
+		MarketPrice:      9000,
+		DepreciationRate: 0,
+		Status:           "completed",
+	}
+
+	// 其他评估师不能修改
+	otherEvaluator := Actor{UserID: 5, Role: "evaluator"}
+	if _, err := svc.Update(otherEvaluator, result.Evaluation.ID, req); !errors.Is(err, ErrEvaluationNotFound) {
+		t.Errorf("其他评估师更新: err = %v, want ErrEvaluationNotFound", err)
+	}
+
+	// 评估师本人可以修改，订单价格同步更新
+	updated, err := svc.Update(evaluator, result.Evaluation.ID, req)
+	if err != nil {
+		t.Fatalf("评估师更新失败: %v", err)
+	}
+	if !almostEqual(updated.Evaluation.FinalPrice, 9000) {
+		t.Errorf("评估价格 = %v, want 9000", updated.Evaluation.FinalPrice)
+	}
+	if !almostEqual(updated.Before.FinalPrice, result.Evaluation.FinalPrice) {
+		t.Errorf("更新前价格 = %v, want %v", updated.Before.FinalPrice, result.Evaluation.FinalPrice)
+	}
+	if price := repos.orders[order.ID].FinalPrice; price == nil || !almostEqual(*price, 9000) {
+		t.Errorf("订单最终价格 = %v, want 9000", price)
+	}
+
+	// 管理员可以修改任意评估
+	req.MarketPrice = 6000
+	if _, err := svc.Update(admin, result.Evaluation.ID, req); err != nil {
+		t.Errorf("管理员更新失败: %v", err)
+	}
+}
+
+func TestEvaluationGetByOrderAuthorization(t *testing.T) {
+	_, svc, order := newEvaluationFixture(t)
+	if _, err := svc.Create(evaluator, evaluationRequest(order.ID)); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, actor := range []Actor{customer, admin, evaluator} {
+		if _, err := svc.GetByOrder(actor, order.ID); err != nil {
+			t.Errorf("%s(%d) 查看评估: err = %v", actor.Role, actor.UserID, err)
+		}
+	}
+	if _, err := svc.GetByOrder(other, order.ID); !errors.Is(err, ErrEvaluationNotFound) {
+		t.Errorf("其他用户查看评估: err = %v, want ErrEvaluationNotFound", err)
+	}
+}
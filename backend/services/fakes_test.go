@@ -0,0 +1,866 @@
+package services
+
+import (
+	"e-device-recycle-backend/listquery"
+	"e-device-recycle-backend/models"
+	"e-device-recycle-backend/repositories"
+	"slices"
+	"sort"
+	"time"
+)
+
+// 内存实现的仓储，用于业务服务的单元测试
+type fakeRepositories struct {
+	users       map[uint]*models.User
+	resets      map[uint]*models.PasswordResetToken
+	devices     map[uint]*models.Device
+	orders      map[uint]*models.RecycleOrder
+	evaluations map[uint]*models.Evaluation
+	brands      map[uint]*models.Brand
+	series      map[uint]*models.DeviceSeries
+	models      map[uint]*models.DeviceModel
+	categories  map[uint]*models.Category
+	identities  map[uint]*models.UserIdentity
+	recovery    map[uint]*models.RecoveryCode
+	auditLogs   map[uint]*models.AuditLog
+	attempts    map[uint]*models.LoginAttempt
+	nextID      uint
+}
+
+// 列表的第一页，内存仓储忽略排序和分页参数
+var firstPage = listquery.Params{Page: 1, PageSize: 10}
+
+// 预置与数据库迁移相同的部分品牌和分类
+func newFakeRepositories() *fakeRepositories {
+	f := &fakeRepositories{
+		users:       make(map[uint]*models.User),
+		resets:      make(map[uint]*models.PasswordResetToken),
+		devices:     make(map[uint]*models.Device),
+		orders:      make(map[uint]*models.RecycleOrder),
+		evaluations: make(map[uint]*models.Evaluation),
+		brands:      make(map[uint]*models.Brand),
+		series:      make(map[uint]*models.DeviceSeries),
+		models:      make(map[uint]*models.DeviceModel),
+		categories:  make(map[uint]*models.Category),
+		identities:  make(map[uint]*models.UserIdentity),
+		recovery:    make(map[uint]*models.RecoveryCode),
+		auditLogs:   make(map[uint]*models.AuditLog),
+		attempts:    make(map[uint]*models.LoginAttempt),
+	}
+	f.Catalog().CreateBrand(&models.Brand{Name: "Apple", Aliases: []string{"苹果"}})
+	f.Catalog().CreateBrand(&models.Brand{Name: "Lenovo", Aliases: []string{"联想"}})
+
+	for _, root := range []models.Category{
+		{Code: "computer", Name: "电脑", SortOrder: 1},
+		{Code: "mobile", Name: "手机平板", SortOrder: 2},
+	} {
+		f.Categories().Create(&root)
+		parentID := root.ID
+		for i, code := range map[string][]string{"computer": {"laptop", "desktop"}, "mobile": {"phone", "tablet"}}[root.Code] {
+			f.Categories().Create(&models.Category{Code: code, Name: code, ParentID: &parentID, SortOrder: i + 1})
+		}
+	}
+	return f
+}
+
+func (f *fakeRepositories) id() uint {
+	f.nextID++
+	return f.nextID
+}
+
+func (f *fakeRepositories) Users() repositories.UserRepository { return fakeUsers{f} }
+func (f *fakeRepositories) PasswordResets() repositories.PasswordResetRepository {
+	return fakePasswordResets{f}
+}
+func (f *fakeRepositories) Devices() repositories.DeviceRepository         { return fakeDevices{f} }
+func (f *fakeRepositories) Catalog() repositories.CatalogRepository        { return fakeCatalog{f} }
+func (f *fakeRepositories) Categories() repositories.CategoryRepository    { return fakeCategories{f} }
+func (f *fakeRepositories) Orders() repositories.OrderRepository           { return fakeOrders{f} }
+func (f *fakeRepositories) Evaluations() repositories.EvaluationRepository { return fakeEvaluations{f} }
+func (f *fakeRepositories) Identities() repositories.IdentityRepository    { return fakeIdentities{f} }
+func (f *fakeRepositories) RecoveryCodes() repositories.RecoveryCodeRepository {
+	return fakeRecoveryCodes{f}
+}
+func (f *fakeRepositories) AuditLogs() repositories.AuditLogRepository { return fakeAuditLogs{f} }
+func (f *fakeRepositories) LoginAttempts() repositories.LoginAttemptRepository {
+	return fakeLoginAttempts{f}
+}
+
+func (f *fakeRepositories) Transaction(fn func(repos repositories.Repositories) error) error {
+	return fn(f)
+}
+
+// 按字段名更新，仅支持测试中用到的字段
+func applyUpdates(target interface{}, updates map[string]interface{}) {
+	for key, value := range updates {
+		switch t := target.(type) {
+		case *models.User:
+			switch key {
+			case "password":
+				t.Password = value.(string)
+			case "real_name":
+				t.RealName = value.(string)
+			case "phone":
+				t.Phone = value.(string)
+			case "email":
+				t.Email = value.(string)
+			case "avatar":
+				t.Avatar = value.(string)
+			case "totp_secret":
+				t.TOTPSecret = value.(string)
+			case "totp_enabled":
+				t.TOTPEnabled = value.(bool)
+			}
+		case *models.UserIdentity:
+			switch key {
+			case "union_id":
+				t.UnionID = value.(string)
+			}
+		case *models.Device:
+			switch key {
+			case "status":
+				t.Status = value.(string)
+			case "base_price":
+				t.BasePrice = value.(float64)
+			case "name":
+				t.Name = value.(string)
+			case "brand":
+				t.Brand = value.(string)
+			case "model":
+				t.Model = value.(string)
+			case "brand_id":
+				id := value.(uint)
+				t.BrandID = &id
+			case "model_id":
+				if id, ok := value.(uint); ok {
+					t.ModelID = &id
+				} else {
+					t.ModelID = nil
+				}
+			case "category":
+				t.Category = value.(string)
+			case "cpu":
+				t.CPU = value.(string)
+			case "memory":
+				t.Memory = value.(string)
+			case "storage":
+				t.Storage = value.(string)
+			case "screen":
+				t.Screen = value.(string)
+			case "spec_memory_gb":
+				t.Specs.MemoryGB = value.(*int)
+			case "spec_storage_gb":
+				t.Specs.StorageGB = value.(*int)
+			case "spec_storage_type":
+				t.Specs.StorageType = value.(string)
+			case "spec_cpu_family":
+				t.Specs.CPUFamily = value.(string)
+			case "spec_cpu_generation":
+				t.Specs.CPUGeneration = value.(*int)
+			case "spec_screen_inches":
+				t.Specs.ScreenInches = value.(*float64)
+			}
+		case *models.RecycleOrder:
+			switch key {
+			case "status":
+				t.Status = value.(string)
+			case "remark":
+				t.Remark = value.(string)
+			case "cancel_reason":
+				t.CancelReason = value.(string)
+			case "final_price":
+				price := value.(float64)
+				t.FinalPrice = &price
+			case "pickup_time":
+				pickup := value.(time.Time)
+				t.PickupTime = &pickup
+			}
+		case *models.Evaluation:
+			switch key {
+			case "appearance_score":
+				t.AppearanceScore = value.(int)
+			case "function_score":
+				t.FunctionScore = value.(int)
+			case "performance_score":
+				t.PerformanceScore = value.(int)
+			case "overall_score":
+				t.OverallScore = value.(float64)
+			case "market_price":
+				t.MarketPrice = value.(float64)
+			case "depreciation_rate":
+				t.DepreciationRate = value.(float64)
+			case "final_price":
+				t.FinalPrice = value.(float64)
+			case "evaluation_report":
+				t.EvaluationReport = value.(string)
+			case "images":
+				t.Images = value.(string)
+			case "status":
+				t.Status = value.(string)
+			}
+		}
+	}
+}
+
+type fakeUsers struct{ f *fakeRepositories }
+
+func (r fakeUsers) FindByID(id uint) (*models.User, error) {
+	if user, ok := r.f.users[id]; ok {
+		copied := *user
+		return &copied, nil
+	}
+	return nil, repositories.ErrNotFound
+}
+
+func (r fakeUsers) find(match func(*models.User) bool) (*models.User, error) {
+	for _, user := range r.f.users {
+		if match(user) {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, repositories.ErrNotFound
+}
+
+func (r fakeUsers) FindByUsername(username string) (*models.User, error) {
+	return r.find(func(u *models.User) bool { return u.Username == username })
+}
+
+func (r fakeUsers) FindActiveByEmail(email string) (*models.User, error) {
+	return r.find(func(u *models.User) bool { return u.Email == email && u.Status == "active" })
+}
+
+func (r fakeUsers) FindActiveByPhone(phone string) (*models.User, error) {
+	return r.find(func(u *models.User) bool { return u.Phone == phone && u.Status == "active" })
+}
+
+func (r fakeUsers) FindByPhone(phone string) (*models.User, error) {
+	return r.find(func(u *models.User) bool { return u.Phone == phone })
+}
+
+func (r fakeUsers) Create(user *models.User) error {
+	user.ID = r.f.id()
+	copied := *user
+	r.f.users[user.ID] = &copied
+	return nil
+}
+
+func (r fakeUsers) Update(user *models.User, updates map[string]interface{}) error {
+	stored := r.f.users[user.ID]
+	applyUpdates(stored, updates)
+	*user = *stored
+	return nil
+}
+
+func (r fakeUsers) UpdatePassword(user *models.User, hashedPassword string) error {
+	stored := r.f.users[user.ID]
+	stored.Password = hashedPassword
+	stored.TokenVersion++
+	*user = *stored
+	return nil
+}
+
+func (r fakeUsers) AdvanceTOTPStep(id uint, step int64) (bool, error) {
+	stored, ok := r.f.users[id]
+	if !ok || stored.TOTPLastStep >= step {
+		return false, nil
+	}
+	stored.TOTPLastStep = step
+	return true, nil
+}
+
+type fakePasswordResets struct{ f *fakeRepositories }
+
+func (r fakePasswordResets) Create(token *models.PasswordResetToken) error {
+	token.ID = r.f.id()
+	token.CreatedAt = time.Now()
+	copied := *token
+	r.f.resets[token.ID] = &copied
+	return nil
+}
+
+func (r fakePasswordResets) InvalidateAll(userID uint, at time.Time) error {
+	for _, token := range r.f.resets {
+		if token.UserID == userID && token.UsedAt == nil {
+			token.UsedAt = &at
+		}
+	}
+	return nil
+}
+
+func (r fakePasswordResets) FindByHash(tokenHash, channel string) (*models.PasswordResetToken, error) {
+	for _, token := range r.f.resets {
+		if token.TokenHash == tokenHash && token.Channel == channel {
+			copied := *token
+			return &copied, nil
+		}
+	}
+	return nil, repositories.ErrNotFound
+}
+
+func (r fakePasswordResets) FindLatestUnused(userID uint, channel string) (*models.PasswordResetToken, error) {
+	var latest *models.PasswordResetToken
+	for _, token := range r.f.resets {
+		if token.UserID == userID && token.Channel == channel && token.UsedAt == nil &&
+			(latest == nil || token.ID > latest.ID) {
+			latest = token
+		}
+	}
+	if latest == nil {
+		return nil, repositories.ErrNotFound
+	}
+	copied := *latest
+	return &copied, nil
+}
+
+func (r fakePasswordResets) RecordFailure(token *models.PasswordResetToken, maxAttempts int) error {
+	stored := r.f.resets[token.ID]
+	stored.Attempts++
+	if stored.Attempts >= maxAttempts {
+		now := time.Now()
+		stored.UsedAt = &now
+	}
+	return nil
+}
+
+func (r fakePasswordResets) CountFailures(userID uint, channel string, since time.Time) (int64, error) {
+	var total int64
+	for _, token := range r.f.resets {
+		if token.UserID == userID && token.Channel == channel && token.CreatedAt.After(since) {
+			total += int64(token.Attempts)
+		}
+	}
+	return total, nil
+}
+
+func (r fakePasswordResets) MarkUsed(id uint, at time.Time) (bool, error) {
+	stored, ok := r.f.resets[id]
+	if !ok || stored.UsedAt != nil {
+		return false, nil
+	}
+	stored.UsedAt = &at
+	return true, nil
+}
+
+type fakeDevices struct{ f *fakeRepositories }
+
+func (r fakeDevices) FindByID(id uint) (*models.Device, error) {
+	if device, ok := r.f.devices[id]; ok {
+		copied := *device
+		return &copied, nil
+	}
+	return nil, repositories.ErrNotFound
+}
+
+func (r fakeDevices) FindByIDAndStatus(id uint, status string) (*models.Device, error) {
+	device, err := r.FindByID(id)
+	if err != nil || device.Status != status {
+		return nil, repositories.ErrNotFound
+	}
+	return device, nil
+}
+
+// 支持测试中用到的过滤条件
+func matchDevice(device *models.Device, filter repositories.DeviceFilter) bool {
+	return (filter.Status == "" || device.Status == filter.Status) &&
+		(filter.Category == "" || device.Category == filter.Category) &&
+		(len(filter.Categories) == 0 || slices.Contains(filter.Categories, device.Category)) &&
+		(filter.BrandID == 0 || device.BrandID != nil && *device.BrandID == filter.BrandID) &&
+		(filter.ModelID == 0 || device.ModelID != nil && *device.ModelID == filter.ModelID) &&
+		inRange(device.Specs.MemoryGB, filter.Memory) &&
+		inRange(device.Specs.StorageGB, filter.Storage) &&
+		(filter.StorageType == "" || device.Specs.StorageType == filter.StorageType)
+}
+
+func inRange(value *int, r listquery.Range) bool {
+	if r.Min == nil && r.Max == nil {
+		return true
+	}
+	return value != nil && (r.Min == nil || float64(*value) >= *r.Min) && (r.Max == nil || float64(*value) <= *r.Max)
+}
+
+func (r fakeDevices) List(filter repositories.DeviceFilter, params listquery.Params) ([]models.Device, int64, error) {
+	var devices []models.Device
+	for _, device := range r.f.devices {
+		if matchDevice(device, filter) {
+			devices = append(devices, *device)
+		}
+	}
+	return devices, int64(len(devices)), nil
+}
+
+func (r fakeDevices) Count(filter repositories.DeviceFilter) (int64, error) {
+	_, total, err := r.List(filter, firstPage)
+	return total, err
+}
+
+func (r fakeDevices) ListAll() ([]models.Device, error) {
+	var devices []models.Device
+	for _, device := range r.f.devices {
+		devices = append(devices, *device)
+	}
+	return devices, nil
+}
+
+func (r fakeDevices) ListByIDs(ids []uint, filter repositories.DeviceFilter) ([]models.Device, error) {
+	var devices []models.Device
+	for _, id := range ids {
+		device, ok := r.f.devices[id]
+		if ok && matchDevice(device, filter) {
+			devices = append(devices, *device)
+		}
+	}
+	return devices, nil
+}
+
+func (r fakeDevices) LastModified() (time.Time, error) {
+	var last time.Time
+	for _, device := range r.f.devices {
+		if device.UpdatedAt.After(last) {
+			last = device.UpdatedAt
+		}
+	}
+	return last, nil
+}
+
+func (r fakeDevices) Create(device *models.Device) error {
+	device.ID = r.f.id()
+	copied := *device
+	r.f.devices[device.ID] = &copied
+	return nil
+}
+
+func (r fakeDevices) Update(device *models.Device, updates map[string]interface{}) error {
+	stored := r.f.devices[device.ID]
+	applyUpdates(stored, updates)
+	*device = *stored
+	return nil
+}
+
+type fakeCatalog struct{ f *fakeRepositories }
+
+func (r fakeCatalog) ListBrands() ([]models.Brand, error) {
+	var brands []models.Brand
+	for _, brand := range r.f.brands {
+		brands = append(brands, *brand)
+	}
+	sort.Slice(brands, func(i, j int) bool { return brands[i].Name < brands[j].Name })
+	return brands, nil
+}
+
+func (r fakeCatalog) FindBrand(id uint) (*models.Brand, error) {
+	if brand, ok := r.f.brands[id]; ok {
+		copied := *brand
+		return &copied, nil
+	}
+	return nil, repositories.ErrNotFound
+}
+
+func (r fakeCatalog) CreateBrand(brand *models.Brand) error {
+	brand.ID = r.f.id()
+	return r.SaveBrand(brand)
+}
+
+func (r fakeCatalog) SaveBrand(brand *models.Brand) error {
+	copied := *brand
+	r.f.brands[brand.ID] = &copied
+	return nil
+}
+
+func (r fakeCatalog) DeleteBrand(id uint) error {
+	delete(r.f.brands, id)
+	return nil
+}
+
+func (r fakeCatalog) ListSeries(brandID uint) ([]models.DeviceSeries, error) {
+	var series []models.DeviceSeries
+	for _, s := range r.f.series {
+		if brandID == 0 || s.BrandID == brandID {
+			series = append(series, *s)
+		}
+	}
+	sort.Slice(series, func(i, j int) bool { return series[i].Name < series[j].Name })
+	return series, nil
+}
+
+func (r fakeCatalog) FindSeries(id uint) (*models.DeviceSeries, error) {
+	if series, ok := r.f.series[id]; ok {
+		copied := *series
+		return &copied, nil
+	}
+	return nil, repositories.ErrNotFound
+}
+
+func (r fakeCatalog) CreateSeries(series *models.DeviceSeries) error {
+	series.ID = r.f.id()
+	return r.SaveSeries(series)
+}
+
+func (r fakeCatalog) SaveSeries(series *models.DeviceSeries) error {
+	copied := *series
+	r.f.series[series.ID] = &copied
+	return nil
+}
+
+func (r fakeCatalog) DeleteSeries(id uint) error {
+	delete(r.f.series, id)
+	return nil
+}
+
+func (r fakeCatalog) ListModels(filter repositories.DeviceModelFilter) ([]models.DeviceModel, error) {
+	var deviceModels []models.DeviceModel
+	for _, m := range r.f.models {
+		if (filter.BrandID == 0 || m.BrandID == filter.BrandID) &&
+			(filter.SeriesID == 0 || m.SeriesID != nil && *m.SeriesID == filter.SeriesID) {
+			deviceModels = append(deviceModels, *m)
+		}
+	}
+	sort.Slice(deviceModels, func(i, j int) bool { return deviceModels[i].Name < deviceModels[j].Name })
+	return deviceModels, nil
+}
+
+func (r fakeCatalog) FindModel(id uint) (*models.DeviceModel, error) {
+	if model, ok := r.f.models[id]; ok {
+		copied := *model
+		return &copied, nil
+	}
+	return nil, repositories.ErrNotFound
+}
+
+func (r fakeCatalog) CreateModel(model *models.DeviceModel) error {
+	model.ID = r.f.id()
+	return r.SaveModel(model)
+}
+
+func (r fakeCatalog) SaveModel(model *models.DeviceModel) error {
+	copied := *model
+	r.f.models[model.ID] = &copied
+	return nil
+}
+
+func (r fakeCatalog) DeleteModel(id uint) error {
+	delete(r.f.models, id)
+	return nil
+}
+
+func (r fakeCatalog) Stats() (repositories.TableStats, error) {
+	stats := repositories.TableStats{Rows: int64(len(r.f.brands) + len(r.f.models))}
+	for _, brand := range r.f.brands {
+		stats = stats.Add(repositories.TableStats{LastModified: brand.UpdatedAt})
+	}
+	for _, model := range r.f.models {
+		stats = stats.Add(repositories.TableStats{LastModified: model.UpdatedAt})
+	}
+	return stats, nil
+}
+
+type fakeCategories struct{ f *fakeRepositories }
+
+func (r fakeCategories) List() ([]models.Category, error) {
+	var categories []models.Category
+	for _, category := range r.f.categories {
+		categories = append(categories, *category)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i].SortOrder != categories[j].SortOrder {
+			return categories[i].SortOrder < categories[j].SortOrder
+		}
+		return categories[i].ID < categories[j].ID
+	})
+	return categories, nil
+}
+
+func (r fakeCategories) FindByID(id uint) (*models.Category, error) {
+	if category, ok := r.f.categories[id]; ok {
+		copied := *category
+		return &copied, nil
+	}
+	return nil, repositories.ErrNotFound
+}
+
+func (r fakeCategories) FindByCode(code string) (*models.Category, error) {
+	for _, category := range r.f.categories {
+		if category.Code == code {
+			copied := *category
+			return &copied, nil
+		}
+	}
+	return nil, repositories.ErrNotFound
+}
+
+func (r fakeCategories) Create(category *models.Category) error {
+	category.ID = r.f.id()
+	return r.Save(category)
+}
+
+func (r fakeCategories) Save(category *models.Category) error {
+	copied := *category
+	r.f.categories[category.ID] = &copied
+	return nil
+}
+
+func (r fakeCategories) Delete(id uint) error {
+	delete(r.f.categories, id)
+	return nil
+}
+
+func (r fakeCategories) Stats() (repositories.TableStats, error) {
+	stats := repositories.TableStats{Rows: int64(len(r.f.categories))}
+	for _, category := range r.f.categories {
+		stats = stats.Add(repositories.TableStats{LastModified: category.UpdatedAt})
+	}
+	return stats, nil
+}
+
+type fakeOrders struct{ f *fakeRepositories }
+
+// 模拟预加载关联数据
+func (r fakeOrders) load(order *models.RecycleOrder) *models.RecycleOrder {
+	copied := *order
+	if user, ok := r.f.users[order.UserID]; ok {
+		copied.User = *user
+	}
+	if device, ok := r.f.devices[order.DeviceID]; ok {
+		copied.Device = *device
+	}
+	copied.Evaluation = nil
+	for _, evaluation := range r.f.evaluations {
+		if evaluation.OrderID == order.ID {
+			e := *evaluation
+			copied.Evaluation = &e
+		}
+	}
+	return &copied
+}
+
+func (r fakeOrders) FindByID(id uint) (*models.RecycleOrder, error) {
+	if order, ok := r.f.orders[id]; ok {
+		return r.load(order), nil
+	}
+	return nil, repositories.ErrNotFound
+}
+
+func (r fakeOrders) List(filter repositories.OrderFilter, params listquery.Params) ([]models.RecycleOrder, int64, error) {
+	var orders []models.RecycleOrder
+	for _, order := range r.f.orders {
+		if (filter.UserID == 0 || order.UserID == filter.UserID) &&
+			(filter.Status == "" || order.Status == filter.Status) {
+			orders = append(orders, *r.load(order))
+		}
+	}
+	return orders, int64(len(orders)), nil
+}
+
+func (r fakeOrders) CountByDevice() (map[uint]int64, error) {
+	counts := make(map[uint]int64)
+	for _, order := range r.f.orders {
+		if order.Status != "cancelled" {
+			counts[order.DeviceID]++
+		}
+	}
+	return counts, nil
+}
+
+func (r fakeOrders) Create(order *models.RecycleOrder) error {
+	order.ID = r.f.id()
+	copied := *order
+	r.f.orders[order.ID] = &copied
+	*order = *r.load(&copied)
+	return nil
+}
+
+func (r fakeOrders) Update(order *models.RecycleOrder, updates map[string]interface{}) error {
+	stored := r.f.orders[order.ID]
+	applyUpdates(stored, updates)
+	*order = *r.load(stored)
+	return nil
+}
+
+type fakeEvaluations struct{ f *fakeRepositories }
+
+func (r fakeEvaluations) load(evaluation *models.Evaluation) *models.Evaluation {
+	copied := *evaluation
+	if order, ok := r.f.orders[evaluation.OrderID]; ok {
+		copied.Order = *order
+	}
+	if evaluator, ok := r.f.users[evaluation.EvaluatorID]; ok {
+		copied.Evaluator = *evaluator
+	}
+	return &copied
+}
+
+func (r fakeEvaluations) FindByID(id uint) (*models.Evaluation, error) {
+	if evaluation, ok := r.f.evaluations[id]; ok {
+		return r.load(evaluation), nil
+	}
+	return nil, repositories.ErrNotFound
+}
+
+func (r fakeEvaluations) FindByOrderID(orderID uint) (*models.Evaluation, error) {
+	for _, evaluation := range r.f.evaluations {
+		if evaluation.OrderID == orderID {
+			return r.load(evaluation), nil
+		}
+	}
+	return nil, repositories.ErrNotFound
+}
+
+func (r fakeEvaluations) List(filter repositories.EvaluationFilter, params listquery.Params) ([]models.Evaluation, int64, error) {
+	var evaluations []models.Evaluation
+	for _, evaluation := range r.f.evaluations {
+		if (filter.Status == "" || evaluation.Status == filter.Status) &&
+			(filter.EvaluatorID == 0 || evaluation.EvaluatorID == filter.EvaluatorID) {
+			evaluations = append(evaluations, *r.load(evaluation))
+		}
+	}
+	return evaluations, int64(len(evaluations)), nil
+}
+
+func (r fakeEvaluations) Create(evaluation *models.Evaluation) error {
+	evaluation.ID = r.f.id()
+	copied := *evaluation
+	r.f.evaluations[evaluation.ID] = &copied
+	*evaluation = *r.load(&copied)
+	return nil
+}
+
+func (r fakeEvaluations) Update(evaluation *models.Evaluation, updates map[string]interface{}) error {
+	stored := r.f.evaluations[evaluation.ID]
+	applyUpdates(stored, updates)
+	*evaluation = *r.load(stored)
+	return nil
+}
+
+type fakeIdentities struct{ f *fakeRepositories }
+
+func (r fakeIdentities) find(match func(*models.UserIdentity) bool) (*models.UserIdentity, error) {
+	for _, identity := range r.f.identities {
+		if match(identity) {
+			copied := *identity
+			return &copied, nil
+		}
+	}
+	return nil, repositories.ErrNotFound
+}
+
+func (r fakeIdentities) FindByOpenID(provider, openID string) (*models.UserIdentity, error) {
+	return r.find(func(i *models.UserIdentity) bool { return i.Provider == provider && i.OpenID == openID })
+}
+
+func (r fakeIdentities) FindByUnionID(unionID string) (*models.UserIdentity, error) {
+	return r.find(func(i *models.UserIdentity) bool { return i.UnionID == unionID })
+}
+
+func (r fakeIdentities) ListByUser(userID uint) ([]models.UserIdentity, error) {
+	var identities []models.UserIdentity
+	for _, identity := range r.f.identities {
+		if identity.UserID == userID {
+			identities = append(identities, *identity)
+		}
+	}
+	sort.Slice(identities, func(i, j int) bool { return identities[i].ID < identities[j].ID })
+	return identities, nil
+}
+
+func (r fakeIdentities) Create(identity *models.UserIdentity) error {
+	identity.ID = r.f.id()
+	copied := *identity
+	r.f.identities[identity.ID] = &copied
+	return nil
+}
+
+func (r fakeIdentities) Update(identity *models.UserIdentity, updates map[string]interface{}) error {
+	stored := r.f.identities[identity.ID]
+	applyUpdates(stored, updates)
+	*identity = *stored
+	return nil
+}
+
+func (r fakeIdentities) Delete(id uint) error {
+	delete(r.f.identities, id)
+	return nil
+}
+
+type fakeRecoveryCodes struct{ f *fakeRepositories }
+
+func (r fakeRecoveryCodes) Replace(userID uint, codeHashes []string) error {
+	r.DeleteAll(userID)
+	for _, hash := range codeHashes {
+		id := r.f.id()
+		r.f.recovery[id] = &models.RecoveryCode{ID: id, UserID: userID, CodeHash: hash}
+	}
+	return nil
+}
+
+func (r fakeRecoveryCodes) DeleteAll(userID uint) error {
+	for id, code := range r.f.recovery {
+		if code.UserID == userID {
+			delete(r.f.recovery, id)
+		}
+	}
+	return nil
+}
+
+func (r fakeRecoveryCodes) Use(userID uint, codeHash string, at time.Time) (bool, error) {
+	for _, code := range r.f.recovery {
+		if code.UserID == userID && code.CodeHash == codeHash && code.UsedAt == nil {
+			code.UsedAt = &at
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type fakeAuditLogs struct{ f *fakeRepositories }
+
+func (r fakeAuditLogs) Create(log *models.AuditLog) error {
+	log.ID = r.f.id()
+	copied := *log
+	r.f.auditLogs[log.ID] = &copied
+	return nil
+}
+
+func matchAuditLog(log *models.AuditLog, filter repositories.AuditLogFilter) bool {
+	return (filter.ActorID == 0 || log.ActorID == filter.ActorID) &&
+		(filter.Action == "" || log.Action == filter.Action) &&
+		(filter.EntityType == "" || log.EntityType == filter.EntityType) &&
+		(filter.EntityID == 0 || log.EntityID == filter.EntityID)
+}
+
+func (r fakeAuditLogs) List(filter repositories.AuditLogFilter, params listquery.Params) ([]models.AuditLog, int64, error) {
+	logs, err := r.ListBefore(filter, 0, len(r.f.auditLogs))
+	return logs, int64(len(logs)), err
+}
+
+func (r fakeAuditLogs) ListBefore(filter repositories.AuditLogFilter, beforeID uint, limit int) ([]models.AuditLog, error) {
+	var logs []models.AuditLog
+	for _, log := range r.f.auditLogs {
+		if matchAuditLog(log, filter) && (beforeID == 0 || log.ID < beforeID) {
+			logs = append(logs, *log)
+		}
+	}
+	sort.Slice(logs, func(i, j int) bool { return logs[i].ID > logs[j].ID })
+	if len(logs) > limit {
+		logs = logs[:limit]
+	}
+	return logs, nil
+}
+
+type fakeLoginAttempts struct{ f *fakeRepositories }
+
+func (r fakeLoginAttempts) Create(attempt *models.LoginAttempt) error {
+	attempt.ID = r.f.id()
+	copied := *attempt
+	r.f.attempts[attempt.ID] = &copied
+	return nil
+}
+
+func (r fakeLoginAttempts) List(filter repositories.LoginAttemptFilter, params listquery.Params) ([]models.LoginAttempt, int64, error) {
+	var attempts []models.LoginAttempt
+	for _, attempt := range r.f.attempts {
+		if (filter.Username == "" || attempt.Username == filter.Username) && (filter.IP == "" || attempt.IP == filter.IP) {
+			attempts = append(attempts, *attempt)
+		}
+	}
+	return attempts, int64(len(attempts)), nil
+}
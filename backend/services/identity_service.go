@@ -0,0 +1,181 @@
+package services
+
+import (
+	"e-device-recycle-backend/identity"
+	"e-device-recycle-backend/models"
+	"e-device-recycle-backend/repositories"
+	"e-device-recycle-backend/utils"
+	"errors"
+)
+
+type IdentityService interface {
+	// 第三方账号登录，首次登录时通过unionid关联已有账号或创建新账号，第二个返回值表示是否新建了账号
+	Login(ident *identity.Identity) (*models.User, bool, error)
+	List(userID uint) ([]models.UserIdentity, error)
+	// 为账号绑定第三方账号，已绑定到该账号时返回false
+	Bind(userID uint, ident *identity.Identity) (bool, error)
+	// 解绑第三方账号，不能解绑没有手机号和邮箱的账号的最后一个第三方账号
+	Unbind(userID uint, provider string) error
+}
+
+type identityService struct {
+	repos repositories.Repositories
+}
+
+func NewIdentityService(repos repositories.Repositories) IdentityService {
+	return &identityService{repos: repos}
+}
+
+func (s *identityService) Login(ident *identity.Identity) (*models.User, bool, error) {
+	var user *models.User
+	isNewUser := false
+
+	existing, err := s.repos.Identities().FindByOpenID(ident.Provider, ident.OpenID)
+	switch {
+	case err == nil:
+		user, err = s.repos.Users().FindByID(existing.UserID)
+		if err != nil {
+			return nil, false, err
+		}
+		// 补充之前未获取到的unionid
+		if existing.UnionID == "" && ident.UnionID != "" {
+			if err := s.repos.Identities().Update(existing, map[string]interface{}{"union_id": ident.UnionID}); err != nil {
+				return nil, false, err
+			}
+		}
+	case errors.Is(err, repositories.ErrNotFound):
+		err = s.repos.Transaction(func(repos repositories.Repositories) error {
+			// 通过unionid关联已绑定同一开放平台的账号
+			if ident.UnionID != "" {
+				sibling, err := repos.Identities().FindByUnionID(ident.UnionID)
+				if err == nil {
+					if user, err = repos.Users().FindByID(sibling.UserID); err != nil {
+						return err
+					}
+				} else if !errors.Is(err, repositories.ErrNotFound) {
+					return err
+				}
+			}
+
+			if user == nil {
+				if user, err = s.createUser(repos, ident); err != nil {
+					return err
+				}
+				isNewUser = true
+			}
+
+			return repos.Identities().Create(&models.UserIdentity{
+				UserID:   user.ID,
+				Provider: ident.Provider,
+				OpenID:   ident.OpenID,
+				UnionID:  ident.UnionID,
+			})
+		})
+		if err != nil {
+			return nil, false, err
+		}
+	default:
+		return nil, false, err
+	}
+
+	if user.Status != "active" {
+		return user, isNewUser, ErrUserDisabled
+	}
+	return user, isNewUser, nil
+}
+
+// 为首次登录的第三方用户创建账号，设置随机密码，之后可通过忘记密码设置
+func (s *identityService) createUser(repos repositories.Repositories, ident *identity.Identity) (*models.User, error) {
+	suffix, err := utils.GenerateNumericCode(8)
+	if err != nil {
+		return nil, err
+	}
+	password, err := utils.GenerateRandomToken(24)
+	if err != nil {
+		return nil, err
+	}
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.User{
+		Username: ident.Provider + "_" + suffix,
+		Password: hashedPassword,
+		Role:     "user",
+		Status:   "active",
+	}
+	if err := repos.Users().Create(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *identityService) List(userID uint) ([]models.UserIdentity, error) {
+	return s.repos.Identities().ListByUser(userID)
+}
+
+func (s *identityService) Bind(userID uint, ident *identity.Identity) (bool, error) {
+	// 该第三方账号已绑定其他用户
+	existing, err := s.repos.Identities().FindByOpenID(ident.Provider, ident.OpenID)
+	if err == nil {
+		if existing.UserID == userID {
+			return false, nil
+		}
+		return false, ErrIdentityTaken
+	}
+	if !errors.Is(err, repositories.ErrNotFound) {
+		return false, err
+	}
+
+	// 每个平台只能绑定一个账号
+	identities, err := s.repos.Identities().ListByUser(userID)
+	if err != nil {
+		return false, err
+	}
+	for _, bound := range identities {
+		if bound.Provider == ident.Provider {
+			return false, ErrIdentityProviderBound
+		}
+	}
+
+	if err := s.repos.Identities().Create(&models.UserIdentity{
+		UserID:   userID,
+		Provider: ident.Provider,
+		OpenID:   ident.OpenID,
+		UnionID:  ident.UnionID,
+	}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *identityService) Unbind(userID uint, provider string) error {
+	identities, err := s.repos.Identities().ListByUser(userID)
+	if err != nil {
+		return err
+	}
+	var bound *models.UserIdentity
+	for i := range identities {
+		if identities[i].Provider == provider {
+			bound = &identities[i]
+		}
+	}
+	if bound == nil {
+		return ErrIdentityNotBound
+	}
+
+	// 第三方登录创建的账号密码是随机生成的，没有手机号和邮箱时无法找回，
+	// 解绑最后一个第三方账号后将无法登录
+	if len(identities) == 1 {
+		user, err := s.repos.Users().FindByID(userID)
+		if err != nil {
+			return err
+		}
+		if user.Phone == "" && user.Email == "" {
+			return ErrIdentityLastLogin
+		}
+	}
+
+	return s.repos.Identities().Delete(bound.ID)
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"e-device-recycle-backend/identity"
+	"e-device-recycle-backend/models"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestIdentityLogin(t *testing.T) {
+	repos := newFakeRepositories()
+	svc := NewIdentityService(repos)
+
+	// 首次登录创建账号，再次登录返回同一账号并补充unionid
+	user, isNew, err := svc.Login(&identity.Identity{Provider: "wechat", OpenID: "open-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isNew || !strings.HasPrefix(user.Username, "wechat_") || user.Status != "active" {
+		t.Errorf("首次登录 isNew = %v, user = %+v", isNew, user)
+	}
+	again, isNew, err := svc.Login(&identity.Identity{Provider: "wechat", OpenID: "open-1", UnionID: "union-1"})
+	if err != nil || isNew || again.ID != user.ID {
+		t.Errorf("再次登录 isNew = %v, id = %d, err = %v", isNew, again.ID, err)
+	}
+
+	// 同一开放平台的其他应用通过unionid关联到已有账号
+	linked, isNew, err := svc.Login(&identity.Identity{Provider: "wechat_web", OpenID: "open-2", UnionID: "union-1"})
+	if err != nil || isNew || linked.ID != user.ID {
+		t.Errorf("unionid关联 isNew = %v, id = %d, err = %v", isNew, linked.ID, err)
+	}
+	if identities, _ := svc.List(user.ID); len(identities) != 2 {
+		t.Errorf("绑定 %d 个第三方账号, want 2", len(identities))
+	}
+
+	repos.users[user.ID].Status = "disabled"
+	if _, _, err := svc.Login(&identity.Identity{Provider: "wechat", OpenID: "open-1"}); !errors.Is(err, ErrUserDisabled) {
+		t.Errorf("禁用账号: err = %v, want ErrUserDisabled", err)
+	}
+}
+
+func TestIdentityBindAndUnbind(t *testing.T) {
+	repos := newFakeRepositories()
+	svc := NewIdentityService(repos)
+	repos.users[customer.UserID] = &models.User{ID: customer.UserID, Role: "user", Status: "active"}
+	repos.users[other.UserID] = &models.User{ID: other.UserID, Role: "user", Status: "active", Phone: "13800000002"}
+
+	wechat := &identity.Identity{Provider: "wechat", OpenID: "open-1"}
+	if created, err := svc.Bind(customer.UserID, wechat); err != nil || !created {
+		t.Fatalf("绑定 created = %v, err = %v", created, err)
+	}
+	if created, err := svc.Bind(customer.UserID, wechat); err != nil || created {
+		t.Errorf("重复绑定 created = %v, err = %v", created, err)
+	}
+	if _, err := svc.Bind(other.UserID, wechat); !errors.Is(err, ErrIdentityTaken) {
+		t.Errorf("已被其他用户绑定: err = %v, want ErrIdentityTaken", err)
+	}
+	if _, err := svc.Bind(customer.UserID, &identity.Identity{Provider: "wechat", OpenID: "open-2"}); !errors.Is(err, ErrIdentityProviderBound) {
+		t.Errorf("同一平台绑定第二个账号: err = %v, want ErrIdentityProviderBound", err)
+	}
+
+	// 没有手机号和邮箱时不能解绑唯一的第三方账号
+	if err := svc.Unbind(customer.UserID, "wechat"); !errors.Is(err, ErrIdentityLastLogin) {
+		t.Errorf("解绑唯一登录方式: err = %v, want ErrIdentityLastLogin", err)
+	}
+	if err := svc.Unbind(customer.UserID, "alipay"); !errors.Is(err, ErrIdentityNotBound) {
+		t.Errorf("未绑定: err = %v, want ErrIdentityNotBound", err)
+	}
+
+	if _, err := svc.Bind(other.UserID, &identity.Identity{Provider: "wechat", OpenID: "open-3"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Unbind(other.UserID, "wechat"); err != nil {
+		t.Errorf("有手机号时解绑: err = %v", err)
+	}
+	if identities, _ := svc.List(other.UserID); len(identities) != 0 {
+		t.Errorf("解绑后还有 %d 个第三方账号", len(identities))
+	}
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"e-device-recycle-backend/listquery"
+	"e-device-recycle-backend/metrics"
+	"e-device-recycle-backend/models"
+	"e-device-recycle-backend/repositories"
+	"e-device-recycle-backend/utils"
+	"errors"
+	"time"
+)
+
+type OrderService interface {
+	// 为用户创建回收订单，按设备信息计算预估价格
+	Create(actor Actor, req models.RecycleOrderCreateRequest) (*models.RecycleOrder, error)
+	List(filter repositories.OrderFilter, params listquery.Params) ([]models.RecycleOrder, int64, error)
+	// 订单详情，非管理员只能查看自己的订单
+	Get(actor Actor, id uint) (*models.RecycleOrder, error)
+	// 管理员更新订单，返回更新前后的订单
+	Update(id uint, req models.RecycleOrderUpdateRequest) (before, after *models.RecycleOrder, err error)
+	// 用户取消自己待处理的订单，reason为空时记为other
+	Cancel(actor Actor, id uint, reason string) error
+}
+
+type orderService struct {
+	repos repositories.Repositories
+	now   func() time.Time
+}
+
+func NewOrderService(repos repositories.Repositories, now func() time.Time) OrderService {
+	return &orderService{repos: repos, now: now}
+}
+
+func (s *orderService) Create(actor Actor, req models.RecycleOrderCreateRequest) (*models.RecycleOrder, error) {
+	// 只能为在售设备下单
+	device, err := s.repos.Devices().FindByIDAndStatus(req.DeviceID, "active")
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrDeviceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 按设备分类的定价参数估价，分类已删除时使用默认参数
+	pricing := DefaultPricing
+	rules, err := categoryRules(s.repos, device.Category)
+	switch {
+	case err == nil:
+		pricing = rules.PricingRules()
+	case !errors.Is(err, ErrCategoryNotFound):
+		return nil, err
+	}
+
+	order := models.RecycleOrder{
+		UserID:         actor.UserID,
+		DeviceID:       req.DeviceID,
+		OrderNo:        utils.GenerateOrderNo(),
+		ContactName:    req.ContactName,
+		ContactPhone:   req.ContactPhone,
+		PickupAddress:  req.PickupAddress,
+		PickupTime:     req.PickupTime,
+		DeviceInfo:     req.DeviceInfo,
+		Images:         req.Images,
+		EstimatedPrice: pricing.Estimate(device.BasePrice, device.Condition, device.YearBought, s.now().Year()),
+		Status:         "pending",
+		Remark:         req.Remark,
+	}
+
+	if err := s.repos.Orders().Create(&order); err != nil {
+		return nil, err
+	}
+
+	metrics.OrdersCreated.Inc()
+	return &order, nil
+}
+
+func (s *orderService) List(filter repositories.OrderFilter, params listquery.Params) ([]models.RecycleOrder, int64, error) {
+	return s.repos.Orders().List(filter, params)
+}
+
+func (s *orderService) Get(actor Actor, id uint) (*models.RecycleOrder, error) {
+	order, err := s.find(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// 普通用户查看他人订单时按不存在处理，不暴露订单信息
+	if !actor.IsAdmin() && order.UserID != actor.UserID {
+		return nil, ErrOrderNotFound
+	}
+	return order, nil
+}
+
+func (s *orderService) Update(id uint, req models.RecycleOrderUpdateRequest) (*models.RecycleOrder, *models.RecycleOrder, error) {
+	order, err := s.find(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	updates := map[string]interface{}{
+		"status": req.Status,
+		"remark": req.Remark,
+	}
+	if req.FinalPrice != nil {
+		updates["final_price"] = *req.FinalPrice
+	}
+	if req.PickupTime != nil {
+		updates["pickup_time"] = *req.PickupTime
+	}
+	if req.Status == "cancelled" && order.Status != "cancelled" {
+		updates["cancel_reason"] = models.CancelReasonAdmin
+	}
+
+	before := *order
+	if err := s.repos.Orders().Update(order, updates); err != nil {
+		return nil, nil, err
+	}
+
+	// 状态变化时记录业务指标
+	if before.Status != order.Status {
+		switch order.Status {
+		case "cancelled":
+			metrics.OrdersCancelled.WithLabelValues(models.CancelReasonAdmin).Inc()
+		case "completed":
+			if order.FinalPrice != nil {
+				metrics.Payouts.Observe(*order.FinalPrice)
+			}
+		}
+	}
+	return &before, order, nil
+}
+
+func (s *orderService) Cancel(actor Actor, id uint, reason string) error {
+	order, err := s.find(id)
+	if err != nil {
+		return err
+	}
+
+	// 只能取消自己的订单
+	if order.UserID != actor.UserID {
+		return ErrOrderNotFound
+	}
+
+	// 只有pending状态的订单可以取消
+	if order.Status != "pending" {
+		return ErrOrderNotCancellable
+	}
+
+	if reason == "" {
+		reason = models.CancelReasonOther
+	}
+	if err := s.repos.Orders().Update(order, map[string]interface{}{
+		"status":        "cancelled",
+		"cancel_reason": reason,
+	}); err != nil {
+		return err
+	}
+
+	metrics.OrdersCancelled.WithLabelValues(reason).Inc()
+	return nil
+}
+
+func (s *orderService) find(id uint) (*models.RecycleOrder, error) {
+	order, err := s.repos.Orders().FindByID(id)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrOrderNotFound
+	}
+	return order, err
+}
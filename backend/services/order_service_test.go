@@ -0,0 +1,247 @@
+package services
+
+import (
+	"e-device-recycle-backend/metrics"
+	"e-device-recycle-backend/models"
+	"e-device-recycle-backend/repositories"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+var (
+	customer = Actor{UserID: 1, Role: "user"}
+	other    = Actor{UserID: 2, Role: "user"}
+	admin    = Actor{UserID: 3, Role: "admin"}
+)
+
+func fixedNow() time.Time {
+	return time.Date(2024, 6, 1, 10, 0, 0, 0, time.Local)
+}
+
+// 准备用户和一台在售设备
+func newOrderFixture(t *testing.T) (*fakeRepositories, OrderService, *models.Device) {
+	t.Helper()
+	repos := newFakeRepositories()
+	for _, actor := range []Actor{customer, other, admin} {
+		repos.users[actor.UserID] = &models.User{ID: actor.UserID, Role: actor.Role, Status: "active"}
+	}
+	repos.nextID = 100
+
+	device := &models.Device{Name: "MacBook Pro", BasePrice: 10000, Condition: "good", YearBought: 2022, Status: "active"}
+	if err := repos.Devices().Create(device); err != nil {
+		t.Fatal(err)
+	}
+	return repos, NewOrderService(repos, fixedNow), device
+}
+
+func createOrder(t *testing.T, svc OrderService, actor Actor, deviceID uint) *models.RecycleOrder {
+	t.Helper()
+	order, err := svc.Create(actor, models.RecycleOrderCreateRequest{
+		DeviceID:      deviceID,
+		ContactName:   "张三",
+		ContactPhone:  "13800000000",
+		PickupAddress: "北京市海淀区",
+	})
+	if err != nil {
+		t.Fatalf("创建订单失败: %v", err)
+	}
+	return order
+}
+
+func TestOrderCreate(t *testing.T) {
+	_, svc, device := newOrderFixture(t)
+
+	order := createOrder(t, svc, customer, device.ID)
+
+	if order.UserID != customer.UserID {
+		t.Errorf("UserID = %d, want %d", order.UserID, customer.UserID)
+	}
+	if order.Status != "pending" {
+		t.Errorf("Status = %q, want pending", order.Status)
+	}
+	if order.OrderNo == "" {
+		t.Error("订单号为空")
+	}
+	if want := EstimateDevicePrice(10000, "good", 2022, 2024); !almostEqual(order.EstimatedPrice, want) {
+		t.Errorf("EstimatedPrice = %v, want %v", order.EstimatedPrice, want)
+	}
+	if order.Device.ID != device.ID {
+		t.Error("订单未加载设备信息")
+	}
+}
+
+func TestOrderCreateRequiresActiveDevice(t *testing.T) {
+	repos, svc, device := newOrderFixture(t)
+
+	_, err := svc.Create(customer, models.RecycleOrderCreateRequest{DeviceID: 9999})
+	if !errors.Is(err, ErrDeviceNotFound) {
+		t.Errorf("不存在的设备: err = %v, want ErrDeviceNotFound", err)
+	}
+
+	repos.devices[device.ID].Status = "inactive"
+	_, err = svc.Create(customer, models.RecycleOrderCreateRequest{DeviceID: device.ID})
+	if !errors.Is(err, ErrDeviceNotFound) {
+		t.Errorf("已下架的设备: err = %v, want ErrDeviceNotFound", err)
+	}
+}
+
+func TestOrderGetAuthorization(t *testing.T) {
+	_, svc, device := newOrderFixture(t)
+	order := createOrder(t, svc, customer, device.ID)
+
+	if _, err := svc.Get(customer, order.ID); err != nil {
+		t.Errorf("下单用户查看订单: err = %v", err)
+	}
+	if _, err := svc.Get(admin, order.ID); err != nil {
+		t.Errorf("管理员查看订单: err = %v", err)
+	}
+	if _, err := svc.Get(other, order.ID); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("其他用户查看订单: err = %v, want ErrOrderNotFound", err)
+	}
+	if _, err := svc.Get(admin, 9999); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("不存在的订单: err = %v, want ErrOrderNotFound", err)
+	}
+}
+
+func TestOrderCancel(t *testing.T) {
+	repos, svc, device := newOrderFixture(t)
+	order := createOrder(t, svc, customer, device.ID)
+
+	// 其他用户和管理员都不能通过该接口取消用户的订单
+	if err := svc.Cancel(other, order.ID, ""); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("其他用户取消: err = %v, want ErrOrderNotFound", err)
+	}
+	if err := svc.Cancel(admin, order.ID, ""); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("管理员取消: err = %v, want ErrOrderNotFound", err)
+	}
+
+	cancelled := testutil.ToFloat64(metrics.OrdersCancelled.WithLabelValues(models.CancelReasonPriceTooLow))
+	if err := svc.Cancel(customer, order.ID, models.CancelReasonPriceTooLow); err != nil {
+		t.Fatalf("取消订单失败: %v", err)
+	}
+	if stored := repos.orders[order.ID]; stored.Status != "cancelled" || stored.CancelReason != models.CancelReasonPriceTooLow {
+		t.Errorf("Status = %q, CancelReason = %q, want cancelled, price_too_low", stored.Status, stored.CancelReason)
+	}
+	if got := testutil.ToFloat64(metrics.OrdersCancelled.WithLabelValues(models.CancelReasonPriceTooLow)); got != cancelled+1 {
+		t.Errorf("取消订单指标 = %v, want %v", got, cancelled+1)
+	}
+
+	// 未提供原因时记为other
+	order = createOrder(t, svc, customer, device.ID)
+	if err := svc.Cancel(customer, order.ID, ""); err != nil {
+		t.Fatalf("取消订单失败: %v", err)
+	}
+	if reason := repos.orders[order.ID].CancelReason; reason != models.CancelReasonOther {
+		t.Errorf("CancelReason = %q, want other", reason)
+	}
+
+	// 已取消的订单不能再次取消
+	if err := svc.Cancel(customer, order.ID, ""); !errors.Is(err, ErrOrderNotCancellable) {
+		t.Errorf("重复取消: err = %v, want ErrOrderNotCancellable", err)
+	}
+}
+
+func TestOrderCancelOnlyPending(t *testing.T) {
+	repos, svc, device := newOrderFixture(t)
+
+	for _, status := range []string{"confirmed", "picked_up", "evaluated", "completed"} {
+		order := createOrder(t, svc, customer, device.ID)
+		repos.orders[order.ID].Status = status
+
+		if err := svc.Cancel(customer, order.ID, ""); !errors.Is(err, ErrOrderNotCancellable) {
+			t.Errorf("状态%s: err = %v, want ErrOrderNotCancellable", status, err)
+		}
+	}
+}
+
+func TestOrderUpdate(t *testing.T) {
+	_, svc, device := newOrderFixture(t)
+	order := createOrder(t, svc, customer, device.ID)
+
+	price := 5200.0
+	before, after, err := svc.Update(order.ID, models.RecycleOrderUpdateRequest{
+		Status:     "confirmed",
+		FinalPrice: &price,
+		Remark:     "已确认上门时间",
+	})
+	if err != nil {
+		t.Fatalf("更新订单失败: %v", err)
+	}
+	if before.Status != "pending" || after.Status != "confirmed" {
+		t.Errorf("状态 %q -> %q, want pending -> confirmed", before.Status, after.Status)
+	}
+	if after.FinalPrice == nil || *after.FinalPrice != price {
+		t.Errorf("FinalPrice = %v, want %v", after.FinalPrice, price)
+	}
+	if before.FinalPrice != nil {
+		t.Error("更新前的订单不应包含最终价格")
+	}
+
+	if _, _, err := svc.Update(9999, models.RecycleOrderUpdateRequest{Status: "confirmed"}); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("不存在的订单: err = %v, want ErrOrderNotFound", err)
+	}
+}
+
+func TestOrderUpdateMetrics(t *testing.T) {
+	repos, svc, device := newOrderFixture(t)
+	order := createOrder(t, svc, customer, device.ID)
+
+	// 订单完成时记录打款金额，重复更新为completed不重复记录
+	price := 4800.0
+	sum := payoutSum(t)
+	for i := 0; i < 2; i++ {
+		if _, _, err := svc.Update(order.ID, models.RecycleOrderUpdateRequest{Status: "completed", FinalPrice: &price}); err != nil {
+			t.Fatalf("更新订单失败: %v", err)
+		}
+	}
+	if got := payoutSum(t); got != sum+price {
+		t.Errorf("打款金额累计 = %v, want %v", got, sum+price)
+	}
+
+	// 管理员取消订单，原因记为admin
+	order = createOrder(t, svc, customer, device.ID)
+	cancelled := testutil.ToFloat64(metrics.OrdersCancelled.WithLabelValues(models.CancelReasonAdmin))
+	if _, _, err := svc.Update(order.ID, models.RecycleOrderUpdateRequest{Status: "cancelled"}); err != nil {
+		t.Fatalf("更新订单失败: %v", err)
+	}
+	if reason := repos.orders[order.ID].CancelReason; reason != models.CancelReasonAdmin {
+		t.Errorf("CancelReason = %q, want admin", reason)
+	}
+	if got := testutil.ToFloat64(metrics.OrdersCancelled.WithLabelValues(models.CancelReasonAdmin)); got != cancelled+1 {
+		t.Errorf("取消订单指标 = %v, want %v", got, cancelled+1)
+	}
+}
+
+// 读取打款金额直方图的累计值
+func payoutSum(t *testing.T) float64 {
+	t.Helper()
+	var m dto.Metric
+	if err := metrics.Payouts.Write(&m); err != nil {
+		t.Fatal(err)
+	}
+	return m.GetHistogram().GetSampleSum()
+}
+
+func TestOrderListFiltersByUser(t *testing.T) {
+	_, svc, device := newOrderFixture(t)
+	createOrder(t, svc, customer, device.ID)
+	createOrder(t, svc, customer, device.ID)
+	createOrder(t, svc, other, device.ID)
+
+	orders, total, err := svc.List(repositories.OrderFilter{UserID: customer.UserID}, firstPage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 2 || len(orders) != 2 {
+		t.Errorf("total = %d, len = %d, want 2", total, len(orders))
+	}
+	for _, order := range orders {
+		if order.UserID != customer.UserID {
+			t.Errorf("返回了其他用户的订单 %d", order.ID)
+		}
+	}
+}
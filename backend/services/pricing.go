@@ -0,0 +1,58 @@
+package services
+
+// 成色系数
+var conditionMultiplier = map[string]float64{
+	"excellent": 1.0,
+	"good":      0.8,
+	"fair":      0.6,
+	"poor":      0.4,
+}
+
+// 按购买年份折旧的定价参数，各分类可以单独设置
+type PricingRules struct {
+	AnnualDepreciation float64 // 每年折旧率
+	MaxDepreciation    float64 // 最大折旧率
+	MinPriceRatio      float64 // 预估价格不低于基础价格的比例
+}
+
+// 分类未设置定价参数时使用
+var DefaultPricing = PricingRules{
+	AnnualDepreciation: 0.1,
+	MaxDepreciation:    0.8,
+	MinPriceRatio:      0.1,
+}
+
+// 按默认定价参数计算设备预估回收价格，currentYear为计算时的年份
+func EstimateDevicePrice(basePrice float64, condition string, yearBought, currentYear int) float64 {
+	return DefaultPricing.Estimate(basePrice, condition, yearBought, currentYear)
+}
+
+// 计算设备预估回收价格，currentYear为计算时的年份
+func (p PricingRules) Estimate(basePrice float64, condition string, yearBought, currentYear int) float64 {
+	depreciationRate := p.AnnualDepreciation * float64(currentYear-yearBought)
+	if depreciationRate > p.MaxDepreciation {
+		depreciationRate = p.MaxDepreciation
+	}
+
+	multiplier, exists := conditionMultiplier[condition]
+	if !exists {
+		multiplier = 0.5
+	}
+
+	finalPrice := basePrice * (1 - depreciationRate) * multiplier
+
+	// 确保价格不低于基础价格的一定比例
+	minPrice := basePrice * p.MinPriceRatio
+	if finalPrice < minPrice {
+		finalPrice = minPrice
+	}
+
+	return finalPrice
+}
+
+// 根据评估师打分计算综合评分和最终价格，评分为1-10分
+func EvaluatePrice(appearance, function, performance int, marketPrice, depreciationRate float64) (overallScore, finalPrice float64) {
+	overallScore = float64(appearance+function+performance) / 3.0
+	finalPrice = marketPrice * (1 - depreciationRate) * (overallScore / 10.0)
+	return overallScore, finalPrice
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"math"
+	"testing"
+)
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+func TestEstimateDevicePrice(t *testing.T) {
+	tests := []struct {
+		name      string
+		basePrice float64
+		condition string
+		year      int
+		want      float64
+	}{
+		{"当年购买成色极好", 10000, "excellent", 2024, 10000},
+		{"两年成色良好", 10000, "good", 2022, 10000 * 0.8 * 0.8},
+		{"三年成色一般", 10000, "fair", 2021, 10000 * 0.7 * 0.6},
+		{"折旧封顶80%", 10000, "excellent", 2010, 10000 * 0.2},
+		{"不低于基础价格10%", 10000, "poor", 2010, 1000},
+		{"未知成色按0.5计算", 10000, "unknown", 2024, 5000},
+		{"基础价格为0", 0, "excellent", 2020, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := EstimateDevicePrice(tt.basePrice, tt.condition, tt.year, 2024)
+			if !almostEqual(got, tt.want) {
+				t.Errorf("EstimateDevicePrice() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluatePrice(t *testing.T) {
+	tests := []struct {
+		name                              string
+		appearance, function, performance int
+		marketPrice, depreciationRate     float64
+		wantScore, wantPrice              float64
+	}{
+		{"满分无折旧", 10, 10, 10, 8000, 0, 10, 8000},
+		{"平均分与折旧", 8, 9, 7, 8000, 0.25, 8, 8000 * 0.75 * 0.8},
+		{"最低分", 1, 1, 1, 5000, 0.5, 1, 5000 * 0.5 * 0.1},
+		{"全部折旧", 10, 10, 10, 5000, 1, 10, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, price := EvaluatePrice(tt.appearance, tt.function, tt.performance, tt.marketPrice, tt.depreciationRate)
+			if !almostEqual(score, tt.wantScore) {
+				t.Errorf("overallScore = %v, want %v", score, tt.wantScore)
+			}
+			if !almostEqual(price, tt.wantPrice) {
+				t.Errorf("finalPrice = %v, want %v", price, tt.wantPrice)
+			}
+		})
+	}
+}
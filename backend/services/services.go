@@ -0,0 +1,102 @@
+package services
+
+import (
+	"e-device-recycle-backend/repositories"
+	"e-device-recycle-backend/search"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// 业务错误，控制器根据错误类型返回对应的HTTP状态码，错误信息可直接返回给前端
+var (
+	ErrUserNotFound           = errors.New("用户不存在")
+	ErrUsernameTaken          = errors.New("用户名已存在")
+	ErrPhoneTaken             = errors.New("手机号已存在")
+	ErrInvalidPassword        = errors.New("密码错误")
+	ErrUserDisabled           = errors.New("账户已被禁用")
+	ErrResetTokenInvalid      = errors.New("重置令牌无效或已过期")
+	ErrResetCodeInvalid       = errors.New("验证码无效或已过期")
+	ErrResetTokenRequired     = errors.New("请提供重置令牌或手机验证码")
+	ErrResetContactRequired   = errors.New("请提供邮箱或手机号")
+	ErrDeviceNotFound         = errors.New("设备不存在")
+	ErrBrandNotFound          = errors.New("品牌不存在")
+	ErrBrandExists            = errors.New("品牌名称或别名已被其他品牌使用")
+	ErrBrandInUse             = errors.New("品牌下还有系列、型号或设备，不能删除")
+	ErrSeriesNotFound         = errors.New("系列不存在")
+	ErrSeriesExists           = errors.New("该品牌下已有同名系列")
+	ErrSeriesInUse            = errors.New("系列下还有型号，不能删除或移到其他品牌")
+	ErrSeriesBrandMismatch    = errors.New("系列不属于该品牌")
+	ErrModelNotFound          = errors.New("型号不存在")
+	ErrModelExists            = errors.New("型号名称或别名已被该品牌的其他型号使用")
+	ErrModelInUse             = errors.New("还有设备使用该型号，不能删除")
+	ErrCategoryNotFound       = errors.New("分类不存在")
+	ErrParentCategoryNotFound = errors.New("上级分类不存在")
+	ErrCategoryExists         = errors.New("分类代码已存在")
+	ErrCategoryInUse          = errors.New("分类下还有子分类或设备，不能删除或修改代码")
+	ErrCategoryParentInvalid  = errors.New("上级分类不能是该分类本身或其子分类")
+	ErrOrderNotFound          = errors.New("订单不存在")
+	ErrOrderNotCancellable    = errors.New("订单状态不允许取消")
+	ErrEvaluationNotFound     = errors.New("评估不存在")
+	ErrEvaluationExists       = errors.New("该订单已有评估记录")
+	ErrIdentityTaken          = errors.New("该账号已绑定其他用户")
+	ErrIdentityProviderBound  = errors.New("已绑定该平台的其他账号")
+	ErrIdentityNotBound       = errors.New("未绑定该平台账号")
+	ErrIdentityLastLogin      = errors.New("这是账号唯一的登录方式")
+	ErrTwoFactorEnabled       = errors.New("已开启两步验证")
+	ErrTwoFactorNotEnabled    = errors.New("未开启两步验证")
+	ErrTwoFactorSetupRequired = errors.New("请先生成两步验证密钥")
+	ErrTwoFactorMandatory     = errors.New("该账号必须开启两步验证")
+	ErrTwoFactorCodeInvalid   = errors.New("验证码错误")
+	ErrTwoFactorCodeRequired  = errors.New("请输入验证码或恢复码")
+	ErrRecoveryCodeInvalid    = errors.New("恢复码无效")
+)
+
+// 请求中的字段不符合目录中的定义，Rule和Param的含义与binding标签相同
+type FieldError struct {
+	Field string
+	Rule  string
+	Param string
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("字段%s不符合规则%s", e.Field, e.Rule)
+}
+
+// 发起操作的用户
+type Actor struct {
+	UserID uint
+	Role   string
+}
+
+func (a Actor) IsAdmin() bool {
+	return a.Role == "admin"
+}
+
+// 业务服务集合，由main组装后注入控制器
+type Services struct {
+	Users       UserService
+	Devices     DeviceService
+	Catalog     CatalogService
+	Categories  CategoryService
+	Orders      OrderService
+	Evaluations EvaluationService
+	Identities  IdentityService
+	TwoFactor   TwoFactorService
+	AuditLogs   AuditLogService
+}
+
+func New(repos repositories.Repositories, index search.Index) *Services {
+	devices := NewDeviceService(repos, index)
+	return &Services{
+		Users:       NewUserService(repos),
+		Devices:     devices,
+		Catalog:     NewCatalogService(repos, devices),
+		Categories:  NewCategoryService(repos, devices),
+		Orders:      NewOrderService(repos, time.Now),
+		Evaluations: NewEvaluationService(repos),
+		Identities:  NewIdentityService(repos),
+		TwoFactor:   NewTwoFactorService(repos, time.Now),
+		AuditLogs:   NewAuditLogService(repos),
+	}
+}
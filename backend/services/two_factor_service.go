@@ -0,0 +1,206 @@
+package services
+
+import (
+	"e-device-recycle-backend/config"
+	"e-device-recycle-backend/models"
+	"e-device-recycle-backend/repositories"
+	"e-device-recycle-backend/utils"
+	"errors"
+	"time"
+)
+
+// 每次生成的恢复码数量
+const recoveryCodeCount = 10
+
+type TwoFactorService interface {
+	// 生成两步验证密钥，确认开启前不生效
+	Setup(userID uint) (*models.TwoFactorSetupResponse, error)
+	// 校验验证码后开启两步验证，返回恢复码
+	Enable(userID uint, code string) ([]string, error)
+	// 校验密码和验证码后关闭两步验证，必须开启两步验证的角色不能关闭
+	Disable(userID uint, password, code string) error
+	// 校验验证码后重新生成恢复码，旧恢复码全部失效
+	RegenerateRecoveryCodes(userID uint, code string) ([]string, error)
+	// 登录第二步，校验验证码或恢复码，验证码和恢复码都只能使用一次
+	Verify(userID uint, code, recoveryCode string) error
+}
+
+type twoFactorService struct {
+	repos repositories.Repositories
+	now   func() time.Time
+}
+
+func NewTwoFactorService(repos repositories.Repositories, now func() time.Time) TwoFactorService {
+	return &twoFactorService{repos: repos, now: now}
+}
+
+func (s *twoFactorService) user(id uint) (*models.User, error) {
+	user, err := s.repos.Users().FindByID(id)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
+	return user, err
+}
+
+func (s *twoFactorService) Setup(userID uint) (*models.TwoFactorSetupResponse, error) {
+	user, err := s.user(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, ErrTwoFactorEnabled
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repos.Users().Update(user, map[string]interface{}{"totp_secret": secret}); err != nil {
+		return nil, err
+	}
+
+	return &models.TwoFactorSetupResponse{
+		Secret:     secret,
+		OTPAuthURI: utils.TOTPURI(config.GetConfig().Auth.TwoFactorIssuer, user.Username, secret),
+	}, nil
+}
+
+func (s *twoFactorService) Enable(userID uint, code string) ([]string, error) {
+	user, err := s.user(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, ErrTwoFactorEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrTwoFactorSetupRequired
+	}
+	if err := s.checkCode(user, code); err != nil {
+		return nil, err
+	}
+
+	var codes []string
+	err = s.repos.Transaction(func(repos repositories.Repositories) error {
+		if err := repos.Users().Update(user, map[string]interface{}{"totp_enabled": true}); err != nil {
+			return err
+		}
+		codes, err = s.replaceRecoveryCodes(repos, user.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (s *twoFactorService) Disable(userID uint, password, code string) error {
+	user, err := s.user(userID)
+	if err != nil {
+		return err
+	}
+	if config.GetConfig().TwoFactorRequired(user.Role) {
+		return ErrTwoFactorMandatory
+	}
+	if !user.TOTPEnabled {
+		return ErrTwoFactorNotEnabled
+	}
+	if !utils.CheckPassword(password, user.Password) {
+		return ErrInvalidPassword
+	}
+	if err := s.checkCode(user, code); err != nil {
+		return err
+	}
+
+	return s.repos.Transaction(func(repos repositories.Repositories) error {
+		if err := repos.Users().Update(user, map[string]interface{}{
+			"totp_enabled": false,
+			"totp_secret":  "",
+		}); err != nil {
+			return err
+		}
+		return repos.RecoveryCodes().DeleteAll(user.ID)
+	})
+}
+
+func (s *twoFactorService) RegenerateRecoveryCodes(userID uint, code string) ([]string, error) {
+	user, err := s.user(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TOTPEnabled {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	if err := s.checkCode(user, code); err != nil {
+		return nil, err
+	}
+
+	var codes []string
+	err = s.repos.Transaction(func(repos repositories.Repositories) error {
+		codes, err = s.replaceRecoveryCodes(repos, user.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (s *twoFactorService) Verify(userID uint, code, recoveryCode string) error {
+	user, err := s.user(userID)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case code != "":
+		return s.checkCode(user, code)
+	case recoveryCode != "":
+		hash := utils.HashToken(utils.NormalizeRecoveryCode(recoveryCode))
+		used, err := s.repos.RecoveryCodes().Use(user.ID, hash, s.now())
+		if err != nil {
+			return err
+		}
+		if !used {
+			return ErrRecoveryCodeInvalid
+		}
+		return nil
+	default:
+		return ErrTwoFactorCodeRequired
+	}
+}
+
+// 校验TOTP验证码并记录时间步，同一验证码不能重复使用
+func (s *twoFactorService) checkCode(user *models.User, code string) error {
+	step, ok := utils.ValidateTOTP(user.TOTPSecret, code, s.now())
+	if !ok || step <= user.TOTPLastStep {
+		return ErrTwoFactorCodeInvalid
+	}
+
+	advanced, err := s.repos.Users().AdvanceTOTPStep(user.ID, step)
+	if err != nil {
+		return err
+	}
+	if !advanced {
+		return ErrTwoFactorCodeInvalid
+	}
+
+	user.TOTPLastStep = step
+	return nil
+}
+
+func (s *twoFactorService) replaceRecoveryCodes(repos repositories.Repositories, userID uint) ([]string, error) {
+	codes, err := utils.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = utils.HashToken(code)
+	}
+	if err := repos.RecoveryCodes().Replace(userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
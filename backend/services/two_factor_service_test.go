@@ -0,0 +1,125 @@
+package services
+
+import (
+	"e-device-recycle-backend/config"
+	"e-device-recycle-backend/models"
+	"e-device-recycle-backend/utils"
+	"errors"
+	"testing"
+	"time"
+)
+
+// 准备用户和可调整的时钟，管理员必须开启两步验证
+func newTwoFactorFixture(t *testing.T) (*fakeRepositories, TwoFactorService, *time.Time) {
+	t.Helper()
+	err := config.Init("", func(cfg *config.Config) {
+		cfg.Env = config.EnvDevelopment
+		cfg.Auth.TwoFactorRequiredRoles = []string{"admin"}
+	})
+	if err != nil {
+		t.Fatalf("加载配置失败: %v", err)
+	}
+
+	repos := newFakeRepositories()
+	hashed, _ := utils.HashPassword("secret123")
+	repos.users[customer.UserID] = &models.User{ID: customer.UserID, Username: "alice", Password: hashed, Role: "user", Status: "active"}
+	repos.users[admin.UserID] = &models.User{ID: admin.UserID, Username: "root", Password: hashed, Role: "admin", Status: "active"}
+
+	now := fixedNow()
+	return repos, NewTwoFactorService(repos, func() time.Time { return now }), &now
+}
+
+func totpCode(t *testing.T, secret string, at time.Time) string {
+	t.Helper()
+	code, err := utils.GenerateTOTP(secret, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func TestTwoFactorEnableAndVerify(t *testing.T) {
+	repos, svc, now := newTwoFactorFixture(t)
+
+	if _, err := svc.Enable(customer.UserID, "000000"); !errors.Is(err, ErrTwoFactorSetupRequired) {
+		t.Errorf("未生成密钥: err = %v, want ErrTwoFactorSetupRequired", err)
+	}
+	setup, err := svc.Setup(customer.UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Enable(customer.UserID, "000000"); !errors.Is(err, ErrTwoFactorCodeInvalid) {
+		t.Errorf("错误验证码: err = %v, want ErrTwoFactorCodeInvalid", err)
+	}
+	codes, err := svc.Enable(customer.UserID, totpCode(t, setup.Secret, *now))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != recoveryCodeCount || len(repos.recovery) != recoveryCodeCount {
+		t.Errorf("恢复码 %d 个，保存 %d 个，want %d", len(codes), len(repos.recovery), recoveryCodeCount)
+	}
+	if _, err := svc.Setup(customer.UserID); !errors.Is(err, ErrTwoFactorEnabled) {
+		t.Errorf("重复开启: err = %v, want ErrTwoFactorEnabled", err)
+	}
+
+	// 开启时使用的验证码不能再用于登录，下一个时间步的验证码可以
+	if err := svc.Verify(customer.UserID, totpCode(t, setup.Secret, *now), ""); !errors.Is(err, ErrTwoFactorCodeInvalid) {
+		t.Errorf("重放验证码: err = %v, want ErrTwoFactorCodeInvalid", err)
+	}
+	*now = now.Add(30 * time.Second)
+	if err := svc.Verify(customer.UserID, totpCode(t, setup.Secret, *now), ""); err != nil {
+		t.Errorf("新验证码: err = %v", err)
+	}
+
+	// 恢复码只能使用一次
+	if err := svc.Verify(customer.UserID, "", codes[0]); err != nil {
+		t.Errorf("恢复码: err = %v", err)
+	}
+	if err := svc.Verify(customer.UserID, "", codes[0]); !errors.Is(err, ErrRecoveryCodeInvalid) {
+		t.Errorf("重复使用恢复码: err = %v, want ErrRecoveryCodeInvalid", err)
+	}
+	if err := svc.Verify(customer.UserID, "", ""); !errors.Is(err, ErrTwoFactorCodeRequired) {
+		t.Errorf("未填写: err = %v, want ErrTwoFactorCodeRequired", err)
+	}
+
+	// 重新生成后旧恢复码失效
+	*now = now.Add(30 * time.Second)
+	regenerated, err := svc.RegenerateRecoveryCodes(customer.UserID, totpCode(t, setup.Secret, *now))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Verify(customer.UserID, "", codes[1]); !errors.Is(err, ErrRecoveryCodeInvalid) {
+		t.Errorf("旧恢复码: err = %v, want ErrRecoveryCodeInvalid", err)
+	}
+	if err := svc.Verify(customer.UserID, "", regenerated[1]); err != nil {
+		t.Errorf("新恢复码: err = %v", err)
+	}
+}
+
+func TestTwoFactorDisable(t *testing.T) {
+	repos, svc, now := newTwoFactorFixture(t)
+
+	if err := svc.Disable(customer.UserID, "secret123", "000000"); !errors.Is(err, ErrTwoFactorNotEnabled) {
+		t.Errorf("未开启: err = %v, want ErrTwoFactorNotEnabled", err)
+	}
+	setup, _ := svc.Setup(customer.UserID)
+	if _, err := svc.Enable(customer.UserID, totpCode(t, setup.Secret, *now)); err != nil {
+		t.Fatal(err)
+	}
+
+	*now = now.Add(30 * time.Second)
+	if err := svc.Disable(customer.UserID, "wrong", totpCode(t, setup.Secret, *now)); !errors.Is(err, ErrInvalidPassword) {
+		t.Errorf("密码错误: err = %v, want ErrInvalidPassword", err)
+	}
+	if err := svc.Disable(customer.UserID, "secret123", totpCode(t, setup.Secret, *now)); err != nil {
+		t.Fatal(err)
+	}
+	if user := repos.users[customer.UserID]; user.TOTPEnabled || user.TOTPSecret != "" || len(repos.recovery) != 0 {
+		t.Errorf("关闭后 enabled = %v, secret = %q, 恢复码 %d 个", user.TOTPEnabled, user.TOTPSecret, len(repos.recovery))
+	}
+
+	// 必须开启两步验证的角色不能关闭
+	if err := svc.Disable(admin.UserID, "secret123", "000000"); !errors.Is(err, ErrTwoFactorMandatory) {
+		t.Errorf("管理员关闭: err = %v, want ErrTwoFactorMandatory", err)
+	}
+}
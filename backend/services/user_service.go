@@ -0,0 +1,292 @@
+package services
+
+import (
+	"e-device-recycle-backend/config"
+	"e-device-recycle-backend/listquery"
+	"e-device-recycle-backend/models"
+	"e-device-recycle-backend/repositories"
+	"e-device-recycle-backend/utils"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// 短信验证码允许的错误次数，按账户在resetCodeAttemptWindow内累计，重新获取验证码不会重置
+const (
+	resetCodeMaxAttempts   = 5
+	resetCodeAttemptWindow = time.Hour
+)
+
+type UserService interface {
+	Register(req models.UserRegisterRequest) (*models.User, error)
+	// 校验用户名和密码。密码错误或账户被禁用时同时返回用户，便于记录失败原因
+	Authenticate(username, password string) (*models.User, error)
+	Get(id uint) (*models.User, error)
+	// 更新个人资料，只允许修改手机号、邮箱、姓名和头像
+	UpdateProfile(id uint, updates map[string]interface{}) (*models.User, error)
+	// 校验原密码后修改密码，其他设备上的登录状态将失效
+	ChangePassword(id uint, oldPassword, newPassword string) (*models.User, error)
+	// 发送重置密码的邮件链接或短信验证码，账户不存在时不返回错误
+	RequestPasswordReset(req models.ForgotPasswordRequest) error
+	// 使用令牌或验证码重置密码，重置后所有登录状态失效
+	ResetPassword(req models.ResetPasswordRequest) error
+	// 记录登录失败或被锁定的尝试
+	RecordLoginAttempt(attempt *models.LoginAttempt) error
+	ListLoginAttempts(filter repositories.LoginAttemptFilter, params listquery.Params) ([]models.LoginAttempt, int64, error)
+}
+
+type userService struct {
+	repos repositories.Repositories
+}
+
+func NewUserService(repos repositories.Repositories) UserService {
+	return &userService{repos: repos}
+}
+
+func (s *userService) Register(req models.UserRegisterRequest) (*models.User, error) {
+	// 检查用户名是否已存在
+	if _, err := s.repos.Users().FindByUsername(req.Username); err == nil {
+		return nil, ErrUsernameTaken
+	} else if !errors.Is(err, repositories.ErrNotFound) {
+		return nil, err
+	}
+
+	// 检查手机号是否已存在
+	if req.Phone != "" {
+		if _, err := s.repos.Users().FindByPhone(req.Phone); err == nil {
+			return nil, ErrPhoneTaken
+		} else if !errors.Is(err, repositories.ErrNotFound) {
+			return nil, err
+		}
+	}
+
+	hashedPassword, err := utils.HashPassword(req.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	user := models.User{
+		Username: req.Username,
+		Password: hashedPassword,
+		Phone:    req.Phone,
+		Email:    req.Email,
+		RealName: req.RealName,
+		Role:     "user",
+		Status:   "active",
+	}
+	if err := s.repos.Users().Create(&user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (s *userService) Authenticate(username, password string) (*models.User, error) {
+	user, err := s.repos.Users().FindByUsername(username)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !utils.CheckPassword(password, user.Password) {
+		return user, ErrInvalidPassword
+	}
+	if user.Status != "active" {
+		return user, ErrUserDisabled
+	}
+	return user, nil
+}
+
+func (s *userService) Get(id uint) (*models.User, error) {
+	user, err := s.repos.Users().FindByID(id)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
+	return user, err
+}
+
+func (s *userService) UpdateProfile(id uint, updates map[string]interface{}) (*models.User, error) {
+	user, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// 过滤可更新的字段
+	allowedFields := []string{"phone", "email", "real_name", "avatar"}
+	filteredUpdates := make(map[string]interface{})
+	for _, field := range allowedFields {
+		if value, exists := updates[field]; exists {
+			filteredUpdates[field] = value
+		}
+	}
+	if len(filteredUpdates) == 0 {
+		return user, nil
+	}
+
+	if err := s.repos.Users().Update(user, filteredUpdates); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *userService) ChangePassword(id uint, oldPassword, newPassword string) (*models.User, error) {
+	user, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !utils.CheckPassword(oldPassword, user.Password) {
+		return nil, ErrInvalidPassword
+	}
+
+	if err := s.setPassword(user, newPassword); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *userService) RequestPasswordReset(req models.ForgotPasswordRequest) error {
+	if (req.Channel == "email" && req.Email == "") || (req.Channel == "sms" && req.Phone == "") {
+		return ErrResetContactRequired
+	}
+
+	var user *models.User
+	var err error
+	if req.Channel == "email" {
+		user, err = s.repos.Users().FindActiveByEmail(req.Email)
+	} else {
+		user, err = s.repos.Users().FindActiveByPhone(req.Phone)
+	}
+	if err != nil {
+		// 账户不存在时不返回错误，避免泄露注册信息
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	// 生成令牌：邮件使用长令牌，短信使用6位验证码
+	var token string
+	var ttl time.Duration
+	if req.Channel == "email" {
+		token, err = utils.GenerateRandomToken(32)
+		ttl = 30 * time.Minute
+	} else {
+		token, err = utils.GenerateNumericCode(6)
+		ttl = 10 * time.Minute
+	}
+	if err != nil {
+		return err
+	}
+
+	// 使之前未使用的令牌失效
+	now := time.Now()
+	if err := s.repos.PasswordResets().InvalidateAll(user.ID, now); err != nil {
+		return err
+	}
+
+	if err := s.repos.PasswordResets().Create(&models.PasswordResetToken{
+		UserID:    user.ID,
+		Channel:   req.Channel,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: now.Add(ttl),
+	}); err != nil {
+		return err
+	}
+
+	notifier := utils.GetNotifier()
+	if req.Channel == "email" {
+		link := fmt.Sprintf("%s?token=%s", config.GetConfig().Auth.PasswordResetURL, token)
+		return notifier.SendEmail(user.Email, "重置密码", fmt.Sprintf("请在30分钟内点击链接重置密码：%s", link))
+	}
+	return notifier.SendSMS(user.Phone, fmt.Sprintf("您的重置密码验证码为%s，10分钟内有效。", token))
+}
+
+func (s *userService) ResetPassword(req models.ResetPasswordRequest) error {
+	resets := s.repos.PasswordResets()
+
+	var resetToken *models.PasswordResetToken
+	var err error
+	switch {
+	case req.Token != "":
+		resetToken, err = resets.FindByHash(utils.HashToken(req.Token), "email")
+		if errors.Is(err, repositories.ErrNotFound) {
+			return ErrResetTokenInvalid
+		}
+		if err != nil {
+			return err
+		}
+	case req.Phone != "" && req.Code != "":
+		user, err := s.repos.Users().FindActiveByPhone(req.Phone)
+		if errors.Is(err, repositories.ErrNotFound) {
+			return ErrResetCodeInvalid
+		}
+		if err != nil {
+			return err
+		}
+		// 错误次数用完后，在时间窗口内即使重新获取验证码也不再校验
+		failures, err := resets.CountFailures(user.ID, "sms", time.Now().Add(-resetCodeAttemptWindow))
+		if err != nil {
+			return err
+		}
+		if failures >= resetCodeMaxAttempts {
+			return ErrResetCodeInvalid
+		}
+		resetToken, err = resets.FindLatestUnused(user.ID, "sms")
+		if errors.Is(err, repositories.ErrNotFound) {
+			return ErrResetCodeInvalid
+		}
+		if err != nil {
+			return err
+		}
+		if resetToken.TokenHash != utils.HashToken(req.Code) {
+			// 验证码错误次数过多则作废
+			if err := resets.RecordFailure(resetToken, resetCodeMaxAttempts); err != nil {
+				return err
+			}
+			return ErrResetCodeInvalid
+		}
+	default:
+		return ErrResetTokenRequired
+	}
+
+	if resetToken.UsedAt != nil || time.Now().After(resetToken.ExpiresAt) {
+		return ErrResetTokenInvalid
+	}
+
+	// 令牌签发后被禁用的账户不能重置密码
+	user, err := s.Get(resetToken.UserID)
+	if err != nil {
+		return err
+	}
+	if user.Status != "active" {
+		return ErrResetTokenInvalid
+	}
+
+	used, err := resets.MarkUsed(resetToken.ID, time.Now())
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrResetTokenInvalid
+	}
+	return s.setPassword(user, req.NewPassword)
+}
+
+func (s *userService) RecordLoginAttempt(attempt *models.LoginAttempt) error {
+	return s.repos.LoginAttempts().Create(attempt)
+}
+
+func (s *userService) ListLoginAttempts(filter repositories.LoginAttemptFilter, params listquery.Params) ([]models.LoginAttempt, int64, error) {
+	return s.repos.LoginAttempts().List(filter, params)
+}
+
+// 更新密码并递增令牌版本
+func (s *userService) setPassword(user *models.User, password string) error {
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	return s.repos.Users().UpdatePassword(user, hashedPassword)
+}
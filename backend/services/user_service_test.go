@@ -0,0 +1,280 @@
+package services
+
+import (
+	"e-device-recycle-backend/models"
+	"e-device-recycle-backend/utils"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func newUserFixture(t *testing.T) (*fakeRepositories, UserService, *models.User) {
+	t.Helper()
+	repos := newFakeRepositories()
+	svc := NewUserService(repos)
+
+	user, err := svc.Register(models.UserRegisterRequest{
+		Username: "alice",
+		Password: "secret123",
+		Phone:    "13800000001",
+		Email:    "alice@example.com",
+	})
+	if err != nil {
+		t.Fatalf("注册失败: %v", err)
+	}
+	return repos, svc, user
+}
+
+func TestRegister(t *testing.T) {
+	_, svc, user := newUserFixture(t)
+
+	if user.Role != "user" || user.Status != "active" {
+		t.Errorf("role = %q, status = %q, want user/active", user.Role, user.Status)
+	}
+	if user.Password == "secret123" || !utils.CheckPassword("secret123", user.Password) {
+		t.Error("密码未正确加密")
+	}
+
+	_, err := svc.Register(models.UserRegisterRequest{Username: "alice", Password: "secret123", Phone: "13800000002"})
+	if !errors.Is(err, ErrUsernameTaken) {
+		t.Errorf("重复用户名: err = %v, want ErrUsernameTaken", err)
+	}
+	_, err = svc.Register(models.UserRegisterRequest{Username: "bob", Password: "secret123", Phone: "13800000001"})
+	if !errors.Is(err, ErrPhoneTaken) {
+		t.Errorf("重复手机号: err = %v, want ErrPhoneTaken", err)
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	repos, svc, user := newUserFixture(t)
+
+	if _, err := svc.Authenticate("alice", "secret123"); err != nil {
+		t.Errorf("正确密码: err = %v", err)
+	}
+	if _, err := svc.Authenticate("nobody", "secret123"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("用户不存在: err = %v, want ErrUserNotFound", err)
+	}
+
+	// 密码错误时返回用户，便于记录失败原因
+	found, err := svc.Authenticate("alice", "wrong")
+	if !errors.Is(err, ErrInvalidPassword) {
+		t.Errorf("密码错误: err = %v, want ErrInvalidPassword", err)
+	}
+	if found == nil || found.ID != user.ID {
+		t.Error("密码错误时应返回用户")
+	}
+
+	repos.users[user.ID].Status = "banned"
+	if _, err := svc.Authenticate("alice", "secret123"); !errors.Is(err, ErrUserDisabled) {
+		t.Errorf("禁用账户: err = %v, want ErrUserDisabled", err)
+	}
+}
+
+func TestUpdateProfileFiltersFields(t *testing.T) {
+	_, svc, user := newUserFixture(t)
+
+	updated, err := svc.UpdateProfile(user.ID, map[string]interface{}{
+		"real_name": "Alice",
+		"role":      "admin",
+		"status":    "active",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.RealName != "Alice" {
+		t.Errorf("RealName = %q, want Alice", updated.RealName)
+	}
+	if updated.Role != "user" {
+		t.Errorf("不允许修改角色，Role = %q", updated.Role)
+	}
+}
+
+func TestChangePassword(t *testing.T) {
+	_, svc, user := newUserFixture(t)
+
+	if _, err := svc.ChangePassword(user.ID, "wrong", "newsecret"); !errors.Is(err, ErrInvalidPassword) {
+		t.Errorf("原密码错误: err = %v, want ErrInvalidPassword", err)
+	}
+
+	updated, err := svc.ChangePassword(user.ID, "secret123", "newsecret")
+	if err != nil {
+		t.Fatalf("修改密码失败: %v", err)
+	}
+	// 令牌版本递增使旧令牌失效
+	if updated.TokenVersion != user.TokenVersion+1 {
+		t.Errorf("TokenVersion = %d, want %d", updated.TokenVersion, user.TokenVersion+1)
+	}
+	if _, err := svc.Authenticate("alice", "newsecret"); err != nil {
+		t.Errorf("新密码登录失败: %v", err)
+	}
+}
+
+// 记录发送的短信内容
+type recordingNotifier struct {
+	utils.LogNotifier
+	sms []string
+}
+
+func (n *recordingNotifier) SendSMS(phone, content string) error {
+	n.sms = append(n.sms, content)
+	return nil
+}
+
+func TestPasswordResetBySMS(t *testing.T) {
+	repos, svc, user := newUserFixture(t)
+
+	notifier := &recordingNotifier{}
+	previous := utils.GetNotifier()
+	utils.SetNotifier(notifier)
+	defer utils.SetNotifier(previous)
+
+	// 未注册的手机号不返回错误，也不发送短信
+	if err := svc.RequestPasswordReset(models.ForgotPasswordRequest{Channel: "sms", Phone: "13900000000"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(notifier.sms) != 0 {
+		t.Fatal("未注册的手机号不应发送短信")
+	}
+
+	if err := svc.RequestPasswordReset(models.ForgotPasswordRequest{Channel: "sms", Phone: user.Phone}); err != nil {
+		t.Fatal(err)
+	}
+	if len(notifier.sms) != 1 {
+		t.Fatalf("发送短信%d条, want 1", len(notifier.sms))
+	}
+	code := regexp.MustCompile(`\d{6}`).FindString(notifier.sms[0])
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+
+	// 验证码错误
+	err := svc.ResetPassword(models.ResetPasswordRequest{Phone: user.Phone, Code: wrong, NewPassword: "resetpass"})
+	if !errors.Is(err, ErrResetCodeInvalid) {
+		t.Errorf("错误验证码: err = %v, want ErrResetCodeInvalid", err)
+	}
+
+	if err := svc.ResetPassword(models.ResetPasswordRequest{Phone: user.Phone, Code: code, NewPassword: "resetpass"}); err != nil {
+		t.Fatalf("重置密码失败: %v", err)
+	}
+	if repos.users[user.ID].TokenVersion != user.TokenVersion+1 {
+		t.Error("重置密码后令牌版本未递增")
+	}
+	if _, err := svc.Authenticate("alice", "resetpass"); err != nil {
+		t.Errorf("新密码登录失败: %v", err)
+	}
+
+	// 验证码只能使用一次
+	err = svc.ResetPassword(models.ResetPasswordRequest{Phone: user.Phone, Code: code, NewPassword: "another"})
+	if !errors.Is(err, ErrResetCodeInvalid) {
+		t.Errorf("重复使用验证码: err = %v, want ErrResetCodeInvalid", err)
+	}
+}
+
+func TestPasswordResetCodeAttemptsLimited(t *testing.T) {
+	_, svc, user := newUserFixture(t)
+
+	notifier := &recordingNotifier{}
+	previous := utils.GetNotifier()
+	utils.SetNotifier(notifier)
+	defer utils.SetNotifier(previous)
+
+	if err := svc.RequestPasswordReset(models.ForgotPasswordRequest{Channel: "sms", Phone: user.Phone}); err != nil {
+		t.Fatal(err)
+	}
+	code := regexp.MustCompile(`\d{6}`).FindString(notifier.sms[0])
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+
+	for i := 0; i < resetCodeMaxAttempts; i++ {
+		svc.ResetPassword(models.ResetPasswordRequest{Phone: user.Phone, Code: wrong, NewPassword: "resetpass"})
+	}
+
+	// 错误次数达到上限后，正确的验证码也失效
+	err := svc.ResetPassword(models.ResetPasswordRequest{Phone: user.Phone, Code: code, NewPassword: "resetpass"})
+	if !errors.Is(err, ErrResetCodeInvalid) {
+		t.Errorf("err = %v, want ErrResetCodeInvalid", err)
+	}
+}
+
+// 错误次数按账户累计，重新获取验证码不会重置
+func TestPasswordResetCodeAttemptsSurviveNewCode(t *testing.T) {
+	_, svc, user := newUserFixture(t)
+
+	notifier := &recordingNotifier{}
+	previous := utils.GetNotifier()
+	utils.SetNotifier(notifier)
+	defer utils.SetNotifier(previous)
+
+	var code string
+	for i := 0; i < resetCodeMaxAttempts; i++ {
+		if err := svc.RequestPasswordReset(models.ForgotPasswordRequest{Channel: "sms", Phone: user.Phone}); err != nil {
+			t.Fatal(err)
+		}
+		code = regexp.MustCompile(`\d{6}`).FindString(notifier.sms[len(notifier.sms)-1])
+		wrong := "000000"
+		if code == wrong {
+			wrong = "111111"
+		}
+		svc.ResetPassword(models.ResetPasswordRequest{Phone: user.Phone, Code: wrong, NewPassword: "resetpass"})
+	}
+
+	if err := svc.RequestPasswordReset(models.ForgotPasswordRequest{Channel: "sms", Phone: user.Phone}); err != nil {
+		t.Fatal(err)
+	}
+	code = regexp.MustCompile(`\d{6}`).FindString(notifier.sms[len(notifier.sms)-1])
+	err := svc.ResetPassword(models.ResetPasswordRequest{Phone: user.Phone, Code: code, NewPassword: "resetpass"})
+	if !errors.Is(err, ErrResetCodeInvalid) {
+		t.Errorf("err = %v, want ErrResetCodeInvalid", err)
+	}
+}
+
+// 获取验证码或链接后被禁用的账户不能重置密码
+func TestPasswordResetRequiresActiveAccount(t *testing.T) {
+	repos, svc, user := newUserFixture(t)
+
+	notifier := &recordingNotifier{}
+	previous := utils.GetNotifier()
+	utils.SetNotifier(notifier)
+	defer utils.SetNotifier(previous)
+
+	if err := svc.RequestPasswordReset(models.ForgotPasswordRequest{Channel: "sms", Phone: user.Phone}); err != nil {
+		t.Fatal(err)
+	}
+	code := regexp.MustCompile(`\d{6}`).FindString(notifier.sms[0])
+	repos.PasswordResets().Create(&models.PasswordResetToken{
+		UserID:    user.ID,
+		Channel:   "email",
+		TokenHash: utils.HashToken("email-token"),
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	repos.users[user.ID].Status = "banned"
+
+	err := svc.ResetPassword(models.ResetPasswordRequest{Phone: user.Phone, Code: code, NewPassword: "resetpass"})
+	if !errors.Is(err, ErrResetCodeInvalid) {
+		t.Errorf("短信: err = %v, want ErrResetCodeInvalid", err)
+	}
+	err = svc.ResetPassword(models.ResetPasswordRequest{Token: "email-token", NewPassword: "resetpass"})
+	if !errors.Is(err, ErrResetTokenInvalid) {
+		t.Errorf("邮件: err = %v, want ErrResetTokenInvalid", err)
+	}
+	if repos.users[user.ID].TokenVersion != user.TokenVersion {
+		t.Error("被禁用的账户不应重置密码")
+	}
+}
+
+func TestResetPasswordRequiresCredentials(t *testing.T) {
+	_, svc, _ := newUserFixture(t)
+
+	err := svc.ResetPassword(models.ResetPasswordRequest{NewPassword: "resetpass"})
+	if !errors.Is(err, ErrResetTokenRequired) {
+		t.Errorf("err = %v, want ErrResetTokenRequired", err)
+	}
+	err = svc.ResetPassword(models.ResetPasswordRequest{Token: "invalid", NewPassword: "resetpass"})
+	if !errors.Is(err, ErrResetTokenInvalid) {
+		t.Errorf("err = %v, want ErrResetTokenInvalid", err)
+	}
+}
@@ -0,0 +1,193 @@
+// Package specs 从设备的规格文本中识别结构化规格：
+//
+//	memory  "16GB DDR5"、"16G"          -> memory_gb=16
+//	storage "512GB SSD + 1TB HDD"       -> storage_gb=1536, storage_type=ssd
+//	cpu     "Intel i7-12700H"           -> cpu_family=intel-core-i7, cpu_generation=12
+//	screen  "14英寸 2.8K"、`15.6"`       -> screen_inches=14
+//
+// 无法识别的项为空，不报错。
+package specs
+
+import (
+	"e-device-recycle-backend/models"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	capacityPattern = regexp.MustCompile(`(?i)(\d+(?:\.\d+)?)\s*([tg])(?:i?b)?\b`)
+	screenPattern   = regexp.MustCompile(`(?i)(\d+(?:\.\d+)?)\s*(?:英寸|寸|inch(?:es)?\b|in\b|"|”|'')`)
+
+	intelUltraPattern = regexp.MustCompile(`(?i)core\s*ultra\s*([579])\s*(\d)\d{2}`)
+	intelCorePattern  = regexp.MustCompile(`(?i)\bi([3579])[\s-]*(\d{3,5})`)
+	ryzenPattern      = regexp.MustCompile(`(?i)ryzen\s*([3579])\s*(?:pro\s*)?(\d)\d{3}`)
+	appleMPattern     = regexp.MustCompile(`(?i)\bm([1-9])\b`)
+	appleAPattern     = regexp.MustCompile(`(?i)\ba(\d{2})\b`)
+	snapdragonPattern = regexp.MustCompile(`(?i)(?:snapdragon|骁龙)\s*8\s*\+?\s*gen\s*(\d)`)
+)
+
+// 识别各项规格文本
+func Parse(cpu, memory, storage, screen string) models.DeviceSpecs {
+	var s models.DeviceSpecs
+	s.MemoryGB = ParseMemory(memory)
+	s.StorageGB, s.StorageType = ParseStorage(storage)
+	s.CPUFamily, s.CPUGeneration = ParseCPU(cpu)
+	s.ScreenInches = ParseScreen(screen)
+	return s
+}
+
+// 内存容量（GB），取第一个容量
+func ParseMemory(text string) *int {
+	capacities := parseCapacities(text)
+	if len(capacities) == 0 {
+		return nil
+	}
+	return &capacities[0]
+}
+
+// 存储总容量（GB）和类型，多块硬盘时容量相加，类型以固态硬盘优先
+func ParseStorage(text string) (*int, string) {
+	capacities := parseCapacities(text)
+	if len(capacities) == 0 {
+		return nil, ""
+	}
+	total := 0
+	for _, capacity := range capacities {
+		total += capacity
+	}
+
+	lower := strings.ToLower(text)
+	storageType := ""
+	switch {
+	case strings.Contains(lower, "ssd") || strings.Contains(lower, "nvme") || strings.Contains(lower, "固态"):
+		storageType = "ssd"
+	case strings.Contains(lower, "hdd") || strings.Contains(lower, "机械"):
+		storageType = "hdd"
+	case strings.Contains(lower, "emmc"):
+		storageType = "emmc"
+	}
+	return &total, storageType
+}
+
+// 文本中的容量，TB按1024GB换算
+func parseCapacities(text string) []int {
+	var capacities []int
+	for _, match := range capacityPattern.FindAllStringSubmatch(text, -1) {
+		n, err := strconv.ParseFloat(match[1], 64)
+		if err != nil || n <= 0 {
+			continue
+		}
+		if strings.EqualFold(match[2], "t") {
+			n *= 1024
+		}
+		capacities = append(capacities, int(n))
+	}
+	return capacities
+}
+
+// 处理器系列和代数，无法识别系列时都为空
+func ParseCPU(text string) (string, *int) {
+	if m := intelUltraPattern.FindStringSubmatch(text); m != nil {
+		return "intel-core-ultra-" + m[1], atoi(m[2])
+	}
+	if m := intelCorePattern.FindStringSubmatch(text); m != nil {
+		return "intel-core-i" + m[1], intelGeneration(m[2])
+	}
+	if m := ryzenPattern.FindStringSubmatch(text); m != nil {
+		return "amd-ryzen-" + m[1], atoi(m[2])
+	}
+	// 避免把酷睿m3、AMD A10识别为苹果芯片
+	lower := strings.ToLower(text)
+	if !strings.Contains(lower, "intel") && !strings.Contains(lower, "amd") {
+		if m := appleMPattern.FindStringSubmatch(text); m != nil {
+			return "apple-m", atoi(m[1])
+		}
+		if m := appleAPattern.FindStringSubmatch(text); m != nil {
+			return "apple-a", atoi(m[1])
+		}
+	}
+	if m := snapdragonPattern.FindStringSubmatch(text); m != nil {
+		return "qualcomm-snapdragon-8", atoi(m[1])
+	}
+	return "", nil
+}
+
+// 酷睿型号数字中的代数：920为1代，8565为8代，1165为11代，12700为12代
+func intelGeneration(number string) *int {
+	switch {
+	case len(number) == 3:
+		return atoi("1")
+	case len(number) == 4 && number[0] != '1':
+		return atoi(number[:1])
+	default:
+		return atoi(number[:2])
+	}
+}
+
+// 屏幕尺寸（英寸）
+func ParseScreen(text string) *float64 {
+	m := screenPattern.FindStringSubmatch(text)
+	if m == nil {
+		return nil
+	}
+	n, err := strconv.ParseFloat(m[1], 64)
+	if err != nil || n < 1 || n > 100 {
+		return nil
+	}
+	return &n
+}
+
+func atoi(s string) *int {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return nil
+	}
+	return &n
+}
+
+// 用override中填写的项覆盖parsed
+func Merge(parsed, override models.DeviceSpecs) models.DeviceSpecs {
+	if override.MemoryGB != nil {
+		parsed.MemoryGB = override.MemoryGB
+	}
+	if override.StorageGB != nil {
+		parsed.StorageGB = override.StorageGB
+	}
+	if override.StorageType != "" {
+		parsed.StorageType = override.StorageType
+	}
+	if override.CPUFamily != "" {
+		parsed.CPUFamily = override.CPUFamily
+	}
+	if override.CPUGeneration != nil {
+		parsed.CPUGeneration = override.CPUGeneration
+	}
+	if override.ScreenInches != nil {
+		parsed.ScreenInches = override.ScreenInches
+	}
+	return parsed
+}
+
+// 规格字段（cpu、memory、storage、screen）对应的数值：处理器代数、容量或尺寸
+func Value(s models.DeviceSpecs, key string) (float64, bool) {
+	switch key {
+	case "cpu":
+		if s.CPUGeneration != nil {
+			return float64(*s.CPUGeneration), true
+		}
+	case "memory":
+		if s.MemoryGB != nil {
+			return float64(*s.MemoryGB), true
+		}
+	case "storage":
+		if s.StorageGB != nil {
+			return float64(*s.StorageGB), true
+		}
+	case "screen":
+		if s.ScreenInches != nil {
+			return *s.ScreenInches, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,114 @@
+package specs
+
+import (
+	"e-device-recycle-backend/models"
+	"testing"
+)
+
+func intValue(p *int) int {
+	if p == nil {
+		return -1
+	}
+	return *p
+}
+
+func TestParseCapacities(t *testing.T) {
+	for _, tc := range []struct {
+		memory string
+		want   int
+	}{
+		{"16GB", 16},
+		{"16G DDR5 5600MHz", 16},
+		{"32 GB", 32},
+		{"8GB+8GB", 8},
+		{"16GB内存", 16},
+		{"LPDDR5", -1},
+		{"", -1},
+	} {
+		if got := intValue(ParseMemory(tc.memory)); got != tc.want {
+			t.Errorf("ParseMemory(%q) = %d, want %d", tc.memory, got, tc.want)
+		}
+	}
+
+	for _, tc := range []struct {
+		storage  string
+		want     int
+		wantType string
+	}{
+		{"512GB SSD", 512, "ssd"},
+		{"1TB NVMe", 1024, "ssd"},
+		{"512GB SSD + 1TB HDD", 1536, "ssd"},
+		{"2T机械硬盘", 2048, "hdd"},
+		{"256GB", 256, ""},
+		{"64GB eMMC", 64, "emmc"},
+		{"固态硬盘", -1, ""},
+	} {
+		got, storageType := ParseStorage(tc.storage)
+		if intValue(got) != tc.want || storageType != tc.wantType {
+			t.Errorf("ParseStorage(%q) = %d %q, want %d %q", tc.storage, intValue(got), storageType, tc.want, tc.wantType)
+		}
+	}
+}
+
+func TestParseCPU(t *testing.T) {
+	for _, tc := range []struct {
+		cpu        string
+		family     string
+		generation int
+	}{
+		{"Intel i7-12700H", "intel-core-i7", 12},
+		{"Intel Core i5-8265U", "intel-core-i5", 8},
+		{"i7 1165G7", "intel-core-i7", 11},
+		{"Intel Core i7-10750H", "intel-core-i7", 10},
+		{"Intel Core Ultra 7 155H", "intel-core-ultra-7", 1},
+		{"AMD Ryzen 7 5800H", "amd-ryzen-7", 5},
+		{"AMD Ryzen 9 PRO 7940HS", "amd-ryzen-9", 7},
+		{"Apple M2 Pro", "apple-m", 2},
+		{"A15 Bionic", "apple-a", 15},
+		{"骁龙8 Gen 2", "qualcomm-snapdragon-8", 2},
+		{"Intel Core m3-8100Y", "", -1},
+		{"麒麟9000S", "", -1},
+	} {
+		family, generation := ParseCPU(tc.cpu)
+		if family != tc.family || intValue(generation) != tc.generation {
+			t.Errorf("ParseCPU(%q) = %q %d, want %q %d", tc.cpu, family, intValue(generation), tc.family, tc.generation)
+		}
+	}
+}
+
+func TestParseScreen(t *testing.T) {
+	for _, tc := range []struct {
+		screen string
+		want   float64
+	}{
+		{"14英寸 2.8K OLED", 14},
+		{`15.6" FHD`, 15.6},
+		{"13.3 inch", 13.3},
+		{"6.1寸", 6.1},
+		{"2560x1600", 0},
+	} {
+		got := ParseScreen(tc.screen)
+		if (got == nil) != (tc.want == 0) || got != nil && *got != tc.want {
+			t.Errorf("ParseScreen(%q) = %v, want %v", tc.screen, got, tc.want)
+		}
+	}
+}
+
+func TestMergeAndValue(t *testing.T) {
+	memory := 32
+	parsed := Parse("Intel i7-12700H", "16GB", "512GB SSD", "14英寸")
+	merged := Merge(parsed, models.DeviceSpecs{MemoryGB: &memory, StorageType: "hdd"})
+
+	if v, ok := Value(merged, "memory"); !ok || v != 32 {
+		t.Errorf("memory = %v %v, want 32", v, ok)
+	}
+	if v, ok := Value(merged, "storage"); !ok || v != 512 || merged.StorageType != "hdd" {
+		t.Errorf("storage = %v %q, want 512 hdd", v, merged.StorageType)
+	}
+	if v, ok := Value(merged, "cpu"); !ok || v != 12 {
+		t.Errorf("cpu = %v %v, want 12", v, ok)
+	}
+	if _, ok := Value(merged, "graphics"); ok {
+		t.Error("graphics 不应有数值")
+	}
+}
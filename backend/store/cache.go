@@ -0,0 +1,28 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// 缓存中不存在该键
+var ErrCacheMiss = errors.New("cache miss")
+
+// 键值缓存，值为序列化后的数据
+type Cache interface {
+	// 读取缓存，不存在或已过期时返回ErrCacheMiss
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+	// 计数加1并返回新值，不过期，用作缓存版本号使一组缓存整体失效
+	Incr(ctx context.Context, key string) (int64, error)
+}
+
+// 根据配置选择缓存：配置了Redis时使用Redis，多实例部署共享缓存和失效
+func NewCache() Cache {
+	if Redis != nil {
+		return NewRedisCache(Redis)
+	}
+	return NewMemoryCache(defaultMemoryCacheSize)
+}
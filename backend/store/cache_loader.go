@@ -0,0 +1,101 @@
+package store
+
+import (
+	"context"
+	"e-device-recycle-backend/metrics"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// 带防击穿保护的缓存读取：同一实例内同一个键的并发未命中只回源一次，
+// 过期时间加随机抖动，避免大量缓存同时过期
+type Loader struct {
+	cache Cache
+	name  string // 指标中的缓存名称
+
+	mu    sync.Mutex
+	calls map[string]*loadCall
+}
+
+type loadCall struct {
+	done  chan struct{}
+	value []byte
+	err   error
+}
+
+func NewLoader(cache Cache, name string) *Loader {
+	return &Loader{cache: cache, name: name, calls: make(map[string]*loadCall)}
+}
+
+// 读取缓存并反序列化到dest，未命中时调用load回源并写入缓存。
+// 缓存不可用时直接回源，不影响业务
+func (l *Loader) Load(ctx context.Context, key string, ttl time.Duration, dest interface{}, load func() (interface{}, error)) error {
+	result := "miss"
+	data, err := l.cache.Get(ctx, key)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, dest); err == nil {
+			metrics.CacheRequests.WithLabelValues(l.name, "hit").Inc()
+			return nil
+		}
+		// 数据结构变化后旧缓存无法解析，按未命中处理
+	case !errors.Is(err, ErrCacheMiss):
+		result = "error"
+		slog.WarnContext(ctx, "读取缓存失败", "cache", l.name, "key", key, "error", err)
+	}
+	metrics.CacheRequests.WithLabelValues(l.name, result).Inc()
+
+	data, err = l.do(key, func() ([]byte, error) {
+		value, err := load()
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		if err := l.cache.Set(ctx, key, data, jitter(ttl)); err != nil {
+			slog.WarnContext(ctx, "写入缓存失败", "cache", l.name, "key", key, "error", err)
+		}
+		return data, nil
+	})
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dest)
+}
+
+// 同一个键同时只执行一次fn，其他调用等待并共享结果
+func (l *Loader) do(key string, fn func() ([]byte, error)) ([]byte, error) {
+	l.mu.Lock()
+	if call, ok := l.calls[key]; ok {
+		l.mu.Unlock()
+		<-call.done
+		return call.value, call.err
+	}
+	call := &loadCall{done: make(chan struct{})}
+	l.calls[key] = call
+	l.mu.Unlock()
+
+	defer func() {
+		l.mu.Lock()
+		delete(l.calls, key)
+		l.mu.Unlock()
+		close(call.done)
+	}()
+
+	call.value, call.err = fn()
+	return call.value, call.err
+}
+
+// 过期时间随机增加0~10%
+func jitter(ttl time.Duration) time.Duration {
+	if ttl < 10 {
+		return ttl
+	}
+	return ttl + time.Duration(rand.Int63n(int64(ttl)/10))
+}
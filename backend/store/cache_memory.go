@@ -0,0 +1,99 @@
+package store
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 内存缓存默认最多保存的条目数
+const defaultMemoryCacheSize = 10000
+
+type cacheEntry struct {
+	value     []byte
+	expiresAt time.Time // 零值表示不过期
+}
+
+// 内存缓存，仅适用于单实例部署：多实例时其他实例的缓存不会随写操作失效，只能等待过期
+type MemoryCache struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	maxSize int
+}
+
+func NewMemoryCache(maxSize int) *MemoryCache {
+	return &MemoryCache{entries: make(map[string]cacheEntry), maxSize: maxSize}
+}
+
+func (c *MemoryCache) Get(ctx context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+	if !entry.expiresAt.IsZero() && !time.Now().Before(entry.expiresAt) {
+		delete(c.entries, key)
+		return nil, ErrCacheMiss
+	}
+	return entry.value, nil
+}
+
+func (c *MemoryCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.entries[key]; !exists && len(c.entries) >= c.maxSize {
+		c.evict()
+	}
+	c.entries[key] = cacheEntry{value: value, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+func (c *MemoryCache) Delete(ctx context.Context, keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		delete(c.entries, key)
+	}
+	return nil
+}
+
+func (c *MemoryCache) Incr(ctx context.Context, key string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var n int64
+	if entry, ok := c.entries[key]; ok {
+		n, _ = strconv.ParseInt(string(entry.value), 10, 64)
+	}
+	n++
+	c.entries[key] = cacheEntry{value: []byte(strconv.FormatInt(n, 10))}
+	return n, nil
+}
+
+// 容量已满时先清理过期条目，仍然不足时随机淘汰十分之一
+func (c *MemoryCache) evict() {
+	now := time.Now()
+	for key, entry := range c.entries {
+		if !entry.expiresAt.IsZero() && !now.Before(entry.expiresAt) {
+			delete(c.entries, key)
+		}
+	}
+
+	excess := len(c.entries) - c.maxSize*9/10
+	for key, entry := range c.entries {
+		if excess <= 0 {
+			break
+		}
+		// 版本号不淘汰，否则已失效的旧缓存会重新生效
+		if entry.expiresAt.IsZero() {
+			continue
+		}
+		delete(c.entries, key)
+		excess--
+	}
+}
@@ -0,0 +1,26 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// 带过期时间的计数器存储，用于登录失败次数、临时锁定等
+type CounterStore interface {
+	// 计数加1，首次计数时设置过期时间，返回计数后的值
+	Incr(ctx context.Context, key string, window time.Duration) (int64, error)
+	Get(ctx context.Context, key string) (int64, error)
+	// 设置锁定标记，ttl后自动解除
+	Lock(ctx context.Context, key string, ttl time.Duration) error
+	// 剩余锁定时间，未锁定返回0
+	LockTTL(ctx context.Context, key string) (time.Duration, error)
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// 根据配置选择计数器存储：配置了Redis时使用Redis，多实例部署共享计数
+func NewCounterStore() CounterStore {
+	if Redis != nil {
+		return NewRedisCounterStore(Redis)
+	}
+	return NewMemoryCounterStore()
+}
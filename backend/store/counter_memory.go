@@ -0,0 +1,104 @@
+package store
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	value     int64
+	expiresAt time.Time
+}
+
+// 内存计数器，仅适用于单实例部署
+type MemoryCounterStore struct {
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+	ops     int
+}
+
+func NewMemoryCounterStore() *MemoryCounterStore {
+	return &MemoryCounterStore{entries: make(map[string]*memoryEntry)}
+}
+
+func (s *MemoryCounterStore) Incr(ctx context.Context, key string, window time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.gc(now)
+
+	entry := s.get(key, now)
+	if entry == nil {
+		entry = &memoryEntry{expiresAt: now.Add(window)}
+		s.entries[key] = entry
+	}
+	entry.value++
+	return entry.value, nil
+}
+
+func (s *MemoryCounterStore) Get(ctx context.Context, key string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry := s.get(key, time.Now()); entry != nil {
+		return entry.value, nil
+	}
+	return 0, nil
+}
+
+func (s *MemoryCounterStore) Lock(ctx context.Context, key string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[key] = &memoryEntry{value: 1, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+func (s *MemoryCounterStore) LockTTL(ctx context.Context, key string) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if entry := s.get(key, now); entry != nil {
+		return entry.expiresAt.Sub(now), nil
+	}
+	return 0, nil
+}
+
+func (s *MemoryCounterStore) Delete(ctx context.Context, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range keys {
+		delete(s.entries, key)
+	}
+	return nil
+}
+
+func (s *MemoryCounterStore) get(key string, now time.Time) *memoryEntry {
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil
+	}
+	if !now.Before(entry.expiresAt) {
+		delete(s.entries, key)
+		return nil
+	}
+	return entry
+}
+
+// 定期清理过期数据，避免内存持续增长
+func (s *MemoryCounterStore) gc(now time.Time) {
+	s.ops++
+	if s.ops < 1000 {
+		return
+	}
+	s.ops = 0
+	for key, entry := range s.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis计数器，多实例共享
+type RedisCounterStore struct {
+	client *redis.Client
+	prefix string
+}
+
+func NewRedisCounterStore(client *redis.Client) *RedisCounterStore {
+	return &RedisCounterStore{client: client, prefix: "counter:"}
+}
+
+// 计数并在首次计数时设置过期时间
+var incrScript = redis.NewScript(`
+local n = redis.call("INCR", KEYS[1])
+if n == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return n
+`)
+
+func (s *RedisCounterStore) Incr(ctx context.Context, key string, window time.Duration) (int64, error) {
+	return incrScript.Run(ctx, s.client, []string{s.prefix + key}, window.Milliseconds()).Int64()
+}
+
+func (s *RedisCounterStore) Get(ctx context.Context, key string) (int64, error) {
+	n, err := s.client.Get(ctx, s.prefix+key).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return n, err
+}
+
+func (s *RedisCounterStore) Lock(ctx context.Context, key string, ttl time.Duration) error {
+	return s.client.Set(ctx, s.prefix+key, 1, ttl).Err()
+}
+
+func (s *RedisCounterStore) LockTTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := s.client.PTTL(ctx, s.prefix+key).Result()
+	if err != nil {
+		return 0, err
+	}
+	// 键不存在或未设置过期时间时返回负数
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+func (s *RedisCounterStore) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = s.prefix + key
+	}
+	return s.client.Del(ctx, prefixed...).Err()
+}
@@ -0,0 +1,111 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newRedisCounterStore(t *testing.T) (*RedisCounterStore, *miniredis.Miniredis) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewRedisCounterStore(client), server
+}
+
+// 两种实现的共同行为
+func testCounterStore(t *testing.T, s CounterStore) {
+	ctx := context.Background()
+
+	for want := int64(1); want <= 3; want++ {
+		if n, err := s.Incr(ctx, "a", time.Minute); err != nil || n != want {
+			t.Fatalf("Incr = %d, %v, want %d", n, err, want)
+		}
+	}
+	if n, err := s.Get(ctx, "a"); err != nil || n != 3 {
+		t.Errorf("Get = %d, %v, want 3", n, err)
+	}
+	if n, err := s.Get(ctx, "missing"); err != nil || n != 0 {
+		t.Errorf("Get(missing) = %d, %v, want 0", n, err)
+	}
+
+	if ttl, err := s.LockTTL(ctx, "lock"); err != nil || ttl != 0 {
+		t.Errorf("未锁定 LockTTL = %v, %v, want 0", ttl, err)
+	}
+	if err := s.Lock(ctx, "lock", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if ttl, err := s.LockTTL(ctx, "lock"); err != nil || ttl <= 0 || ttl > time.Minute {
+		t.Errorf("LockTTL = %v, %v, want (0, 1m]", ttl, err)
+	}
+
+	if err := s.Delete(ctx, "a", "lock"); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := s.Get(ctx, "a"); n != 0 {
+		t.Errorf("删除后 Get = %d, want 0", n)
+	}
+	if ttl, _ := s.LockTTL(ctx, "lock"); ttl != 0 {
+		t.Errorf("删除后 LockTTL = %v, want 0", ttl)
+	}
+	if err := s.Delete(ctx); err != nil {
+		t.Errorf("Delete() = %v", err)
+	}
+}
+
+func TestMemoryCounterStore(t *testing.T) {
+	testCounterStore(t, NewMemoryCounterStore())
+}
+
+func TestMemoryCounterStoreExpiry(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryCounterStore()
+
+	s.Incr(ctx, "a", 20*time.Millisecond)
+	s.Lock(ctx, "lock", 20*time.Millisecond)
+	// 窗口内再次计数不延长过期时间
+	time.Sleep(10 * time.Millisecond)
+	s.Incr(ctx, "a", time.Minute)
+	time.Sleep(15 * time.Millisecond)
+
+	if n, _ := s.Get(ctx, "a"); n != 0 {
+		t.Errorf("过期后 Get = %d, want 0", n)
+	}
+	if ttl, _ := s.LockTTL(ctx, "lock"); ttl != 0 {
+		t.Errorf("过期后 LockTTL = %v, want 0", ttl)
+	}
+	if n, _ := s.Incr(ctx, "a", time.Minute); n != 1 {
+		t.Errorf("过期后 Incr = %d, want 1", n)
+	}
+}
+
+func TestRedisCounterStore(t *testing.T) {
+	s, _ := newRedisCounterStore(t)
+	testCounterStore(t, s)
+}
+
+func TestRedisCounterStoreExpiry(t *testing.T) {
+	ctx := context.Background()
+	s, server := newRedisCounterStore(t)
+
+	s.Incr(ctx, "a", time.Minute)
+	s.Lock(ctx, "lock", time.Minute)
+	// 窗口内再次计数不延长过期时间
+	server.FastForward(30 * time.Second)
+	s.Incr(ctx, "a", time.Hour)
+	if ttl := server.TTL("counter:a"); ttl != 30*time.Second {
+		t.Errorf("TTL = %v, want 30s", ttl)
+	}
+
+	server.FastForward(30 * time.Second)
+	if n, _ := s.Get(ctx, "a"); n != 0 {
+		t.Errorf("过期后 Get = %d, want 0", n)
+	}
+	if ttl, _ := s.LockTTL(ctx, "lock"); ttl != 0 {
+		t.Errorf("过期后 LockTTL = %v, want 0", ttl)
+	}
+}
@@ -0,0 +1,27 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// 令牌桶限流结果
+type RateLimitResult struct {
+	Allowed    bool
+	Remaining  int           // 剩余令牌数
+	RetryAfter time.Duration // 被拒绝时需要等待的时间
+}
+
+// 令牌桶限流器
+type RateLimiter interface {
+	// 从key对应的令牌桶中取一个令牌，rate为每秒补充的令牌数，burst为桶容量
+	Allow(ctx context.Context, key string, rate float64, burst int) (RateLimitResult, error)
+}
+
+// 根据配置选择限流器：配置了Redis时使用Redis，多实例共享限额
+func NewRateLimiter() RateLimiter {
+	if Redis != nil {
+		return NewRedisRateLimiter(Redis)
+	}
+	return NewMemoryRateLimiter()
+}
@@ -0,0 +1,66 @@
+package store
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+	idle   time.Duration // 桶从空到满所需时间，超过该时间未访问即可回收
+}
+
+// 内存令牌桶，仅适用于单实例部署
+type MemoryRateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	ops     int
+}
+
+func NewMemoryRateLimiter() *MemoryRateLimiter {
+	return &MemoryRateLimiter{buckets: make(map[string]*tokenBucket)}
+}
+
+func (l *MemoryRateLimiter) Allow(ctx context.Context, key string, rate float64, burst int) (RateLimitResult, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.gc(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: float64(burst), last: now}
+		l.buckets[key] = b
+	}
+	b.idle = time.Duration(float64(burst) / rate * float64(time.Second))
+
+	// 按经过的时间补充令牌
+	b.tokens = math.Min(float64(burst), b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / rate * float64(time.Second))
+		return RateLimitResult{Allowed: false, Remaining: 0, RetryAfter: wait}, nil
+	}
+
+	b.tokens--
+	return RateLimitResult{Allowed: true, Remaining: int(b.tokens)}, nil
+}
+
+// 定期回收已经补满的令牌桶
+func (l *MemoryRateLimiter) gc(now time.Time) {
+	l.ops++
+	if l.ops < 10000 {
+		return
+	}
+	l.ops = 0
+	for key, b := range l.buckets {
+		if now.Sub(b.last) > b.idle {
+			delete(l.buckets, key)
+		}
+	}
+}
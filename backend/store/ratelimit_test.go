@@ -0,0 +1,47 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryRateLimiterBurstAndRefill(t *testing.T) {
+	ctx := context.Background()
+	l := NewMemoryRateLimiter()
+	// 每秒补充20个令牌，容量3
+	rate, burst := 20.0, 3
+
+	for want := 2; want >= 0; want-- {
+		result, err := l.Allow(ctx, "a", rate, burst)
+		if err != nil || !result.Allowed || result.Remaining != want {
+			t.Fatalf("Allow = %+v, %v, want allowed remaining %d", result, err, want)
+		}
+	}
+	result, _ := l.Allow(ctx, "a", rate, burst)
+	if result.Allowed || result.RetryAfter <= 0 || result.RetryAfter > 50*time.Millisecond {
+		t.Fatalf("桶空后 Allow = %+v, want 拒绝并等待不超过50ms", result)
+	}
+
+	// 其他key使用独立的令牌桶
+	if result, _ := l.Allow(ctx, "b", rate, burst); !result.Allowed {
+		t.Error("其他key不应受影响")
+	}
+
+	time.Sleep(result.RetryAfter + 10*time.Millisecond)
+	if result, _ := l.Allow(ctx, "a", rate, burst); !result.Allowed {
+		t.Errorf("补充令牌后 Allow = %+v, want allowed", result)
+	}
+}
+
+func TestMemoryRateLimiterRefillCappedAtBurst(t *testing.T) {
+	ctx := context.Background()
+	l := NewMemoryRateLimiter()
+
+	l.Allow(ctx, "a", 1000, 2)
+	time.Sleep(20 * time.Millisecond)
+	// 空闲期间补充的令牌不超过容量
+	if result, _ := l.Allow(ctx, "a", 1000, 2); result.Remaining != 1 {
+		t.Errorf("Remaining = %d, want 1", result.Remaining)
+	}
+}
@@ -0,0 +1,43 @@
+package store
+
+import (
+	"context"
+	"e-device-recycle-backend/config"
+	"log"
+	"log/slog"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis客户端，未配置REDIS_HOST时为nil
+var Redis *redis.Client
+
+func InitRedis() {
+	cfg := config.GetConfig()
+	if cfg.Redis.Host == "" {
+		return
+	}
+
+	Redis = redis.NewClient(&redis.Options{
+		Addr:     cfg.Redis.Host + ":" + cfg.Redis.Port,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := Redis.Ping(ctx).Err(); err != nil {
+		log.Fatal("连接Redis失败:", err)
+	}
+
+	slog.Info("Redis连接成功", "addr", Redis.Options().Addr)
+}
+
+// 检查Redis连接，未配置Redis时返回nil
+func Ping(ctx context.Context) error {
+	if Redis == nil {
+		return nil
+	}
+	return Redis.Ping(ctx).Err()
+}
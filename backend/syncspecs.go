@@ -0,0 +1,27 @@
+package main
+
+import (
+	"e-device-recycle-backend/models"
+	"e-device-recycle-backend/repositories"
+	"e-device-recycle-backend/search"
+	"e-device-recycle-backend/services"
+	"flag"
+	"fmt"
+	"log"
+)
+
+// sync-specs 子命令：从设备的规格文本识别结构化规格，用于填充迁移前已有的设备
+func runSyncSpecs(args []string) {
+	fs := flag.NewFlagSet("sync-specs", flag.ExitOnError)
+	overwrite := fs.Bool("overwrite", false, "重新识别所有设备，覆盖已有的结构化规格（包括创建时手动填写的）")
+	fs.Parse(args)
+
+	models.InitDB()
+
+	devices := services.NewDeviceService(repositories.New(models.DB), search.NewMemoryIndex())
+	n, err := devices.SyncSpecs(*overwrite)
+	if err != nil {
+		log.Fatal("识别设备规格失败:", err)
+	}
+	fmt.Printf("已更新%d个设备的结构化规格\n", n)
+}
@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// 将模型转换为扁平的字段快照。嵌入的值对象（如设备规格specs）展开为带前缀的字段（specs.memory_gb），
+// 带id的关联对象（如订单中的device）忽略
+func Snapshot(v interface{}) map[string]interface{} {
+	if v == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil
+	}
+
+	snapshot := make(map[string]interface{}, len(fields))
+	flatten(snapshot, "", fields)
+	return snapshot
+}
+
+func flatten(snapshot map[string]interface{}, prefix string, fields map[string]interface{}) {
+	for key, value := range fields {
+		object, isObject := value.(map[string]interface{})
+		switch {
+		case !isObject:
+			snapshot[prefix+key] = value
+		case object["id"] == nil:
+			flatten(snapshot, prefix+key+".", object)
+		}
+	}
+}
+
+// 比较两个快照，返回变化的字段及其新旧值
+func DiffSnapshots(before, after map[string]interface{}) map[string][2]interface{} {
+	diff := make(map[string][2]interface{})
+	for key, oldValue := range before {
+		if newValue, ok := after[key]; !ok || !reflect.DeepEqual(oldValue, newValue) {
+			diff[key] = [2]interface{}{oldValue, after[key]}
+		}
+	}
+	for key, newValue := range after {
+		if _, ok := before[key]; !ok {
+			diff[key] = [2]interface{}{nil, newValue}
+		}
+	}
+	// 更新时间每次都会变化，不作为变更内容
+	delete(diff, "updated_at")
+	return diff
+}
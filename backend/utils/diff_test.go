@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"e-device-recycle-backend/models"
+	"reflect"
+	"testing"
+)
+
+func TestSnapshotFlattensEmbeddedObjects(t *testing.T) {
+	memory := 16
+	before := Snapshot(models.RecycleOrder{
+		ID:     1,
+		Status: "pending",
+		Device: models.Device{ID: 2, Name: "MacBook", Specs: models.DeviceSpecs{MemoryGB: &memory}},
+	})
+	if _, ok := before["device.name"]; ok {
+		t.Error("关联对象不应展开")
+	}
+	if before["status"] != "pending" {
+		t.Errorf("status = %v", before["status"])
+	}
+
+	device := models.Device{ID: 2, Name: "MacBook", Specs: models.DeviceSpecs{MemoryGB: &memory, StorageType: "ssd"}}
+	old := Snapshot(device)
+	memory = 32
+	device.Specs.StorageType = "hdd"
+	diff := DiffSnapshots(old, Snapshot(device))
+
+	want := map[string][2]interface{}{
+		"specs.memory_gb":    {16.0, 32.0},
+		"specs.storage_type": {"ssd", "hdd"},
+	}
+	if !reflect.DeepEqual(diff, want) {
+		t.Errorf("diff = %v, want %v", diff, want)
+	}
+}
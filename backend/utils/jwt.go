@@ -12,15 +12,18 @@ type Claims struct {
 	UserID   uint   `json:"user_id"`
 	Username string `json:"username"`
 	Role     string `json:"role"`
+	Version  uint   `json:"ver"`               // 对应用户的令牌版本
+	Purpose  string `json:"purpose,omitempty"` // 非空表示临时令牌（如两步验证），不能用于访问接口
 	jwt.RegisteredClaims
 }
 
 // 生成JWT token
-func GenerateJWT(userID uint, username, role string) (string, error) {
+func GenerateJWT(userID uint, username, role string, version uint) (string, error) {
 	claims := Claims{
 		UserID:   userID,
 		Username: username,
 		Role:     role,
+		Version:  version,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)), // 24小时过期
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -30,13 +33,13 @@ func GenerateJWT(userID uint, username, role string) (string, error) {
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(config.GetConfig().JWTSecret))
+	return token.SignedString([]byte(config.GetConfig().Auth.JWTSecret))
 }
 
 // 验证JWT token
 func ValidateJWT(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(config.GetConfig().JWTSecret), nil
+		return []byte(config.GetConfig().Auth.JWTSecret), nil
 	})
 
 	if err != nil {
@@ -49,3 +52,35 @@ func ValidateJWT(tokenString string) (*Claims, error) {
 
 	return nil, errors.New("无效的token")
 }
+
+// 生成两步验证等流程使用的临时令牌，5分钟过期
+func GenerateChallengeJWT(userID uint, username, role string, version uint, purpose string) (string, error) {
+	claims := Claims{
+		UserID:   userID,
+		Username: username,
+		Role:     role,
+		Version:  version,
+		Purpose:  purpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(5 * time.Minute)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+			Issuer:    "device-recycle",
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(config.GetConfig().Auth.JWTSecret))
+}
+
+// 验证临时令牌及其用途
+func ValidateChallengeJWT(tokenString, purpose string) (*Claims, error) {
+	claims, err := ValidateJWT(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != purpose {
+		return nil, errors.New("无效的token")
+	}
+	return claims, nil
+}
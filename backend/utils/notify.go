@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+)
+
+// 通知发送接口（邮件、短信）
+type Notifier interface {
+	SendEmail(to, subject, body string) error
+	SendSMS(phone, content string) error
+}
+
+// 日志通知器：未接入邮件/短信服务时，将内容输出到日志
+type LogNotifier struct{}
+
+func (LogNotifier) SendEmail(to, subject, body string) error {
+	slog.Info("发送邮件", "to", to, "subject", subject, "body", body)
+	return nil
+}
+
+func (LogNotifier) SendSMS(phone, content string) error {
+	slog.Info("发送短信", "phone", phone, "content", content)
+	return nil
+}
+
+var notifier Notifier = LogNotifier{}
+
+// 替换通知器，接入真实的邮件/短信服务时调用
+func SetNotifier(n Notifier) {
+	notifier = n
+}
+
+func GetNotifier() Notifier {
+	return notifier
+}
+
+// SMTP通知器：通过SMTP发送邮件，短信仍输出到日志
+type SMTPNotifier struct {
+	LogNotifier
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+func (n SMTPNotifier) SendEmail(to, subject, body string) error {
+	var auth smtp.Auth
+	if n.Username != "" {
+		auth = smtp.PlainAuth("", n.Username, n.Password, n.Host)
+	}
+
+	msg := strings.Join([]string{
+		"From: " + n.From,
+		"To: " + to,
+		"Subject: " + mime.BEncoding.Encode("UTF-8", subject),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"Content-Transfer-Encoding: base64",
+		"",
+		base64.StdEncoding.EncodeToString([]byte(body)),
+	}, "\r\n")
+
+	addr := net.JoinHostPort(n.Host, strconv.Itoa(n.Port))
+	if err := smtp.SendMail(addr, auth, n.From, []string{to}, []byte(msg)); err != nil {
+		return fmt.Errorf("发送邮件失败: %w", err)
+	}
+	return nil
+}
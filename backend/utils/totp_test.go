@@ -0,0 +1,150 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// RFC 4226 / RFC 6238 附录中的测试密钥 "12345678901234567890"
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestHOTPVectors(t *testing.T) {
+	// RFC 4226 附录D
+	want := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+	for counter, code := range want {
+		if got := hotp([]byte("12345678901234567890"), int64(counter)); got != code {
+			t.Errorf("hotp(%d) = %s, want %s", counter, got, code)
+		}
+	}
+}
+
+func TestTOTPVectors(t *testing.T) {
+	// RFC 6238 附录B（SHA1），取8位结果的后6位
+	for _, tc := range []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	} {
+		at := time.Unix(tc.unix, 0)
+		if got, err := GenerateTOTP(rfcSecret, at); err != nil || got != tc.code {
+			t.Errorf("GenerateTOTP(%d) = %s, %v, want %s", tc.unix, got, err, tc.code)
+		}
+		if step, ok := ValidateTOTP(rfcSecret, tc.code, at); !ok || step != tc.unix/30 {
+			t.Errorf("ValidateTOTP(%d) = %d, %v, want %d", tc.unix, step, ok, tc.unix/30)
+		}
+	}
+}
+
+func TestValidateTOTPWindow(t *testing.T) {
+	// 时间步 1111111109/30 = 37037036，覆盖 [1111111080, 1111111110)
+	code, step := "081804", int64(37037036)
+	start := time.Unix(step*30, 0)
+
+	for _, tc := range []struct {
+		name string
+		at   time.Time
+		ok   bool
+	}{
+		{"时间步开始", start, true},
+		{"时间步结束", start.Add(29 * time.Second), true},
+		{"前一个时间步", start.Add(-time.Second), true},
+		{"后一个时间步的最后一秒", start.Add(59 * time.Second), true},
+		{"超出前一个时间步", start.Add(-31 * time.Second), false},
+		{"超出后一个时间步", start.Add(60 * time.Second), false},
+	} {
+		got, ok := ValidateTOTP(rfcSecret, code, tc.at)
+		if ok != tc.ok || ok && got != step {
+			t.Errorf("%s: ValidateTOTP = %d, %v, want %d, %v", tc.name, got, ok, step, tc.ok)
+		}
+	}
+}
+
+func TestValidateTOTPReplay(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	code, err := GenerateTOTP(rfcSecret, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 在允许的误差内再次提交返回相同的时间步，调用方据此拒绝重放
+	first, ok := ValidateTOTP(rfcSecret, code, now)
+	if !ok {
+		t.Fatal("验证码应有效")
+	}
+	if again, ok := ValidateTOTP(rfcSecret, code, now.Add(30*time.Second)); !ok || again != first {
+		t.Errorf("重放 = %d, %v, want %d", again, ok, first)
+	}
+	// 上一个时间步的验证码匹配到更早的时间步
+	previous, _ := GenerateTOTP(rfcSecret, now.Add(-30*time.Second))
+	if step, ok := ValidateTOTP(rfcSecret, previous, now); !ok || step != first-1 {
+		t.Errorf("上一个时间步 = %d, %v, want %d", step, ok, first-1)
+	}
+}
+
+func TestValidateTOTPInvalidInput(t *testing.T) {
+	now := time.Unix(59, 0)
+	for _, tc := range []struct {
+		name, secret, code string
+	}{
+		{"错误的验证码", rfcSecret, "000000"},
+		{"位数不对", rfcSecret, "94287082"},
+		{"密钥不是Base32", "not-base32!", "287082"},
+	} {
+		if _, ok := ValidateTOTP(tc.secret, tc.code, now); ok {
+			t.Errorf("%s: 应校验失败", tc.name)
+		}
+	}
+	// 密钥不区分大小写，忽略首尾空格
+	if _, ok := ValidateTOTP(" "+strings.ToLower(rfcSecret)+" ", "287082", now); !ok {
+		t.Error("小写密钥应校验通过")
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := GenerateTOTP(secret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ValidateTOTP(secret, code, time.Now()); !ok {
+		t.Error("新密钥生成的验证码应校验通过")
+	}
+	if uri := TOTPURI("电脑回收", "alice", secret); !strings.HasPrefix(uri, "otpauth://totp/") || !strings.Contains(uri, "secret="+secret) {
+		t.Errorf("TOTPURI = %s", uri)
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		if len(code) != 11 || code[5] != '-' || seen[code] {
+			t.Errorf("恢复码格式错误或重复: %q", code)
+		}
+		seen[code] = true
+	}
+
+	for input, want := range map[string]string{
+		"abcde-fghjk":   "abcde-fghjk",
+		" ABCDE-FGHJK ": "abcde-fghjk",
+		"abcdefghjk":    "abcde-fghjk",
+		"abcde fghjk":   "abcde-fghjk",
+	} {
+		if got := NormalizeRecoveryCode(input); got != want {
+			t.Errorf("NormalizeRecoveryCode(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
@@ -79,35 +79,63 @@ export function createApp() {
       const token = uni.getStorageSync('token')
       return {
         'Content-Type': 'application/json',
-        'Authorization': token ? 'Bearer ' + token : ''
+        'Authorization': token ? 'Bearer ' + token : '',
+        // 错误信息按界面语言返回
+        'Accept-Language': uni.getLocale ? uni.getLocale() : 'zh-CN'
       }
     },
     
-    // 处理响应
+    // 处理响应，错误响应格式为 {error: {code, message, fields, request_id}}
     handleResponse(res, resolve, reject) {
-      if (res.statusCode === 200) {
+      if (res.statusCode >= 200 && res.statusCode < 300) {
         resolve(res.data)
-      } else if (res.statusCode === 401) {
-        // 未授权，跳转到登录页
+        return
+      }
+
+      const error = (res.data && res.data.error) || {}
+      if (['UNAUTHORIZED', 'TOKEN_INVALID', 'TOKEN_EXPIRED'].includes(error.code)) {
+        // 登录状态失效，跳转到登录页
         uni.removeStorageSync('token')
         uni.removeStorageSync('userInfo')
         uni.reLaunch({
           url: '/pages/user/login'
         })
-        reject(res.data)
       } else {
         // 显示错误信息
         uni.showToast({
-          title: res.data.error || '请求失败',
+          title: error.message || '请求失败',
           icon: 'none'
         })
-        reject(res.data)
       }
+      reject(res.data)
     }
   }
   
   // 全局工具函数
   app.config.globalProperties.$utils = {
+    // 获取接口错误信息
+    errorMessage(data, fallback) {
+      const error = data && data.error
+      return (error && error.message) || fallback
+    },
+
+    // 选择订单取消原因，用户关闭选择框时返回null
+    chooseCancelReason() {
+      const reasons = [
+        { value: 'changed_mind', label: '不想卖了' },
+        { value: 'price_too_low', label: '估价太低' },
+        { value: 'sold_elsewhere', label: '已在别处出售' },
+        { value: 'other', label: '其他原因' }
+      ]
+      return new Promise((resolve) => {
+        uni.showActionSheet({
+          itemList: reasons.map(reason => reason.label),
+          success: (res) => resolve(reasons[res.tapIndex].value),
+          fail: () => resolve(null)
+        })
+      })
+    },
+
     // 格式化时间
     formatTime(time) {
       if (!time) return ''
@@ -6,7 +6,7 @@ echo ================================
 echo.
 echo 1. 启动后端服务...
 cd backend
-start cmd /k "echo 启动Go后端服务 && go run main.go"
+start cmd /k "echo 启动Go后端服务 && go run . migrate up && go run ."
 
 echo.
 echo 2. 启动前端开发服务...
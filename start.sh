@@ -23,7 +23,7 @@ echo
 # 启动后端服务
 echo "1. 启动后端服务..."
 cd backend
-gnome-terminal --title="Go后端服务" -- bash -c "echo '启动Go后端服务...'; go run main.go; exec bash" &
+gnome-terminal --title="Go后端服务" -- bash -c "echo '启动Go后端服务...'; go run . migrate up && go run .; exec bash" &
 cd ..
 
 # 启动前端服务